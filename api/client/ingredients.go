@@ -0,0 +1,68 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gogrlx/grlx/api"
+	"github.com/gogrlx/grlx/auth"
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/types"
+)
+
+// ListIngredients returns the ingredients registered on each targeted
+// sprout, keyed by sprout ID.
+func ListIngredients(target string) (map[string]types.IngredientList, error) {
+	client := APIClient
+	ctx := context.Background()
+	FarmerURL := config.FarmerURL
+	lists := map[string]types.IngredientList{}
+	targets, err := ResolveTargets(target)
+	if err != nil {
+		return lists, err
+	}
+	if len(targets) == 0 {
+		return lists, types.ErrSproutIDNotFound
+	}
+	var ta types.TargetedAction
+	ta.Target = []types.KeyManager{}
+	for _, sprout := range targets {
+		ta.Target = append(ta.Target, types.KeyManager{SproutID: sprout})
+	}
+	url := FarmerURL + api.Routes["ListIngredients"].Pattern
+	jw, _ := json.Marshal(ta)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jw))
+	if err != nil {
+		return lists, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	newToken, err := auth.NewToken()
+	if err != nil {
+		return lists, err
+	}
+	req.Header.Set("Authorization", newToken)
+	resp, err := client.Do(req)
+	if err != nil {
+		return lists, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return lists, types.ErrSproutIDNotFound
+	default:
+		return lists, fmt.Errorf("farmer returned %s", resp.Status)
+	}
+	var results struct {
+		Results map[string]types.IngredientList `json:"results"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&results)
+	if results.Results != nil {
+		lists = results.Results
+	}
+	return lists, err
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogrlx/grlx/pki"
+	"github.com/gogrlx/grlx/types"
+	log "github.com/taigrr/log-socket/log"
+)
+
+// ingredientsTimeout bounds how long each sprout has to reply with its
+// ingredients.
+var ingredientsTimeout = 15 * time.Second
+
+// ListIngredients asks each targeted sprout which ingredients it has
+// registered, including any plugins, and replies with an IngredientList
+// per sprout.
+func ListIngredients(w http.ResponseWriter, r *http.Request) {
+	var targetAction types.TargetedAction
+	err := json.NewDecoder(r.Body).Decode(&targetAction)
+	if err != nil {
+		log.Trace("An invalid request was made.")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// verify our sprout id is valid
+	for _, target := range targetAction.Target {
+		if !pki.IsValidSproutID(target.SproutID) || strings.Contains(target.SproutID, "_") {
+			log.Trace("An invalid Sprout ID was submitted. Ignoring.")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		registered, _ := pki.NKeyExists(target.SproutID, "")
+		if !registered {
+			var results types.TargetedResults
+			results.Results = nil
+			log.Trace("An unknown Sprout was queried. Ignoring.")
+			jw, _ := json.Marshal(results)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write(jw)
+			return
+		}
+	}
+
+	var results types.TargetedResults
+	results.Results = make(map[string]interface{})
+	var wg sync.WaitGroup
+	var m sync.Mutex
+	for _, target := range targetAction.Target {
+		wg.Add(1)
+		go func(target types.KeyManager) {
+			defer wg.Done()
+			var list types.IngredientList
+			err := ec.Request("grlx.sprouts."+target.SproutID+".ingredients.list", struct{}{}, &list, ingredientsTimeout)
+			if err != nil {
+				log.Tracef("Error listing the Sprout's ingredients: %v", err)
+				list = types.IngredientList{Error: err.Error()}
+			}
+			m.Lock()
+			results.Results[target.SproutID] = list
+			m.Unlock()
+		}(target)
+	}
+	wg.Wait()
+	jr, err := json.Marshal(results)
+	if err != nil {
+		log.Error(err)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(jr)
+}
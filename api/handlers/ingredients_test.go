@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	nats "github.com/nats-io/nats.go"
+
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/types"
+)
+
+// startBus starts a bus for the handlers to use and accepts the given
+// sprouts in a temporary PKI directory.
+func startBus(t *testing.T, sprouts ...string) *nats.EncodedConn {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server did not start")
+	}
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := nats.NewEncodedConn(nc, nats.JSON_ENCODER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(conn.Close)
+	RegisterEC(conn)
+	farmerPKI := config.FarmerPKI
+	t.Cleanup(func() {
+		RegisterEC(nil)
+		config.FarmerPKI = farmerPKI
+	})
+	config.FarmerPKI = t.TempDir()
+	accepted := filepath.Join(config.FarmerPKI, "sprouts", "accepted")
+	if err = os.MkdirAll(accepted, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range sprouts {
+		if err = os.WriteFile(filepath.Join(accepted, id), []byte("nkey"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return conn
+}
+
+func TestListIngredients(t *testing.T) {
+	conn := startBus(t, "web-01.example.com", "db-01")
+	list := types.IngredientList{Ingredients: []types.IngredientInfo{{
+		Name:    "file",
+		Methods: []types.MethodInfo{{Name: "managed"}},
+	}}}
+	_, err := conn.Subscribe("grlx.sprouts.web-01.example.com.ingredients.list", func(_, reply string, _ struct{}) {
+		conn.Publish(reply, list)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// db-01 is listening but never answers
+	_, err = conn.Subscribe("grlx.sprouts.db-01.ingredients.list", func(_, _ string, _ struct{}) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(timeout time.Duration) { ingredientsTimeout = timeout }(ingredientsTimeout)
+	ingredientsTimeout = 100 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		sprouts  []string
+		code     int
+		expected map[string]types.IngredientList
+	}{
+		{
+			name:     "success",
+			sprouts:  []string{"web-01.example.com"},
+			code:     http.StatusOK,
+			expected: map[string]types.IngredientList{"web-01.example.com": list},
+		},
+		{
+			name:     "timeout",
+			sprouts:  []string{"web-01.example.com", "db-01"},
+			code:     http.StatusOK,
+			expected: map[string]types.IngredientList{"web-01.example.com": list, "db-01": {Error: nats.ErrTimeout.Error()}},
+		},
+		{name: "unknown sprout", sprouts: []string{"web-01.example.com", "web-02"}, code: http.StatusNotFound},
+		{name: "invalid sprout", sprouts: []string{"web_01"}, code: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			action := types.TargetedAction{}
+			for _, id := range test.sprouts {
+				action.Target = append(action.Target, types.KeyManager{SproutID: id})
+			}
+			body, err := json.Marshal(action)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := httptest.NewRecorder()
+			ListIngredients(w, httptest.NewRequest(http.MethodPost, "/ingredients/list", bytes.NewReader(body)))
+			if w.Code != test.code {
+				t.Fatalf("expected status %d, got %d", test.code, w.Code)
+			}
+			if test.expected == nil {
+				return
+			}
+			var results struct {
+				Results map[string]types.IngredientList `json:"results"`
+			}
+			if err = json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+				t.Fatal(err)
+			}
+			expected, _ := json.Marshal(test.expected)
+			got, _ := json.Marshal(results.Results)
+			if string(got) != string(expected) {
+				t.Errorf("expected %s, got %s", expected, got)
+			}
+		})
+	}
+}
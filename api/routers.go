@@ -122,4 +122,9 @@ var Routes = map[string]Route{
 		Pattern:     "/cmd/run",
 		HandlerFunc: cmd.HCmdRun,
 	},
+	"ListIngredients": {
+		Method:      http.MethodPost,
+		Pattern:     "/ingredients/list",
+		HandlerFunc: handlers.ListIngredients,
+	},
 }
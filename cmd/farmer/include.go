@@ -0,0 +1,7 @@
+package main
+
+// The farmer registers every compiled-in ingredient so that recipes can be
+// checked against their property schemas before they are sent to a sprout.
+import (
+	_ "github.com/gogrlx/grlx/ingredients/all"
+)
@@ -17,6 +17,7 @@ import (
 	"github.com/gogrlx/grlx/config"
 	"github.com/gogrlx/grlx/cook"
 	"github.com/gogrlx/grlx/ingredients/cmd"
+	"github.com/gogrlx/grlx/ingredients/file/farmer"
 	"github.com/gogrlx/grlx/ingredients/test"
 	"github.com/gogrlx/grlx/jobs"
 	"github.com/gogrlx/grlx/pki"
@@ -215,6 +216,10 @@ func ConnectFarmer() {
 	cook.RegisterEC(ec)
 	jobs.RegisterEC(ec)
 	handlers.RegisterEC(ec)
+	err = farmer.Serve(ec)
+	if err != nil {
+		log.Errorf("Got an error serving files to sprouts: %+v\n", err)
+	}
 	defer ec.Close()
 	select {}
 }
@@ -99,7 +99,7 @@ var cmdCook = &cobra.Command{
 				}
 				b.WriteString("\tExecution Notes: \n")
 				for _, change := range step.Changes {
-					b.WriteString(fmt.Sprintf("\t\t%s\n", change))
+					writeChange(&b, change)
 				}
 				// TODO add started and duration
 				b.WriteString("----------\n")
@@ -193,3 +193,25 @@ func init() {
 	cmdCook.MarkPersistentFlagRequired("target")
 	rootCmd.AddCommand(cmdCook)
 }
+
+// writeChange adds a step note to the output, coloring the lines of
+// unified diffs.
+func writeChange(b *strings.Builder, change string) {
+	if !strings.HasPrefix(change, "--- ") || !strings.Contains(change, "\n+++ ") {
+		b.WriteString(fmt.Sprintf("\t\t%s\n", change))
+		return
+	}
+	for _, line := range strings.Split(change, "\n") {
+		switch {
+		case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+			line = color.New(color.Bold).Sprint(line)
+		case strings.HasPrefix(line, "@@"):
+			line = color.CyanString(line)
+		case strings.HasPrefix(line, "+"):
+			line = color.GreenString(line)
+		case strings.HasPrefix(line, "-"):
+			line = color.RedString(line)
+		}
+		b.WriteString(fmt.Sprintf("\t\t%s\n", line))
+	}
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/taigrr/log-socket/log"
+
+	"github.com/gogrlx/grlx/api/client"
+	"github.com/gogrlx/grlx/types"
+)
+
+// ingredientsCmd represents the ingredients command
+var ingredientsCmd = &cobra.Command{
+	Use:   "ingredients",
+	Short: "Discover the ingredients, methods and properties available on Sprouts",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	ingredientsCmd.PersistentFlags().BoolVarP(&targetAll, "all", "A", false, "Query all Sprouts")
+	ingredientsCmd.PersistentFlags().StringVarP(&sproutTarget, "target", "T", "", "List of target Sprouts")
+	ingredientsCmd.AddCommand(ingredientsCmdList)
+	ingredientsCmd.AddCommand(ingredientsCmdDescribe)
+	rootCmd.AddCommand(ingredientsCmd)
+}
+
+var ingredientsCmdList = &cobra.Command{
+	Use:   "list -T <target>",
+	Short: "List the ingredients and methods each Sprout can cook",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		lists := listIngredients()
+		switch outputMode {
+		case "json":
+			jw, _ := json.Marshal(lists)
+			fmt.Println(string(jw))
+		case "", "text":
+			for _, sproutID := range sortedSprouts(lists) {
+				list := lists[sproutID]
+				if list.Error != "" {
+					color.Red("%s: %s\n", sproutID, list.Error)
+					continue
+				}
+				fmt.Printf("%s:\n", sproutID)
+				for _, ing := range list.Ingredients {
+					methods := []string{}
+					for _, method := range ing.Methods {
+						if method.Error != "" {
+							methods = append(methods, color.RedString("%s (%s)", method.Name, method.Error))
+							continue
+						}
+						methods = append(methods, method.Name)
+					}
+					fmt.Printf("  %s: %s\n", ing.Name, strings.Join(methods, ", "))
+				}
+			}
+		}
+	},
+}
+
+var ingredientsCmdDescribe = &cobra.Command{
+	Use:   "describe <ingredient>[.<method>] -T <target>",
+	Short: "Describe the properties of an ingredient's methods",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ingredient, method, _ := strings.Cut(args[0], ".")
+		lists := listIngredients()
+		for sproutID, list := range lists {
+			lists[sproutID] = filterIngredients(list, ingredient, method)
+		}
+		switch outputMode {
+		case "json":
+			jw, _ := json.Marshal(lists)
+			fmt.Println(string(jw))
+		case "", "text":
+			for _, sproutID := range sortedSprouts(lists) {
+				list := lists[sproutID]
+				if list.Error != "" {
+					color.Red("%s: %s\n", sproutID, list.Error)
+					continue
+				}
+				for _, ing := range list.Ingredients {
+					for _, m := range ing.Methods {
+						fmt.Printf("%s: %s.%s\n", sproutID, ing.Name, m.Name)
+						if m.Error != "" {
+							color.Red("  %s\n", m.Error)
+							continue
+						}
+						printProperties(m.Properties)
+					}
+				}
+			}
+		}
+	},
+}
+
+// listIngredients fetches the ingredients of the targeted sprouts, exiting
+// if the farmer can't be asked.
+func listIngredients() map[string]types.IngredientList {
+	if targetAll {
+		sproutTarget = ".*"
+	}
+	lists, err := client.ListIngredients(sproutTarget)
+	if err != nil {
+		switch err {
+		case types.ErrSproutIDNotFound:
+			log.Fatalf("A targeted Sprout does not exist or is not accepted.")
+		default:
+			log.Fatal(err)
+		}
+	}
+	return lists
+}
+
+// filterIngredients keeps only the named ingredient and, if method is set,
+// that method, recording an error if the sprout doesn't have them.
+func filterIngredients(list types.IngredientList, ingredient, method string) types.IngredientList {
+	if list.Error != "" {
+		return list
+	}
+	for _, ing := range list.Ingredients {
+		if ing.Name != ingredient {
+			continue
+		}
+		if method == "" {
+			return types.IngredientList{Ingredients: []types.IngredientInfo{ing}}
+		}
+		for _, m := range ing.Methods {
+			if m.Name == method {
+				ing.Methods = []types.MethodInfo{m}
+				return types.IngredientList{Ingredients: []types.IngredientInfo{ing}}
+			}
+		}
+		return types.IngredientList{Error: fmt.Sprintf("ingredient %s has no method %s", ingredient, method)}
+	}
+	return types.IngredientList{Error: fmt.Sprintf("unknown ingredient %s", ingredient)}
+}
+
+// printProperties prints a table of properties, required ones first.
+func printProperties(props []types.PropertyInfo) {
+	sorted := append([]types.PropertyInfo{}, props...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Required && !sorted[j].Required })
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  PROPERTY\tTYPE\tREQUIRED\tDEFAULT\tDESCRIPTION")
+	for _, prop := range sorted {
+		required := ""
+		if prop.Required {
+			required = "yes"
+		}
+		def := ""
+		if prop.Default != nil {
+			def = fmt.Sprintf("%v", prop.Default)
+		}
+		desc := prop.Description
+		if len(prop.Enum) > 0 {
+			desc = strings.TrimSpace(fmt.Sprintf("%s (one of %s)", desc, strings.Join(prop.Enum, ", ")))
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", prop.Key, prop.Type, required, def, desc)
+	}
+	tw.Flush()
+}
+
+func sortedSprouts(lists map[string]types.IngredientList) []string {
+	ids := make([]string, 0, len(lists))
+	for id := range lists {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gogrlx/grlx/cmd/grlx/lsp"
+)
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a language server for recipe files over stdio",
+	Long: `Run a language server for .grlx recipe files, speaking the Language Server
+Protocol over stdin and stdout. Point your editor's LSP client at
+"grlx lsp" to check recipes as you write them and complete ingredients,
+properties and step IDs.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return lsp.Serve(os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lspCmd)
+}
@@ -61,13 +61,14 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/grlx/grlx)")
 	noFailForCert := false
 	if len(os.Args) > 1 {
-		noFailForCert = os.Args[1] == "version" || os.Args[1] == "help" || os.Args[1] == "auth" || os.Args[1] == "init"
+		noFailForCert = os.Args[1] == "version" || os.Args[1] == "help" || os.Args[1] == "auth" || os.Args[1] == "init" || os.Args[1] == "lsp"
 	}
-	isInit := false
+	// the language server speaks over stdio, so it must never prompt
+	skipFetch := false
 	if len(os.Args) > 1 {
-		isInit = os.Args[1] == "init"
+		skipFetch = os.Args[1] == "init" || os.Args[1] == "lsp"
 	}
-	if !pki.RootCACached("grlx") && !isInit {
+	if !pki.RootCACached("grlx") && !skipFetch {
 		fmt.Print("The TLS certificate for this farmer is unknown. Would you like to download and trust it? ")
 		shouldDownload, err := util.UserConfirmWithDefault(true)
 		for err != nil {
@@ -0,0 +1,7 @@
+package main
+
+// The language server completes and checks recipes against the schemas of
+// every compiled-in ingredient.
+import (
+	_ "github.com/gogrlx/grlx/ingredients/all"
+)
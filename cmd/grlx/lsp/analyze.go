@@ -0,0 +1,488 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gogrlx/grlx/cook"
+	"github.com/gogrlx/grlx/cook/rootball"
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	templateAction = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+	yamlErrLine    = regexp.MustCompile(`line (\d+):`)
+)
+
+// recipeFile is a recipe parsed with the positions of its includes, steps
+// and requisites, so that problems can be reported where they are.
+type recipeFile struct {
+	path  string
+	lines []string
+	// templated marks the lines where a template action was masked out
+	// next to other content, whose values aren't known until the recipe
+	// is rendered for a sprout
+	templated map[int]bool
+	includes  []includeRef
+	steps     []stepDef
+	diags     []Diagnostic
+}
+
+type includeRef struct {
+	name string
+	rng  Range
+}
+
+type stepDef struct {
+	id     string
+	rng    Range
+	key    string
+	keyRng Range
+	props  []propDef
+	reqs   []includeRef
+	step   *types.Step
+}
+
+type propDef struct {
+	key       string
+	keyRng    Range
+	value     *yaml.Node
+	valueRng  Range
+	templated bool
+}
+
+// maskTemplates blanks out template actions, keeping every other
+// character where it is, since recipes are only valid YAML once rendered.
+func maskTemplates(text string) (string, map[int]bool) {
+	templated := map[int]bool{}
+	masked := []byte(text)
+	for _, loc := range templateAction.FindAllStringIndex(text, -1) {
+		for i := loc[0]; i < loc[1]; i++ {
+			if masked[i] != '\n' {
+				masked[i] = ' '
+			}
+		}
+		first := strings.Count(text[:loc[0]], "\n")
+		last := first + strings.Count(text[loc[0]:loc[1]], "\n")
+		for l := first; l <= last; l++ {
+			templated[l] = true
+		}
+	}
+	lines := strings.Split(string(masked), "\n")
+	for l := range templated {
+		if l < len(lines) && strings.TrimSpace(lines[l]) == "" {
+			delete(templated, l)
+		}
+	}
+	return string(masked), templated
+}
+
+// parseRecipe parses a recipe without checking it against the registry or
+// its includes. Problems with its structure are recorded as diagnostics.
+func parseRecipe(path, text string) *recipeFile {
+	masked, templated := maskTemplates(text)
+	f := &recipeFile{path: path, lines: strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), templated: templated}
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(masked), &root); err != nil {
+		line := 0
+		if m := yamlErrLine.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			line--
+		}
+		f.addDiag(f.lineRange(line), severityError, strings.TrimPrefix(err.Error(), "yaml: "))
+		return f
+	}
+	if len(root.Content) == 0 {
+		return f
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		f.addDiag(f.nodeRange(doc), severityError, "a recipe must be a map of include and steps")
+		return f
+	}
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		switch key.Value {
+		case "include":
+			f.parseIncludes(value)
+		case "steps":
+			f.parseSteps(value)
+		}
+	}
+	return f
+}
+
+func (f *recipeFile) parseIncludes(n *yaml.Node) {
+	if n.Kind != yaml.SequenceNode {
+		f.addDiag(f.nodeRange(n), severityError, "include must be a list of recipes")
+		return
+	}
+	for _, item := range n.Content {
+		if item.Kind != yaml.ScalarNode || item.Tag != "!!str" {
+			f.addDiag(f.nodeRange(item), severityError, "include must be a list of recipes")
+			continue
+		}
+		f.includes = append(f.includes, includeRef{name: item.Value, rng: f.nodeRange(item)})
+	}
+}
+
+func (f *recipeFile) parseSteps(n *yaml.Node) {
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+		return
+	}
+	if n.Kind != yaml.MappingNode {
+		f.addDiag(f.nodeRange(n), severityError, "steps must be a map of step IDs to steps")
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		idNode, stepNode := n.Content[i], n.Content[i+1]
+		s := stepDef{id: idNode.Value, rng: f.nodeRange(idNode), keyRng: f.nodeRange(idNode)}
+		if stepNode.Kind == yaml.MappingNode && len(stepNode.Content) >= 2 {
+			s.key = stepNode.Content[0].Value
+			s.keyRng = f.nodeRange(stepNode.Content[0])
+			f.parseProps(&s, stepNode.Content[1])
+		}
+		var m map[string]interface{}
+		if err := stepNode.Decode(&m); err != nil {
+			f.addDiag(s.rng, severityError, fmt.Sprintf("step %s must be a map with one ingredient.method", s.id))
+		} else if step, err := cook.ParseStep(s.id, m); err != nil {
+			f.addDiag(s.keyRng, severityError, strings.TrimPrefix(err.Error(), "error: "))
+		} else {
+			s.step = &step
+		}
+		f.steps = append(f.steps, s)
+	}
+}
+
+func (f *recipeFile) parseProps(s *stepDef, n *yaml.Node) {
+	if n.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, item := range n.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			key, value := item.Content[i], item.Content[i+1]
+			p := propDef{key: key.Value, keyRng: f.nodeRange(key), value: value, valueRng: f.nodeRange(value)}
+			p.templated = f.templated[key.Line-1] || f.templated[value.Line-1]
+			s.props = append(s.props, p)
+			if key.Value == "requisites" {
+				f.parseRequisites(s, value)
+			}
+		}
+	}
+}
+
+func (f *recipeFile) parseRequisites(s *stepDef, n *yaml.Node) {
+	if n.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, item := range n.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			ids := []*yaml.Node{item.Content[i+1]}
+			if item.Content[i+1].Kind == yaml.SequenceNode {
+				ids = item.Content[i+1].Content
+			}
+			for _, id := range ids {
+				if id.Kind == yaml.ScalarNode {
+					s.reqs = append(s.reqs, includeRef{name: id.Value, rng: f.nodeRange(id)})
+				}
+			}
+		}
+	}
+}
+
+func (f *recipeFile) addDiag(rng Range, severity int, msg string) {
+	f.diags = append(f.diags, Diagnostic{Range: rng, Severity: severity, Source: "grlx", Message: msg})
+}
+
+func (f *recipeFile) line(l int) string {
+	if l < 0 || l >= len(f.lines) {
+		return ""
+	}
+	return f.lines[l]
+}
+
+// lineRange covers the whole of line l.
+func (f *recipeFile) lineRange(l int) Range {
+	return Range{Start: Position{Line: l}, End: Position{Line: l, Character: utf16Len(f.line(l))}}
+}
+
+// nodeRange covers a node's value on the line it starts on.
+func (f *recipeFile) nodeRange(n *yaml.Node) Range {
+	l := n.Line - 1
+	text := f.line(l)
+	start := runeOffset(text, n.Column-1)
+	end := len(text)
+	if n.Kind == yaml.ScalarNode && n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+		width := len(n.Value)
+		if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+			width += 2
+		}
+		end = min(start+width, len(text))
+	}
+	return Range{
+		Start: Position{Line: l, Character: utf16Len(text[:start])},
+		End:   Position{Line: l, Character: utf16Len(text[:end])},
+	}
+}
+
+func (f *recipeFile) findStep(id string) *stepDef {
+	for i := range f.steps {
+		if f.steps[i].id == id {
+			return &f.steps[i]
+		}
+	}
+	return nil
+}
+
+// source reads recipe files, preferring the unsaved text of open ones.
+type source interface {
+	read(path string) (string, bool)
+	root() string
+}
+
+// resolveInclude finds the file an include refers to. Includes starting
+// with a dot are relative to the including recipe, others to the root of
+// the workspace or, failing that, the including recipe's directory.
+func resolveInclude(src source, from, name string) (string, error) {
+	dir := filepath.Dir(from)
+	if strings.HasPrefix(name, ".") {
+		return cook.ResolveRecipeFilePath(dir, types.RecipeName(strings.TrimPrefix(name, ".")))
+	}
+	var err error
+	for _, base := range []string{src.root(), dir} {
+		if base == "" {
+			continue
+		}
+		var path string
+		path, err = cook.ResolveRecipeFilePath(base, types.RecipeName(name))
+		if err == nil {
+			return path, nil
+		}
+	}
+	return "", err
+}
+
+// included parses every recipe f includes, directly or not, keyed by path.
+func included(src source, f *recipeFile) map[string]*recipeFile {
+	files := map[string]*recipeFile{}
+	queue := []*recipeFile{f}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, inc := range current.includes {
+			path, err := resolveInclude(src, current.path, inc.name)
+			if err != nil || path == f.path {
+				continue
+			}
+			if _, ok := files[path]; ok {
+				continue
+			}
+			text, ok := src.read(path)
+			if !ok {
+				continue
+			}
+			files[path] = parseRecipe(path, text)
+			queue = append(queue, files[path])
+		}
+	}
+	return files
+}
+
+// analyze checks a recipe the way the farmer does before cooking it:
+// each step is parsed as recipeToStep would, its properties are checked
+// against the ingredient registry, and its requisites must name steps in
+// the recipe or its includes without forming a cycle.
+func analyze(src source, path, text string) (*recipeFile, []Diagnostic) {
+	f := parseRecipe(path, text)
+	diags := append([]Diagnostic{}, f.diags...)
+	addDiag := func(rng Range, severity int, msg string) {
+		diags = append(diags, Diagnostic{Range: rng, Severity: severity, Source: "grlx", Message: msg})
+	}
+
+	for _, inc := range f.includes {
+		if _, err := resolveInclude(src, path, inc.name); err != nil {
+			addDiag(inc.rng, severityError, fmt.Sprintf("cannot find recipe %s", inc.name))
+		}
+	}
+	others := included(src, f)
+	definedIn := map[string]string{}
+	otherSteps := []*types.Step{}
+	for _, p := range sortedKeys(others) {
+		for _, s := range others[p].steps {
+			if _, ok := definedIn[s.id]; !ok {
+				definedIn[s.id] = p
+				if s.step != nil {
+					otherSteps = append(otherSteps, s.step)
+				}
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	steps := []*types.Step{}
+	complete := true
+	for _, s := range f.steps {
+		if seen[s.id] {
+			addDiag(s.rng, severityError, fmt.Sprintf("step %s is defined more than once", s.id))
+		} else if other, ok := definedIn[s.id]; ok {
+			addDiag(s.rng, severityError, fmt.Sprintf("step %s is also defined in %s", s.id, other))
+		}
+		seen[s.id] = true
+		if s.step == nil {
+			complete = false
+			continue
+		}
+		steps = append(steps, s.step)
+		diags = append(diags, checkProperties(s)...)
+		for _, req := range s.reqs {
+			if !seen[req.name] && f.findStep(req.name) == nil && definedIn[req.name] == "" {
+				complete = false
+				addDiag(req.rng, severityError, fmt.Sprintf("step %s is not defined in this recipe or its includes", req.name))
+			}
+		}
+	}
+
+	if complete {
+		all := append(steps, otherSteps...)
+		if ok, _ := rootball.AllRequisitesDefined(all); ok {
+			if hasCycle, cycle := rootball.HasCycle(all); hasCycle {
+				ids := []string{}
+				inCycle := map[string]bool{}
+				for _, id := range cycle {
+					ids = append(ids, string(id))
+					inCycle[string(id)] = true
+				}
+				for _, s := range f.steps {
+					if inCycle[s.id] {
+						addDiag(s.rng, severityError, fmt.Sprintf("%v: %s", types.ErrDependencyCycleFound, strings.Join(ids, " -> ")))
+					}
+				}
+			}
+		}
+	}
+	return f, diags
+}
+
+// checkProperties checks a step's properties against its ingredient's
+// schema. Ingredients which aren't compiled in may still be provided by a
+// plugin on the sprout, so they only earn a warning.
+func checkProperties(s stepDef) []Diagnostic {
+	diags := []Diagnostic{}
+	addDiag := func(rng Range, severity int, msg string) {
+		diags = append(diags, Diagnostic{Range: rng, Severity: severity, Source: "grlx", Message: msg})
+	}
+	schema, err := ingredients.SchemaFor(s.step.Ingredient, s.step.Method)
+	switch {
+	case errors.Is(err, ingredients.ErrUnknownIngredient):
+		addDiag(s.keyRng, severityWarning, fmt.Sprintf("ingredient %s is not built into grlx and must be provided by a plugin on the sprout", s.step.Ingredient))
+		return diags
+	case errors.Is(err, ingredients.ErrUnknownMethod):
+		addDiag(s.keyRng, severityError, fmt.Sprintf("ingredient %s has no method %s", s.step.Ingredient, s.step.Method))
+		return diags
+	case err != nil:
+		addDiag(s.keyRng, severityError, err.Error())
+		return diags
+	}
+	props := map[string]ingredients.MethodProps{}
+	keys := []string{}
+	for _, prop := range schema {
+		props[prop.Key] = prop
+		keys = append(keys, prop.Key)
+	}
+	present := map[string]bool{}
+	for _, p := range s.props {
+		present[p.key] = true
+		if p.key == "requisites" {
+			continue
+		}
+		prop, ok := props[p.key]
+		if !ok {
+			msg := fmt.Sprintf("unknown property %s for %s", p.key, s.key)
+			if guess := closest(p.key, keys); guess != "" {
+				msg += fmt.Sprintf(", did you mean %s?", guess)
+			}
+			addDiag(p.keyRng, severityError, msg)
+			continue
+		}
+		if p.templated {
+			continue
+		}
+		var v interface{}
+		if err := p.value.Decode(&v); err != nil {
+			continue
+		}
+		if _, err := (ingredients.MethodPropsSet{prop}).Decode(map[string]interface{}{p.key: v}); err != nil {
+			addDiag(p.valueRng, severityError, strings.ReplaceAll(err.Error(), "\n", ": "))
+		}
+	}
+	for _, key := range keys {
+		if props[key].IsReq && !present[key] {
+			addDiag(s.keyRng, severityError, fmt.Sprintf("missing required property %s", key))
+		}
+	}
+	return diags
+}
+
+// closest returns the candidate nearest to s, if it is a plausible typo.
+func closest(s string, candidates []string) string {
+	best, bestDist := "", 3
+	for _, c := range candidates {
+		if d := editDistance(s, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func sortedKeys(m map[string]*recipeFile) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readFile reads a recipe from disk.
+func readFile(path string) (string, bool) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
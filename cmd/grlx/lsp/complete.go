@@ -0,0 +1,292 @@
+package lsp
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var requisiteTypes = []types.ReqType{
+	types.Require,
+	types.RequireAny,
+	types.OnChanges,
+	types.OnChangesAny,
+	types.OnFail,
+	types.OnFailAny,
+}
+
+// outlineLine is a line of a recipe split into its indentation, list
+// marker, key and value. Completion can't rely on the recipe parsing, as
+// the line being typed rarely is valid YAML.
+type outlineLine struct {
+	indent int
+	dash   bool
+	keyCol int
+	key    string
+	hasKey bool
+	value  string
+}
+
+func outline(line string) (outlineLine, bool) {
+	var o outlineLine
+	trimmed := strings.TrimLeft(line, " ")
+	o.indent = len(line) - len(trimmed)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return o, false
+	}
+	if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+		o.dash = true
+		rest := strings.TrimLeft(strings.TrimPrefix(trimmed, "-"), " ")
+		o.keyCol = len(line) - len(rest)
+		trimmed = rest
+	} else {
+		o.keyCol = o.indent
+	}
+	o.key = trimmed
+	if i := strings.Index(trimmed, ":"); i >= 0 && (i == len(trimmed)-1 || trimmed[i+1] == ' ') {
+		o.hasKey = true
+		o.key = unquote(strings.TrimSpace(trimmed[:i]))
+		o.value = strings.TrimSpace(trimmed[i+1:])
+	}
+	return o, true
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// ancestors returns the keys enclosing line l, outermost first.
+func ancestors(lines []string, l int, current outlineLine) []string {
+	path := []string{}
+	col, dash := current.indent, current.dash
+	for i := l - 1; i >= 0 && col > 0; i-- {
+		o, ok := outline(lines[i])
+		if !ok || !o.hasKey {
+			continue
+		}
+		if o.keyCol < col || (o.keyCol == col && dash && o.value == "") {
+			path = append([]string{o.key}, path...)
+			col, dash = o.indent, o.dash
+		}
+	}
+	return path
+}
+
+// complete suggests what may be typed at pos: top-level keys, recipes to
+// include, ingredient methods, their properties and values, requisite
+// types and the IDs of steps to depend on.
+func (s *server) complete(uri string, pos Position) []CompletionItem {
+	text, ok := s.docs[uri]
+	if !ok {
+		return []CompletionItem{}
+	}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if pos.Line >= len(lines) {
+		return []CompletionItem{}
+	}
+	line := lines[pos.Line]
+	cursor := byteOffset(line, pos.Character)
+	current, _ := outline(line[:cursor])
+	path := ancestors(lines, pos.Line, current)
+
+	prefix := current.key
+	if current.hasKey {
+		prefix = current.value
+		if i := strings.LastIndexAny(prefix, "[,"); i >= 0 {
+			prefix = strings.TrimLeft(prefix[i+1:], " ")
+		}
+	}
+	edit := func(newText string) *TextEdit {
+		return &TextEdit{
+			Range: Range{
+				Start: Position{Line: pos.Line, Character: utf16Len(line[:cursor-len(prefix)])},
+				End:   pos,
+			},
+			NewText: newText,
+		}
+	}
+
+	items := []CompletionItem{}
+	add := func(label string, kind int, detail, doc, newText string) {
+		items = append(items, CompletionItem{Label: label, Kind: kind, Detail: detail, Documentation: doc, TextEdit: edit(newText)})
+	}
+	onKey := !current.hasKey
+	switch {
+	case len(path) == 0 && onKey:
+		add("include", kindKeyword, "recipes to include", "", "include:")
+		add("steps", kindKeyword, "steps of the recipe", "", "steps:")
+	case len(path) == 1 && path[0] == "include" && onKey:
+		for _, name := range s.recipeNames(uriToPath(uri)) {
+			add(name, kindFile, "", "", name)
+		}
+	case len(path) == 2 && path[0] == "steps" && onKey:
+		for _, ing := range ingredients.Registered() {
+			for _, method := range ing.Methods {
+				label := ing.Name + "." + method.Name
+				add(label, kindMethod, "", "", label+":")
+			}
+		}
+	case len(path) == 3 && path[0] == "steps" && onKey:
+		for _, prop := range propertiesOf(path[2]) {
+			detail := prop.Type
+			if prop.IsReq {
+				detail += ", required"
+			}
+			add(prop.Key, kindProperty, detail, prop.Description, prop.Key+": ")
+		}
+		add("requisites", kindProperty, "list", "steps which must run before this one", "requisites:")
+	case len(path) == 3 && path[0] == "steps":
+		for _, prop := range propertiesOf(path[2]) {
+			if prop.Key != current.key {
+				continue
+			}
+			values := prop.Enum
+			if len(values) == 0 && prop.Type == "bool" {
+				values = []string{"true", "false"}
+			}
+			for _, v := range values {
+				add(v, kindEnumMember, "", "", v)
+			}
+		}
+	case len(path) == 4 && path[0] == "steps" && path[3] == "requisites" && onKey:
+		for _, req := range requisiteTypes {
+			add(string(req), kindKeyword, "", "", string(req)+": ")
+		}
+	case len(path) == 4 && path[0] == "steps" && path[3] == "requisites",
+		len(path) == 5 && path[0] == "steps" && path[3] == "requisites" && onKey:
+		for _, id := range s.stepIDs(uri, lines, pos.Line) {
+			if id != path[1] {
+				add(id, kindReference, "", "", id)
+			}
+		}
+	}
+	return items
+}
+
+// propertiesOf returns the properties of an ingredient.method key, if it
+// names a registered one.
+func propertiesOf(key string) ingredients.MethodPropsSet {
+	ing, method, ok := strings.Cut(key, ".")
+	if !ok {
+		return nil
+	}
+	schema, err := ingredients.SchemaFor(types.Ingredient(ing), method)
+	if err != nil {
+		return nil
+	}
+	return schema
+}
+
+// stepIDs returns the IDs of the steps in a document and in the recipes it
+// includes. The line being typed is left out so the rest still parses.
+func (s *server) stepIDs(uri string, lines []string, skip int) []string {
+	rest := append([]string{}, lines...)
+	rest[skip] = ""
+	f := parseRecipe(uriToPath(uri), strings.Join(rest, "\n"))
+	seen := map[string]bool{}
+	ids := []string{}
+	for _, step := range f.steps {
+		if !seen[step.id] {
+			seen[step.id] = true
+			ids = append(ids, step.id)
+		}
+	}
+	others := included(s, f)
+	for _, p := range sortedKeys(others) {
+		for _, step := range others[p].steps {
+			if !seen[step.id] {
+				seen[step.id] = true
+				ids = append(ids, step.id)
+			}
+		}
+	}
+	return ids
+}
+
+// recipeNames lists the recipes under the workspace root, by the names
+// they're included with.
+func (s *server) recipeNames(from string) []string {
+	base := s.rootPath
+	if base == "" {
+		base = filepath.Dir(from)
+	}
+	names := []string{}
+	ext := "." + config.GrlxExt
+	filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != base && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if path == from || !strings.HasSuffix(path, ext) {
+			return nil
+		}
+		rel, err := filepath.Rel(base, strings.TrimSuffix(path, ext))
+		if err != nil {
+			return nil
+		}
+		rel = strings.TrimSuffix(rel, string(filepath.Separator)+"init")
+		if rel == "init" {
+			return nil
+		}
+		names = append(names, strings.ReplaceAll(rel, string(filepath.Separator), "."))
+		return nil
+	})
+	sort.Strings(names)
+	return names
+}
+
+// definition finds the recipe an include refers to, or the step a
+// requisite refers to.
+func (s *server) definition(uri string, pos Position) *Location {
+	text, ok := s.docs[uri]
+	if !ok {
+		return nil
+	}
+	path := uriToPath(uri)
+	f := parseRecipe(path, text)
+	for _, inc := range f.includes {
+		if !contains(inc.rng, pos) {
+			continue
+		}
+		target, err := resolveInclude(s, path, inc.name)
+		if err != nil {
+			return nil
+		}
+		return &Location{URI: pathToURI(target)}
+	}
+	for _, step := range f.steps {
+		for _, req := range step.reqs {
+			if !contains(req.rng, pos) {
+				continue
+			}
+			if def := f.findStep(req.name); def != nil {
+				return &Location{URI: uri, Range: def.rng}
+			}
+			others := included(s, f)
+			for _, p := range sortedKeys(others) {
+				if def := others[p].findStep(req.name); def != nil {
+					return &Location{URI: pathToURI(p), Range: def.rng}
+				}
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func contains(rng Range, pos Position) bool {
+	return pos.Line == rng.Start.Line && pos.Character >= rng.Start.Character && pos.Character <= rng.End.Character
+}
@@ -0,0 +1,365 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	_ "github.com/gogrlx/grlx/ingredients/cmd"
+	_ "github.com/gogrlx/grlx/ingredients/file"
+)
+
+func newTestServer(root string) *server {
+	return &server{out: &writer{w: io.Discard}, rootPath: root, docs: map[string]string{}}
+}
+
+func writeRecipe(t *testing.T, path, text string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "recipes")
+	writeRecipe(t, filepath.Join(root, "base.grlx"), `steps:
+  base:
+    cmd.run:
+      - name: true
+`)
+	testCases := []struct {
+		id    string
+		text  string
+		diags []string
+	}{
+		{
+			id: "valid",
+			text: `include:
+  - base
+steps:
+  hello:
+    cmd.run:
+      - name: echo {{ .name }}
+      - timeout: 1m
+      - requisites:
+        - require: base
+`,
+		},
+		{
+			id: "file managed",
+			text: `steps:
+  config:
+    file.managed:
+      - name: /etc/app.conf
+      - source: grlx://app/app.conf
+      - skip_verify: true
+      - mode: "0644"
+`,
+		},
+		{
+			id: "file managed typo",
+			text: `steps:
+  config:
+    file.managed:
+      - name: /etc/app.conf
+      - sorce: grlx://app/app.conf
+`,
+			diags: []string{
+				"4:8 unknown property sorce for file.managed, did you mean source?",
+				"2:4 missing required property source",
+			},
+		},
+		{
+			id: "unknown method",
+			text: `steps:
+  hello:
+    cmd.walk:
+      - name: echo
+`,
+			diags: []string{"2:4 ingredient cmd has no method walk"},
+		},
+		{
+			id: "misspelled property",
+			text: `steps:
+  hello:
+    cmd.run:
+      - name: echo
+      - timout: 1m
+`,
+			diags: []string{"4:8 unknown property timout for cmd.run, did you mean timeout?"},
+		},
+		{
+			id: "invalid and missing property",
+			text: `steps:
+  hello:
+    cmd.run:
+      - timeout: soon
+`,
+			diags: []string{
+				"3:17 invalid property: timeout must be a duration such as 30s, not soon",
+				"2:4 missing required property name",
+			},
+		},
+		{
+			id: "plugin ingredient",
+			text: `steps:
+  hello:
+    custom.run:
+      - anything: 1
+`,
+			diags: []string{"2:4 ingredient custom is not built into grlx and must be provided by a plugin on the sprout"},
+		},
+		{
+			id: "missing requisite and include",
+			text: `include:
+  - base
+  - .missing
+steps:
+  hello:
+    cmd.run:
+      - name: echo
+      - requisites:
+        - require: [base, nowhere]
+`,
+			diags: []string{
+				"2:4 cannot find recipe .missing",
+				"8:26 step nowhere is not defined in this recipe or its includes",
+			},
+		},
+		{
+			id: "cycle",
+			text: `steps:
+  a:
+    cmd.run:
+      - name: a
+      - requisites:
+        - require: b
+  b:
+    cmd.run:
+      - name: b
+      - requisites:
+        - onchanges: a
+`,
+			diags: []string{
+				"1:2 found a dependency cycle: a -> b -> a",
+				"6:2 found a dependency cycle: a -> b -> a",
+			},
+		},
+		{
+			id: "duplicate across includes",
+			text: `include:
+  - base
+steps:
+  base:
+    cmd.run:
+      - name: true
+`,
+			diags: []string{"3:2 step base is also defined in " + filepath.Join(root, "base.grlx")},
+		},
+		{
+			id: "bad key",
+			text: `steps:
+  hello:
+    cmd:
+      - name: echo
+`,
+			diags: []string{"2:4 recipe key must be in the form ingredient.method"},
+		},
+		{
+			id: "yaml error",
+			text: `steps:
+  hello:
+    cmd.run:
+      - name: "echo
+`,
+			diags: []string{"3:0 line 4: found unexpected end of stream"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.id, func(t *testing.T) {
+			s := newTestServer(root)
+			_, diags := analyze(s, filepath.Join(root, "test.grlx"), tc.text)
+			got := []string{}
+			for _, d := range diags {
+				got = append(got, fmt.Sprintf("%d:%d %s", d.Range.Start.Line, d.Range.Start.Character, d.Message))
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.diags, "\n") {
+				t.Errorf("expected diagnostics\n%s\ngot\n%s", strings.Join(tc.diags, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestComplete(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "recipes")
+	writeRecipe(t, filepath.Join(root, "base.grlx"), "steps:\n  base:\n    cmd.run:\n      - name: true\n")
+	writeRecipe(t, filepath.Join(root, "web", "init.grlx"), "steps: {}\n")
+	testCases := []struct {
+		id       string
+		text     string
+		expected []string
+		absent   []string
+	}{
+		{
+			id:       "top level",
+			text:     "st|",
+			expected: []string{"include", "steps"},
+		},
+		{
+			id:       "include",
+			text:     "include:\n  - |",
+			expected: []string{"base", "web"},
+		},
+		{
+			id:       "ingredient method",
+			text:     "steps:\n  hello:\n    cmd.|",
+			expected: []string{"cmd.run"},
+		},
+		{
+			id:       "property",
+			text:     "steps:\n  hello:\n    cmd.run:\n      - name: echo\n      - ti|",
+			expected: []string{"timeout", "requisites"},
+		},
+		{
+			id:       "file property",
+			text:     "steps:\n  config:\n    file.managed:\n      - name: /etc/app.conf\n      - |",
+			expected: []string{"source", "skip_verify", "mode", "requisites"},
+		},
+		{
+			id:       "file method",
+			text:     "steps:\n  config:\n    file.|",
+			expected: []string{"file.managed", "file.absent"},
+		},
+		{
+			id:       "requisite type",
+			text:     "steps:\n  hello:\n    cmd.run:\n      - requisites:\n        - |",
+			expected: []string{"require", "onchanges_any"},
+		},
+		{
+			id:       "requisite value",
+			text:     "include:\n  - base\nsteps:\n  other:\n    cmd.run:\n      - name: true\n  hello:\n    cmd.run:\n      - requisites:\n        - require: |",
+			expected: []string{"other", "base"},
+			absent:   []string{"hello"},
+		},
+		{
+			id:       "requisite list",
+			text:     "steps:\n  other:\n    cmd.run: []\n  hello:\n    cmd.run:\n      - requisites:\n        - require:\n          - |",
+			expected: []string{"other"},
+			absent:   []string{"hello"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.id, func(t *testing.T) {
+			s := newTestServer(root)
+			uri := pathToURI(filepath.Join(root, "test.grlx"))
+			cursor := strings.Index(tc.text, "|")
+			s.docs[uri] = strings.Replace(tc.text, "|", "", 1)
+			lines := strings.Split(tc.text[:cursor], "\n")
+			pos := Position{Line: len(lines) - 1, Character: len(lines[len(lines)-1])}
+			labels := map[string]bool{}
+			for _, item := range s.complete(uri, pos) {
+				labels[item.Label] = true
+			}
+			for _, label := range tc.expected {
+				if !labels[label] {
+					t.Errorf("expected completion %s, got %v", label, labels)
+				}
+			}
+			for _, label := range tc.absent {
+				if labels[label] {
+					t.Errorf("unexpected completion %s", label)
+				}
+			}
+		})
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "recipes")
+	base := filepath.Join(root, "base.grlx")
+	writeRecipe(t, base, "steps:\n  base:\n    cmd.run:\n      - name: true\n")
+	s := newTestServer(root)
+	uri := pathToURI(filepath.Join(root, "test.grlx"))
+	s.docs[uri] = "include:\n  - base\nsteps:\n  hello:\n    cmd.run:\n      - requisites:\n        - require: base\n"
+
+	loc := s.definition(uri, Position{Line: 1, Character: 5})
+	if loc == nil || loc.URI != pathToURI(base) {
+		t.Errorf("expected include to resolve to %s, got %v", base, loc)
+	}
+	loc = s.definition(uri, Position{Line: 6, Character: 20})
+	if loc == nil || loc.URI != pathToURI(base) || loc.Range.Start.Line != 1 {
+		t.Errorf("expected requisite to resolve to the base step, got %v", loc)
+	}
+	if loc = s.definition(uri, Position{Line: 3, Character: 3}); loc != nil {
+		t.Errorf("expected no definition, got %v", loc)
+	}
+}
+
+func TestServe(t *testing.T) {
+	root := t.TempDir()
+	var in bytes.Buffer
+	send := func(v interface{}) {
+		body, _ := json.Marshal(v)
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	uri := pathToURI(filepath.Join(root, "test.grlx"))
+	send(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": map[string]interface{}{"rootUri": pathToURI(root)}})
+	send(map[string]interface{}{"jsonrpc": "2.0", "method": "initialized", "params": map[string]interface{}{}})
+	send(map[string]interface{}{"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "version": 1, "text": "steps:\n  hello:\n    cmd.walk: []\n"},
+	}})
+	send(map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": "textDocument/hover", "params": map[string]interface{}{}})
+	send(map[string]interface{}{"jsonrpc": "2.0", "id": 3, "method": "shutdown"})
+	send(map[string]interface{}{"jsonrpc": "2.0", "method": "exit"})
+
+	var out bytes.Buffer
+	if err := Serve(&in, &out); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(&out)
+	var replies []map[string]interface{}
+	for {
+		header, err := textproto.NewReader(r).ReadMIMEHeader()
+		if err != nil {
+			break
+		}
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			t.Fatal(err)
+		}
+		reply := map[string]interface{}{}
+		if err := json.Unmarshal(body, &reply); err != nil {
+			t.Fatal(err)
+		}
+		replies = append(replies, reply)
+	}
+	if len(replies) != 4 {
+		t.Fatalf("expected 4 messages, got %d: %v", len(replies), replies)
+	}
+	if replies[0]["id"] != float64(1) {
+		t.Errorf("expected a reply to initialize, got %v", replies[0])
+	}
+	params, _ := replies[1]["params"].(map[string]interface{})
+	diags, _ := params["diagnostics"].([]interface{})
+	if replies[1]["method"] != "textDocument/publishDiagnostics" || params["uri"] != uri || len(diags) != 1 {
+		t.Errorf("expected one diagnostic for %s, got %v", uri, replies[1])
+	}
+	if replies[2]["id"] != float64(2) || replies[2]["error"] == nil {
+		t.Errorf("expected an error for an unknown method, got %v", replies[2])
+	}
+	if _, ok := replies[3]["result"]; replies[3]["id"] != float64(3) || !ok {
+		t.Errorf("expected a reply to shutdown, got %v", replies[3])
+	}
+}
@@ -0,0 +1,213 @@
+// Package lsp implements a language server for grlx recipe files, so that
+// editors can report the problems the farmer would find before a recipe is
+// cooked and complete ingredients, properties and step IDs.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+// server holds the state of one editor session.
+type server struct {
+	out      *writer
+	rootPath string
+	docs     map[string]string
+	shutdown bool
+}
+
+// Serve answers requests read from in until the client exits or in is
+// closed, writing responses and notifications to out.
+func Serve(in io.Reader, out io.Writer) error {
+	s := &server{out: &writer{w: out}, docs: map[string]string{}}
+	r := bufio.NewReader(in)
+	for {
+		msg, err := readMessage(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if errors.Is(err, ErrMissingContentLength) {
+			return err
+		}
+		if err != nil {
+			if werr := s.out.write(failure{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: responseError{Code: codeParseError, Message: err.Error()}}); werr != nil {
+				return werr
+			}
+			continue
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a message and writes the response to requests.
+func (s *server) handle(msg message) error {
+	res, rerr := s.dispatch(msg)
+	if msg.ID == nil {
+		return nil
+	}
+	if rerr != nil {
+		return s.out.write(failure{JSONRPC: "2.0", ID: *msg.ID, Error: *rerr})
+	}
+	return s.out.write(result{JSONRPC: "2.0", ID: *msg.ID, Result: res})
+}
+
+func (s *server) dispatch(msg message) (interface{}, *responseError) {
+	invalid := func(err error) *responseError {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	switch msg.Method {
+	case "initialize":
+		var params initializeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		s.initialize(params)
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": 1,
+				"completionProvider": map[string]interface{}{
+					"triggerCharacters": []string{".", " ", "-"},
+				},
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "grlx"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		s.docs[params.TextDocument.URI] = params.TextDocument.Text
+		return nil, s.publishAll()
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		text := s.docs[params.TextDocument.URI]
+		for _, change := range params.ContentChanges {
+			text = applyChange(text, change)
+		}
+		s.docs[params.TextDocument.URI] = text
+		return nil, s.publishAll()
+	case "textDocument/didClose":
+		var params textDocumentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		delete(s.docs, params.TextDocument.URI)
+		if err := s.publish(params.TextDocument.URI, []Diagnostic{}); err != nil {
+			return nil, &responseError{Code: codeInvalidRequest, Message: err.Error()}
+		}
+		return nil, s.publishAll()
+	case "textDocument/completion":
+		var params positionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		return completionList{Items: s.complete(params.TextDocument.URI, params.Position)}, nil
+	case "textDocument/definition":
+		var params positionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		loc := s.definition(params.TextDocument.URI, params.Position)
+		if loc == nil {
+			return nil, nil
+		}
+		return loc, nil
+	case "initialized", "textDocument/didSave", "$/cancelRequest", "$/setTrace", "workspace/didChangeConfiguration":
+		return nil, nil
+	}
+	if msg.ID == nil {
+		return nil, nil
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+}
+
+func (s *server) initialize(params initializeParams) {
+	switch {
+	case params.RootURI != "":
+		s.rootPath = uriToPath(params.RootURI)
+	case params.RootPath != "":
+		s.rootPath = params.RootPath
+	case len(params.WorkspaceFolders) > 0:
+		s.rootPath = uriToPath(params.WorkspaceFolders[0].URI)
+	}
+}
+
+// publishAll reports diagnostics for every open document, since a change
+// to one recipe can break the recipes which include it.
+func (s *server) publishAll() *responseError {
+	uris := make([]string, 0, len(s.docs))
+	for uri := range s.docs {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		_, diags := analyze(s, uriToPath(uri), s.docs[uri])
+		if err := s.publish(uri, diags); err != nil {
+			return &responseError{Code: codeInvalidRequest, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+func (s *server) publish(uri string, diags []Diagnostic) error {
+	return s.out.write(notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  publishDiagnosticsParams{URI: uri, Diagnostics: diags},
+	})
+}
+
+func (s *server) root() string {
+	return s.rootPath
+}
+
+func (s *server) read(path string) (string, bool) {
+	if text, ok := s.docs[pathToURI(path)]; ok {
+		return text, true
+	}
+	return readFile(path)
+}
+
+// applyChange applies an incremental change, or replaces the whole text if
+// the change has no range.
+func applyChange(text string, change contentChange) string {
+	if change.Range == nil {
+		return change.Text
+	}
+	start := offset(text, change.Range.Start)
+	end := max(offset(text, change.Range.End), start)
+	return text[:start] + change.Text + text[end:]
+}
+
+// offset converts a position to a byte offset in text.
+func offset(text string, pos Position) int {
+	i := 0
+	for l := 0; l < pos.Line; l++ {
+		next := strings.IndexByte(text[i:], '\n')
+		if next < 0 {
+			return len(text)
+		}
+		i += next + 1
+	}
+	line := text[i:]
+	if end := strings.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+	return i + byteOffset(line, pos.Character)
+}
@@ -1,9 +1,5 @@
 package main
 
 import (
-	_ "github.com/gogrlx/grlx/ingredients/cmd"
-	_ "github.com/gogrlx/grlx/ingredients/file"
-	_ "github.com/gogrlx/grlx/ingredients/group"
-	_ "github.com/gogrlx/grlx/ingredients/service/systemd"
-	_ "github.com/gogrlx/grlx/ingredients/user"
+	_ "github.com/gogrlx/grlx/ingredients/all"
 )
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"os"
@@ -12,6 +13,9 @@ import (
 	"github.com/gogrlx/grlx/config"
 	"github.com/gogrlx/grlx/cook"
 	"github.com/gogrlx/grlx/ingredients/cmd"
+	"github.com/gogrlx/grlx/ingredients/event"
+	"github.com/gogrlx/grlx/ingredients/file/farmer"
+	"github.com/gogrlx/grlx/ingredients/plugin"
 	"github.com/gogrlx/grlx/ingredients/test"
 	"github.com/gogrlx/grlx/pki"
 
@@ -38,6 +42,14 @@ func main() {
 	config.LoadConfig("sprout")
 	defer log.Flush()
 	certs.GenNKey(false)
+	plugins, err := plugin.LoadDir(context.Background(), config.PluginDir)
+	if err != nil {
+		log.Errorf("Error loading plugins: %v", err)
+	}
+	for _, p := range plugins {
+		name, methods := p.Methods()
+		log.Debugf("Loaded plugin ingredient %s with methods %v", name, methods)
+	}
 	for err := pki.LoadRootCA("sprout"); err != nil; err = pki.LoadRootCA("sprout") {
 		log.Debugf("Error with RootCA: %v", err)
 		// TODO make this delay configurable
@@ -98,6 +110,7 @@ func ConnectSprout() {
 	nc, err := nats.Connect(FarmerBusURL, nats.Secure(config), opt,
 		nats.MaxReconnects(-1),
 		nats.ReconnectWait(time.Second*15),
+		nats.CustomInboxPrefix(pki.SproutInboxPrefix(sproutID)),
 		nats.DisconnectHandler(func(_ *nats.Conn) {
 			connectionAttempts++
 			log.Debugf("Reconnecting to Farmer, attempt: %d\n", connectionAttempts)
@@ -108,6 +121,7 @@ func ConnectSprout() {
 		nc, err = nats.Connect(FarmerBusURL, nats.Secure(config), opt,
 			nats.MaxReconnects(-1),
 			nats.ReconnectWait(time.Second*15),
+			nats.CustomInboxPrefix(pki.SproutInboxPrefix(sproutID)),
 			// TODO: Add a reconnect handler
 			nats.DisconnectHandler(func(_ *nats.Conn) {
 				connectionAttempts++
@@ -125,7 +139,9 @@ func ConnectSprout() {
 	ec, _ := nats.NewEncodedConn(nc, nats.JSON_ENCODER)
 	test.RegisterEC(ec)
 	cmd.RegisterEC(ec)
+	event.RegisterEC(ec)
 	cook.RegisterEC(ec)
+	farmer.RegisterEC(ec)
 	err = natsInit(ec)
 	if err != nil {
 		log.Panicf("Error with natsInit: %v", err)
@@ -8,6 +8,7 @@ import (
 	log "github.com/taigrr/log-socket/log"
 
 	"github.com/gogrlx/grlx/cook"
+	"github.com/gogrlx/grlx/ingredients"
 	"github.com/gogrlx/grlx/ingredients/cmd"
 	"github.com/gogrlx/grlx/ingredients/test"
 	"github.com/gogrlx/grlx/pki"
@@ -84,5 +85,12 @@ func natsInit(nc *nats.EncodedConn) error {
 	if err != nil {
 		return err
 	}
+	_, err = nc.Subscribe("grlx.sprouts."+sproutID+".ingredients.list", func(m *nats.Msg) {
+		listB, _ := json.Marshal(types.IngredientList{Ingredients: ingredients.Registered()})
+		m.Respond(listB)
+	})
+	if err != nil {
+		return err
+	}
 	return nil
 }
@@ -43,6 +43,8 @@ var (
 	NKeyFarmerPubFile    string
 	NKeySproutPrivFile   string
 	NKeySproutPubFile    string
+	PluginDir            string
+	PluginTimeout        time.Duration
 	// TODO the final path arg should be dynamic to allow for dev/prod/etc
 	RecipeDir    = filepath.Join("/", "srv", "grlx", "recipes", "prod")
 	RootCA       string
@@ -192,6 +194,8 @@ func LoadConfig(binary string) {
 			jety.SetDefault("joblogdir", "/var/cache/grlx/sprout/jobs")
 			jety.SetDefault("nkeysproutprivfile", "/etc/grlx/pki/sprout/sprout.nkey")
 			jety.SetDefault("cachedir", "/var/cache/grlx/sprout/files/provided")
+			jety.SetDefault("plugindir", "/etc/grlx/plugins")
+			jety.SetDefault("plugintimeout", 10*time.Minute)
 
 			JobLogDir = jety.GetString("joblogdir")
 		}
@@ -234,6 +238,8 @@ func LoadConfig(binary string) {
 	NKeySproutPrivFile = jety.GetString("nkeysproutprivfile")
 	NKeySproutPubFile = jety.GetString("nkeysproutpubfile")
 	FarmerOrganization = jety.GetString("farmerorganization")
+	PluginDir = jety.GetString("plugindir")
+	PluginTimeout = jety.GetDuration("plugintimeout")
 	RootCA = jety.GetString("rootca")
 	RootCAPriv = jety.GetString("rootcapriv")
 	SproutID = jety.GetString("sproutid")
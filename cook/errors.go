@@ -5,7 +5,8 @@ import (
 )
 
 var (
-	ErrNoRecipe      = errors.New("no recipe")
-	ErrInvalidFormat = errors.New("invalid recipe format")
-	ErrDuplicateKey  = errors.New("duplicate key in joined maps")
+	ErrNoRecipe          = errors.New("no recipe")
+	ErrInvalidFormat     = errors.New("invalid recipe format")
+	ErrDuplicateKey      = errors.New("duplicate key in joined maps")
+	ErrInvalidProperties = errors.New("invalid step properties")
 )
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
@@ -14,6 +16,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/ingredients"
 	"github.com/gogrlx/grlx/props"
 	"github.com/gogrlx/grlx/types"
 )
@@ -78,6 +81,10 @@ func SendCookEvent(sproutID string, recipeID types.RecipeName, JID string) error
 	if err != nil {
 		return err
 	}
+	err = validateStepProperties(steps)
+	if err != nil {
+		return err
+	}
 	tree, err := validateRecipeTree(steps)
 	if err != nil {
 		return err
@@ -105,6 +112,24 @@ func SendCookEvent(sproutID string, recipeID types.RecipeName, JID string) error
 	return nil
 }
 
+// validateStepProperties checks every step against its ingredient's
+// property schema, so that a misspelled or mistyped property fails before
+// the recipe is sent to the sprout.
+func validateStepProperties(steps []*types.Step) error {
+	sorted := slices.Clone(steps)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	var errs []error
+	for _, step := range sorted {
+		if err := ingredients.ValidateStep(*step); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(append([]error{ErrInvalidProperties}, errs...)...)
+	}
+	return nil
+}
+
 func GenerateJobID() string {
 	return uuid.New().String()
 }
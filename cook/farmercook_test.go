@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/gogrlx/grlx/ingredients"
+	_ "github.com/gogrlx/grlx/ingredients/test"
 	"github.com/gogrlx/grlx/types"
 )
 
@@ -88,3 +90,44 @@ func TestResolveRecipeFilePath(t *testing.T) {
 // 		})
 // 	}
 // }
+
+func TestValidateStepProperties(t *testing.T) {
+	recipe := map[string]interface{}{
+		"ok": map[string]interface{}{"test.succeed_with_changes": []interface{}{
+			map[string]interface{}{"name": "ok"},
+			map[string]interface{}{"notes": []interface{}{"one", 2}},
+		}},
+		"typo": map[string]interface{}{"test.succeed_with_changes": []interface{}{
+			map[string]interface{}{"name": "typo"},
+			map[string]interface{}{"chnages": false},
+		}},
+		"sleep": map[string]interface{}{"test.sleep": []interface{}{
+			map[string]interface{}{"name": "sleep"},
+			map[string]interface{}{"duration": "a while"},
+		}},
+		"plugin": map[string]interface{}{"notcompiledin.run": []interface{}{
+			map[string]interface{}{"anything": 1},
+		}},
+	}
+	steps, err := makeRecipeSteps(recipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = validateStepProperties(steps)
+	if !errors.Is(err, ErrInvalidProperties) {
+		t.Fatalf("expected %v, got %v", ErrInvalidProperties, err)
+	}
+	if !errors.Is(err, ingredients.ErrUnknownProperty) || !errors.Is(err, ingredients.ErrInvalidProperty) {
+		t.Errorf("expected errors for both invalid steps, got %v", err)
+	}
+
+	valid := []*types.Step{}
+	for _, step := range steps {
+		if step.ID == "ok" || step.ID == "plugin" {
+			valid = append(valid, step)
+		}
+	}
+	if err := validateStepProperties(valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package cook_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gogrlx/grlx/cook"
+	"github.com/gogrlx/grlx/ingredients"
+	_ "github.com/gogrlx/grlx/ingredients/all"
+)
+
+// The recipes under testing/recipes are validated as they are written,
+// including the mistakes some of them were written to exercise.
+func TestValidateFixtureRecipes(t *testing.T) {
+	testCases := []struct {
+		file  string
+		step  string
+		error error
+	}{
+		{file: "dev.grlx", step: "install golang"},
+		{file: "dev.grlx", step: "add go to path"},
+		// require belongs under requisites, so it is an unknown property
+		{file: "dev.grlx", step: "get go version", error: ingredients.ErrUnknownProperty},
+		{file: "dev.grlx", step: "temp file deleted"},
+		{file: "dev.grlx", step: "configure conky"},
+		{file: "independent.grlx", step: "touch unimportant file"},
+		{file: "invalidReq.grlx", step: "this is a step that can never run", error: ingredients.ErrUnknownMethod},
+		{file: "missing.grlx", step: "fix golang", error: ingredients.ErrUnknownProperty},
+		// file.cached never reads destination
+		{file: "simpletest.grlx", step: "create test file", error: ingredients.ErrUnknownProperty},
+		{file: "userCreation.grlx", step: "create void user"},
+		{file: "apache/apache.grlx", step: "configure http config"},
+		// file.exists does not manage ownership or modes
+		{file: "apache/apache.grlx", step: "configure keynav", error: ingredients.ErrUnknownProperty},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.file+"/"+tc.step, func(t *testing.T) {
+			steps := fixtureSteps(t, filepath.Join("..", "testing", "recipes", tc.file))
+			props, ok := steps[tc.step].(map[string]interface{})
+			if !ok {
+				t.Fatalf("step %s not found", tc.step)
+			}
+			step, err := cook.ParseStep(tc.step, props)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ingredients.ValidateStep(step)
+			if tc.error == nil && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			} else if !errors.Is(err, tc.error) {
+				t.Errorf("expected error %v, got %v", tc.error, err)
+			}
+		})
+	}
+}
+
+// fixtureSteps renders a recipe with every prop set and returns its steps.
+func fixtureSteps(t *testing.T, path string) map[string]interface{} {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := template.New(path).Funcs(template.FuncMap{
+		"props": func(string) interface{} { return true },
+	}).Parse(string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	recipe := map[string]interface{}{}
+	if err = yaml.Unmarshal(buf.Bytes(), &recipe); err != nil {
+		t.Fatal(err)
+	}
+	steps, _ := recipe["steps"].(map[string]interface{})
+	return steps
+}
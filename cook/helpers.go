@@ -39,6 +39,13 @@ func makeRecipeSteps(recipes map[string]interface{}) ([]*types.Step, error) {
 	return steps, nil
 }
 
+// ParseStep parses a single step of a recipe file the way the farmer does
+// before cooking it, so that tools such as the language server report the
+// same errors.
+func ParseStep(id string, recipe map[string]interface{}) (types.Step, error) {
+	return recipeToStep(id, recipe)
+}
+
 func recipeToStep(id string, recipe map[string]interface{}) (types.Step, error) {
 	var step types.Step
 	if len(recipe) != 1 {
@@ -286,7 +293,13 @@ func extractIncludes(sproutID, basepath, recipePath string, file []byte) ([]type
 }
 
 func renderRecipeTemplate(sproutID, recipeName string, file []byte) ([]byte, error) {
-	temp := template.New(recipeName)
+	return RenderTemplate(sproutID, recipeName, file, nil)
+}
+
+// RenderTemplate renders file with the same template functions that are
+// available to recipes, so ingredients can template the files they manage.
+func RenderTemplate(sproutID, name string, file []byte, data interface{}) ([]byte, error) {
+	temp := template.New(name)
 	gFuncs := populateFuncMap(sproutID)
 	temp.Funcs(gFuncs)
 	rt, err := temp.Parse(string(file))
@@ -295,7 +308,7 @@ func renderRecipeTemplate(sproutID, recipeName string, file []byte) ([]byte, err
 	}
 	rt.Option("missingkey=error")
 	buf := bytes.NewBuffer([]byte{})
-	err = rt.Execute(buf, nil)
+	err = rt.Execute(buf, data)
 	if err != nil {
 		return []byte{}, err
 	}
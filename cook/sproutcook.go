@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"sync"
 
 	"github.com/taigrr/log-socket/log"
@@ -92,7 +93,8 @@ func CookRecipeEnvelope(envelope types.RecipeEnvelope) error {
 				t.CompletionStatus = types.StepInProgress
 				completionMap[id] = t
 				// all requisites are met, so start the step in a goroutine
-				go func(step types.Step, cChan chan types.StepCompletion) {
+				// with a snapshot of the steps completed so far
+				go func(step types.Step, completions map[types.StepID]types.StepCompletion, cChan chan types.StepCompletion) {
 					// use the ingredient package to load and cook the step
 					ingredient, err := ingredients.NewRecipeCooker(step.ID, step.Ingredient, step.Method, step.Properties)
 					if err != nil {
@@ -105,7 +107,7 @@ func CookRecipeEnvelope(envelope types.RecipeEnvelope) error {
 					}
 					var res types.Result
 					// TODO allow for cancellation
-					bgCtx := context.Background()
+					bgCtx := types.WithCompletions(types.WithJobID(context.Background(), envelope.JobID), completions)
 					// TODO make sure envelope.Test is set in grlx and farmer
 					if envelope.Test {
 						res, err = ingredient.Test(bgCtx)
@@ -134,7 +136,7 @@ func CookRecipeEnvelope(envelope types.RecipeEnvelope) error {
 							Error:            err,
 						}
 					}
-				}(stepMap[id], completionChan)
+				}(stepMap[id], maps.Clone(completionMap), completionChan)
 				noneInProgress = false
 			}
 			if noneInProgress {
@@ -0,0 +1,168 @@
+// Package accounts reads the local account databases, so that the user and
+// group ingredients can compare them against the desired state, and runs
+// the shadow-utils commands which change them.
+package accounts
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+var (
+	PasswdFile = "/etc/passwd"
+	GroupFile  = "/etc/group"
+	ShadowFile = "/etc/shadow"
+)
+
+type PasswdEntry struct {
+	Name    string
+	UID     string
+	GID     string
+	Comment string
+	Home    string
+	Shell   string
+}
+
+type GroupEntry struct {
+	Name    string
+	GID     string
+	Members []string
+}
+
+type ShadowEntry struct {
+	Name     string
+	Password string
+	// Expire is the day the account expires on, counted from the epoch,
+	// or empty if it never does
+	Expire string
+}
+
+// Run executes an account management command such as useradd. Tests
+// replace it to record commands instead of running them.
+var Run = func(ctx context.Context, name string, args ...string) error {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// readEntries returns the colon-separated fields of each line of path,
+// skipping comments and NIS compat lines.
+func readEntries(path string, minFields int) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries [][]string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) < minFields {
+			continue
+		}
+		entries = append(entries, fields)
+	}
+	return entries, scanner.Err()
+}
+
+// LookupUser returns the passwd entry for name, or nil if there is none.
+func LookupUser(name string) (*PasswdEntry, error) {
+	entries, err := readEntries(PasswdFile, 7)
+	if err != nil {
+		return nil, err
+	}
+	for _, fields := range entries {
+		if fields[0] == name {
+			return &PasswdEntry{
+				Name: fields[0], UID: fields[2], GID: fields[3],
+				Comment: fields[4], Home: fields[5], Shell: fields[6],
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// Groups returns every entry of the group database.
+func Groups() ([]GroupEntry, error) {
+	entries, err := readEntries(GroupFile, 4)
+	if err != nil {
+		return nil, err
+	}
+	groups := []GroupEntry{}
+	for _, fields := range entries {
+		members := []string{}
+		for _, m := range strings.Split(fields[3], ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				members = append(members, m)
+			}
+		}
+		groups = append(groups, GroupEntry{Name: fields[0], GID: fields[2], Members: members})
+	}
+	return groups, nil
+}
+
+// LookupGroup returns the group with the given name, or failing that the
+// given numeric ID, or nil if there is neither.
+func LookupGroup(nameOrID string) (*GroupEntry, error) {
+	groups, err := Groups()
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		if groups[i].Name == nameOrID {
+			return &groups[i], nil
+		}
+	}
+	for i := range groups {
+		if groups[i].GID == nameOrID {
+			return &groups[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// SupplementaryGroups returns the sorted names of the groups which list
+// user as a member.
+func SupplementaryGroups(user string) ([]string, error) {
+	groups, err := Groups()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, g := range groups {
+		for _, m := range g.Members {
+			if m == user {
+				names = append(names, g.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// LookupShadow returns the shadow entry for name, or nil if there is none.
+// Reading the shadow database needs root.
+func LookupShadow(name string) (*ShadowEntry, error) {
+	entries, err := readEntries(ShadowFile, 8)
+	if err != nil {
+		return nil, err
+	}
+	for _, fields := range entries {
+		if fields[0] == name {
+			return &ShadowEntry{Name: fields[0], Password: fields[1], Expire: fields[7]}, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,32 @@
+// Package all registers every compiled-in ingredient. The farmer, sprout
+// and grlx binaries import it so that they agree on which ingredients and
+// property schemas exist.
+package all
+
+import (
+	_ "github.com/gogrlx/grlx/ingredients/archive"
+	_ "github.com/gogrlx/grlx/ingredients/cmd"
+	_ "github.com/gogrlx/grlx/ingredients/cron"
+	_ "github.com/gogrlx/grlx/ingredients/event"
+	_ "github.com/gogrlx/grlx/ingredients/file"
+	_ "github.com/gogrlx/grlx/ingredients/git"
+	_ "github.com/gogrlx/grlx/ingredients/group"
+	_ "github.com/gogrlx/grlx/ingredients/host"
+	_ "github.com/gogrlx/grlx/ingredients/kmod"
+	_ "github.com/gogrlx/grlx/ingredients/pkg"
+	_ "github.com/gogrlx/grlx/ingredients/pkg/apk"
+	_ "github.com/gogrlx/grlx/ingredients/pkg/apt"
+	_ "github.com/gogrlx/grlx/ingredients/pkg/dnf"
+	_ "github.com/gogrlx/grlx/ingredients/pkg/pacman"
+	_ "github.com/gogrlx/grlx/ingredients/pkgrepo"
+	_ "github.com/gogrlx/grlx/ingredients/service/openrc"
+	_ "github.com/gogrlx/grlx/ingredients/service/runit"
+	_ "github.com/gogrlx/grlx/ingredients/service/systemd"
+	_ "github.com/gogrlx/grlx/ingredients/service/sysvinit"
+	_ "github.com/gogrlx/grlx/ingredients/sshauth"
+	_ "github.com/gogrlx/grlx/ingredients/sysctl"
+	_ "github.com/gogrlx/grlx/ingredients/systemd"
+	_ "github.com/gogrlx/grlx/ingredients/test"
+	_ "github.com/gogrlx/grlx/ingredients/user"
+	_ "github.com/gogrlx/grlx/ingredients/wait"
+)
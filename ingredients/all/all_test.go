@@ -0,0 +1,24 @@
+package all
+
+import (
+	"testing"
+
+	"github.com/gogrlx/grlx/ingredients"
+)
+
+func TestIngredientSchemas(t *testing.T) {
+	infos := ingredients.Registered()
+	if len(infos) == 0 {
+		t.Fatal("expected the compiled-in ingredients to be registered")
+	}
+	for _, info := range infos {
+		if len(info.Methods) == 0 {
+			t.Errorf("ingredient %s has no methods", info.Name)
+		}
+		for _, method := range info.Methods {
+			if method.Error != "" {
+				t.Errorf("%s.%s has no schema: %s", info.Name, method.Name, method.Error)
+			}
+		}
+	}
+}
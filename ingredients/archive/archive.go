@@ -0,0 +1,132 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrArchiveMethodUndefined = errors.New("archive method undefined")
+	ErrUnknownFormat          = errors.New("unknown archive format")
+	ErrUnsafePath             = errors.New("archive entry escapes the destination")
+	ErrMultipleTopLevel       = errors.New("archive does not have a single top-level directory")
+)
+
+type Archive struct {
+	id     string
+	method string
+	params map[string]interface{}
+}
+
+func (a Archive) Parse(id, method string, params map[string]interface{}) (types.RecipeCooker, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return Archive{
+		id: id, method: method,
+		params: params,
+	}, nil
+}
+
+func (a Archive) validate() error {
+	set, err := a.PropertiesForMethod(a.method)
+	if err != nil {
+		return err
+	}
+	propSet, err := ingredients.PropMapToPropSet(set)
+	if err != nil {
+		return err
+	}
+	for _, v := range propSet {
+		if v.IsReq {
+			if v.Key == "name" {
+				name, ok := a.params[v.Key].(string)
+				if !ok {
+					return types.ErrMissingName
+				}
+				if name == "" {
+					return types.ErrMissingName
+				}
+
+			} else {
+				if _, ok := a.params[v.Key]; !ok {
+					return fmt.Errorf("missing required property %s", v.Key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (a Archive) Test(ctx context.Context) (types.Result, error) {
+	switch a.method {
+	case "extracted":
+		return a.extracted(ctx, true)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrArchiveMethodUndefined, fmt.Errorf("method %s undefined", a.method))
+	}
+}
+
+func (a Archive) Apply(ctx context.Context) (types.Result, error) {
+	switch a.method {
+	case "extracted":
+		return a.extracted(ctx, false)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrArchiveMethodUndefined, fmt.Errorf("method %s undefined", a.method))
+	}
+}
+
+func (a Archive) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	switch method {
+	case "extracted":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the directory to extract the archive into"},
+			ingredients.MethodProps{Key: "source", Type: "string", IsReq: true, Description: "the path/URL of the archive"},
+			ingredients.MethodProps{Key: "source_hash", Type: "string", IsReq: false, Description: "hash to verify the archive specified by source"},
+			ingredients.MethodProps{Key: "hash", Type: "string", IsReq: false, Description: "alias for source_hash"},
+			ingredients.MethodProps{Key: "skip_verify", Type: "bool", IsReq: false, Description: "do not verify the archive against a hash"},
+			ingredients.MethodProps{Key: "archive_format", Type: "string", IsReq: false, Description: "tar or zip, if it cannot be detected from the archive"},
+			ingredients.MethodProps{Key: "enforce_toplevel", Type: "bool", IsReq: false, Description: "require a single top-level directory in the archive (default true)"},
+			ingredients.MethodProps{Key: "strip_components", Type: "int", IsReq: false, Description: "number of leading path components to remove from each entry"},
+			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false, Description: "the user that should own the extracted files"},
+			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false, Description: "the group that should own the extracted files"},
+			ingredients.MethodProps{Key: "if_missing", Type: "string", IsReq: false, Description: "only extract if this path does not exist"},
+			ingredients.MethodProps{Key: "force", Type: "bool", IsReq: false, Description: "extract even if the archive has already been extracted"},
+		}, nil
+	default:
+		return nil, errors.Join(ErrArchiveMethodUndefined, fmt.Errorf("method %s undefined", method))
+	}
+}
+
+func (a Archive) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := a.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (a Archive) Methods() (string, []string) {
+	return "archive", []string{"extracted"}
+}
+
+func (a Archive) Properties() (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(a.params)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+func init() {
+	ingredients.RegisterAllMethods(Archive{})
+}
@@ -0,0 +1,308 @@
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/ingredients/file"
+	"github.com/gogrlx/grlx/types"
+)
+
+// manifest records what was extracted into a directory, so that later runs
+// can tell whether the archive is already in place.
+type manifest struct {
+	Source string   `json:"source"`
+	Hash   string   `json:"hash"`
+	Files  []string `json:"files"`
+}
+
+func manifestPath(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return filepath.Join(config.CacheDir, "archive-"+hex.EncodeToString(sum[:])+".json")
+}
+
+func readManifest(name string) (manifest, bool) {
+	var m manifest
+	b, err := os.ReadFile(manifestPath(name))
+	if err != nil {
+		return m, false
+	}
+	return m, json.Unmarshal(b, &m) == nil
+}
+
+func writeManifest(name string, m manifest) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath(name), b, 0o644)
+}
+
+// current reports whether m describes archive hash extracted from source
+// into name, with every extracted path still present.
+func (m manifest) current(name, source, hash string) bool {
+	if m.Source != source || m.Hash != hash {
+		return false
+	}
+	for _, f := range m.Files {
+		if _, err := os.Lstat(filepath.Join(name, f)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// extracted ensures the archive at source has been extracted into name.
+//
+// if if_missing is set and that path exists, nothing is done
+// if enforce_toplevel is true (the default), archives which do not
+// contain a single top-level directory are refused
+// strip_components removes leading path components from each entry
+// if force is true the archive is extracted even if the manifest from a
+// previous extraction shows it is already in place
+func (a Archive) extracted(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := a.validate(); err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	name := filepath.Clean(a.params["name"].(string))
+	if name == "/" {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, types.ErrModifyRoot
+	}
+	source, ok := a.params["source"].(string)
+	if !ok || source == "" {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, types.ErrMissingSource
+	}
+	hash, _ := a.params["source_hash"].(string)
+	if hash == "" {
+		hash, _ = a.params["hash"].(string)
+	}
+	skipVerify, _ := a.params["skip_verify"].(bool)
+	force, _ := a.params["force"].(bool)
+	format, _ := a.params["archive_format"].(string)
+	enforceToplevel := true
+	if v, ok := a.params["enforce_toplevel"].(bool); ok {
+		enforceToplevel = v
+	}
+	strip, _ := a.params["strip_components"].(int)
+	if strip < 0 {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, fmt.Errorf("invalid strip_components %d", strip)
+	}
+	uid, gid, err := a.ownership()
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	if ifMissing, ok := a.params["if_missing"].(string); ok && ifMissing != "" {
+		if _, err := os.Stat(ifMissing); err == nil {
+			notes = append(notes, types.Snprintf("%s exists, skipping extraction", ifMissing))
+			return types.Result{
+				Succeeded: true, Failed: false, Notes: notes,
+			}, nil
+		}
+	}
+
+	archivePath, cacheNotes, err := file.CacheSource(ctx, a.id, a.params, name+"-archive", source, hash, skipVerify, test)
+	notes = append(notes, cacheNotes...)
+	if test && errors.Is(err, file.ErrNotCached) {
+		// the entries aren't known until the archive has been downloaded
+		notes = append(notes, types.Snprintf("would download %s", source))
+		notes = append(notes, types.Snprintf("would extract %s to %s", source, name))
+		return types.Result{
+			Succeeded: true, Failed: false,
+			Changed: true, Notes: notes,
+		}, nil
+	}
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	digest, err := sha256File(archivePath)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+
+	// list the archive first so that nothing is written if any entry is
+	// unsafe
+	var files []string
+	tops := map[string]bool{}
+	topIsFile := false
+	err = walkArchive(ctx, archivePath, format, func(e entry, _ io.Reader) error {
+		if top := topLevel(e.name); top != "" {
+			tops[top] = true
+			if top == path.Clean(e.name) && e.typ != typeDir {
+				topIsFile = true
+			}
+		}
+		rel, err := relPath(e.name, strip)
+		if err != nil || rel == "" {
+			return err
+		}
+		if e.typ == typeSymlink {
+			if err = checkLink(rel, e.linkname); err != nil {
+				return err
+			}
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		notes = append(notes, types.Snprintf("failed to read archive %s", source))
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	if enforceToplevel && (len(tops) != 1 || topIsFile) {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, ErrMultipleTopLevel
+	}
+
+	changed := false
+	if m, ok := readManifest(name); ok && !force && m.current(name, source, digest) {
+		notes = append(notes, types.Snprintf("%s is already extracted to %s", source, name))
+	} else if test {
+		changed = true
+		notes = append(notes, types.Snprintf("would extract %d entries from %s to %s", len(files), source, name))
+	} else {
+		if err = os.MkdirAll(name, 0o755); err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+		err = walkArchive(ctx, archivePath, format, func(e entry, r io.Reader) error {
+			rel, err := relPath(e.name, strip)
+			if err != nil || rel == "" {
+				return err
+			}
+			return extractEntry(name, rel, e, r, strip)
+		})
+		if err != nil {
+			notes = append(notes, types.Snprintf("failed to extract %s to %s", source, name))
+			return types.Result{
+				Succeeded: false, Failed: true, Changed: true, Notes: notes,
+			}, err
+		}
+		changed = true
+		notes = append(notes, types.Snprintf("extracted %d entries from %s to %s", len(files), source, name))
+		if err = writeManifest(name, manifest{Source: source, Hash: digest, Files: files}); err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Changed: true, Notes: notes,
+			}, err
+		}
+	}
+
+	owned, err := chownAll(name, files, uid, gid, test)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Changed: changed, Notes: notes,
+		}, err
+	}
+	if owned > 0 {
+		changed = true
+		if test {
+			notes = append(notes, types.Snprintf("would change ownership of %d entries in %s", owned, name))
+		} else {
+			notes = append(notes, types.Snprintf("changed ownership of %d entries in %s", owned, name))
+		}
+	}
+	return types.Result{
+		Succeeded: true, Failed: false,
+		Changed: changed, Notes: notes,
+	}, nil
+}
+
+// ownership resolves the user and group properties to IDs, using -1 for
+// those that are not set.
+func (a Archive) ownership() (int, int, error) {
+	uid, gid := -1, -1
+	if name, ok := a.params["user"].(string); ok && name != "" {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return uid, gid, err
+		}
+		if uid, err = strconv.Atoi(u.Uid); err != nil {
+			return uid, gid, err
+		}
+	}
+	if name, ok := a.params["group"].(string); ok && name != "" {
+		g, err := user.LookupGroup(name)
+		if err != nil {
+			return uid, gid, err
+		}
+		if gid, err = strconv.Atoi(g.Gid); err != nil {
+			return uid, gid, err
+		}
+	}
+	return uid, gid, nil
+}
+
+// chownAll gives each of files under dir the requested owner, returning
+// how many needed changing. Paths that don't exist yet (in test mode) are
+// counted as needing a change.
+func chownAll(dir string, files []string, uid, gid int, test bool) (int, error) {
+	if uid == -1 && gid == -1 {
+		return 0, nil
+	}
+	count := 0
+	for _, f := range files {
+		p := filepath.Join(dir, f)
+		fi, err := os.Lstat(p)
+		if os.IsNotExist(err) && test {
+			count++
+			continue
+		}
+		if err != nil {
+			return count, err
+		}
+		st, ok := fi.Sys().(*syscall.Stat_t)
+		if ok && (uid == -1 || int(st.Uid) == uid) && (gid == -1 || int(st.Gid) == gid) {
+			continue
+		}
+		count++
+		if test {
+			continue
+		}
+		if err = os.Lchown(p, uid, gid); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+func sha256File(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,349 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ulikunitz/xz"
+
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/types"
+)
+
+type testEntry struct {
+	name     string
+	body     string
+	linkname string
+	typ      byte
+}
+
+func writeTar(t *testing.T, w io.Writer, entries []testEntry) {
+	t.Helper()
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0o644, Typeflag: e.typ, Linkname: e.linkname}
+		switch e.typ {
+		case tar.TypeDir:
+			hdr.Mode = 0o755
+		case tar.TypeReg:
+			hdr.Size = int64(len(e.body))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if e.typ == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func makeArchive(t *testing.T, dir, name, format string, entries []testEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	switch format {
+	case "tar":
+		writeTar(t, &buf, entries)
+	case "tar.gz":
+		gz := gzip.NewWriter(&buf)
+		writeTar(t, gz, entries)
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	case "tar.xz":
+		xw, err := xz.NewWriter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeTar(t, xw, entries)
+		if err := xw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	case "zip":
+		zw := zip.NewWriter(&buf)
+		for _, e := range entries {
+			w, err := zw.Create(e.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = w.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func sha256Spec(t *testing.T, p string) string {
+	t.Helper()
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("sha256=%x", sha256.Sum256(b))
+}
+
+func TestExtracted(t *testing.T) {
+	tempDir := t.TempDir()
+	cd := config.CacheDir
+	defer func() { config.CacheDir = cd }()
+	config.CacheDir = filepath.Join(tempDir, "cache")
+	if err := os.MkdirAll(config.CacheDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	app := []testEntry{
+		{name: "app/", typ: tar.TypeDir},
+		{name: "app/bin/", typ: tar.TypeDir},
+		{name: "app/bin/run", body: "#!/bin/sh\n", typ: tar.TypeReg},
+		{name: "app/README", body: "readme\n", typ: tar.TypeReg},
+		{name: "app/docs", linkname: "README", typ: tar.TypeSymlink},
+	}
+	tests := []struct {
+		name     string
+		format   string
+		entries  []testEntry
+		params   map[string]interface{}
+		test     bool
+		expected []string
+		missing  []string
+		error    error
+	}{
+		{
+			name:     "tar.gz",
+			format:   "tar.gz",
+			entries:  app,
+			expected: []string{"app/bin/run", "app/README", "app/docs"},
+		},
+		{
+			name:     "tar.xz with strip_components",
+			format:   "tar.xz",
+			entries:  app,
+			params:   map[string]interface{}{"strip_components": 1},
+			expected: []string{"bin/run", "README"},
+			missing:  []string{"app"},
+		},
+		{
+			name:     "zip",
+			format:   "zip",
+			entries:  []testEntry{{name: "app/a.txt", body: "a"}, {name: "app/b/c.txt", body: "c"}},
+			expected: []string{"app/a.txt", "app/b/c.txt"},
+		},
+		{
+			name:    "test mode",
+			format:  "tar",
+			entries: app,
+			test:    true,
+			missing: []string{"app"},
+		},
+		{
+			name:    "enforce_toplevel",
+			format:  "tar",
+			entries: []testEntry{{name: "a.txt", body: "a", typ: tar.TypeReg}, {name: "b.txt", body: "b", typ: tar.TypeReg}},
+			error:   ErrMultipleTopLevel,
+			missing: []string{"a.txt"},
+		},
+		{
+			name:     "enforce_toplevel disabled",
+			format:   "tar",
+			entries:  []testEntry{{name: "a.txt", body: "a", typ: tar.TypeReg}, {name: "b.txt", body: "b", typ: tar.TypeReg}},
+			params:   map[string]interface{}{"enforce_toplevel": false},
+			expected: []string{"a.txt", "b.txt"},
+		},
+		{
+			name:    "path traversal",
+			format:  "tar",
+			entries: []testEntry{{name: "app/", typ: tar.TypeDir}, {name: "app/../../evil", body: "x", typ: tar.TypeReg}},
+			error:   ErrUnsafePath,
+			missing: []string{"app"},
+		},
+		{
+			name:    "absolute path",
+			format:  "zip",
+			entries: []testEntry{{name: "/app/evil", body: "x"}},
+			error:   ErrUnsafePath,
+		},
+		{
+			name:    "symlink escape",
+			format:  "tar",
+			entries: []testEntry{{name: "app/", typ: tar.TypeDir}, {name: "app/link", linkname: "../../etc", typ: tar.TypeSymlink}},
+			error:   ErrUnsafePath,
+			missing: []string{"app"},
+		},
+		{
+			name:   "symlink chain escape",
+			format: "tar",
+			entries: []testEntry{
+				{name: "app/", typ: tar.TypeDir},
+				{name: "app/up", linkname: "d/d/d/../../..", typ: tar.TypeSymlink},
+				{name: "app/d", linkname: ".", typ: tar.TypeSymlink},
+			},
+			error:   ErrUnsafePath,
+			missing: []string{"app"},
+		},
+		{
+			name:   "symlink to parent",
+			format: "tar",
+			entries: []testEntry{
+				{name: "app/", typ: tar.TypeDir},
+				{name: "app/lib/", typ: tar.TypeDir},
+				{name: "app/README", body: "readme\n", typ: tar.TypeReg},
+				{name: "app/lib/README", linkname: "../README", typ: tar.TypeSymlink},
+			},
+			expected: []string{"app/lib/README"},
+		},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := makeArchive(t, tempDir, fmt.Sprintf("archive-%d", i), test.format, test.entries)
+			dest := filepath.Join(tempDir, fmt.Sprintf("dest-%d", i))
+			params := map[string]interface{}{
+				"name": dest, "source": src, "source_hash": sha256Spec(t, src),
+			}
+			for k, v := range test.params {
+				params[k] = v
+			}
+			a := Archive{id: "extract", method: "extracted", params: params}
+			var res types.Result
+			var err error
+			if test.test {
+				res, err = a.Test(context.Background())
+			} else {
+				res, err = a.Apply(context.Background())
+			}
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+			} else if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			} else if !res.Changed {
+				t.Errorf("expected a change, got %v", res.Notes)
+			}
+			for _, p := range test.expected {
+				if _, err := os.Lstat(filepath.Join(dest, p)); err != nil {
+					t.Errorf("expected %s to be extracted: %v", p, err)
+				}
+			}
+			for _, p := range test.missing {
+				if _, err := os.Lstat(filepath.Join(dest, p)); err == nil {
+					t.Errorf("expected %s not to be extracted", p)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractedIdempotent(t *testing.T) {
+	tempDir := t.TempDir()
+	cd := config.CacheDir
+	defer func() { config.CacheDir = cd }()
+	config.CacheDir = filepath.Join(tempDir, "cache")
+	if err := os.MkdirAll(config.CacheDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	src := makeArchive(t, tempDir, "app.tar.gz", "tar.gz", []testEntry{
+		{name: "app/", typ: tar.TypeDir},
+		{name: "app/file", body: "contents", typ: tar.TypeReg},
+	})
+	dest := filepath.Join(tempDir, "dest")
+	ifMissing := filepath.Join(tempDir, "marker")
+	params := map[string]interface{}{
+		"name": dest, "source": src, "source_hash": sha256Spec(t, src),
+	}
+	a := Archive{id: "extract", method: "extracted", params: params}
+	res, err := a.Apply(context.Background())
+	if err != nil || !res.Changed {
+		t.Fatalf("expected first extraction to change, got %v %v", res.Notes, err)
+	}
+	res, err = a.Apply(context.Background())
+	if err != nil || res.Changed {
+		t.Fatalf("expected second extraction to be a no-op, got %v %v", res.Notes, err)
+	}
+	if len(res.Notes) == 0 || res.Notes[len(res.Notes)-1].String() != fmt.Sprintf("%s is already extracted to %s", src, dest) {
+		t.Errorf("unexpected notes %v", res.Notes)
+	}
+
+	// removing an extracted file means the archive must be extracted again
+	if err = os.Remove(filepath.Join(dest, "app", "file")); err != nil {
+		t.Fatal(err)
+	}
+	res, err = a.Test(context.Background())
+	if err != nil || !res.Changed {
+		t.Fatalf("expected test to report a change, got %v %v", res.Notes, err)
+	}
+
+	if err = os.WriteFile(ifMissing, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	params["if_missing"] = ifMissing
+	res, err = a.Apply(context.Background())
+	if err != nil || res.Changed {
+		t.Fatalf("expected if_missing to skip extraction, got %v %v", res.Notes, err)
+	}
+	if _, err = os.Stat(filepath.Join(dest, "app", "file")); err == nil {
+		t.Errorf("expected file not to be extracted when if_missing exists")
+	}
+}
+
+func TestExtractedTestDoesNotDownload(t *testing.T) {
+	tempDir := t.TempDir()
+	cd := config.CacheDir
+	defer func() { config.CacheDir = cd }()
+	config.CacheDir = filepath.Join(tempDir, "cache")
+	if err := os.MkdirAll(config.CacheDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	src := makeArchive(t, tempDir, "app.tar.gz", "tar.gz", []testEntry{
+		{name: "app/", typ: tar.TypeDir},
+		{name: "app/file", body: "contents", typ: tar.TypeReg},
+	})
+	dest := filepath.Join(tempDir, "dest")
+	a := Archive{id: "extract", method: "extracted", params: map[string]interface{}{
+		"name": dest, "source": src, "source_hash": sha256Spec(t, src),
+	}}
+	res, err := a.Test(context.Background())
+	if err != nil || !res.Changed {
+		t.Fatalf("expected test to report a change, got %v %v", res.Notes, err)
+	}
+	notes := []string{}
+	for _, n := range res.Notes {
+		notes = append(notes, n.String())
+	}
+	for _, note := range []string{fmt.Sprintf("would download %s", src), fmt.Sprintf("would extract %s to %s", src, dest)} {
+		if !slices.Contains(notes, note) {
+			t.Errorf("expected note %q, got %v", note, notes)
+		}
+	}
+	cached, err := os.ReadDir(config.CacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cached) != 0 {
+		t.Errorf("expected nothing to be cached in test mode, got %d entries", len(cached))
+	}
+	if _, err = os.Stat(dest); err == nil {
+		t.Errorf("expected %s not to be created in test mode", dest)
+	}
+}
@@ -0,0 +1,383 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ulikunitz/xz"
+)
+
+type entryType int
+
+const (
+	typeFile entryType = iota
+	typeDir
+	typeSymlink
+	typeHardlink
+)
+
+// entry is an archive member, independent of the archive format.
+type entry struct {
+	name     string
+	typ      entryType
+	mode     fs.FileMode
+	modTime  time.Time
+	linkname string
+}
+
+// walkFunc is called for every member of an archive. r is only valid for
+// regular files and only until walkFunc returns.
+type walkFunc func(e entry, r io.Reader) error
+
+// detectFormat works out the container and compression of the archive at
+// name from its first bytes, falling back to format ("tar" or "zip").
+func detectFormat(name, format string) (container, compression string, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", "", err
+	}
+	head = head[:n]
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return "zip", "", nil
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return "tar", "gzip", nil
+	case bytes.HasPrefix(head, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		return "tar", "xz", nil
+	case bytes.HasPrefix(head, []byte("BZh")):
+		return "tar", "bzip2", nil
+	case len(head) >= 262 && string(head[257:262]) == "ustar":
+		return "tar", "", nil
+	}
+	switch format {
+	case "tar", "zip":
+		return format, "", nil
+	case "":
+		return "", "", fmt.Errorf("%w: %s", ErrUnknownFormat, name)
+	default:
+		return "", "", fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// walkArchive calls fn for every member of the archive at name.
+func walkArchive(ctx context.Context, name, format string, fn walkFunc) error {
+	container, compression, err := detectFormat(name, format)
+	if err != nil {
+		return err
+	}
+	if container == "zip" {
+		return walkZip(ctx, name, fn)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = bufio.NewReader(f)
+	switch compression {
+	case "gzip":
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	case "xz":
+		r, err = xz.NewReader(r)
+		if err != nil {
+			return err
+		}
+	case "bzip2":
+		r = bzip2.NewReader(r)
+	}
+	return walkTar(ctx, r, fn)
+}
+
+func walkTar(ctx context.Context, r io.Reader, fn walkFunc) error {
+	tr := tar.NewReader(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		e := entry{
+			name:     hdr.Name,
+			mode:     fs.FileMode(hdr.Mode) & fs.ModePerm,
+			modTime:  hdr.ModTime,
+			linkname: hdr.Linkname,
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			e.typ = typeFile
+		case tar.TypeDir:
+			e.typ = typeDir
+		case tar.TypeSymlink:
+			e.typ = typeSymlink
+		case tar.TypeLink:
+			e.typ = typeHardlink
+		default:
+			// devices, fifos and pax/global headers are not extracted
+			continue
+		}
+		if err = fn(e, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func walkZip(ctx context.Context, name string, fn walkFunc) error {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, zf := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		e := entry{
+			name:    zf.Name,
+			mode:    zf.Mode() & fs.ModePerm,
+			modTime: zf.Modified,
+		}
+		switch {
+		case zf.Mode().IsDir() || strings.HasSuffix(zf.Name, "/"):
+			e.typ = typeDir
+		case zf.Mode()&fs.ModeSymlink != 0:
+			e.typ = typeSymlink
+			rc, err := zf.Open()
+			if err != nil {
+				return err
+			}
+			target, err := io.ReadAll(io.LimitReader(rc, 4096))
+			rc.Close()
+			if err != nil {
+				return err
+			}
+			e.linkname = string(target)
+		case zf.Mode().IsRegular():
+			e.typ = typeFile
+		default:
+			continue
+		}
+		if e.typ != typeFile {
+			if err = fn(e, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		err = fn(e, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relPath turns an archive member name into a path relative to the
+// destination, removing strip leading components. It returns "" for
+// members that are stripped away entirely, and an error for members that
+// would land outside the destination.
+func relPath(name string, strip int) (string, error) {
+	if strings.HasPrefix(name, "/") || strings.Contains(name, "\\") {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+		}
+	}
+	cleaned := path.Clean(name)
+	if cleaned == "." {
+		return "", nil
+	}
+	parts := strings.Split(cleaned, "/")
+	if strip >= len(parts) {
+		return "", nil
+	}
+	rel := filepath.FromSlash(strings.Join(parts[strip:], "/"))
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	return rel, nil
+}
+
+// topLevel returns the first path component of an archive member.
+func topLevel(name string) string {
+	cleaned := path.Clean(strings.TrimPrefix(name, "./"))
+	if cleaned == "." {
+		return ""
+	}
+	return strings.SplitN(cleaned, "/", 2)[0]
+}
+
+// checkLink makes sure a symlink at rel pointing to target stays inside the
+// destination. Other links in the archive may stand in for any descending
+// component of target, so ".." is only allowed at the start, where it is
+// resolved from the link's own directory rather than through another link.
+func checkLink(rel, target string) error {
+	if filepath.IsAbs(target) {
+		return fmt.Errorf("%w: %s -> %s", ErrUnsafePath, rel, target)
+	}
+	descended := false
+	for _, part := range strings.Split(filepath.ToSlash(target), "/") {
+		switch part {
+		case "", ".":
+		case "..":
+			if descended {
+				return fmt.Errorf("%w: %s -> %s", ErrUnsafePath, rel, target)
+			}
+		default:
+			descended = true
+		}
+	}
+	resolved := filepath.Join(filepath.Dir(rel), filepath.FromSlash(target))
+	if !filepath.IsLocal(resolved) {
+		return fmt.Errorf("%w: %s -> %s", ErrUnsafePath, rel, target)
+	}
+	return nil
+}
+
+// checkParents refuses to write through a symlink inside the destination,
+// which an earlier archive member could have planted to escape it.
+func checkParents(dest, rel string) error {
+	dir := filepath.Dir(rel)
+	if dir == "." {
+		return nil
+	}
+	current := dest
+	for _, part := range strings.Split(dir, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		fi, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("%w: %s is a symlink", ErrUnsafePath, current)
+		}
+	}
+	return nil
+}
+
+// extractEntry writes a single member to dest/rel.
+func extractEntry(dest, rel string, e entry, r io.Reader, strip int) error {
+	if err := checkParents(dest, rel); err != nil {
+		return err
+	}
+	target := filepath.Join(dest, rel)
+	if e.typ != typeDir {
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+	}
+	switch e.typ {
+	case typeDir:
+		mode := e.mode
+		if mode == 0 {
+			mode = 0o755
+		}
+		if err := os.MkdirAll(target, mode); err != nil {
+			return err
+		}
+		return os.Chmod(target, mode)
+	case typeSymlink:
+		if err := checkLink(rel, e.linkname); err != nil {
+			return err
+		}
+		if err := removeExisting(target); err != nil {
+			return err
+		}
+		return os.Symlink(e.linkname, target)
+	case typeHardlink:
+		linkRel, err := relPath(e.linkname, strip)
+		if err != nil {
+			return err
+		}
+		if linkRel == "" {
+			return fmt.Errorf("%w: %s links to stripped entry %s", ErrUnsafePath, e.name, e.linkname)
+		}
+		if err = checkParents(dest, linkRel); err != nil {
+			return err
+		}
+		if err = removeExisting(target); err != nil {
+			return err
+		}
+		return os.Link(filepath.Join(dest, linkRel), target)
+	default:
+		if err := removeExisting(target); err != nil {
+			return err
+		}
+		mode := e.mode
+		if mode == 0 {
+			mode = 0o644
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, r)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		if err = os.Chmod(target, mode); err != nil {
+			return err
+		}
+		if !e.modTime.IsZero() {
+			return os.Chtimes(target, e.modTime, e.modTime)
+		}
+		return nil
+	}
+}
+
+// removeExisting clears the way for a new file or link, but never removes
+// a directory.
+func removeExisting(target string) error {
+	fi, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("cannot replace directory %s", target)
+	}
+	return os.Remove(target)
+}
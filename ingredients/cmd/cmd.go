@@ -82,23 +82,31 @@ func (c Cmd) Apply(ctx context.Context) (types.Result, error) {
 }
 
 // TODO create map for method: type
-func (c Cmd) PropertiesForMethod(method string) (map[string]string, error) {
+func (c Cmd) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
 	switch method {
 	case "run":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-			ingredients.MethodProps{Key: "args", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "env", Type: "[]string", IsReq: false},
-			ingredients.MethodProps{Key: "cwd", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "runas", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "path", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "timeout", Type: "string", IsReq: false},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the command to run"},
+			ingredients.MethodProps{Key: "args", Type: "string", IsReq: false, Description: "not yet used; include arguments in name"},
+			ingredients.MethodProps{Key: "env", Type: "[]string", IsReq: false, Description: "environment variables as KEY=value"},
+			ingredients.MethodProps{Key: "cwd", Type: "string", IsReq: false, Description: "the directory to run the command in"},
+			ingredients.MethodProps{Key: "runas", Type: "string", IsReq: false, Description: "the user to run the command as"},
+			ingredients.MethodProps{Key: "path", Type: "string", IsReq: false, Description: "the executable to run instead of looking up the first word of name"},
+			ingredients.MethodProps{Key: "timeout", Type: "duration", IsReq: false, Description: "how long the command may run"},
+		}, nil
 	default:
 		return nil, fmt.Errorf("method %s undefined", method)
 	}
 }
 
+func (c Cmd) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := c.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
 func (c Cmd) Methods() (string, []string) {
 	return "cmd", []string{"run"}
 }
@@ -0,0 +1,146 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrCronMethodUndefined = errors.New("cron method undefined")
+	ErrInvalidSchedule     = errors.New("invalid cron schedule")
+	ErrInvalidCronFile     = errors.New("invalid cron.d file name")
+	ErrInvalidEnvName      = errors.New("invalid environment variable name")
+	ErrInvalidEntry        = errors.New("cron entries must be a single line")
+)
+
+type Cron struct {
+	id     string
+	method string
+	params map[string]interface{}
+}
+
+func (c Cron) Parse(id, method string, params map[string]interface{}) (types.RecipeCooker, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return Cron{
+		id: id, method: method,
+		params: params,
+	}, nil
+}
+
+func (c Cron) validate() error {
+	set, err := c.PropertiesForMethod(c.method)
+	if err != nil {
+		return err
+	}
+	propSet, err := ingredients.PropMapToPropSet(set)
+	if err != nil {
+		return err
+	}
+	for _, v := range propSet {
+		if v.IsReq {
+			if v.Key == "name" {
+				name, ok := c.params[v.Key].(string)
+				if !ok {
+					return types.ErrMissingName
+				}
+				if name == "" {
+					return types.ErrMissingName
+				}
+
+			} else {
+				if _, ok := c.params[v.Key]; !ok {
+					return fmt.Errorf("missing required property %s", v.Key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c Cron) Test(ctx context.Context) (types.Result, error) {
+	switch c.method {
+	case "present":
+		return c.present(ctx, true)
+	case "absent":
+		return c.absent(ctx, true)
+	case "env_present":
+		return c.envPresent(ctx, true)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrCronMethodUndefined, fmt.Errorf("method %s undefined", c.method))
+	}
+}
+
+func (c Cron) Apply(ctx context.Context) (types.Result, error) {
+	switch c.method {
+	case "present":
+		return c.present(ctx, false)
+	case "absent":
+		return c.absent(ctx, false)
+	case "env_present":
+		return c.envPresent(ctx, false)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrCronMethodUndefined, fmt.Errorf("method %s undefined", c.method))
+	}
+}
+
+func (c Cron) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	target := ingredients.MethodPropsSet{
+		ingredients.MethodProps{Key: "user", Type: "string", IsReq: false, Description: "the user whose crontab to manage, or who runs the job in a cron.d file; defaults to root"},
+		ingredients.MethodProps{Key: "file", Type: "string", IsReq: false, Description: "manage /etc/cron.d/<file> instead of the user's crontab"},
+	}
+	switch method {
+	case "present":
+		return append(ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the command to run"},
+			ingredients.MethodProps{Key: "schedule", Type: "string", IsReq: true, Description: "five cron fields such as */5 * * * *, or a nickname such as @daily"},
+			ingredients.MethodProps{Key: "identifier", Type: "string", IsReq: false, Description: "the ID marking the entry so it can be updated in place; defaults to name"},
+		}, target...), nil
+	case "absent":
+		return append(ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the command of the entry"},
+			ingredients.MethodProps{Key: "identifier", Type: "string", IsReq: false, Description: "the ID marking the entry; defaults to name"},
+		}, target...), nil
+	case "env_present":
+		return append(ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the environment variable, such as MAILTO"},
+			ingredients.MethodProps{Key: "value", Type: "string", IsReq: true, Description: "the value of the variable"},
+		}, target...), nil
+	default:
+		return nil, errors.Join(ErrCronMethodUndefined, fmt.Errorf("method %s undefined", method))
+	}
+}
+
+func (c Cron) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := c.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (c Cron) Methods() (string, []string) {
+	return "cron", []string{"absent", "env_present", "present"}
+}
+
+func (c Cron) Properties() (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(c.params)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+func init() {
+	ingredients.RegisterAllMethods(Cron{})
+}
@@ -0,0 +1,229 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// setup keeps crontabs in memory and points cron.d at a temporary
+// directory.
+func setup(t *testing.T) map[string]string {
+	t.Helper()
+	read, write, dir := readCrontab, writeCrontab, cronDir
+	t.Cleanup(func() { readCrontab, writeCrontab, cronDir = read, write, dir })
+	cronDir = t.TempDir()
+	crontabs := map[string]string{}
+	readCrontab = func(ctx context.Context, user string) (string, error) {
+		return crontabs[user], nil
+	}
+	writeCrontab = func(ctx context.Context, user, content string) error {
+		crontabs[user] = content
+		return nil
+	}
+	return crontabs
+}
+
+func TestCron(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		params   map[string]interface{}
+		existing string
+		test     bool
+		expected string
+		note     string
+		changed  bool
+		error    error
+	}{
+		{
+			name:     "Add",
+			method:   "present",
+			params:   map[string]interface{}{"name": "/usr/bin/backup", "schedule": "0  3 * * mon-fri"},
+			existing: "MAILTO=ops\n",
+			expected: "MAILTO=ops\n# grlx: /usr/bin/backup\n0 3 * * mon-fri /usr/bin/backup\n",
+			note:     "cron job /usr/bin/backup has been added to crontab for root",
+			changed:  true,
+		},
+		{
+			name:     "Update",
+			method:   "present",
+			params:   map[string]interface{}{"name": "/usr/bin/backup --full", "identifier": "backup", "schedule": "@daily", "user": "app"},
+			existing: "# grlx: backup\n0 3 * * * /usr/bin/backup\n*/5 * * * * /usr/bin/poll\n",
+			expected: "# grlx: backup\n@daily /usr/bin/backup --full\n*/5 * * * * /usr/bin/poll\n",
+			note:     "cron job backup has been updated in crontab for app",
+			changed:  true,
+		},
+		{
+			name:     "Unchanged",
+			method:   "present",
+			params:   map[string]interface{}{"name": "/usr/bin/backup", "identifier": "backup", "schedule": "0 3 * * *"},
+			existing: "# grlx: backup\n0 3 * * * /usr/bin/backup\n",
+			expected: "# grlx: backup\n0 3 * * * /usr/bin/backup\n",
+			note:     "cron job backup is already present in crontab for root",
+		},
+		{
+			name:     "Test",
+			method:   "present",
+			params:   map[string]interface{}{"name": "/usr/bin/backup", "schedule": "0 3 * * *"},
+			existing: "MAILTO=ops\n",
+			test:     true,
+			expected: "MAILTO=ops\n",
+			note:     "cron job /usr/bin/backup would be added to crontab for root",
+			changed:  true,
+		},
+		{
+			name:     "Absent",
+			method:   "absent",
+			params:   map[string]interface{}{"name": "/usr/bin/backup", "identifier": "backup"},
+			existing: "# grlx: backup\n0 3 * * * /usr/bin/backup\n*/5 * * * * /usr/bin/poll\n",
+			expected: "*/5 * * * * /usr/bin/poll\n",
+			note:     "cron job backup has been removed from crontab for root",
+			changed:  true,
+		},
+		{
+			name:     "AlreadyAbsent",
+			method:   "absent",
+			params:   map[string]interface{}{"name": "/usr/bin/backup"},
+			existing: "0 3 * * * /usr/bin/backup\n",
+			expected: "0 3 * * * /usr/bin/backup\n",
+			note:     "cron job /usr/bin/backup is already absent from crontab for root",
+		},
+		{
+			name:     "EnvNew",
+			method:   "env_present",
+			params:   map[string]interface{}{"name": "MAILTO", "value": "ops@example.com"},
+			existing: "# grlx: backup\n0 3 * * * /usr/bin/backup\n",
+			expected: "MAILTO=ops@example.com\n# grlx: backup\n0 3 * * * /usr/bin/backup\n",
+			note:     "variable MAILTO has been set in crontab for root",
+			changed:  true,
+		},
+		{
+			name:     "EnvUpdate",
+			method:   "env_present",
+			params:   map[string]interface{}{"name": "MAILTO", "value": ""},
+			existing: "MAILTO = ops\n",
+			expected: "MAILTO=\n",
+			note:     "variable MAILTO has been set in crontab for root",
+			changed:  true,
+		},
+		{
+			name:     "EnvUnchanged",
+			method:   "env_present",
+			params:   map[string]interface{}{"name": "SHELL", "value": "/bin/bash"},
+			existing: "SHELL=/bin/bash\n",
+			expected: "SHELL=/bin/bash\n",
+			note:     "variable SHELL is already set in crontab for root",
+		},
+		{
+			name:   "InvalidSchedule",
+			method: "present",
+			params: map[string]interface{}{"name": "/bin/true", "schedule": "61 * * * *"},
+			error:  ErrInvalidSchedule,
+		},
+		{
+			name:   "InvalidNickname",
+			method: "present",
+			params: map[string]interface{}{"name": "/bin/true", "schedule": "@fortnightly"},
+			error:  ErrInvalidSchedule,
+		},
+		{
+			name:   "TooFewFields",
+			method: "present",
+			params: map[string]interface{}{"name": "/bin/true", "schedule": "* * * *"},
+			error:  ErrInvalidSchedule,
+		},
+		{
+			name:   "BackwardsRange",
+			method: "present",
+			params: map[string]interface{}{"name": "/bin/true", "schedule": "* 5-1 * * *"},
+			error:  ErrInvalidSchedule,
+		},
+		{
+			name:   "InvalidEnvName",
+			method: "env_present",
+			params: map[string]interface{}{"name": "1PATH", "value": "/bin"},
+			error:  ErrInvalidEnvName,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			crontabs := setup(t)
+			user, _ := test.params["user"].(string)
+			if user == "" {
+				user = "root"
+			}
+			crontabs[user] = test.existing
+			c := Cron{id: test.name, method: test.method, params: test.params}
+			var res types.Result
+			var err error
+			if test.test {
+				res, err = c.Test(context.Background())
+			} else {
+				res, err = c.Apply(context.Background())
+			}
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(res.Notes) == 0 || res.Notes[0].String() != test.note {
+				t.Errorf("expected first note %q, got %v", test.note, res.Notes)
+			}
+			if test.changed && len(res.Notes) != 2 {
+				t.Errorf("expected a diff note, got %v", res.Notes)
+			}
+			if res.Changed != test.changed {
+				t.Errorf("expected changed to be %v, got %v", test.changed, res.Changed)
+			}
+			if crontabs[user] != test.expected {
+				t.Errorf("expected crontab\n%s\ngot\n%s", test.expected, crontabs[user])
+			}
+		})
+	}
+}
+
+func TestCronD(t *testing.T) {
+	setup(t)
+	present := Cron{id: "logrotate", method: "present", params: map[string]interface{}{
+		"name": "/usr/sbin/logrotate /etc/logrotate.conf", "identifier": "logrotate",
+		"schedule": "@hourly", "file": "grlx-logrotate",
+	}}
+	res, err := present.Apply(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(cronDir, "grlx-logrotate")
+	expected := "# grlx: logrotate\n@hourly root /usr/sbin/logrotate /etc/logrotate.conf\n"
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != expected {
+		t.Errorf("expected %s to contain\n%s\ngot\n%s", path, expected, content)
+	}
+	if res.Notes[0].String() != "cron job logrotate has been added to "+path {
+		t.Errorf("unexpected note %v", res.Notes[0])
+	}
+	absent := Cron{id: "logrotate", method: "absent", params: map[string]interface{}{
+		"name": "/usr/sbin/logrotate /etc/logrotate.conf", "identifier": "logrotate", "file": "grlx-logrotate",
+	}}
+	if _, err = absent.Apply(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected empty %s to be removed, got %v", path, err)
+	}
+	invalid := Cron{id: "invalid", method: "absent", params: map[string]interface{}{"name": "x", "file": "../passwd"}}
+	if _, err = invalid.Apply(context.Background()); !errors.Is(err, ErrInvalidCronFile) {
+		t.Errorf("expected error %v, got %v", ErrInvalidCronFile, err)
+	}
+}
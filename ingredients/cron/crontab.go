@@ -0,0 +1,312 @@
+package cron
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gogrlx/grlx/ingredients/file"
+	"github.com/gogrlx/grlx/types"
+)
+
+// markerPrefix starts the comment on the line above each managed entry,
+// which is followed by the entry's identifier.
+const markerPrefix = "# grlx: "
+
+var (
+	cronDir = "/etc/cron.d"
+	// cron.d files with other characters in their names are ignored by
+	// cron, so refuse to create them
+	cronFileName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	envName      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// readCrontab returns the crontab of user, which is empty if they have none.
+var readCrontab = func(ctx context.Context, user string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "crontab", "-u", user, "-l")
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if strings.Contains(stderr.String(), "no crontab for") {
+			return "", nil
+		}
+		return "", fmt.Errorf("crontab -u %s -l: %w: %s", user, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// writeCrontab replaces the crontab of user.
+var writeCrontab = func(ctx context.Context, user, content string) error {
+	cmd := exec.CommandContext(ctx, "crontab", "-u", user, "-")
+	cmd.Stdin = strings.NewReader(content)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("crontab -u %s -: %w: %s", user, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// crontab is either a user's crontab or a file in /etc/cron.d.
+type crontab struct {
+	user string
+	// path is empty for a user's crontab
+	path string
+}
+
+func (t crontab) String() string {
+	if t.path != "" {
+		return t.path
+	}
+	return "crontab for " + t.user
+}
+
+func (t crontab) read(ctx context.Context) (string, error) {
+	if t.path == "" {
+		return readCrontab(ctx, t.user)
+	}
+	b, err := os.ReadFile(t.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return string(b), err
+}
+
+func (t crontab) write(ctx context.Context, content string) error {
+	if t.path == "" {
+		return writeCrontab(ctx, t.user, content)
+	}
+	if content == "" {
+		err := os.Remove(t.path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	dir := filepath.Dir(t.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(t.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), t.path)
+}
+
+// line renders an entry for this crontab, which in cron.d files names the
+// user to run it as.
+func (t crontab) line(schedule, command string) string {
+	if t.path != "" {
+		return schedule + " " + t.user + " " + command
+	}
+	return schedule + " " + command
+}
+
+func (c Cron) target() (crontab, error) {
+	user, _ := c.params["user"].(string)
+	if user == "" {
+		user = "root"
+	}
+	name, _ := c.params["file"].(string)
+	if name == "" {
+		return crontab{user: user}, nil
+	}
+	if !cronFileName.MatchString(name) {
+		return crontab{}, errors.Join(ErrInvalidCronFile, fmt.Errorf("%q may only contain letters, digits, underscores and hyphens", name))
+	}
+	return crontab{user: user, path: filepath.Join(cronDir, name)}, nil
+}
+
+func splitCrontab(content string) []string {
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return []string{}
+	}
+	return strings.Split(content, "\n")
+}
+
+func joinCrontab(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	// cron ignores a last line without a newline
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// update writes the new contents of the crontab, or in test mode only
+// reports them, along with a diff of the change.
+func (c Cron) update(ctx context.Context, test bool, t crontab, old string, lines []string, would, done string) (types.Result, error) {
+	updated := joinCrontab(lines)
+	notes := []fmt.Stringer{}
+	if test {
+		notes = append(notes, types.SimpleNote(would))
+	} else {
+		if err := t.write(ctx, updated); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		notes = append(notes, types.SimpleNote(done))
+	}
+	notes = append(notes, types.SimpleNote(file.UnifiedDiff(t.String(), t.String(), []byte(old), []byte(updated))))
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+}
+
+func (c Cron) identifier() string {
+	if id, ok := c.params["identifier"].(string); ok && id != "" {
+		return id
+	}
+	return c.params["name"].(string)
+}
+
+// findEntry returns the index of the marker line for id, or -1.
+func findEntry(lines []string, id string) int {
+	for i, line := range lines {
+		if strings.TrimSpace(line) == markerPrefix+id {
+			return i
+		}
+	}
+	return -1
+}
+
+// present adds the entry to the crontab under a marker comment holding
+// its identifier, or updates the line below the marker if the schedule or
+// command has changed.
+func (c Cron) present(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := c.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	command := c.params["name"].(string)
+	id := c.identifier()
+	if strings.ContainsAny(command+id, "\n") {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, ErrInvalidEntry
+	}
+	schedule, _ := c.params["schedule"].(string)
+	schedule, err := parseSchedule(schedule)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	t, err := c.target()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	old, err := t.read(ctx)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	lines := splitCrontab(old)
+	entry := t.line(schedule, command)
+	i := findEntry(lines, id)
+	switch {
+	case i == -1:
+		lines = append(lines, markerPrefix+id, entry)
+		return c.update(ctx, test, t, old, lines,
+			fmt.Sprintf("cron job %s would be added to %s", id, t),
+			fmt.Sprintf("cron job %s has been added to %s", id, t))
+	case i+1 < len(lines) && lines[i+1] == entry:
+		notes = append(notes, types.Snprintf("cron job %s is already present in %s", id, t))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	case i+1 < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i+1]), "#"):
+		lines[i+1] = entry
+	default:
+		// the entry below the marker was removed by hand
+		lines = append(lines[:i+1], append([]string{entry}, lines[i+1:]...)...)
+	}
+	return c.update(ctx, test, t, old, lines,
+		fmt.Sprintf("cron job %s would be updated in %s", id, t),
+		fmt.Sprintf("cron job %s has been updated in %s", id, t))
+}
+
+// absent removes the entry with the identifier and its marker comment.
+func (c Cron) absent(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := c.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	id := c.identifier()
+	t, err := c.target()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	old, err := t.read(ctx)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	lines := splitCrontab(old)
+	i := findEntry(lines, id)
+	if i == -1 {
+		notes = append(notes, types.Snprintf("cron job %s is already absent from %s", id, t))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	}
+	end := i + 1
+	if end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), "#") {
+		end++
+	}
+	lines = append(lines[:i], lines[end:]...)
+	return c.update(ctx, test, t, old, lines,
+		fmt.Sprintf("cron job %s would be removed from %s", id, t),
+		fmt.Sprintf("cron job %s has been removed from %s", id, t))
+}
+
+// envPresent sets an environment variable for the entries in the crontab.
+// New variables go at the top, so that they apply to every entry.
+func (c Cron) envPresent(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := c.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	name := c.params["name"].(string)
+	if !envName.MatchString(name) {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, errors.Join(ErrInvalidEnvName, fmt.Errorf("%q", name))
+	}
+	value := fmt.Sprintf("%v", c.params["value"])
+	if strings.Contains(value, "\n") {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, ErrInvalidEntry
+	}
+	t, err := c.target()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	old, err := t.read(ctx)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	lines := splitCrontab(old)
+	assignment := name + "=" + value
+	current := regexp.MustCompile(`^\s*` + name + `\s*=`)
+	found := false
+	for i, line := range lines {
+		if !current.MatchString(line) {
+			continue
+		}
+		if line == assignment {
+			notes = append(notes, types.Snprintf("variable %s is already set in %s", name, t))
+			return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+		}
+		lines[i] = assignment
+		found = true
+		break
+	}
+	if !found {
+		lines = append([]string{assignment}, lines...)
+	}
+	return c.update(ctx, test, t, old, lines,
+		fmt.Sprintf("variable %s would be set in %s", name, t),
+		fmt.Sprintf("variable %s has been set in %s", name, t))
+}
@@ -0,0 +1,98 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// nicknames are the @ schedules understood by cronie and Vixie cron.
+var nicknames = map[string]bool{
+	"@reboot": true, "@yearly": true, "@annually": true, "@monthly": true,
+	"@weekly": true, "@daily": true, "@midnight": true, "@hourly": true,
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+// fields describes the five time fields in order. Month and day of week
+// names are matched case-insensitively by their first three letters.
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	// both 0 and 7 are Sunday
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// parseSchedule validates a schedule and returns it normalised to single
+// spaces between fields.
+func parseSchedule(schedule string) (string, error) {
+	parts := strings.Fields(schedule)
+	if len(parts) == 1 && strings.HasPrefix(parts[0], "@") {
+		if !nicknames[parts[0]] {
+			return "", errors.Join(ErrInvalidSchedule, fmt.Errorf("unknown schedule %s", parts[0]))
+		}
+		return parts[0], nil
+	}
+	if len(parts) != len(fields) {
+		return "", errors.Join(ErrInvalidSchedule, fmt.Errorf("%q has %d fields, expected %d", schedule, len(parts), len(fields)))
+	}
+	for i, part := range parts {
+		if err := fields[i].validate(part); err != nil {
+			return "", errors.Join(ErrInvalidSchedule, err)
+		}
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// validate checks a comma-separated list of *, single values and ranges,
+// each with an optional /step.
+func (f field) validate(expr string) error {
+	for _, item := range strings.Split(expr, ",") {
+		span, step, hasStep := strings.Cut(item, "/")
+		if hasStep {
+			n, err := strconv.Atoi(step)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step %q in %s field %q", step, f.name, expr)
+			}
+		}
+		if span == "*" {
+			continue
+		}
+		low, high, isRange := strings.Cut(span, "-")
+		start, err := f.value(low)
+		if err != nil {
+			return fmt.Errorf("%w in %s field %q", err, f.name, expr)
+		}
+		if !isRange {
+			continue
+		}
+		end, err := f.value(high)
+		if err != nil {
+			return fmt.Errorf("%w in %s field %q", err, f.name, expr)
+		}
+		if end < start {
+			return fmt.Errorf("range %s is backwards in %s field %q", span, f.name, expr)
+		}
+	}
+	return nil
+}
+
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return i + f.min, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("%q is not between %d and %d", s, f.min, f.max)
+	}
+	return n, nil
+}
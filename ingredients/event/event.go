@@ -0,0 +1,271 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	nats "github.com/nats-io/nats.go"
+
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrEventMethodUndefined = errors.New("event method undefined")
+	ErrInvalidTag           = errors.New("invalid event tag")
+	ErrNotConnected         = errors.New("not connected to the farmer")
+	ErrUnknownStep          = errors.New("unknown step")
+	ErrStepNotFinished      = errors.New("step has not finished")
+)
+
+// flushTimeout bounds how long send waits for the farmer to take the event.
+const flushTimeout = 5 * time.Second
+
+var ec *nats.EncodedConn
+
+func RegisterEC(n *nats.EncodedConn) {
+	ec = n
+}
+
+// Event publishes events from recipes so that the outside world can follow
+// milestones, such as a migration finishing.
+type Event struct {
+	id     string
+	method string
+	params map[string]interface{}
+}
+
+func (e Event) Parse(id, method string, params map[string]interface{}) (types.RecipeCooker, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return Event{
+		id: id, method: method,
+		params: params,
+	}, nil
+}
+
+func (e Event) validate() error {
+	set, err := e.PropertiesForMethod(e.method)
+	if err != nil {
+		return err
+	}
+	propSet, err := ingredients.PropMapToPropSet(set)
+	if err != nil {
+		return err
+	}
+	for _, v := range propSet {
+		if v.IsReq {
+			if v.Key == "name" {
+				name, ok := e.params[v.Key].(string)
+				if !ok {
+					return types.ErrMissingName
+				}
+				if name == "" {
+					return types.ErrMissingName
+				}
+
+			} else {
+				if _, ok := e.params[v.Key]; !ok {
+					return fmt.Errorf("missing required property %s", v.Key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (e Event) Test(ctx context.Context) (types.Result, error) {
+	switch e.method {
+	case "send":
+		return e.send(ctx, true)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrEventMethodUndefined, fmt.Errorf("method %s undefined", e.method))
+	}
+}
+
+func (e Event) Apply(ctx context.Context) (types.Result, error) {
+	switch e.method {
+	case "send":
+		return e.send(ctx, false)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrEventMethodUndefined, fmt.Errorf("method %s undefined", e.method))
+	}
+}
+
+// checkTag makes sure the tag is one or more dot-separated NATS subject
+// tokens, so that it can't widen the subject or contain wildcards.
+func checkTag(tag string) error {
+	for _, token := range strings.Split(tag, ".") {
+		if token == "" || strings.ContainsAny(token, "*> \t\r\n") {
+			return errors.Join(ErrInvalidTag, fmt.Errorf("%q must be dot-separated words without spaces or wildcards", tag))
+		}
+	}
+	return nil
+}
+
+// stepIDs reads the results property, which may be a single step ID or a
+// list of them.
+func (e Event) stepIDs() ([]types.StepID, error) {
+	ids := []types.StepID{}
+	switch v := e.params["results"].(type) {
+	case nil:
+	case string:
+		ids = append(ids, types.StepID(v))
+	case []string:
+		for _, id := range v {
+			ids = append(ids, types.StepID(id))
+		}
+	case []interface{}:
+		for _, id := range v {
+			s, ok := id.(string)
+			if !ok {
+				return nil, fmt.Errorf("results must be a list of step IDs, not %v", e.params["results"])
+			}
+			ids = append(ids, types.StepID(s))
+		}
+	default:
+		return nil, fmt.Errorf("results must be a list of step IDs, not %v", v)
+	}
+	return ids, nil
+}
+
+// results looks up how the steps in the results property finished. The
+// cook engine only passes along the steps completed before this one
+// started, so those steps should be required by the step sending the
+// event.
+func (e Event) results(ctx context.Context) (map[types.StepID]types.EventResult, error) {
+	ids, err := e.stepIDs()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	completions, _ := types.CompletionsFromContext(ctx)
+	results := map[types.StepID]types.EventResult{}
+	for _, id := range ids {
+		completion, ok := completions[id]
+		if !ok {
+			return nil, errors.Join(ErrUnknownStep, fmt.Errorf("no step %s in this job", id))
+		}
+		if !slices.Contains([]types.CompletionStatus{types.StepCompleted, types.StepFailed}, completion.CompletionStatus) {
+			return nil, errors.Join(ErrStepNotFinished, fmt.Errorf("step %s is %s; add it as a requisite of %s", id, completion.CompletionStatus, e.id))
+		}
+		result := types.EventResult{
+			Status:  completion.CompletionStatus.String(),
+			Changed: completion.ChangesMade,
+			Changes: completion.Changes,
+		}
+		if completion.Error != nil {
+			result.Error = completion.Error.Error()
+		}
+		results[id] = result
+	}
+	return results, nil
+}
+
+// send publishes an event on grlx.events.<sprout>.<tag>, where the tag is
+// the name property. The payload carries the data property and how the
+// steps listed in results finished. Sending an event changes nothing on
+// the sprout, so the result never reports a change; test mode builds the
+// payload without publishing it.
+func (e Event) send(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := e.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	tag, _ := e.params["name"].(string)
+	if err := checkTag(tag); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if config.SproutID == "" {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, errors.New("the sprout ID is not set")
+	}
+	data := map[string]interface{}{}
+	switch v := e.params["data"].(type) {
+	case nil:
+	case map[string]interface{}:
+		data = v
+	default:
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, fmt.Errorf("data must be a map, not %v", v)
+	}
+	results, err := e.results(ctx)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	event := types.Event{
+		SproutID: config.SproutID,
+		JobID:    types.JobIDFromContext(ctx),
+		Tag:      tag,
+		Time:     time.Now().UTC(),
+		Data:     data,
+		Results:  results,
+	}
+	// catch payloads the encoder can't handle before publishing
+	if _, err = json.Marshal(event); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	subject := "grlx.events." + config.SproutID + "." + tag
+	if test {
+		notes = append(notes, types.Snprintf("would send event %s on %s", tag, subject))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	}
+	if ec == nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, ErrNotConnected
+	}
+	if err = ec.Publish(subject, event); err != nil {
+		notes = append(notes, types.Snprintf("failed to send event %s", tag))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if err = ec.FlushTimeout(flushTimeout); err != nil {
+		notes = append(notes, types.Snprintf("failed to send event %s", tag))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	notes = append(notes, types.Snprintf("sent event %s on %s", tag, subject))
+	return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+}
+
+func (e Event) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	switch method {
+	case "send":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the event tag, published on grlx.events.<sprout>.<tag>"},
+			ingredients.MethodProps{Key: "data", Type: "map", IsReq: false, Description: "fields to include in the event"},
+			ingredients.MethodProps{Key: "results", Type: "[]string", IsReq: false, Description: "IDs of earlier steps whose results to include; require them so they finish first"},
+		}, nil
+	default:
+		return nil, errors.Join(ErrEventMethodUndefined, fmt.Errorf("method %s undefined", method))
+	}
+}
+
+func (e Event) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := e.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (e Event) Methods() (string, []string) {
+	return "event", []string{"send"}
+}
+
+func (e Event) Properties() (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(e.params)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+func init() {
+	ingredients.RegisterAllMethods(Event{})
+}
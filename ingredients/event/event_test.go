@@ -0,0 +1,137 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	nats "github.com/nats-io/nats.go"
+
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/types"
+)
+
+func TestSend(t *testing.T) {
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	defer srv.Shutdown()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server did not start")
+	}
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := nats.NewEncodedConn(nc, nats.JSON_ENCODER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	RegisterEC(conn)
+	defer RegisterEC(nil)
+	config.SproutID = "web-03"
+
+	received := make(chan *nats.Msg, 10)
+	sub, err := nc.ChanSubscribe("grlx.events.web-03.>", received)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	ctx := types.WithJobID(context.Background(), "job-1")
+	ctx = types.WithCompletions(ctx, map[types.StepID]types.StepCompletion{
+		"migrate": {ID: "migrate", CompletionStatus: types.StepCompleted, ChangesMade: true, Changes: []string{"applied 3 migrations"}},
+		"restart": {ID: "restart", CompletionStatus: types.StepFailed, Error: errors.New("unit not found")},
+		"later":   {ID: "later", CompletionStatus: types.StepNotStarted},
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		params  map[string]interface{}
+		test    bool
+		subject string
+		error   error
+	}{
+		{name: "Send", method: "send", params: map[string]interface{}{"name": "deploy.finished", "data": map[string]interface{}{"version": "1.2.3"}, "results": []interface{}{"migrate", "restart"}}, subject: "grlx.events.web-03.deploy.finished"},
+		{name: "Test", method: "send", params: map[string]interface{}{"name": "migrated"}, test: true},
+		{name: "Wildcard", method: "send", params: map[string]interface{}{"name": "deploy.>"}, error: ErrInvalidTag},
+		{name: "EmptyToken", method: "send", params: map[string]interface{}{"name": "deploy..finished"}, error: ErrInvalidTag},
+		{name: "UnknownStep", method: "send", params: map[string]interface{}{"name": "migrated", "results": "missing"}, error: ErrUnknownStep},
+		{name: "NotFinished", method: "send", params: map[string]interface{}{"name": "migrated", "results": []string{"later"}}, error: ErrStepNotFinished},
+		{name: "MissingName", method: "send", params: map[string]interface{}{}, error: types.ErrMissingName},
+		{name: "BadMethod", method: "broadcast", params: map[string]interface{}{"name": "migrated"}, error: ErrEventMethodUndefined},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := Event{id: test.name, method: test.method, params: test.params}
+			apply := e.Apply
+			if test.test {
+				apply = e.Test
+			}
+			res, err := apply(ctx)
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				if !res.Failed {
+					t.Error("expected the step to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !res.Succeeded || res.Changed || len(res.Notes) != 1 {
+				t.Fatalf("unexpected result %+v", res)
+			}
+			if test.test {
+				if note := res.Notes[0].String(); note != "would send event migrated on grlx.events.web-03.migrated" {
+					t.Errorf("unexpected note %s", note)
+				}
+				return
+			}
+			select {
+			case msg := <-received:
+				if msg.Subject != test.subject {
+					t.Errorf("expected subject %s, got %s", test.subject, msg.Subject)
+				}
+				var event types.Event
+				if err := nats.EncoderForType(nats.JSON_ENCODER).Decode(msg.Subject, msg.Data, &event); err != nil {
+					t.Fatal(err)
+				}
+				if event.SproutID != "web-03" || event.JobID != "job-1" || event.Tag != "deploy.finished" || event.Data["version"] != "1.2.3" {
+					t.Errorf("unexpected event %+v", event)
+				}
+				migrate, restart := event.Results["migrate"], event.Results["restart"]
+				if migrate.Status != "completed" || !migrate.Changed || len(migrate.Changes) != 1 {
+					t.Errorf("unexpected migrate result %+v", migrate)
+				}
+				if restart.Status != "failed" || restart.Error != "unit not found" {
+					t.Errorf("unexpected restart result %+v", restart)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no event received")
+			}
+		})
+	}
+	select {
+	case msg := <-received:
+		t.Errorf("unexpected event on %s", msg.Subject)
+	default:
+	}
+}
+
+func TestSendNotConnected(t *testing.T) {
+	config.SproutID = "web-03"
+	e := Event{id: "send", method: "send", params: map[string]interface{}{"name": "migrated"}}
+	res, err := e.Apply(context.Background())
+	if !errors.Is(err, ErrNotConnected) || !res.Failed {
+		t.Fatalf("expected ErrNotConnected, got %v", err)
+	}
+}
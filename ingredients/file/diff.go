@@ -0,0 +1,291 @@
+package file
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+const (
+	// diffContext is the number of unchanged lines shown around a change
+	diffContext = 3
+	// maxDiffBytes is the largest file that will be diffed
+	maxDiffBytes = 1 << 20
+	// maxDiffEditLines caps the input to the line matcher, whose running
+	// time grows with the lines times the edits; larger changes are shown
+	// as a complete replacement
+	maxDiffEditLines = 4000
+	// maxDiffLines is the longest diff that will be reported
+	maxDiffLines = 500
+)
+
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// showChanges reports whether the show_changes property asks for diffs,
+// which it does unless explicitly disabled.
+func (f File) showChanges() bool {
+	if show, ok := f.params["show_changes"].(bool); ok {
+		return show
+	}
+	return true
+}
+
+// diffNotes returns a note holding the diff between the old and new
+// contents of name, or nothing if they are the same or show_changes is
+// false.
+func (f File) diffNotes(name string, old, new []byte, exists bool) []fmt.Stringer {
+	if !f.showChanges() || (exists && bytes.Equal(old, new)) {
+		return nil
+	}
+	oldName := name
+	if !exists {
+		oldName = "/dev/null"
+	}
+	return []fmt.Stringer{types.SimpleNote(UnifiedDiff(oldName, name, old, new))}
+}
+
+// UnifiedDiff renders the difference between old and new in unified diff
+// format. Binary and oversized files are summarised rather than diffed,
+// and long diffs are truncated.
+func UnifiedDiff(oldName, newName string, old, new []byte) string {
+	if isBinary(old) || isBinary(new) {
+		return fmt.Sprintf("Binary files %s and %s differ", oldName, newName)
+	}
+	if len(old) > maxDiffBytes || len(new) > maxDiffBytes {
+		return fmt.Sprintf("--- %s\n+++ %s\n(file too large to diff: %d bytes to %d bytes)", oldName, newName, len(old), len(new))
+	}
+	ops := diffLines(splitLines(string(old)), splitLines(string(new)))
+	out := []string{"--- " + oldName, "+++ " + newName}
+	out = append(out, formatHunks(ops)...)
+	if len(out) > maxDiffLines {
+		more := len(out) - maxDiffLines
+		out = append(out[:maxDiffLines], fmt.Sprintf("... diff truncated, %d more lines", more))
+	}
+	return strings.Join(out, "\n")
+}
+
+// isBinary guesses whether b holds binary data, in the same way as most
+// diff tools: by looking for a NUL byte near the start or invalid UTF-8.
+func isBinary(b []byte) bool {
+	head := b
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	return bytes.IndexByte(head, 0) != -1 || !utf8.Valid(head)
+}
+
+// splitLines splits s into lines, keeping the line endings so that a
+// missing newline at the end of the file counts as a difference.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script from a to b using Myers'
+// algorithm. Unchanged leading and trailing lines are trimmed first.
+func diffLines(a, b []string) []diffOp {
+	prefix, suffix := commonEnds(a, b)
+	ops := make([]diffOp, 0, len(a)+len(b))
+	ops = appendOps(ops, ' ', a[:prefix])
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(midA)+len(midB) > maxDiffEditLines {
+		ops = appendOps(ops, '-', midA)
+		ops = appendOps(ops, '+', midB)
+	} else {
+		ops = myers(midA, midB, ops)
+	}
+	return deletionsFirst(appendOps(ops, ' ', a[len(a)-suffix:]))
+}
+
+// deletionsFirst reorders each run of changed lines so that the removed
+// lines come before the added ones, as diff(1) prints them.
+func deletionsFirst(ops []diffOp) []diffOp {
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		j := i
+		for j < len(ops) && ops[j].kind != ' ' {
+			j++
+		}
+		run := ops[i:j]
+		sort.SliceStable(run, func(x, y int) bool {
+			return run[x].kind == '-' && run[y].kind == '+'
+		})
+		i = j
+	}
+	return ops
+}
+
+// commonEnds returns the number of lines a and b share at the start and,
+// not counting those, at the end.
+func commonEnds(a, b []string) (int, int) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	return prefix, suffix
+}
+
+func appendOps(ops []diffOp, kind byte, lines []string) []diffOp {
+	for _, l := range lines {
+		ops = append(ops, diffOp{kind, l})
+	}
+	return ops
+}
+
+// myers appends the edit script from a to b to ops. Rather than keeping
+// every step of the search to trace the path back, it finds the middle
+// snake of the path and recurses on either side of it, so only the two
+// search frontiers are held in memory.
+func myers(a, b []string, ops []diffOp) []diffOp {
+	prefix, suffix := commonEnds(a, b)
+	ops = appendOps(ops, ' ', a[:prefix])
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	switch {
+	case len(midA) == 0:
+		ops = appendOps(ops, '+', midB)
+	case len(midB) == 0:
+		ops = appendOps(ops, '-', midA)
+	default:
+		x, y, u, v := middleSnake(midA, midB)
+		ops = myers(midA[:x], midB[:y], ops)
+		ops = appendOps(ops, ' ', midA[x:u])
+		ops = myers(midA[u:], midB[v:], ops)
+	}
+	return appendOps(ops, ' ', a[len(a)-suffix:])
+}
+
+// middleSnake searches forwards from the start and backwards from the end
+// of a and b at the same time, returning the run of matching lines, from
+// (x, y) to (u, v), where the two searches meet on a shortest path.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	max := (n + m + 1) / 2
+	offset := max + 1
+	delta := n - m
+	odd := delta%2 != 0
+	// forward[k] and backward[k] hold the furthest x reached on diagonal
+	// k, with backward measured from the ends of a and b
+	forward := make([]int, 2*max+3)
+	backward := make([]int, 2*max+3)
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x0 int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x0 = forward[offset+k+1]
+			} else {
+				x0 = forward[offset+k-1] + 1
+			}
+			x, y := x0, x0-k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if r := delta - k; odd && r >= -(d-1) && r <= d-1 && x+backward[offset+r] >= n {
+				return x0, x0 - k, x, y
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var x0 int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x0 = backward[offset+k+1]
+			} else {
+				x0 = backward[offset+k-1] + 1
+			}
+			x, y := x0, x0-k
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if f := delta - k; !odd && f >= -d && f <= d && x+forward[offset+f] >= n {
+				return n - x, m - y, n - x0, m - (x0 - k)
+			}
+		}
+	}
+	// unreachable: the searches always meet within max steps
+	return 0, 0, n, m
+}
+
+// formatHunks groups an edit script into unified diff hunks.
+func formatHunks(ops []diffOp) []string {
+	// line numbers in the old and new file before each op
+	oldLine := make([]int, len(ops)+1)
+	newLine := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldLine[i+1], newLine[i+1] = oldLine[i], newLine[i]
+		if op.kind != '+' {
+			oldLine[i+1]++
+		}
+		if op.kind != '-' {
+			newLine[i+1]++
+		}
+	}
+	var out []string
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		// extend the hunk while the next change is close enough to share
+		// context with this one
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			} else if j-end > 2*diffContext {
+				break
+			}
+		}
+		stop := end + diffContext + 1
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+		oldCount := oldLine[stop] - oldLine[start]
+		newCount := newLine[stop] - newLine[start]
+		out = append(out, fmt.Sprintf("@@ -%s +%s @@",
+			hunkRange(oldLine[start]+1, oldCount), hunkRange(newLine[start]+1, newCount)))
+		for _, op := range ops[start:stop] {
+			line := string(op.kind) + op.line
+			if strings.HasSuffix(line, "\n") {
+				out = append(out, strings.TrimSuffix(line, "\n"))
+			} else {
+				out = append(out, line, `\ No newline at end of file`)
+			}
+		}
+		i = stop
+	}
+	return out
+}
+
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	default:
+		return fmt.Sprintf("%d,%d", start, count)
+	}
+}
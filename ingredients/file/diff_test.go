@@ -0,0 +1,99 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	long := func(n int, changed int) string {
+		var b strings.Builder
+		for i := 1; i <= n; i++ {
+			if i == changed {
+				b.WriteString("changed\n")
+				continue
+			}
+			fmt.Fprintf(&b, "line %d\n", i)
+		}
+		return b.String()
+	}
+	tests := []struct {
+		name     string
+		old, new string
+		expected string
+	}{
+		{
+			name:     "new file",
+			old:      "",
+			new:      "a\nb\n",
+			expected: "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b",
+		},
+		{
+			name:     "emptied file",
+			old:      "a\n",
+			new:      "",
+			expected: "--- old\n+++ new\n@@ -1 +0,0 @@\n-a",
+		},
+		{
+			name:     "replaced line",
+			old:      "a\nb\nc\n",
+			new:      "a\nx\nc\n",
+			expected: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c",
+		},
+		{
+			name:     "line replaced by two",
+			old:      "old\n",
+			new:      "header\nfrom source\n",
+			expected: "--- old\n+++ new\n@@ -1 +1,2 @@\n-old\n+header\n+from source",
+		},
+		{
+			name:     "missing newline",
+			old:      "a",
+			new:      "a\n",
+			expected: "--- old\n+++ new\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a",
+		},
+		{
+			name:     "context is limited",
+			old:      long(20, 0),
+			new:      long(20, 10),
+			expected: "--- old\n+++ new\n@@ -7,7 +7,7 @@\n line 7\n line 8\n line 9\n-line 10\n+changed\n line 11\n line 12\n line 13",
+		},
+		{
+			name:     "separate hunks",
+			old:      long(20, 0),
+			new:      strings.Replace(long(20, 2), "line 19\n", "changed\n", 1),
+			expected: "--- old\n+++ new\n@@ -1,5 +1,5 @@\n line 1\n-line 2\n+changed\n line 3\n line 4\n line 5\n@@ -16,5 +16,5 @@\n line 16\n line 17\n line 18\n-line 19\n+changed\n line 20",
+		},
+		{
+			name:     "binary",
+			old:      "a\x00b",
+			new:      "a",
+			expected: "Binary files old and new differ",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := UnifiedDiff("old", "new", []byte(test.old), []byte(test.new))
+			if diff != test.expected {
+				t.Errorf("expected diff\n%s\ngot\n%s", test.expected, diff)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffTruncated(t *testing.T) {
+	var old, new strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&old, "old %d\n", i)
+		fmt.Fprintf(&new, "new %d\n", i)
+	}
+	diff := UnifiedDiff("old", "new", []byte(old.String()), []byte(new.String()))
+	lines := strings.Split(diff, "\n")
+	if len(lines) != maxDiffLines+1 {
+		t.Errorf("expected %d lines, got %d", maxDiffLines+1, len(lines))
+	}
+	if !strings.HasPrefix(lines[len(lines)-1], "... diff truncated") {
+		t.Errorf("expected truncation note, got %s", lines[len(lines)-1])
+	}
+}
@@ -0,0 +1,166 @@
+// Package farmer provides grlx:// file sources, which are served by the
+// farmer from its recipe directory and fetched by sprouts over the bus.
+package farmer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	nats "github.com/nats-io/nats.go"
+
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/ingredients/file/hashers"
+	"github.com/gogrlx/grlx/types"
+)
+
+var ErrNotConnected = errors.New("not connected to the farmer")
+
+// requestTimeout bounds each request to the farmer when the context
+// carries no deadline of its own.
+const requestTimeout = 30 * time.Second
+
+var ec *nats.EncodedConn
+
+// RegisterEC sets the connection sprouts use to fetch grlx:// sources.
+func RegisterEC(n *nats.EncodedConn) {
+	ec = n
+}
+
+type FarmerFile struct {
+	ID          string
+	Source      string
+	Destination string
+	Hash        string
+	Props       map[string]interface{}
+}
+
+// path is the source relative to the farmer's recipe directory.
+func (ff FarmerFile) path() string {
+	return strings.TrimPrefix(ff.Source, "grlx://")
+}
+
+func (ff FarmerFile) request(ctx context.Context, method string, req Request, resp *Response) error {
+	if ec == nil {
+		return ErrNotConnected
+	}
+	if config.SproutID == "" {
+		return errors.New("the sprout ID is not set")
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, requestTimeout)
+		defer cancel()
+	}
+	err := ec.RequestWithContext(ctx, "grlx.files."+config.SproutID+"."+method, req, resp)
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("farmer could not %s %s: %s", method, ff.Source, resp.Error)
+	}
+	return nil
+}
+
+func (ff FarmerFile) Download(ctx context.Context) error {
+	// without a hash there is nothing to verify, so always fetch
+	if ff.Hash != "" {
+		ok, err := ff.Verify(ctx)
+		if ok {
+			return nil
+		}
+		if err != nil && !errors.Is(err, types.ErrFileNotFound) {
+			return err
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(ff.Destination), "."+filepath.Base(ff.Destination)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	var offset int64
+	for {
+		var resp Response
+		err = ff.request(ctx, "get", Request{Path: ff.path(), Offset: offset}, &resp)
+		if err == nil {
+			_, err = tmp.Write(resp.Data)
+		}
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		offset += int64(len(resp.Data))
+		if resp.EOF {
+			break
+		}
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if ff.Hash != "" {
+		valid, err := ff.cacheFile(tmp.Name()).Verify(ctx)
+		if err == nil && !valid {
+			err = errors.Join(types.ErrHashMismatch, fmt.Errorf("%s does not match hash %s", ff.Source, ff.Hash))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err = os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), ff.Destination)
+}
+
+// List asks the farmer for the regular files under the source directory.
+func (ff FarmerFile) List(ctx context.Context) ([]string, error) {
+	var resp Response
+	if err := ff.request(ctx, "list", Request{Path: ff.path()}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Files == nil {
+		return []string{}, nil
+	}
+	return resp.Files, nil
+}
+
+func (ff FarmerFile) Properties() (map[string]interface{}, error) {
+	return ff.Props, nil
+}
+
+func (ff FarmerFile) Parse(id, source, destination, hash string, properties map[string]interface{}) (types.FileProvider, error) {
+	if properties == nil {
+		properties = make(map[string]interface{})
+	}
+	return FarmerFile{ID: id, Source: source, Destination: destination, Hash: hash, Props: properties}, nil
+}
+
+func (ff FarmerFile) Protocols() []string {
+	return []string{"grlx"}
+}
+
+func (ff FarmerFile) Verify(ctx context.Context) (bool, error) {
+	return ff.cacheFile(ff.Destination).Verify(ctx)
+}
+
+// cacheFile describes the file at path as holding the expected hash.
+func (ff FarmerFile) cacheFile(path string) hashers.CacheFile {
+	hashType := ""
+	if ff.Props["hashType"] == nil {
+		hashType = hashers.GuessHashType(ff.Hash)
+	} else if ht, ok := ff.Props["hashType"].(string); !ok {
+		hashType = hashers.GuessHashType(ff.Hash)
+	} else {
+		hashType = ht
+	}
+	return hashers.CacheFile{
+		ID:          ff.ID,
+		Destination: path,
+		Hash:        ff.Hash,
+		HashType:    hashType,
+	}
+}
@@ -0,0 +1,195 @@
+package farmer
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	nats "github.com/nats-io/nats.go"
+
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/types"
+)
+
+// serveRecipes starts a bus with the farmer serving a recipe directory
+// holding tree, and registers a sprout connection to it.
+func serveRecipes(t *testing.T, tree map[string]string) string {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server did not start")
+	}
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := nats.NewEncodedConn(nc, nats.JSON_ENCODER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(conn.Close)
+	if err = Serve(conn); err != nil {
+		t.Fatal(err)
+	}
+	RegisterEC(conn)
+	recipeDir, sproutID := config.RecipeDir, config.SproutID
+	t.Cleanup(func() {
+		RegisterEC(nil)
+		config.RecipeDir, config.SproutID = recipeDir, sproutID
+	})
+	config.RecipeDir = t.TempDir()
+	config.SproutID = "web-03.example.com"
+	for rel, content := range tree {
+		p := filepath.Join(config.RecipeDir, filepath.FromSlash(rel))
+		if err = os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return config.RecipeDir
+}
+
+func md5Hash(content string) string {
+	sum := md5.Sum([]byte(content))
+	return "md5:" + hex.EncodeToString(sum[:])
+}
+
+func TestDownload(t *testing.T) {
+	large := strings.Repeat("0123456789abcdef", 1000)
+	recipeDir := serveRecipes(t, map[string]string{
+		"apache/http.conf": "Listen 80\n",
+		"large.bin":        large,
+		"empty":            "",
+	})
+	outside := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(outside, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(recipeDir, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	defer func(size int) { chunkSize = size }(chunkSize)
+	chunkSize = 4096
+
+	tests := []struct {
+		name     string
+		source   string
+		hash     string
+		expected string
+		fails    bool
+		error    error
+	}{
+		{name: "Nested", source: "grlx://apache/http.conf", expected: "Listen 80\n"},
+		{name: "Chunked", source: "grlx://large.bin", expected: large},
+		{name: "Empty", source: "grlx://empty", expected: ""},
+		{name: "Hash", source: "grlx://apache/http.conf", hash: md5Hash("Listen 80\n"), expected: "Listen 80\n"},
+		{name: "HashMismatch", source: "grlx://apache/http.conf", hash: md5Hash("Listen 443\n"), fails: true, error: types.ErrHashMismatch},
+		{name: "Missing", source: "grlx://apache/missing.conf", fails: true},
+		{name: "Directory", source: "grlx://apache", fails: true},
+		{name: "ParentDir", source: "grlx://../secret", fails: true},
+		{name: "Symlink", source: "grlx://escape", fails: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "dest")
+			fp, err := FarmerFile{}.Parse(test.name, test.source, dest, test.hash, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = fp.Download(context.Background())
+			if test.error != nil && !errors.Is(err, test.error) {
+				t.Fatalf("expected error %v, got %v", test.error, err)
+			}
+			if test.fails {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				if _, statErr := os.Stat(dest); !os.IsNotExist(statErr) {
+					t.Errorf("expected %s not to be written, got %v", dest, statErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			b, err := os.ReadFile(dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.expected {
+				t.Errorf("expected %d bytes, got %d", len(test.expected), len(b))
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	recipeDir := serveRecipes(t, map[string]string{
+		"nginx/sites/default.conf": "server {}\n",
+		"nginx/sites/app/app.conf": "server { listen 8080; }\n",
+		"nginx/nginx.conf":         "worker_processes 4;\n",
+	})
+	if err := os.Symlink(t.TempDir(), filepath.Join(recipeDir, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(recipeDir, "nginx", "sites"), filepath.Join(recipeDir, "sites")); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		source   string
+		expected []string
+		error    bool
+	}{
+		{name: "Directory", source: "grlx://nginx/sites/", expected: []string{"app/app.conf", "default.conf"}},
+		{name: "LinkedDirectory", source: "grlx://sites", expected: []string{"app/app.conf", "default.conf"}},
+		{name: "Missing", source: "grlx://nginx/missing", error: true},
+		{name: "ParentDir", source: "grlx://nginx/../../", error: true},
+		{name: "Symlink", source: "grlx://escape", error: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files, err := FarmerFile{ID: test.name, Source: test.source}.List(context.Background())
+			if test.error {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", files)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			sort.Strings(files)
+			if strings.Join(files, "|") != strings.Join(test.expected, "|") {
+				t.Errorf("expected %v, got %v", test.expected, files)
+			}
+		})
+	}
+}
+
+func TestNotConnected(t *testing.T) {
+	RegisterEC(nil)
+	_, err := FarmerFile{Source: "grlx://nginx/sites"}.List(context.Background())
+	if !errors.Is(err, ErrNotConnected) {
+		t.Errorf("expected %v, got %v", ErrNotConnected, err)
+	}
+	err = FarmerFile{Source: "grlx://nginx.conf", Destination: filepath.Join(t.TempDir(), "nginx.conf")}.Download(context.Background())
+	if !errors.Is(err, ErrNotConnected) {
+		t.Errorf("expected %v, got %v", ErrNotConnected, err)
+	}
+}
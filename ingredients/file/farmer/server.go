@@ -0,0 +1,141 @@
+package farmer
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/taigrr/log-socket/log"
+
+	"github.com/gogrlx/grlx/config"
+)
+
+var ErrOutsideRecipeDir = errors.New("path is outside the recipe directory")
+
+// chunkSize bounds the file data in one reply, keeping it well under the
+// bus's payload limit once encoded.
+var chunkSize = 256 * 1024
+
+// Request is sent by a sprout on grlx.files.<sprout>.list or
+// grlx.files.<sprout>.get, with Path relative to the recipe directory.
+type Request struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset,omitempty"`
+}
+
+// Response answers a Request. A get is answered one chunk at a time,
+// starting at the requested offset, until EOF is set.
+type Response struct {
+	Files []string `json:"files,omitempty"`
+	Data  []byte   `json:"data,omitempty"`
+	EOF   bool     `json:"eof,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+// Serve answers file requests from sprouts on the farmer, serving files
+// from the recipe directory.
+func Serve(conn *nats.EncodedConn) error {
+	_, err := conn.Subscribe("grlx.files.>", func(subject, reply string, req *Request) {
+		var resp Response
+		var err error
+		// sprout IDs may contain dots, so the method is the last token
+		switch subject[strings.LastIndex(subject, ".")+1:] {
+		case "list":
+			resp.Files, err = list(req.Path)
+		case "get":
+			resp.Data, resp.EOF, err = get(req.Path, req.Offset)
+		default:
+			return
+		}
+		if err != nil {
+			log.Debugf("failed to serve %s for %s: %v", req.Path, subject, err)
+			resp.Error = err.Error()
+		}
+		if err = conn.Publish(reply, resp); err != nil {
+			log.Error(err)
+		}
+	})
+	return err
+}
+
+// resolve returns the real path of the file or directory at path, which
+// must stay inside the recipe directory even after following symlinks.
+func resolve(path string) (string, error) {
+	path = filepath.FromSlash(strings.Trim(path, "/"))
+	if path == "" {
+		path = "."
+	}
+	if !filepath.IsLocal(path) {
+		return "", ErrOutsideRecipeDir
+	}
+	root, err := filepath.EvalSymlinks(config.RecipeDir)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, path))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", ErrOutsideRecipeDir
+	}
+	return resolved, nil
+}
+
+// list walks the directory at path, returning its regular files.
+func list(path string) ([]string, error) {
+	root, err := resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
+
+// get reads the chunk of the file at path starting at offset.
+func get(path string, offset int64) ([]byte, bool, error) {
+	resolved, err := resolve(path)
+	if err != nil {
+		return nil, false, err
+	}
+	f, err := os.Open(resolved)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, false, errors.New("not a regular file")
+	}
+	data := make([]byte, chunkSize)
+	n, err := f.ReadAt(data, offset)
+	if errors.Is(err, io.EOF) {
+		return data[:n], true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data[:n], offset+int64(n) >= info.Size(), nil
+}
@@ -2,6 +2,7 @@ package file
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,7 +14,12 @@ import (
 	"github.com/gogrlx/grlx/types"
 )
 
-var ErrFileMethodUndefined = errors.New("file method undefined")
+var (
+	ErrFileMethodUndefined = errors.New("file method undefined")
+	// ErrNotCached is returned by test runs for sources which have not been
+	// fetched yet, so the content they would produce isn't known.
+	ErrNotCached = errors.New("source has not been cached yet")
+)
 
 type File struct {
 	id     string
@@ -84,14 +90,28 @@ func (f File) Test(ctx context.Context) (types.Result, error) {
 		return f.absent(ctx, true)
 	case "append":
 		return f.append(ctx, true)
+	case "blockreplace":
+		return f.blockreplace(ctx, true)
 	case "directory":
 		return f.directory(ctx, true)
 	case "exists":
 		return f.exists(ctx, true)
+	case "hardlink":
+		return f.hardlink(ctx, true)
 	case "missing":
 		return f.missing(ctx, true)
+	case "permissions":
+		return f.permissions(ctx, true)
 	case "prepend":
 		return f.prepend(ctx, true)
+	case "recurse":
+		return f.recurse(ctx, true)
+	case "rename":
+		return f.rename(ctx, true)
+	case "replace":
+		return f.replace(ctx, true)
+	case "serialize":
+		return f.serialize(ctx, true)
 	case "touch":
 		return f.touch(ctx, true)
 	case "cached":
@@ -101,6 +121,12 @@ func (f File) Test(ctx context.Context) (types.Result, error) {
 		return res, err
 	case "content":
 		return f.content(ctx, true)
+	case "copy":
+		return f.copy(ctx, true)
+	case "keyvalue":
+		return f.keyvalue(ctx, true)
+	case "line":
+		return f.line(ctx, true)
 	case "managed":
 		return f.managed(ctx, true)
 	case "symlink":
@@ -116,9 +142,12 @@ func (f File) dest() (string, error) {
 	if !ok || name == "" {
 		return "", types.ErrMissingName
 	}
-	basename := filepath.Base(name)
 	if sv, okSkip := f.params["skip_verify"].(bool); okSkip && sv {
-		return filepath.Join(config.CacheDir, "skip_"+basename), nil
+		source, _ := f.params["source"].(string)
+		if source == "" {
+			source = name
+		}
+		return skipVerifyDest(source), nil
 	}
 	hash, ok := f.params["hash"].(string)
 	if !ok || hash == "" {
@@ -127,234 +156,189 @@ func (f File) dest() (string, error) {
 	return filepath.Join(config.CacheDir, hash), nil
 }
 
-func (f File) cacheSources(ctx context.Context, test bool) (types.Result, error) {
+// skipVerifyDest is where an unverified copy of source is cached. There is
+// no hash to key the copy on, so it is keyed on the full source, keeping
+// apart sources which share a basename.
+func skipVerifyDest(source string) string {
+	return filepath.Join(config.CacheDir, fmt.Sprintf("skip_%x", sha256.Sum256([]byte(source))))
+}
+
+// listProp reads a list property, which is a []string once decoded
+// against the method's schema and a []interface{} when set directly.
+func (f File) listProp(key string) ([]interface{}, bool) {
+	switch v := f.params[key].(type) {
+	case []interface{}:
+		return v, true
+	case []string:
+		list := make([]interface{}, 0, len(v))
+		for _, s := range v {
+			list = append(list, s)
+		}
+		return list, true
+	}
+	return nil, false
+}
+
+// providerProps are passed through to the file provider when a source
+// is cached on behalf of another method.
+var providerProps = []string{
+	"bearer_token", "ca_bundle", "headers", "password",
+	"proxy", "retries", "retry_delay", "timeout", "username",
+}
+
+// cacheSource caches a single source under cacheName and returns the path
+// of the cached copy. Test runs don't fetch anything, so they return
+// ErrNotCached unless an up to date copy is already cached.
+func (f File) cacheSource(ctx context.Context, cacheName, src, srcHash string, skipVerify, test bool) (string, []fmt.Stringer, error) {
+	if srcHash == "" && !skipVerify {
+		return "", nil, types.ErrMissingHash
+	}
+	if !skipVerify {
+		// resolve checksum files once, up front, so the cached copy is
+		// stored under the digest
+		var err error
+		srcHash, err = f.resolveHash(ctx, src, srcHash)
+		if err != nil {
+			return "", []fmt.Stringer{types.Snprintf("failed to resolve hash for source %s", src)}, err
+		}
+	}
+	params := map[string]interface{}{
+		"source": src, "hash": srcHash,
+		"skip_verify": skipVerify, "name": cacheName,
+	}
+	for _, k := range providerProps {
+		if v, ok := f.params[k]; ok {
+			params[k] = v
+		}
+	}
+	srcFile, err := f.Parse(cacheName, "cached", params)
+	if err != nil {
+		return "", []fmt.Stringer{types.Snprintf("failed to cache source %s", src)}, err
+	}
+	run := srcFile.Apply
+	if test {
+		run = srcFile.Test
+	}
+	cacheRes, err := run(ctx)
+	notes := cacheRes.Notes
+	if err != nil || !cacheRes.Succeeded {
+		notes = append(notes, types.Snprintf("failed to cache source %s", src))
+		return "", notes, errors.Join(err, types.ErrCacheFailure)
+	}
+	if test && cacheRes.Changed {
+		return "", notes, ErrNotCached
+	}
+	sourceDest, err := srcFile.(File).dest()
+	if err != nil {
+		return "", notes, err
+	}
+	if _, err = os.Stat(sourceDest); err != nil {
+		notes = append(notes, types.Snprintf("failed to open cached source %s", sourceDest))
+		return "", notes, err
+	}
+	return sourceDest, notes, nil
+}
+
+// CacheSource caches src through the file provider registry on behalf of
+// another ingredient and returns the path of the cached copy. Provider
+// settings such as headers or credentials are taken from props. Test runs
+// don't fetch anything, so they return ErrNotCached unless an up to date
+// copy is already cached.
+func CacheSource(ctx context.Context, id string, props map[string]interface{}, cacheName, src, srcHash string, skipVerify, test bool) (string, []fmt.Stringer, error) {
+	f := File{id: id, method: "cached", params: props}
+	return f.cacheSource(ctx, cacheName, src, srcHash, skipVerify, test)
+}
+
+// uncachedResult reports a test run whose sources haven't been fetched yet;
+// the new content isn't known, so no diff is shown.
+func uncachedResult(name string, exists bool, notes []fmt.Stringer) types.Result {
+	if exists {
+		notes = append(notes, types.Snprintf("%s would be updated", name))
+	} else {
+		notes = append(notes, types.Snprintf("%s would be created", name))
+	}
+	return types.Result{
+		Succeeded: true, Failed: false,
+		Changed: true, Notes: notes,
+	}
+}
+
+// cacheSources caches `source` and every entry of `sources`, returning the
+// paths of the cached copies in that order.
+func (f File) cacheSources(ctx context.Context, test bool) ([]string, types.Result, error) {
+	var notes []fmt.Stringer
+	cachedPaths := []string{}
 	name, ok := f.params["name"].(string)
 	if !ok {
-		return types.Result{
+		return cachedPaths, types.Result{
 			Succeeded: false, Failed: true,
 		}, types.ErrMissingName
 	}
 	name = filepath.Clean(name)
 	if name == "" {
-		return types.Result{
+		return cachedPaths, types.Result{
 			Succeeded: false, Failed: true,
 		}, types.ErrMissingName
 	}
 	if name == "/" {
-		return types.Result{
+		return cachedPaths, types.Result{
 			Succeeded: false, Failed: true,
 		}, types.ErrModifyRoot
 	}
-	{
-		sourceDest := ""
-		if src, ok := f.params["source"].(string); ok && src != "" {
-			if srcHash, ok := f.params["source_hash"].(string); ok && srcHash != "" {
-				srcFile, err := f.Parse(f.id+"-source", "cached", map[string]interface{}{
-					"source": src, "hash": srcHash,
-					"name": name + "-source",
-				})
-				if err != nil {
-					return types.Result{
-						Succeeded: false, Failed: true,
-						Changed: false, Notes: []fmt.Stringer{
-							types.SimpleNote(fmt.Sprintf("failed to cache source %s", srcFile)),
-						},
-					}, err
-				}
-				cacheRes, err := srcFile.Apply(ctx)
-				if err != nil || !cacheRes.Succeeded {
-					return types.Result{
-						Succeeded: false, Failed: true,
-						Changed: false, Notes: []fmt.Stringer{
-							types.SimpleNote(fmt.Sprintf("failed to cache source %s", srcFile)),
-						},
-					}, errors.Join(err, types.ErrCacheFailure)
-				}
-				sourceDest, err = srcFile.(*File).dest()
-			} else if skipVerify, ok := f.params["skip_verify"].(bool); ok && skipVerify {
-				srcFile, err := f.Parse(f.id+"-source", "cached", map[string]interface{}{
-					"source":      src,
-					"skip_verify": skipVerify, "name": name + "-source",
-				})
-				if err != nil {
-					return types.Result{
-						Succeeded: false, Failed: true,
-						Changed: false, Notes: []fmt.Stringer{
-							types.SimpleNote(fmt.Sprintf("failed to cache source %s", srcFile)),
-						},
-					}, err
-				}
-				cacheRes, err := srcFile.Apply(ctx)
-				if err != nil || !cacheRes.Succeeded {
-					return types.Result{
-						Succeeded: false, Failed: true,
-						Changed: false, Notes: []fmt.Stringer{
-							types.SimpleNote(fmt.Sprintf("failed to cache source %s", srcFile)),
-						},
-					}, errors.Join(err, types.ErrCacheFailure)
-				}
-				sourceDest, err = srcFile.(*File).dest()
-			} else {
-				return types.Result{
-					Succeeded: false, Failed: true,
-				}, types.ErrMissingHash
-			}
-		}
-		_, err := os.Stat(sourceDest)
-		if err != nil {
-			return types.Result{
-				Succeeded: false, Failed: true,
-				Changed: false, Notes: []fmt.Stringer{
-					types.SimpleNote(fmt.Sprintf("failed to open cached source %s", sourceDest)),
-				},
+	skipVerify, _ := f.params["skip_verify"].(bool)
+	notCached := false
+	if src, ok := f.params["source"].(string); ok && src != "" {
+		srcHash, _ := f.params["source_hash"].(string)
+		sourceDest, cacheNotes, err := f.cacheSource(ctx, name+"-source", src, srcHash, skipVerify, test)
+		notes = append(notes, cacheNotes...)
+		if errors.Is(err, ErrNotCached) {
+			notCached = true
+		} else if err != nil {
+			return cachedPaths, types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
 			}, err
 		}
+		cachedPaths = append(cachedPaths, sourceDest)
 	}
-	{
-		var srces []interface{}
-		var srcHashes []interface{}
-		var ok bool
-		skip := false
-		if srces, ok = f.params["sources"].([]interface{}); ok && len(srces) > 0 {
-			if srcHashes, ok = f.params["source_hashes"].([]interface{}); ok {
-				if skipVerify, ok := f.params["skip_verify"].(bool); ok && skipVerify {
-					skip = true
-				} else if len(srces) != len(srcHashes) {
-					return types.Result{
-						Succeeded: false, Failed: true,
-						Changed: false, Notes: []fmt.Stringer{
-							types.SimpleNote("sources and source_hashes must be the same length"),
-						},
-					}, types.ErrMissingHash
-				}
-			}
-		}
-		for i, src := range srces {
-			var file types.RecipeCooker
-			var err error
-			if srcStr, ok := src.(string); ok && srcStr != "" {
-				cachedName := fmt.Sprintf("%s-source-%d", f.id, i)
-				if !skip {
-					if srcHash, ok := srcHashes[i].(string); ok && srcHash != "" {
-						cachedName = srcHash
-					} else {
-						return types.Result{
-							Succeeded: false, Failed: true,
-							Changed: false, Notes: []fmt.Stringer{
-								types.SimpleNote(fmt.Sprintf("missing source_hash for source %s", srcStr)),
-							},
-						}, types.ErrMissingHash
-					}
-				}
-				file, err = f.Parse(fmt.Sprintf("%s-source-%d", f.id, i), "cached", map[string]interface{}{
-					"source":      srcStr,
-					"skip_verify": skip, "name": cachedName,
-				})
-				if err != nil {
-					return types.Result{
-						Succeeded: false, Failed: true,
-						Changed: false, Notes: []fmt.Stringer{
-							types.SimpleNote(fmt.Sprintf("failed to cache source %s", srcStr)),
-						},
-					}, err
-				}
-			} else {
-				return types.Result{
-					Succeeded: false, Failed: true,
-					Changed: false, Notes: []fmt.Stringer{
-						types.SimpleNote(fmt.Sprintf("invalid source %v", src)),
-					},
-				}, types.ErrMissingSource
-			}
-			cacheRes, err := file.Apply(ctx)
-			if err != nil || !cacheRes.Succeeded {
-				return types.Result{
-					Succeeded: false, Failed: true,
-					Changed: false, Notes: []fmt.Stringer{
-						types.SimpleNote(fmt.Sprintf("failed to cache source %s", src)),
-					},
-				}, errors.Join(err, types.ErrCacheFailure)
-			}
-			sourceDest, err := file.(*File).dest()
-			if err != nil {
-				_, err := os.Stat(sourceDest)
-				if err != nil {
-					return types.Result{
-						Succeeded: false, Failed: true,
-						Changed: false, Notes: []fmt.Stringer{
-							types.SimpleNote(fmt.Sprintf("failed to open cached source %s", sourceDest)),
-						},
-					}, err
-				}
-			}
-
+	srces, _ := f.listProp("sources")
+	srcHashes, _ := f.listProp("source_hashes")
+	if len(srces) > 0 && !skipVerify && len(srces) != len(srcHashes) {
+		notes = append(notes, types.SimpleNote("sources and source_hashes must be the same length"))
+		return cachedPaths, types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, types.ErrMissingHash
+	}
+	for i, src := range srces {
+		srcStr, ok := src.(string)
+		if !ok || srcStr == "" {
+			notes = append(notes, types.Snprintf("invalid source %v", src))
+			return cachedPaths, types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, types.ErrMissingSource
 		}
-		sourceDest := ""
-		if src, ok := f.params["source"].(string); ok && src != "" {
-			if srcHash, ok := f.params["source_hash"].(string); ok && srcHash != "" {
-				srcFile, err := f.Parse(f.id+"-source", "cached", map[string]interface{}{
-					"source": src, "hash": srcHash,
-					"name": name + "-source",
-				})
-				if err != nil {
-					return types.Result{
-						Succeeded: false, Failed: true,
-						Changed: false, Notes: []fmt.Stringer{
-							types.SimpleNote(fmt.Sprintf("failed to cache source %s", srcFile)),
-						},
-					}, err
-				}
-				cacheRes, err := srcFile.Apply(ctx)
-				if err != nil || !cacheRes.Succeeded {
-					return types.Result{
-						Succeeded: false, Failed: true,
-						Changed: false, Notes: []fmt.Stringer{
-							types.SimpleNote(fmt.Sprintf("failed to cache source %s", srcFile)),
-						},
-					}, errors.Join(err, types.ErrCacheFailure)
-				}
-				sourceDest, err = srcFile.(*File).dest()
-			} else if skipVerify, ok := f.params["skip_verify"].(bool); ok && skipVerify {
-				srcFile, err := f.Parse(f.id+"-source", "cached", map[string]interface{}{
-					"source":      src,
-					"skip_verify": skipVerify, "name": name + "-source",
-				})
-				if err != nil {
-					return types.Result{
-						Succeeded: false, Failed: true,
-						Changed: false, Notes: []fmt.Stringer{
-							types.SimpleNote(fmt.Sprintf("failed to cache source %s", srcFile)),
-						},
-					}, err
-				}
-				cacheRes, err := srcFile.Apply(ctx)
-				if err != nil || !cacheRes.Succeeded {
-					return types.Result{
-						Succeeded: false, Failed: true,
-						Changed: false, Notes: []fmt.Stringer{
-							types.SimpleNote(fmt.Sprintf("failed to cache source %s", srcFile)),
-						},
-					}, errors.Join(err, types.ErrCacheFailure)
-				}
-				sourceDest, err = srcFile.(*File).dest()
-			} else {
-				return types.Result{
-					Succeeded: false, Failed: true,
-				}, types.ErrMissingHash
-			}
+		srcHash := ""
+		if i < len(srcHashes) {
+			srcHash, _ = srcHashes[i].(string)
 		}
-		_, err := os.Stat(sourceDest)
-		if err != nil {
-			return types.Result{
-				Succeeded: false, Failed: true,
-				Changed: false, Notes: []fmt.Stringer{
-					types.SimpleNote(fmt.Sprintf("failed to open cached source %s", sourceDest)),
-				},
+		sourceDest, cacheNotes, err := f.cacheSource(ctx, fmt.Sprintf("%s-source-%d", name, i), srcStr, srcHash, skipVerify, test)
+		notes = append(notes, cacheNotes...)
+		if errors.Is(err, ErrNotCached) {
+			notCached = true
+			continue
+		} else if err != nil {
+			return cachedPaths, types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
 			}, err
 		}
+		cachedPaths = append(cachedPaths, sourceDest)
 	}
-	return types.Result{
-		Succeeded: true, Failed: false,
+	if notCached {
+		return cachedPaths, types.Result{
+			Succeeded: true, Failed: false, Notes: notes,
+		}, ErrNotCached
+	}
+	return cachedPaths, types.Result{
+		Succeeded: true, Failed: false, Notes: notes,
 	}, nil
 }
 
@@ -370,11 +354,7 @@ func stringSliceIsSubset(a, b []string) (bool, []string) {
 			b = b[1:]
 		case a[0] < b[0]:
 			missing = append(missing, a[0])
-			if len(a) == 1 {
-				return len(missing) == 0, missing
-			}
 			a = a[1:]
-			b = b[1:]
 		case a[0] > b[0]:
 			b = b[1:]
 		}
@@ -388,14 +368,28 @@ func (f File) Apply(ctx context.Context) (types.Result, error) {
 		return f.absent(ctx, false)
 	case "append":
 		return f.append(ctx, false)
+	case "blockreplace":
+		return f.blockreplace(ctx, false)
 	case "directory":
 		return f.directory(ctx, false)
 	case "exists":
 		return f.exists(ctx, false)
+	case "hardlink":
+		return f.hardlink(ctx, false)
 	case "missing":
 		return f.missing(ctx, false)
+	case "permissions":
+		return f.permissions(ctx, false)
 	case "prepend":
 		return f.prepend(ctx, false)
+	case "recurse":
+		return f.recurse(ctx, false)
+	case "rename":
+		return f.rename(ctx, false)
+	case "replace":
+		return f.replace(ctx, false)
+	case "serialize":
+		return f.serialize(ctx, false)
 	case "touch":
 		return f.touch(ctx, false)
 	case "cached":
@@ -405,6 +399,12 @@ func (f File) Apply(ctx context.Context) (types.Result, error) {
 		return res, err
 	case "content":
 		return f.content(ctx, false)
+	case "copy":
+		return f.copy(ctx, false)
+	case "keyvalue":
+		return f.keyvalue(ctx, false)
+	case "line":
+		return f.line(ctx, false)
 	case "managed":
 		return f.managed(ctx, false)
 	case "symlink":
@@ -419,13 +419,13 @@ func (f File) Apply(ctx context.Context) (types.Result, error) {
 	}
 }
 
-func (f File) PropertiesForMethod(method string) (map[string]string, error) {
-	switch f.method {
+func (f File) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	switch method {
 	// TODO use ingredients.MethodPropsSet for remaining methods
 	case "absent":
 		return ingredients.MethodPropsSet{
 			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path of the file to delete"},
-		}.ToMap(), nil
+		}, nil
 	case "append":
 		return ingredients.MethodPropsSet{
 			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path of the file to append to"},
@@ -436,111 +436,285 @@ func (f File) PropertiesForMethod(method string) (map[string]string, error) {
 			ingredients.MethodProps{Key: "sources", Type: "[]string", IsReq: false, Description: "source, but in list format"},
 			ingredients.MethodProps{Key: "template", Type: "bool", IsReq: false, Description: "whether to render the file as a template before appending (experimental)"},
 			ingredients.MethodProps{Key: "text", Type: "[]string", IsReq: false, Description: "the text to append to the file"},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "show_changes", Type: "bool", IsReq: false, Description: "report the changes made to the file (default true)"},
+		}, nil
+	case "blockreplace":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path of the file to edit"},
+			ingredients.MethodProps{Key: "marker_start", Type: "string", IsReq: true, Description: "the line starting the block"},
+			ingredients.MethodProps{Key: "marker_end", Type: "string", IsReq: true, Description: "the line ending the block"},
+			ingredients.MethodProps{Key: "text", Type: "[]string", IsReq: false, Description: "the text to put between the markers"},
+			ingredients.MethodProps{Key: "source", Type: "string", IsReq: false, Description: "put the contents of a file sourced from this path/URL between the markers"},
+			ingredients.MethodProps{Key: "source_hash", Type: "string", IsReq: false, Description: "hash to verify the file specified by source"},
+			ingredients.MethodProps{Key: "sources", Type: "[]string", IsReq: false, Description: "source, but in list format"},
+			ingredients.MethodProps{Key: "source_hashes", Type: "[]string", IsReq: false, Description: "corresponding hashes for sources"},
+			ingredients.MethodProps{Key: "template", Type: "bool", IsReq: false, Description: "whether to render the sources as templates (experimental)"},
+			ingredients.MethodProps{Key: "append_if_not_found", Type: "bool", IsReq: false, Description: "add the block to the end of the file if the markers are missing"},
+			ingredients.MethodProps{Key: "prepend_if_not_found", Type: "bool", IsReq: false, Description: "add the block to the start of the file if the markers are missing"},
+			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false, Description: "the user that should own the file"},
+			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false, Description: "the group that should own the file"},
+			ingredients.MethodProps{Key: "mode", Type: "mode", IsReq: false, Description: "the octal mode of the file"},
+			ingredients.MethodProps{Key: "backup", Type: "string", IsReq: false, Description: "copy the previous contents aside; true for name.bak or a backup path"},
+			ingredients.MethodProps{Key: "show_changes", Type: "bool", IsReq: false, Description: "report the changes made to the file (default true)"},
+		}, nil
 	case "cached":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-			ingredients.MethodProps{Key: "hash", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "skip_verify", Type: "bool", IsReq: false},
-			ingredients.MethodProps{Key: "source", Type: "string", IsReq: true},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name of the cached copy"},
+			ingredients.MethodProps{Key: "hash", Type: "string", IsReq: false, Description: "the hash to verify the source against, such as sha256=..."},
+			ingredients.MethodProps{Key: "skip_verify", Type: "bool", IsReq: false, Description: "do not verify the source against a hash"},
+			ingredients.MethodProps{Key: "hashType", Type: "string", IsReq: false, Description: "the algorithm of hash, if it cannot be told from the hash"},
+			ingredients.MethodProps{Key: "source", Type: "string", IsReq: true, Description: "the path or URL to cache"},
+			ingredients.MethodProps{Key: "headers", Type: "map", IsReq: false, Description: "headers to send with HTTP requests"},
+			ingredients.MethodProps{Key: "username", Type: "string", IsReq: false, Description: "the username for HTTP basic auth"},
+			ingredients.MethodProps{Key: "password", Type: "string", IsReq: false, Description: "the password for HTTP basic auth"},
+			ingredients.MethodProps{Key: "bearer_token", Type: "string", IsReq: false, Description: "a bearer token to send with HTTP requests"},
+			ingredients.MethodProps{Key: "ca_bundle", Type: "string", IsReq: false, Description: "a PEM file of CAs to trust for HTTPS"},
+			ingredients.MethodProps{Key: "proxy", Type: "string", IsReq: false, Description: "the proxy URL to use"},
+			ingredients.MethodProps{Key: "retries", Type: "int", IsReq: false, Description: "how many times to retry a failed download"},
+			ingredients.MethodProps{Key: "retry_delay", Type: "duration", IsReq: false, Description: "how long to wait between retries"},
+			ingredients.MethodProps{Key: "timeout", Type: "duration", IsReq: false, Description: "how long to wait for a download"},
+		}, nil
 	case "contains":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-			ingredients.MethodProps{Key: "source", Type: "string", IsReq: true},
-			ingredients.MethodProps{Key: "source_hash", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "source_hashes", Type: "[]string", IsReq: false},
-			ingredients.MethodProps{Key: "sources", Type: "[]string", IsReq: false},
-			ingredients.MethodProps{Key: "template", Type: "bool", IsReq: false},
-			ingredients.MethodProps{Key: "text", Type: "[]string", IsReq: false},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path of the file to check"},
+			ingredients.MethodProps{Key: "source", Type: "string", IsReq: true, Description: "a file whose lines must appear, sourced from this path/URL"},
+			ingredients.MethodProps{Key: "source_hash", Type: "string", IsReq: false, Description: "hash to verify the file specified by source"},
+			ingredients.MethodProps{Key: "source_hashes", Type: "[]string", IsReq: false, Description: "corresponding hashes for sources"},
+			ingredients.MethodProps{Key: "sources", Type: "[]string", IsReq: false, Description: "source, but in list format"},
+			ingredients.MethodProps{Key: "skip_verify", Type: "bool", IsReq: false, Description: "do not verify sources against a hash"},
+			ingredients.MethodProps{Key: "template", Type: "bool", IsReq: false, Description: "whether to render the source as a template first (experimental)"},
+			ingredients.MethodProps{Key: "text", Type: "[]string", IsReq: false, Description: "the text the file must contain"},
+		}, nil
 	case "content":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-			ingredients.MethodProps{Key: "text", Type: "[]string", IsReq: false},
-			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false},
-			ingredients.MethodProps{Key: "source", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "source_hash", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "template", Type: "bool", IsReq: false},
-			ingredients.MethodProps{Key: "sources", Type: "[]string", IsReq: false},
-			ingredients.MethodProps{Key: "source_hashes", Type: "[]string", IsReq: false},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path of the file to write"},
+			ingredients.MethodProps{Key: "text", Type: "[]string", IsReq: false, Description: "the text to write to the file"},
+			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false, Description: "create parent directories if they do not exist"},
+			ingredients.MethodProps{Key: "source", Type: "string", IsReq: false, Description: "write the contents of a file sourced from this path/URL"},
+			ingredients.MethodProps{Key: "source_hash", Type: "string", IsReq: false, Description: "hash to verify the file specified by source"},
+			ingredients.MethodProps{Key: "template", Type: "bool", IsReq: false, Description: "whether to render the file as a template before writing (experimental)"},
+			ingredients.MethodProps{Key: "sources", Type: "[]string", IsReq: false, Description: "source, but in list format"},
+			ingredients.MethodProps{Key: "source_hashes", Type: "[]string", IsReq: false, Description: "corresponding hashes for sources"},
+			ingredients.MethodProps{Key: "show_changes", Type: "bool", IsReq: false, Description: "report the changes made to the file (default true)"},
+		}, nil
+	case "copy":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the path to copy to"},
+			ingredients.MethodProps{Key: "source", Type: "string", IsReq: true, Description: "the file on the sprout to copy"},
+			ingredients.MethodProps{Key: "force", Type: "bool", IsReq: false, Description: "replace name if it exists and differs from source"},
+			ingredients.MethodProps{Key: "preserve", Type: "bool", IsReq: false, Description: "keep the owner, mode and modification time of source"},
+			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false, Description: "create parent directories if they do not exist"},
+			ingredients.MethodProps{Key: "dir_mode", Type: "mode", IsReq: false, Description: "the octal mode of created parent directories"},
+			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false, Description: "the user that should own the copy"},
+			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false, Description: "the group that should own the copy"},
+			ingredients.MethodProps{Key: "mode", Type: "mode", IsReq: false, Description: "the octal mode of the copy"},
+		}, nil
 	case "directory":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "recurse", Type: "bool", IsReq: false},
-			ingredients.MethodProps{Key: "dir_mode", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "file_mode", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path of the directory"},
+			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false, Description: "the owner of the directory"},
+			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false, Description: "the group of the directory"},
+			ingredients.MethodProps{Key: "recurse", Type: "bool", IsReq: false, Description: "apply user, group and modes to the directory's contents"},
+			ingredients.MethodProps{Key: "dir_mode", Type: "mode", IsReq: false, Description: "the octal mode of directories"},
+			ingredients.MethodProps{Key: "file_mode", Type: "mode", IsReq: false, Description: "the octal mode of files when recursing"},
+			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false, Description: "create parent directories if they do not exist"},
+			ingredients.MethodProps{Key: "clean", Type: "bool", IsReq: false, Description: "remove everything in the directory that isn't excluded; requires exclude"},
+			ingredients.MethodProps{Key: "exclude", Type: "[]string", IsReq: false, Description: "glob patterns for paths that clean leaves alone"},
+		}, nil
+	case "hardlink":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the path of the link"},
+			ingredients.MethodProps{Key: "target", Type: "string", IsReq: true, Description: "the file to link to"},
+			ingredients.MethodProps{Key: "force", Type: "bool", IsReq: false, Description: "replace a file that is already at name"},
+			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false, Description: "create parent directories if they do not exist"},
+			ingredients.MethodProps{Key: "dir_mode", Type: "mode", IsReq: false, Description: "the octal mode of created parent directories"},
+		}, nil
+	case "keyvalue":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path of the file to edit"},
+			ingredients.MethodProps{Key: "key_values", Type: "map", IsReq: false, Description: "the keys to set and their values"},
+			ingredients.MethodProps{Key: "remove", Type: "[]string", IsReq: false, Description: "keys to remove"},
+			ingredients.MethodProps{Key: "separator", Type: "string", IsReq: false, Description: "the text between key and value (default =); blank splits on whitespace"},
+			ingredients.MethodProps{Key: "uncomment", Type: "string", IsReq: false, Description: "a comment prefix; commented-out lines for missing keys are replaced"},
+			ingredients.MethodProps{Key: "append_if_not_found", Type: "bool", IsReq: false, Description: "add missing keys to the end of the file (default true)"},
+			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false, Description: "create parent directories if they do not exist"},
+			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false, Description: "the user that should own the file"},
+			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false, Description: "the group that should own the file"},
+			ingredients.MethodProps{Key: "mode", Type: "mode", IsReq: false, Description: "the octal mode of the file"},
+			ingredients.MethodProps{Key: "backup", Type: "string", IsReq: false, Description: "copy the previous contents aside; true for name.bak or a backup path"},
+			ingredients.MethodProps{Key: "show_changes", Type: "bool", IsReq: false, Description: "report the changes made to the file (default true)"},
+		}, nil
+	case "line":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path of the file to edit"},
+			ingredients.MethodProps{Key: "mode", Type: "string", IsReq: true, Description: "how to manage the line", Enum: []string{"ensure", "insert", "replace", "delete"}},
+			ingredients.MethodProps{Key: "content", Type: "string", IsReq: false, Description: "the line to manage"},
+			ingredients.MethodProps{Key: "match", Type: "string", IsReq: false, Description: "a regular expression for the lines to replace or delete"},
+			ingredients.MethodProps{Key: "after", Type: "string", IsReq: false, Description: "a regular expression for the line content should follow"},
+			ingredients.MethodProps{Key: "before", Type: "string", IsReq: false, Description: "a regular expression for the line content should precede"},
+			ingredients.MethodProps{Key: "location", Type: "string", IsReq: false, Description: "start or end (default): where to add content without after or before"},
+			ingredients.MethodProps{Key: "indent", Type: "bool", IsReq: false, Description: "copy the indentation of the neighbouring line (default true)"},
+			ingredients.MethodProps{Key: "flags", Type: "[]string", IsReq: false, Description: "IGNORECASE, MULTILINE, DOTALL or UNGREEDY"},
+			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false, Description: "the user that should own the file"},
+			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false, Description: "the group that should own the file"},
+			ingredients.MethodProps{Key: "file_mode", Type: "mode", IsReq: false, Description: "the octal mode of the file"},
+			ingredients.MethodProps{Key: "backup", Type: "string", IsReq: false, Description: "copy the previous contents aside; true for name.bak or a backup path"},
+			ingredients.MethodProps{Key: "show_changes", Type: "bool", IsReq: false, Description: "report the changes made to the file (default true)"},
+		}, nil
 	case "managed":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-			ingredients.MethodProps{Key: "source", Type: "string", IsReq: true},
-			ingredients.MethodProps{Key: "source_hash", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "mode", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "template", Type: "bool", IsReq: false},
-			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false},
-			ingredients.MethodProps{Key: "dir_mode", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "sources", Type: "[]string", IsReq: true},
-			ingredients.MethodProps{Key: "source_hashes", Type: "[]string", IsReq: false},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path of the file to manage"},
+			ingredients.MethodProps{Key: "source", Type: "string", IsReq: true, Description: "the path/URL to source the file contents from"},
+			ingredients.MethodProps{Key: "source_hash", Type: "string", IsReq: false, Description: "hash to verify the file specified by source"},
+			ingredients.MethodProps{Key: "skip_verify", Type: "bool", IsReq: false, Description: "do not verify the source against a hash"},
+			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false, Description: "the user that should own the file"},
+			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false, Description: "the group that should own the file"},
+			ingredients.MethodProps{Key: "mode", Type: "mode", IsReq: false, Description: "the octal mode of the file"},
+			ingredients.MethodProps{Key: "template", Type: "bool", IsReq: false, Description: "render the source with the recipe template engine"},
+			ingredients.MethodProps{Key: "context", Type: "map", IsReq: false, Description: "data made available to the template"},
+			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false, Description: "create parent directories if they do not exist"},
+			ingredients.MethodProps{Key: "dir_mode", Type: "mode", IsReq: false, Description: "the octal mode of created parent directories"},
+			ingredients.MethodProps{Key: "replace", Type: "bool", IsReq: false, Description: "replace the contents of an existing file (default true)"},
+			ingredients.MethodProps{Key: "backup", Type: "string", IsReq: false, Description: "copy the previous contents aside; true for name.bak or a backup path"},
+			ingredients.MethodProps{Key: "show_changes", Type: "bool", IsReq: false, Description: "report the changes made to the file (default true)"},
+			ingredients.MethodProps{Key: "create", Type: "bool", IsReq: false, Description: "create the file if it does not exist (default true)"},
+			ingredients.MethodProps{Key: "follow_symlinks", Type: "bool", IsReq: false, Description: "manage the target of a symlink rather than the link (default true)"},
+		}, nil
 	case "missing":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path which must not exist"},
+		}, nil
+	case "permissions":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the existing path to change"},
+			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false, Description: "the user that should own the path"},
+			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false, Description: "the group that should own the path"},
+			ingredients.MethodProps{Key: "mode", Type: "mode", IsReq: false, Description: "the octal mode of the path"},
+			ingredients.MethodProps{Key: "recurse", Type: "bool", IsReq: false, Description: "also change everything under a directory"},
+			ingredients.MethodProps{Key: "dir_mode", Type: "mode", IsReq: false, Description: "the octal mode of directories, instead of mode"},
+			ingredients.MethodProps{Key: "file_mode", Type: "mode", IsReq: false, Description: "the octal mode of files, instead of mode"},
+		}, nil
 	case "prepend":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-			ingredients.MethodProps{Key: "text", Type: "[]string", IsReq: false},
-			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false},
-			ingredients.MethodProps{Key: "source", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "source_hash", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "template", Type: "bool", IsReq: false},
-			ingredients.MethodProps{Key: "sources", Type: "[]string", IsReq: false},
-			ingredients.MethodProps{Key: "source_hashes", Type: "[]string", IsReq: false},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path of the file to prepend to"},
+			ingredients.MethodProps{Key: "text", Type: "[]string", IsReq: false, Description: "the text to prepend to the file"},
+			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false, Description: "create parent directories if they do not exist"},
+			ingredients.MethodProps{Key: "source", Type: "string", IsReq: false, Description: "prepend lines from a file sourced from this path/URL"},
+			ingredients.MethodProps{Key: "source_hash", Type: "string", IsReq: false, Description: "hash to verify the file specified by source"},
+			ingredients.MethodProps{Key: "template", Type: "bool", IsReq: false, Description: "whether to render the file as a template before prepending (experimental)"},
+			ingredients.MethodProps{Key: "sources", Type: "[]string", IsReq: false, Description: "source, but in list format"},
+			ingredients.MethodProps{Key: "source_hashes", Type: "[]string", IsReq: false, Description: "corresponding hashes for sources"},
+			ingredients.MethodProps{Key: "show_changes", Type: "bool", IsReq: false, Description: "report the changes made to the file (default true)"},
+		}, nil
+	case "recurse":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the directory to mirror the source into"},
+			ingredients.MethodProps{Key: "source", Type: "string", IsReq: true, Description: "the path/URL of the directory to mirror"},
+			ingredients.MethodProps{Key: "clean", Type: "bool", IsReq: false, Description: "remove files that are not in the source"},
+			ingredients.MethodProps{Key: "include", Type: "[]string", IsReq: false, Description: "glob patterns for the files to copy (default all)"},
+			ingredients.MethodProps{Key: "exclude", Type: "[]string", IsReq: false, Description: "glob patterns for files to skip, which clean leaves alone"},
+			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false, Description: "the user that should own the files and directories"},
+			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false, Description: "the group that should own the files and directories"},
+			ingredients.MethodProps{Key: "dir_mode", Type: "mode", IsReq: false, Description: "the octal mode of the directories"},
+			ingredients.MethodProps{Key: "file_mode", Type: "mode", IsReq: false, Description: "the octal mode of the files"},
+			ingredients.MethodProps{Key: "template", Type: "bool", IsReq: false, Description: "render each file with the recipe template engine"},
+			ingredients.MethodProps{Key: "context", Type: "map", IsReq: false, Description: "data made available to the templates"},
+			ingredients.MethodProps{Key: "backup", Type: "string", IsReq: false, Description: "copy the previous contents aside; true for name.bak or a backup path"},
+			ingredients.MethodProps{Key: "show_changes", Type: "bool", IsReq: false, Description: "report the changes made to each file (default true)"},
+		}, nil
+	case "rename":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the new path"},
+			ingredients.MethodProps{Key: "source", Type: "string", IsReq: true, Description: "the path to move"},
+			ingredients.MethodProps{Key: "force", Type: "bool", IsReq: false, Description: "replace anything already at name"},
+			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false, Description: "create parent directories if they do not exist"},
+			ingredients.MethodProps{Key: "dir_mode", Type: "mode", IsReq: false, Description: "the octal mode of created parent directories"},
+		}, nil
+	case "replace":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path of the file to edit"},
+			ingredients.MethodProps{Key: "pattern", Type: "string", IsReq: true, Description: "the regular expression to replace"},
+			ingredients.MethodProps{Key: "repl", Type: "string", IsReq: true, Description: "the replacement, which may refer to groups as $1 or ${name}"},
+			ingredients.MethodProps{Key: "count", Type: "int", IsReq: false, Description: "the most matches to replace (default 0, all of them)"},
+			ingredients.MethodProps{Key: "flags", Type: "[]string", IsReq: false, Description: "IGNORECASE, MULTILINE, DOTALL or UNGREEDY"},
+			ingredients.MethodProps{Key: "append_if_not_found", Type: "bool", IsReq: false, Description: "add not_found_content to the end of the file if nothing matches"},
+			ingredients.MethodProps{Key: "prepend_if_not_found", Type: "bool", IsReq: false, Description: "add not_found_content to the start of the file if nothing matches"},
+			ingredients.MethodProps{Key: "not_found_content", Type: "string", IsReq: false, Description: "the text to add if nothing matches (default repl)"},
+			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false, Description: "the user that should own the file"},
+			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false, Description: "the group that should own the file"},
+			ingredients.MethodProps{Key: "mode", Type: "mode", IsReq: false, Description: "the octal mode of the file"},
+			ingredients.MethodProps{Key: "backup", Type: "string", IsReq: false, Description: "copy the previous contents aside; true for name.bak or a backup path"},
+			ingredients.MethodProps{Key: "show_changes", Type: "bool", IsReq: false, Description: "report the changes made to the file (default true)"},
+		}, nil
 	case "exists":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path which must exist"},
+		}, nil
+	case "serialize":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path of the file to edit"},
+			ingredients.MethodProps{Key: "format", Type: "string", IsReq: false, Description: "json, yaml, toml or ini; guessed from the extension if unset"},
+			ingredients.MethodProps{Key: "dataset", Type: "map", IsReq: false, Description: "the data to write"},
+			ingredients.MethodProps{Key: "merge", Type: "bool", IsReq: false, Description: "merge dataset into the existing data instead of replacing it"},
+			ingredients.MethodProps{Key: "remove", Type: "[]string", IsReq: false, Description: "dotted paths of keys to remove, such as server.port"},
+			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false, Description: "create parent directories if they do not exist"},
+			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false, Description: "the user that should own the file"},
+			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false, Description: "the group that should own the file"},
+			ingredients.MethodProps{Key: "mode", Type: "mode", IsReq: false, Description: "the octal mode of the file"},
+			ingredients.MethodProps{Key: "backup", Type: "string", IsReq: false, Description: "copy the previous contents aside; true for name.bak or a backup path"},
+			ingredients.MethodProps{Key: "show_changes", Type: "bool", IsReq: false, Description: "report the changes made to the file (default true)"},
+		}, nil
 	case "symlink":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-			ingredients.MethodProps{Key: "target", Type: "string", IsReq: true},
-			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false},
-			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "mode", Type: "string", IsReq: false},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path of the symlink"},
+			ingredients.MethodProps{Key: "target", Type: "string", IsReq: true, Description: "the path the symlink points to"},
+			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false, Description: "create parent directories if they do not exist"},
+			ingredients.MethodProps{Key: "user", Type: "string", IsReq: false, Description: "the owner of the symlink"},
+			ingredients.MethodProps{Key: "group", Type: "string", IsReq: false, Description: "the group of the symlink"},
+			ingredients.MethodProps{Key: "mode", Type: "mode", IsReq: false, Description: "the octal mode of the symlink"},
+		}, nil
 	case "touch":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-			ingredients.MethodProps{Key: "atime", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "mtime", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name/path of the file to touch"},
+			ingredients.MethodProps{Key: "atime", Type: "string", IsReq: false, Description: "the access time to set, in RFC 3339 format"},
+			ingredients.MethodProps{Key: "mtime", Type: "string", IsReq: false, Description: "the modification time to set, in RFC 3339 format"},
+			ingredients.MethodProps{Key: "makedirs", Type: "bool", IsReq: false, Description: "create parent directories if they do not exist"},
+		}, nil
 	default:
 		return nil, errors.Join(ErrFileMethodUndefined, fmt.Errorf("method %s undefined", f.method))
 	}
 }
 
+func (f File) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := f.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
 func (f File) Methods() (string, []string) {
 	return "file", []string{
 		"absent",
 		"append",
+		"blockreplace",
 		"cached",
 		"contains",
 		"content",
+		"copy",
 		"directory",
+		"hardlink",
+		"keyvalue",
+		"line",
 		"managed",
 		"missing",
+		"permissions",
 		"prepend",
+		"recurse",
+		"rename",
+		"replace",
 		"exists",
+		"serialize",
 		"symlink",
 		"touch",
 	}
@@ -1,7 +1,6 @@
 package file
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
@@ -11,12 +10,11 @@ import (
 	"github.com/gogrlx/grlx/types"
 )
 
+// append adds any lines of text, source or sources which are not already
+// in the file to the end of it, creating the file if needed.
 func (f File) append(ctx context.Context, test bool) (types.Result, error) {
 	// TODO
-	// "name": "string", "text": "[]string", "makedirs": "bool",
-	// "source": "string", "source_hash": "string",
-	// "template": "bool", "sources": "[]string",
-	// "source_hashes": "[]string",
+	// "template": "bool",
 	var notes []fmt.Stringer
 	name, ok := f.params["name"].(string)
 	if !ok {
@@ -38,54 +36,62 @@ func (f File) append(ctx context.Context, test bool) (types.Result, error) {
 			Changed: res.Changed, Notes: notes,
 		}, err
 	}
-	if os.IsNotExist(err) {
-		f, err := os.Create(name)
+	var current []byte
+	exists := true
+	switch {
+	case os.IsNotExist(err):
+		exists = false
+		makedirs, _ := f.params["makedirs"].(bool)
+		dirNotes, err := f.ensureParent(name, makedirs, test)
+		notes = append(notes, dirNotes...)
 		if err != nil {
 			return types.Result{
 				Succeeded: false, Failed: true, Notes: notes,
 			}, err
 		}
-		defer f.Close()
-		_, writeErr := missing.WriteTo(f)
-		if writeErr != nil {
+	case errors.Is(err, types.ErrMissingContent):
+		current, err = os.ReadFile(name)
+		if err != nil {
 			return types.Result{
 				Succeeded: false, Failed: true, Notes: notes,
 			}, err
 		}
-		notes = append(notes, types.Snprintf("appended %v", name))
+	default:
 		return types.Result{
-			Succeeded: true, Failed: false,
-			Changed: true, Notes: notes,
-		}, nil
+			Succeeded: false, Failed: true,
+			Changed: false, Notes: notes,
+		}, err
 	}
-	if errors.Is(err, types.ErrMissingContent) {
-		f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0o644)
-		// TODO: Bug consider muxing errors to make this more descriptive of the issue that occurred
-		if err != nil {
-			return types.Result{
-				Succeeded: false, Failed: true, Notes: notes,
-			}, err
-		}
-		defer f.Close()
-		scanner := bufio.NewScanner(&missing)
-		line := ""
-		for scanner.Scan() {
-			line = scanner.Text()
-			_, err := f.WriteString(line)
-			if err != nil {
-				return types.Result{
-					Succeeded: false, Failed: true, Notes: notes,
-				}, err
-			}
-		}
-		notes = append(notes, types.Snprintf("appended %v", name))
+	addition := missing.Bytes()
+	if len(current) > 0 && current[len(current)-1] != '\n' {
+		addition = append([]byte{'\n'}, addition...)
+	}
+	desired := append(append([]byte{}, current...), addition...)
+	diff := f.diffNotes(name, current, desired, exists)
+	if test {
+		notes = append(notes, diff...)
+		notes = append(notes, types.Snprintf("would append %v", name))
 		return types.Result{
 			Succeeded: true, Failed: false,
 			Changed: true, Notes: notes,
 		}, nil
 	}
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	defer file.Close()
+	if _, err = file.Write(addition); err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	notes = append(notes, diff...)
+	notes = append(notes, types.Snprintf("appended %v", name))
 	return types.Result{
-		Succeeded: false, Failed: true,
-		Changed: false, Notes: notes,
-	}, err
+		Succeeded: true, Failed: false,
+		Changed: true, Notes: notes,
+	}, nil
 }
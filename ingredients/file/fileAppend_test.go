@@ -106,19 +106,53 @@ func TestAppend(t *testing.T) {
 			expected: types.Result{
 				Succeeded: true,
 				Failed:    false,
-				Changed:   false,
-				Notes:     []fmt.Stringer{types.Snprintf("file %s does not contain all specified content", fileWithoutContent), types.Snprintf("appended %s", fileWithoutContent)},
+				Changed:   true,
+				Notes: []fmt.Stringer{
+					types.Snprintf("file %s does not contain all specified content", fileWithoutContent),
+					types.Snprintf("--- %s\n+++ %s\n@@ -0,0 +1 @@\n+test", fileWithoutContent, fileWithoutContent),
+					types.Snprintf("appended %s", fileWithoutContent),
+				},
 			},
 			error: nil,
 		},
 		{
-			name:   "AppendFileWithoutContent",
+			name:   "AppendFileAlreadyAppended",
 			params: map[string]interface{}{"name": fileWithoutContent, "text": "test"},
 			expected: types.Result{
 				Succeeded: true,
 				Failed:    false,
 				Changed:   false,
-				Notes:     []fmt.Stringer{types.Snprintf("file %s does not contain all specified content", fileWithoutContent), types.Snprintf("appended %s", fileWithoutContent)},
+				Notes:     []fmt.Stringer{},
+			},
+			error: nil,
+		},
+		{
+			name:   "AppendFileTest",
+			params: map[string]interface{}{"name": fileWithContent, "text": []interface{}{"first", "second"}},
+			expected: types.Result{
+				Succeeded: true,
+				Failed:    false,
+				Changed:   true,
+				Notes: []fmt.Stringer{
+					types.Snprintf("file %s does not contain all specified content", fileWithContent),
+					types.Snprintf("--- %s\n+++ %s\n@@ -1 +1,3 @@\n-test\n\\ No newline at end of file\n+test\n+first\n+second", fileWithContent, fileWithContent),
+					types.Snprintf("would append %s", fileWithContent),
+				},
+			},
+			error: nil,
+			test:  true,
+		},
+		{
+			name:   "AppendFileNoShowChanges",
+			params: map[string]interface{}{"name": fileWithContent, "text": "first", "show_changes": false},
+			expected: types.Result{
+				Succeeded: true,
+				Failed:    false,
+				Changed:   true,
+				Notes: []fmt.Stringer{
+					types.Snprintf("file %s does not contain all specified content", fileWithContent),
+					types.Snprintf("appended %s", fileWithContent),
+				},
 			},
 			error: nil,
 		},
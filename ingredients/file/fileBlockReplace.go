@@ -0,0 +1,84 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+var ErrMarkerNotFound = errors.New("block markers not found")
+
+// blockreplace manages the lines between marker_start and marker_end,
+// replacing them with text and the contents of source and sources. The
+// marker lines are found by substring, so they may carry a comment prefix,
+// and are kept as they are.
+//
+// If the markers aren't in the file, append_if_not_found or
+// prepend_if_not_found add the whole block; otherwise this fails.
+func (f File) blockreplace(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	name, current, exists, err := f.readTarget()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if !exists {
+		notes = append(notes, types.Snprintf("%s does not exist", name))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, types.ErrFileNotFound
+	}
+	start, _ := f.params["marker_start"].(string)
+	end, _ := f.params["marker_end"].(string)
+	start, end = strings.TrimSpace(start), strings.TrimSpace(end)
+	if start == "" || end == "" {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, fmt.Errorf("marker_start and marker_end are required")
+	}
+	block, cacheNotes, err := f.textContent(ctx, test, name)
+	notes = append(notes, cacheNotes...)
+	if test && errors.Is(err, ErrNotCached) {
+		return uncachedResult(name, true, notes), nil
+	}
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+
+	lines := splitContentLines(string(current))
+	blockLines := splitContentLines(string(block))
+	first, last := -1, -1
+	for i, l := range lines {
+		if first < 0 && strings.Contains(l, start) {
+			first = i
+		} else if first >= 0 && strings.Contains(l, end) {
+			last = i
+			break
+		}
+	}
+	var edited []string
+	if first >= 0 && last >= 0 {
+		edited = append(edited, lines[:first+1]...)
+		edited = append(edited, blockLines...)
+		edited = append(edited, lines[last:]...)
+	} else {
+		appendMissing, _ := f.params["append_if_not_found"].(bool)
+		prependMissing, _ := f.params["prepend_if_not_found"].(bool)
+		whole := append(append([]string{start}, blockLines...), end)
+		switch {
+		case prependMissing:
+			edited = append(whole, lines...)
+		case appendMissing:
+			edited = append(lines, whole...)
+		default:
+			notes = append(notes, types.Snprintf("markers %s and %s were not found in %s", start, end, name))
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, ErrMarkerNotFound
+		}
+	}
+
+	desired := []byte(strings.Join(edited, "\n") + "\n")
+	if strings.Join(edited, "\n") == strings.Join(lines, "\n") {
+		desired = current
+	}
+	res, err := f.writeEdited(name, current, desired, exists, test)
+	res.Notes = append(notes, res.Notes...)
+	return res, err
+}
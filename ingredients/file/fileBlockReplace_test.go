@@ -0,0 +1,114 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlockReplace(t *testing.T) {
+	start, end := "# BEGIN grlx", "# END grlx"
+	tests := []struct {
+		name     string
+		existing string
+		params   map[string]interface{}
+		test     bool
+		expected string
+		changed  bool
+		error    error
+	}{
+		{
+			name:     "Replace",
+			existing: "127.0.0.1 localhost\n# BEGIN grlx managed\n10.0.0.1 old\n# END grlx managed\n::1 localhost\n",
+			params:   map[string]interface{}{"text": []interface{}{"10.0.0.2 db", "10.0.0.3 web"}},
+			expected: "127.0.0.1 localhost\n# BEGIN grlx managed\n10.0.0.2 db\n10.0.0.3 web\n# END grlx managed\n::1 localhost\n",
+			changed:  true,
+		},
+		{
+			name:     "Unchanged",
+			existing: "# BEGIN grlx\nx\n# END grlx\n",
+			params:   map[string]interface{}{"text": "x"},
+			expected: "# BEGIN grlx\nx\n# END grlx\n",
+		},
+		{
+			name:     "Empty",
+			existing: "a\n# BEGIN grlx\nx\n# END grlx\n",
+			params:   map[string]interface{}{},
+			expected: "a\n# BEGIN grlx\n# END grlx\n",
+			changed:  true,
+		},
+		{
+			name:     "Append",
+			existing: "a\n",
+			params:   map[string]interface{}{"text": "x", "append_if_not_found": true},
+			expected: "a\n# BEGIN grlx\nx\n# END grlx\n",
+			changed:  true,
+		},
+		{
+			name:     "Prepend",
+			existing: "a\n",
+			params:   map[string]interface{}{"text": "x", "prepend_if_not_found": true},
+			expected: "# BEGIN grlx\nx\n# END grlx\na\n",
+			changed:  true,
+		},
+		{
+			name:     "Test",
+			existing: "# BEGIN grlx\n# END grlx\n",
+			params:   map[string]interface{}{"text": "x"},
+			test:     true,
+			expected: "# BEGIN grlx\n# END grlx\n",
+			changed:  true,
+		},
+		{
+			name:     "NotFound",
+			existing: "a\n# BEGIN grlx\n",
+			params:   map[string]interface{}{"text": "x"},
+			error:    ErrMarkerNotFound,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "hosts")
+			if err := os.WriteFile(name, []byte(test.existing), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			test.params["name"] = name
+			test.params["marker_start"] = start
+			test.params["marker_end"] = end
+			f := File{id: test.name, method: "blockreplace", params: test.params}
+			apply := f.Apply
+			if test.test {
+				apply = f.Test
+			}
+			res, err := apply(context.Background())
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if res.Changed != test.changed {
+				t.Errorf("expected changed to be %v, got %v: %v", test.changed, res.Changed, res.Notes)
+			}
+			content, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != test.expected {
+				t.Errorf("expected\n%s\ngot\n%s", test.expected, content)
+			}
+			if test.test {
+				return
+			}
+			res, err = f.Apply(context.Background())
+			if err != nil || res.Changed {
+				t.Errorf("expected a second run to change nothing, got %v %v", err, res.Notes)
+			}
+		})
+	}
+}
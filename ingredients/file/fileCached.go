@@ -2,10 +2,16 @@ package file
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/ingredients/file/hashers"
 	"github.com/gogrlx/grlx/types"
 )
 
@@ -25,6 +31,23 @@ func (f File) cached(ctx context.Context, test bool) (types.Result, error) {
 			Succeeded: false, Failed: true, Notes: notes,
 		}, types.ErrMissingHash
 	}
+	if !skipVerify {
+		resolved, err := f.resolveHash(ctx, source, hash)
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+		if resolved != hash {
+			params := make(map[string]interface{}, len(f.params))
+			for k, v := range f.params {
+				params[k] = v
+			}
+			params["hash"] = resolved
+			f.params = params
+			hash = resolved
+		}
+	}
 	cacheDest, err := f.dest()
 	if err != nil {
 		return types.Result{
@@ -38,34 +61,38 @@ func (f File) cached(ctx context.Context, test bool) (types.Result, error) {
 		}, err
 	}
 	if skipVerify {
-		_, statErr := os.Stat(cacheDest)
-		if statErr == nil {
-			notes = append(notes, types.Snprintf("%s already exists and skipVerify is true", cacheDest))
+		// an unverified copy can't be checked against its source, so it is
+		// fetched again on every run
+		previous := fileDigest(cacheDest)
+		if test {
+			if previous == "" {
+				notes = append(notes, types.Snprintf("%s would be cached", cacheDest))
+			} else {
+				notes = append(notes, types.Snprintf("%s would be fetched again", cacheDest))
+			}
 			return types.Result{
 				Succeeded: true, Failed: false,
-				Changed: false, Notes: notes,
+				Changed: previous == "", Notes: notes,
 			}, nil
-		} else {
-			if test {
-				notes = append(notes, types.Snprintf("%s would be cached", cacheDest))
-				return types.Result{
-					Succeeded: true, Failed: false,
-					Changed: true, Notes: notes,
-				}, nil
-			}
-			err = fp.Download(ctx)
-			if err != nil {
-				return types.Result{
-					Succeeded: false, Failed: true,
-				}, err
-			}
-			notes = append(notes, types.Snprintf("%s has been cached", cacheDest))
+		}
+		err = fp.Download(ctx)
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true,
+			}, err
+		}
+		if fileDigest(cacheDest) == previous {
+			notes = append(notes, types.Snprintf("%s is up to date", cacheDest))
 			return types.Result{
 				Succeeded: true, Failed: false,
-				Changed: true, Notes: notes,
+				Changed: false, Notes: notes,
 			}, nil
-
 		}
+		notes = append(notes, types.Snprintf("%s has been cached", cacheDest))
+		return types.Result{
+			Succeeded: true, Failed: false,
+			Changed: true, Notes: notes,
+		}, nil
 	}
 	valid, errVal := fp.Verify(ctx)
 	if errVal != nil && !errors.Is(errVal, types.ErrFileNotFound) {
@@ -87,15 +114,66 @@ func (f File) cached(ctx context.Context, test bool) (types.Result, error) {
 				Succeeded: false, Failed: true,
 			}, err
 		}
+		// not every provider checks what it fetched
+		valid, err = fp.Verify(ctx)
+		if err == nil && !valid {
+			err = errors.Join(types.ErrHashMismatch, fmt.Errorf("%s does not match hash %s", source, hash))
+		}
+		if err != nil {
+			notes = append(notes, types.Snprintf("failed to verify %s", cacheDest))
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
 		notes = append(notes, types.Snprintf("%s has been cached", cacheDest))
 		return types.Result{
 			Succeeded: true, Failed: false,
 			Changed: true, Notes: notes,
 		}, nil
-
 	}
 	return types.Result{
 		Succeeded: true, Failed: false,
 		Changed: false, Notes: notes,
 	}, nil
 }
+
+// fileDigest returns the sha256 of a file, or "" if it can't be read.
+func fileDigest(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	digest, _, err := hashers.SHA256(f, "")
+	if err != nil {
+		return ""
+	}
+	return digest
+}
+
+// resolveHash returns hash unchanged unless it points at a remote checksum
+// file (e.g. https://example.com/SHA256SUMS), in which case the checksum
+// file is fetched and the entry for source is returned.
+func (f File) resolveHash(ctx context.Context, source, hash string) (string, error) {
+	if !strings.Contains(hash, "://") && !strings.HasPrefix(hash, "/") {
+		return hash, nil
+	}
+	sumDest := filepath.Join(config.CacheDir, fmt.Sprintf("sums_%x", sha256.Sum256([]byte(hash))))
+	fp, err := NewFileProvider(f.id+"-hash", hash, sumDest, "", f.params)
+	if err != nil {
+		return "", err
+	}
+	if err = fp.Download(ctx); err != nil {
+		return "", errors.Join(err, fmt.Errorf("failed to fetch checksum file %s", hash))
+	}
+	sums, err := os.Open(sumDest)
+	if err != nil {
+		return "", err
+	}
+	defer sums.Close()
+	name := source
+	if u, err := url.Parse(source); err == nil && u.Path != "" {
+		name = u.Path
+	}
+	return hashers.LookupHashFile(sums, name)
+}
@@ -2,11 +2,13 @@ package file
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/gogrlx/grlx/config"
@@ -62,7 +64,7 @@ func TestCached(t *testing.T) {
 			expected: types.Result{
 				Succeeded: true,
 				Failed:    false,
-				Notes:     []fmt.Stringer{types.SimpleNote("skip_testName has been cached")},
+				Notes:     []fmt.Stringer{types.Snprintf("%s has been cached", skipVerifyDest("/test"))},
 			},
 			error: nil,
 		},
@@ -76,7 +78,7 @@ func TestCached(t *testing.T) {
 			expected: types.Result{
 				Succeeded: true,
 				Failed:    false,
-				Notes:     []fmt.Stringer{types.SimpleNote("skip_testName would be cached")},
+				Notes:     []fmt.Stringer{types.Snprintf("%s would be cached", skipVerifyDest("/test"))},
 			},
 			error: nil,
 			test:  true,
@@ -116,51 +118,164 @@ func TestCached(t *testing.T) {
 	}
 }
 
-type testServer struct{}
+type testServer struct {
+	mtx  sync.Mutex
+	data string
+}
 
 func (h *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("testData"))
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	w.Write([]byte(h.data + r.URL.Path))
+}
+
+func (h *testServer) set(data string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.data = data
 }
 
-// Caching works well when the file is being downloaded
+// Unverified copies are keyed on their source and fetched again each run
 func TestCachedSkipVerify(t *testing.T) {
 	listener, err := net.Listen("tcp", "localhost:0")
 	if err != nil {
 		panic(err)
 	}
-
-	go func() {
-		v := &testServer{}
-		http.Serve(listener, v)
-	}()
+	server := &testServer{data: "testData"}
+	go http.Serve(listener, server)
 	port := listener.Addr().(*net.TCPAddr).Port
 	td := t.TempDir()
-	host := fmt.Sprintf("http://localhost:%d/test", port)
-	dest := filepath.Join(td, "skip_dst")
-	skipped := filepath.Join(td, "skip_skip_dst")
+	host := fmt.Sprintf("http://localhost:%d", port)
 	config.CacheDir = td
 	// Unset the cache dir so that we don't interfere with other tests
 	defer func() {
 		config.CacheDir = ""
 	}()
-	expected := types.Result{
+	cached := func(source string) (types.Result, error) {
+		f := File{
+			id:     "test",
+			method: "cached",
+			params: map[string]interface{}{
+				"name":        filepath.Join(td, "dst"),
+				"source":      source,
+				"skip_verify": true,
+			},
+		}
+		return f.cached(context.Background(), false)
+	}
+	source := host + "/a/test"
+	dest := skipVerifyDest(source)
+	res, err := cached(source)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	compareResults(t, res, types.Result{
+		Succeeded: true, Changed: true,
+		Notes: []fmt.Stringer{types.Snprintf("%s has been cached", dest)},
+	})
+	res, _ = cached(source)
+	compareResults(t, res, types.Result{
 		Succeeded: true,
-		Failed:    false,
-		Notes:     []fmt.Stringer{types.Snprintf("%s already exists and skipVerify is true", skipped)},
+		Notes:     []fmt.Stringer{types.Snprintf("%s is up to date", dest)},
+	})
+
+	server.set("newData")
+	res, _ = cached(source)
+	compareResults(t, res, types.Result{
+		Succeeded: true, Changed: true,
+		Notes: []fmt.Stringer{types.Snprintf("%s has been cached", dest)},
+	})
+	if b, _ := os.ReadFile(dest); string(b) != "newData/a/test" {
+		t.Errorf("expected the changed source to be fetched, got %q", b)
 	}
+
+	other := host + "/b/test"
+	if _, err = cached(other); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if skipVerifyDest(other) == dest {
+		t.Fatal("expected sources sharing a basename to be cached apart")
+	}
+	if b, _ := os.ReadFile(dest); string(b) != "newData/a/test" {
+		t.Errorf("expected %s to be left alone, got %q", dest, b)
+	}
+}
+
+type sumsServer struct{}
+
+func (h *sumsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/SHA256SUMS":
+		w.Write([]byte("ba477a0ac57e10dd90bb5bf0289c5990fe839c619b26fde7c2aac62f526d4113  test\n"))
+	default:
+		w.Write([]byte("testData"))
+	}
+}
+
+// A source_hash may point at a remote checksum file
+func TestCachedRemoteHash(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go http.Serve(listener, &sumsServer{})
+	host := fmt.Sprintf("http://localhost:%d", listener.Addr().(*net.TCPAddr).Port)
+	config.CacheDir = t.TempDir()
+	defer func() {
+		config.CacheDir = ""
+	}()
 	f := File{
 		id:     "test",
 		method: "cached",
 		params: map[string]interface{}{
-			"name":        dest,
-			"source":      host,
-			"skip_verify": true,
+			"name":   "test",
+			"source": host + "/test",
+			"hash":   host + "/SHA256SUMS",
 		},
 	}
-	_, err = f.cached(context.Background(), false)
+	res, err := f.cached(context.Background(), false)
 	if err != nil {
-		t.Errorf("expected no error, got %v", err)
+		t.Fatalf("expected no error, got %v", err)
+	}
+	cached := filepath.Join(config.CacheDir, "ba477a0ac57e10dd90bb5bf0289c5990fe839c619b26fde7c2aac62f526d4113")
+	compareResults(t, res, types.Result{
+		Succeeded: true, Failed: false,
+		Notes: []fmt.Stringer{types.Snprintf("%s has been cached", cached)},
+	})
+	res, err = f.cached(context.Background(), false)
+	if err != nil || res.Changed {
+		t.Errorf("expected cached file to be reused, got %v %v", res, err)
+	}
+}
+
+// A fetched copy which doesn't match its hash must not be reported as cached
+func TestCachedHashMismatch(t *testing.T) {
+	td := t.TempDir()
+	source := filepath.Join(td, "source")
+	if err := os.WriteFile(source, []byte("testData"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config.CacheDir = filepath.Join(td, "cache")
+	if err := os.Mkdir(config.CacheDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		config.CacheDir = ""
+	}()
+	f := File{
+		id:     "test",
+		method: "cached",
+		params: map[string]interface{}{
+			"name":   "test",
+			"source": source,
+			"hash":   "sha256=0000000000000000000000000000000000000000000000000000000000000000",
+		},
+	}
+	res, err := f.cached(context.Background(), false)
+	if !errors.Is(err, types.ErrHashMismatch) {
+		t.Fatalf("expected error %v, got %v", types.ErrHashMismatch, err)
+	}
+	if res.Succeeded || !res.Failed || res.Changed {
+		t.Errorf("expected a failed result, got %v", res)
 	}
-	res, _ := f.cached(context.Background(), false)
-	compareResults(t, res, expected)
 }
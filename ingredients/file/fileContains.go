@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/gogrlx/grlx/types"
 )
@@ -40,10 +41,13 @@ func (f File) contains(ctx context.Context, test bool) (types.Result, bytes.Buff
 	{
 		if text, ok := f.params["text"].(string); ok && text != "" {
 			content.WriteString(text)
-		} else if texti, ok := f.params["text"].([]interface{}); ok {
+			if !strings.HasSuffix(text, "\n") {
+				content.WriteString("\n")
+			}
+		} else if texti, ok := f.listProp("text"); ok {
 			for _, v := range texti {
 				// need to make sure it's a string and not yaml parsing as an int
-				content.WriteString(fmt.Sprintf("%v", v))
+				content.WriteString(fmt.Sprintf("%v\n", v))
 			}
 		}
 	}
@@ -71,7 +75,7 @@ func (f File) contains(ctx context.Context, test bool) (types.Result, bytes.Buff
 						Changed: false, Notes: notes,
 					}, content, errors.Join(err, types.ErrCacheFailure)
 				}
-				sourceDest, err = srcFile.(*File).dest()
+				sourceDest, err = srcFile.(File).dest()
 			} else if skipVerify, ok := f.params["skip_verify"].(bool); ok && skipVerify {
 				srcFile, err := f.Parse(f.id+"-source", "cached", map[string]interface{}{
 					"source":      src,
@@ -92,7 +96,7 @@ func (f File) contains(ctx context.Context, test bool) (types.Result, bytes.Buff
 						Changed: false, Notes: notes,
 					}, content, errors.Join(err, types.ErrCacheFailure)
 				}
-				sourceDest, err = srcFile.(*File).dest()
+				sourceDest, err = srcFile.(File).dest()
 			} else {
 				return types.Result{
 					Succeeded: false, Failed: true, Notes: notes,
@@ -115,8 +119,8 @@ func (f File) contains(ctx context.Context, test bool) (types.Result, bytes.Buff
 		var srcHashes []interface{}
 		var ok bool
 		skip := false
-		if srces, ok = f.params["sources"].([]interface{}); ok && len(srces) > 0 {
-			if srcHashes, ok = f.params["source_hashes"].([]interface{}); ok {
+		if srces, ok = f.listProp("sources"); ok && len(srces) > 0 {
+			if srcHashes, ok = f.listProp("source_hashes"); ok {
 				if skipVerify, ok := f.params["skip_verify"].(bool); ok && skipVerify {
 					skip = true
 				} else if len(srces) != len(srcHashes) {
@@ -171,7 +175,7 @@ func (f File) contains(ctx context.Context, test bool) (types.Result, bytes.Buff
 					Changed: false, Notes: notes,
 				}, content, errors.Join(err, types.ErrCacheFailure)
 			}
-			sourceDest, err := file.(*File).dest()
+			sourceDest, err := file.(File).dest()
 			if err != nil {
 				f, err := os.Open(sourceDest)
 				if err != nil {
@@ -212,7 +216,7 @@ func (f File) contains(ctx context.Context, test bool) (types.Result, bytes.Buff
 						Changed: false, Notes: notes,
 					}, content, errors.Join(err, types.ErrCacheFailure)
 				}
-				sourceDest, err = srcFile.(*File).dest()
+				sourceDest, err = srcFile.(File).dest()
 			} else if skipVerify, ok := f.params["skip_verify"].(bool); ok && skipVerify {
 				srcFile, err := f.Parse(f.id+"-source", "cached", map[string]interface{}{
 					"source":      src,
@@ -234,7 +238,7 @@ func (f File) contains(ctx context.Context, test bool) (types.Result, bytes.Buff
 						Changed: false, Notes: notes,
 					}, content, errors.Join(err, types.ErrCacheFailure)
 				}
-				sourceDest, err = srcFile.(*File).dest()
+				sourceDest, err = srcFile.(File).dest()
 			} else {
 				return types.Result{
 					Succeeded: false, Failed: true,
@@ -269,21 +273,33 @@ func (f File) contains(ctx context.Context, test bool) (types.Result, bytes.Buff
 	sort.Strings(currentContents)
 
 	shouldContents := []string{}
-	scanner = bufio.NewScanner(&content)
+	scanner = bufio.NewScanner(bytes.NewReader(content.Bytes()))
 	for scanner.Scan() {
 		shouldContents = append(shouldContents, scanner.Text())
 	}
-	sort.Strings(shouldContents)
+	sortedContents := append([]string{}, shouldContents...)
+	sort.Strings(sortedContents)
 
-	isSubset, _ := stringSliceIsSubset(shouldContents, currentContents)
+	isSubset, missingLines := stringSliceIsSubset(sortedContents, currentContents)
 	if isSubset {
 		return types.Result{
 			Succeeded: true, Failed: false, Notes: notes,
 		}, bytes.Buffer{}, nil
 	}
+	// hand back the missing lines in the order they were given
+	isMissing := make(map[string]bool, len(missingLines))
+	for _, line := range missingLines {
+		isMissing[line] = true
+	}
+	missing := bytes.Buffer{}
+	for _, line := range shouldContents {
+		if isMissing[line] {
+			missing.WriteString(line + "\n")
+		}
+	}
 	notes = append(notes, types.Snprintf("file %s does not contain all specified content", name))
 	return types.Result{
 		Succeeded: false, Failed: true,
 		Changed: false, Notes: notes,
-	}, content, types.ErrMissingContent
+	}, missing, types.ErrMissingContent
 }
@@ -2,6 +2,7 @@ package file
 
 import (
 	"context"
+	"crypto/md5"
 	"errors"
 	"fmt"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/ingredients/file/hashers"
 	"github.com/gogrlx/grlx/types"
 )
 
@@ -18,16 +20,24 @@ func TestFileContent(t *testing.T) {
 	// Restore config.CacheDir after test
 	defer func() { config.CacheDir = cd }()
 	config.CacheDir = filepath.Join(tempDir, "cache")
+	if err := os.MkdirAll(config.CacheDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
 	newDir := filepath.Join(tempDir, "this/item")
 	dirEntry := filepath.Dir(newDir)
-	fmt.Println(newDir)
 	doesExist := filepath.Join(tempDir, "doesExist")
 	_, err := os.Create(doesExist)
 	if err != nil {
 		t.Fatal(err)
 	}
 	sourceExist := filepath.Join(tempDir, "sourceExist")
-	_, err = os.Create(sourceExist)
+	err = os.WriteFile(sourceExist, []byte("from source\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceHash := fmt.Sprintf("md5:%x", md5.Sum([]byte("from source\n")))
+	written := filepath.Join(tempDir, "written")
+	err = os.WriteFile(written, []byte("old\n"), 0o644)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -37,6 +47,7 @@ func TestFileContent(t *testing.T) {
 		expected types.Result
 		error    error
 		test     bool
+		content  string
 	}{
 		{
 			name: "incorrect name",
@@ -77,7 +88,7 @@ func TestFileContent(t *testing.T) {
 					types.Snprintf("created directory %s", dirEntry),
 				},
 			},
-			error: nil,
+			error: errors.Join(types.ErrMissingContent, fmt.Errorf("one of text, source or sources is required")),
 			test:  false,
 		},
 		{
@@ -132,7 +143,7 @@ func TestFileContent(t *testing.T) {
 		{
 			name: "sources missing hashes w/ skip_verify",
 			params: map[string]interface{}{
-				"name":        "test",
+				"name":        doesExist,
 				"sources":     []string{sourceExist, doesExist},
 				"skip_verify": true,
 			},
@@ -156,12 +167,103 @@ func TestFileContent(t *testing.T) {
 				Succeeded: false,
 				Failed:    true,
 				Changed:   false,
-				Notes:     []fmt.Stringer{},
+				Notes: []fmt.Stringer{
+					types.Snprintf("failed to cache source %s", sourceExist),
+				},
 			},
-			// TODO: This should be a lot cleaner, relying on a stdblib error that we have little control over is difficult to test.
-			error: errors.Join(fmt.Errorf("open %s: no such file or directory", filepath.Join(config.CacheDir, "test1")), types.ErrCacheFailure),
+			error: errors.Join(errors.Join(hashers.ErrInvalidHashSpec, fmt.Errorf("hash test1 is not a hex digest")), types.ErrCacheFailure),
 			test:  false,
 		},
+		{
+			name: "text",
+			params: map[string]interface{}{
+				"name": newDir,
+				"text": []interface{}{"first", 2},
+			},
+			expected: types.Result{
+				Succeeded: true,
+				Failed:    false,
+				Changed:   true,
+				Notes: []fmt.Stringer{
+					types.Snprintf("--- /dev/null\n+++ %s\n@@ -0,0 +1,2 @@\n+first\n+2", newDir),
+					types.Snprintf("%s has been created", newDir),
+				},
+			},
+			content: "first\n2\n",
+		},
+		{
+			name: "unchanged",
+			params: map[string]interface{}{
+				"name": newDir,
+				"text": []interface{}{"first", 2},
+			},
+			expected: types.Result{
+				Succeeded: true,
+				Failed:    false,
+				Changed:   false,
+				Notes: []fmt.Stringer{
+					types.Snprintf("%s is already in the correct state", newDir),
+				},
+			},
+			content: "first\n2\n",
+		},
+		{
+			name: "text and source test",
+			params: map[string]interface{}{
+				"name":        written,
+				"text":        "header",
+				"source":      sourceExist,
+				"source_hash": sourceHash,
+			},
+			expected: types.Result{
+				Succeeded: true,
+				Failed:    false,
+				Changed:   true,
+				Notes: []fmt.Stringer{
+					types.Snprintf("%s would be cached", filepath.Join(config.CacheDir, sourceHash)),
+					types.Snprintf("%s would be updated", written),
+				},
+			},
+			test:    true,
+			content: "old\n",
+		},
+		{
+			name: "text and source",
+			params: map[string]interface{}{
+				"name":        written,
+				"text":        "header",
+				"source":      sourceExist,
+				"source_hash": sourceHash,
+			},
+			expected: types.Result{
+				Succeeded: true,
+				Failed:    false,
+				Changed:   true,
+				Notes: []fmt.Stringer{
+					types.Snprintf("%s has been cached", filepath.Join(config.CacheDir, sourceHash)),
+					types.Snprintf("--- %s\n+++ %s\n@@ -1 +1,2 @@\n-old\n+header\n+from source", written, written),
+					types.Snprintf("%s has been updated", written),
+				},
+			},
+			content: "header\nfrom source\n",
+		},
+		{
+			name: "without changes shown",
+			params: map[string]interface{}{
+				"name":         written,
+				"text":         "footer",
+				"show_changes": false,
+			},
+			expected: types.Result{
+				Succeeded: true,
+				Failed:    false,
+				Changed:   true,
+				Notes: []fmt.Stringer{
+					types.Snprintf("%s has been updated", written),
+				},
+			},
+			content: "footer\n",
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -177,8 +279,20 @@ func TestFileContent(t *testing.T) {
 				} else if err.Error() != test.error.Error() {
 					t.Errorf("expected error %v, got %v", test.error, err)
 				}
+			} else if test.error != nil {
+				t.Errorf("expected error %v, got nil", test.error)
 			}
 			compareResults(t, result, test.expected)
+			if test.content != "" {
+				name := test.params["name"].(string)
+				b, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != test.content {
+					t.Errorf("expected %s to contain %q, got %q", name, test.content, string(b))
+				}
+			}
 		})
 	}
 }
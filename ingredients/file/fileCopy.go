@@ -0,0 +1,194 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrNotRegularFile    = errors.New("not a regular file")
+	ErrDestinationExists = errors.New("destination already exists")
+)
+
+// pathParam reads a path property, returning missing if it is unset.
+func (f File) pathParam(key string, missing error) (string, error) {
+	p, ok := f.params[key].(string)
+	if !ok || p == "" {
+		return "", missing
+	}
+	p = filepath.Clean(p)
+	if p == "/" {
+		return "", types.ErrModifyRoot
+	}
+	return p, nil
+}
+
+// sameContents reports whether the files a and b hold the same bytes.
+func sameContents(a, b string) (bool, error) {
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+	sa, err := fa.Stat()
+	if err != nil {
+		return false, err
+	}
+	sb, err := fb.Stat()
+	if err != nil {
+		return false, err
+	}
+	if sa.Size() != sb.Size() {
+		return false, nil
+	}
+	ba, bb := make([]byte, 32*1024), make([]byte, 32*1024)
+	for {
+		na, errA := io.ReadFull(fa, ba)
+		nb, errB := io.ReadFull(fb, bb)
+		if !bytes.Equal(ba[:na], bb[:nb]) {
+			return false, nil
+		}
+		if errors.Is(errA, io.EOF) || errors.Is(errA, io.ErrUnexpectedEOF) {
+			return true, nil
+		}
+		if errA != nil {
+			return false, errA
+		}
+		if errB != nil {
+			return false, errB
+		}
+	}
+}
+
+// copyFile copies src to a temporary file beside dst with the given mode
+// and renames it into place, so dst is never left half written.
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, in)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// copy copies source, a file already on the sprout, to name. An existing
+// name is left alone unless force is set, in which case it is replaced
+// if its contents differ. With preserve, the copy keeps the owner, mode
+// and modification time of source; user, group and mode take precedence.
+// Directories are copied with file.recurse instead.
+func (f File) copy(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	name, err := f.pathParam("name", types.ErrMissingName)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	source, err := f.pathParam("source", types.ErrMissingSource)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if name == source {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, fmt.Errorf("cannot copy %s onto itself", name)
+	}
+	force, _ := f.params["force"].(bool)
+	preserve, _ := f.params["preserve"].(bool)
+	makedirs, _ := f.params["makedirs"].(bool)
+	srcInfo, err := os.Stat(source)
+	if os.IsNotExist(err) {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, errors.Join(types.ErrFileNotFound, fmt.Errorf("source %s does not exist", source))
+	} else if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if !srcInfo.Mode().IsRegular() {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, errors.Join(ErrNotRegularFile, fmt.Errorf("%s is not a regular file; copy directories with file.recurse", source))
+	}
+	owner, err := f.parseOwnership("mode")
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if preserve {
+		owner = owner.preserveFrom(srcInfo)
+	}
+
+	// new files get the mode of the source, replaced ones keep their own
+	mode := srcInfo.Mode() & os.ModePerm
+	write := true
+	destInfo, err := os.Stat(name)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	case !destInfo.Mode().IsRegular():
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, errors.Join(ErrNotRegularFile, fmt.Errorf("%s exists and is not a regular file", name))
+	default:
+		mode = destInfo.Mode() & os.ModePerm
+		same, err := sameContents(source, name)
+		if err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		write = !same && force
+		if !same && !force {
+			notes = append(notes, types.Snprintf("%s exists and differs from %s; set force to replace it", name, source))
+		}
+	}
+
+	changed := false
+	if write {
+		parentNotes, err := f.ensureParent(name, makedirs, test)
+		notes = append(notes, parentNotes...)
+		if err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		changed = true
+		if test {
+			notes = append(notes, types.Snprintf("would copy %s to %s", source, name))
+		} else {
+			if err = copyFile(source, name, mode); err != nil {
+				notes = append(notes, types.Snprintf("failed to copy %s to %s", source, name))
+				return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+			}
+			notes = append(notes, types.Snprintf("copied %s to %s", source, name))
+			if preserve {
+				if err = os.Chtimes(name, srcInfo.ModTime(), srcInfo.ModTime()); err != nil {
+					return types.Result{Succeeded: false, Failed: true, Changed: true, Notes: notes}, err
+				}
+			}
+		}
+	}
+	ownerChanged, ownerNotes, err := owner.apply(name, test)
+	notes = append(notes, ownerNotes...)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Changed: changed, Notes: notes}, err
+	}
+	changed = changed || ownerChanged
+	if !changed && len(notes) == 0 {
+		notes = append(notes, types.Snprintf("%s is already a copy of %s", name, source))
+	}
+	return types.Result{Succeeded: true, Failed: false, Changed: changed, Notes: notes}, nil
+}
@@ -0,0 +1,169 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+func TestCopy(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "source")
+	os.WriteFile(source, []byte("hello\n"), 0o640)
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	os.Chtimes(source, mtime, mtime)
+	same := filepath.Join(tempDir, "same")
+	os.WriteFile(same, []byte("hello\n"), 0o644)
+	differs := filepath.Join(tempDir, "differs")
+	os.WriteFile(differs, []byte("goodbye\n"), 0o600)
+	replaced := filepath.Join(tempDir, "replaced")
+	os.WriteFile(replaced, []byte("goodbye\n"), 0o600)
+	newFile := filepath.Join(tempDir, "new")
+	preserved := filepath.Join(tempDir, "preserved")
+	nested := filepath.Join(tempDir, "a", "b", "nested")
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		expected types.Result
+		content  string
+		mode     os.FileMode
+		error    error
+		test     bool
+	}{
+		{
+			name:     "MissingSource",
+			params:   map[string]interface{}{"name": newFile},
+			expected: types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{}},
+			error:    types.ErrMissingSource,
+		},
+		{
+			name:     "SourceDoesNotExist",
+			params:   map[string]interface{}{"name": newFile, "source": filepath.Join(tempDir, "nothing")},
+			expected: types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{}},
+			error:    types.ErrFileNotFound,
+		},
+		{
+			name:     "SourceIsDirectory",
+			params:   map[string]interface{}{"name": newFile, "source": tempDir},
+			expected: types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{}},
+			error:    ErrNotRegularFile,
+		},
+		{
+			name:   "TestCopy",
+			params: map[string]interface{}{"name": newFile, "source": source},
+			expected: types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{
+				types.Snprintf("would copy %s to %s", source, newFile),
+			}},
+			test: true,
+		},
+		{
+			name:   "Copy",
+			params: map[string]interface{}{"name": newFile, "source": source},
+			expected: types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{
+				types.Snprintf("copied %s to %s", source, newFile),
+			}},
+			content: "hello\n",
+			mode:    0o640,
+		},
+		{
+			name:   "AlreadyCopied",
+			params: map[string]interface{}{"name": same, "source": source},
+			expected: types.Result{Succeeded: true, Failed: false, Notes: []fmt.Stringer{
+				types.Snprintf("%s is already a copy of %s", same, source),
+			}},
+			content: "hello\n",
+			mode:    0o644,
+		},
+		{
+			name:   "DiffersWithoutForce",
+			params: map[string]interface{}{"name": differs, "source": source},
+			expected: types.Result{Succeeded: true, Failed: false, Notes: []fmt.Stringer{
+				types.Snprintf("%s exists and differs from %s; set force to replace it", differs, source),
+			}},
+			content: "goodbye\n",
+			mode:    0o600,
+		},
+		{
+			name:   "Force",
+			params: map[string]interface{}{"name": replaced, "source": source, "force": true, "mode": "0644"},
+			expected: types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{
+				types.Snprintf("copied %s to %s", source, replaced),
+				types.Snprintf("chmod %s to 0644", replaced),
+			}},
+			content: "hello\n",
+			mode:    0o644,
+		},
+		{
+			name:   "Preserve",
+			params: map[string]interface{}{"name": preserved, "source": source, "preserve": true},
+			expected: types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{
+				types.Snprintf("copied %s to %s", source, preserved),
+			}},
+			content: "hello\n",
+			mode:    0o640,
+		},
+		{
+			name:     "NoParent",
+			params:   map[string]interface{}{"name": nested, "source": source},
+			expected: types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{}},
+			error:    types.ErrPathNotFound,
+		},
+		{
+			name:   "MakeDirs",
+			params: map[string]interface{}{"name": nested, "source": source, "makedirs": true},
+			expected: types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{
+				types.Snprintf("created directory %s", filepath.Dir(nested)),
+				types.Snprintf("copied %s to %s", source, nested),
+			}},
+			content: "hello\n",
+			mode:    0o640,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := File{id: "", method: "copy", params: test.params}
+			var result types.Result
+			var err error
+			if test.test {
+				result, err = f.Test(context.TODO())
+			} else {
+				result, err = f.Apply(context.TODO())
+			}
+			if !errors.Is(err, test.error) {
+				t.Fatalf("expected error `%v`, got `%v`", test.error, err)
+			}
+			compareResults(t, result, test.expected)
+			if result.Changed != test.expected.Changed {
+				t.Errorf("expected changed to be %v, got %v", test.expected.Changed, result.Changed)
+			}
+			if test.content == "" {
+				return
+			}
+			name := test.params["name"].(string)
+			content, err := os.ReadFile(name)
+			if err != nil || string(content) != test.content {
+				t.Errorf("expected %s to hold %q, got %q (%v)", name, test.content, content, err)
+			}
+			fi, _ := os.Stat(name)
+			if fi.Mode().Perm() != test.mode {
+				t.Errorf("expected mode %04o, got %04o", test.mode, fi.Mode().Perm())
+			}
+		})
+	}
+	if _, err := os.Stat(newFile); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(preserved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fi.ModTime().Equal(mtime) {
+		t.Errorf("expected preserve to keep the modification time %s, got %s", mtime, fi.ModTime())
+	}
+}
@@ -2,6 +2,7 @@ package file
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -12,6 +13,8 @@ import (
 	"github.com/gogrlx/grlx/types"
 )
 
+var ErrCleanWithoutExclude = errors.New("clean requires exclude patterns for the paths to keep")
+
 func (f File) directory(ctx context.Context, test bool) (types.Result, error) {
 	notes := []fmt.Stringer{}
 	type dir struct {
@@ -46,6 +49,19 @@ func (f File) directory(ctx context.Context, test bool) (types.Result, error) {
 			Succeeded: false, Failed: true, Notes: notes,
 		}, types.ErrDeleteRoot
 	}
+	// without exclude patterns clean would empty the directory, which is
+	// better done on purpose with file.absent
+	if clean, _ := f.params["clean"].(bool); clean {
+		exclude, err := f.globList("exclude")
+		if err == nil && len(exclude) == 0 {
+			err = ErrCleanWithoutExclude
+		}
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+	}
 	d := dir{}
 	// create the directory if it doesn't exist
 	{
@@ -246,8 +262,27 @@ func (f File) directory(ctx context.Context, test bool) (types.Result, error) {
 			}
 		}
 	}
+	// remove everything in the directory that isn't excluded
+	changed := false
+	if val, ok := f.params["clean"].(bool); ok && val {
+		d.clean = true
+		exclude, err := f.globList("exclude")
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+		cleaned, cleanNotes, err := f.cleanDir(name, nil, exclude, test)
+		notes = append(notes, cleanNotes...)
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+		changed = cleaned
+	}
 
 	return types.Result{
-		Succeeded: true, Failed: false, Notes: notes,
+		Succeeded: true, Failed: false, Changed: changed, Notes: notes,
 	}, nil
 }
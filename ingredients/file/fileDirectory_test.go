@@ -117,6 +117,48 @@ func TestDirectory(t *testing.T) {
 			test:  true,
 			error: nil,
 		},
+		{
+			name: "DirectoryCleanWithoutExclude",
+			params: map[string]interface{}{
+				"name":  sampleDir,
+				"clean": true,
+			},
+			expected: types.Result{
+				Succeeded: false,
+				Failed:    true,
+				Notes:     []fmt.Stringer{},
+			},
+			error: ErrCleanWithoutExclude,
+		},
+		{
+			name: "DirectoryTestClean",
+			params: map[string]interface{}{
+				"name":    sampleDir,
+				"clean":   true,
+				"exclude": []interface{}{"*.keep"},
+			},
+			expected: types.Result{
+				Succeeded: true,
+				Failed:    false,
+				Notes:     []fmt.Stringer{types.Snprintf("directory %s already exists", sampleDir), types.Snprintf("%s would be removed", file)},
+			},
+			test:  true,
+			error: nil,
+		},
+		{
+			name: "DirectoryCleanExcluded",
+			params: map[string]interface{}{
+				"name":    sampleDir,
+				"clean":   true,
+				"exclude": []interface{}{"there-is-a-*"},
+			},
+			expected: types.Result{
+				Succeeded: true,
+				Failed:    false,
+				Notes:     []fmt.Stringer{types.Snprintf("directory %s already exists", sampleDir)},
+			},
+			error: nil,
+		},
 		{
 			// TODO: Update to match error for a directory that doesn't exist
 			name: "DirectoryChangeFileModeNotExist",
@@ -0,0 +1,90 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// hardlink makes name a hard link to target. A name which is already a
+// link to target is left alone; anything else at name is only replaced if
+// force is set, and directories are never replaced. Since a hard link
+// shares its owner and mode with target, those are managed on target.
+func (f File) hardlink(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	name, err := f.pathParam("name", types.ErrMissingName)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	target, err := f.pathParam("target", types.ErrMissingTarget)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	force, _ := f.params["force"].(bool)
+	makedirs, _ := f.params["makedirs"].(bool)
+	targetInfo, err := os.Stat(target)
+	if os.IsNotExist(err) {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, errors.Join(types.ErrFileNotFound, fmt.Errorf("target %s does not exist", target))
+	} else if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if targetInfo.IsDir() {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, errors.Join(ErrNotRegularFile, fmt.Errorf("cannot hard link to the directory %s", target))
+	}
+	nameInfo, err := os.Lstat(name)
+	exists := err == nil
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	case os.SameFile(nameInfo, targetInfo):
+		notes = append(notes, types.Snprintf("%s is already a hard link to %s", name, target))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	case nameInfo.IsDir():
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, errors.Join(ErrDestinationExists, fmt.Errorf("%s is a directory", name))
+	case !force:
+		notes = append(notes, types.Snprintf("%s already exists; set force to replace it", name))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, ErrDestinationExists
+	}
+
+	parentNotes, err := f.ensureParent(name, makedirs, test)
+	notes = append(notes, parentNotes...)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if test {
+		if exists {
+			notes = append(notes, types.Snprintf("would replace %s with a hard link to %s", name, target))
+		} else {
+			notes = append(notes, types.Snprintf("would create hard link %s to %s", name, target))
+		}
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+	if !exists {
+		if err = os.Link(target, name); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		notes = append(notes, types.Snprintf("created hard link %s to %s", name, target))
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+	// link beside name and rename over it, so name never goes missing
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	tmp.Close()
+	os.Remove(tmp.Name())
+	if err = os.Link(target, tmp.Name()); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if err = os.Rename(tmp.Name(), name); err != nil {
+		os.Remove(tmp.Name())
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	notes = append(notes, types.Snprintf("replaced %s with a hard link to %s", name, target))
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+}
@@ -0,0 +1,109 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+func TestHardlink(t *testing.T) {
+	tempDir := t.TempDir()
+	target := filepath.Join(tempDir, "target")
+	os.WriteFile(target, []byte("hello\n"), 0o644)
+	link := filepath.Join(tempDir, "link")
+	occupied := filepath.Join(tempDir, "occupied")
+	os.WriteFile(occupied, []byte("goodbye\n"), 0o644)
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		expected types.Result
+		error    error
+		test     bool
+	}{
+		{
+			name:     "MissingTarget",
+			params:   map[string]interface{}{"name": link},
+			expected: types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{}},
+			error:    types.ErrMissingTarget,
+		},
+		{
+			name:     "TargetIsDirectory",
+			params:   map[string]interface{}{"name": link, "target": tempDir},
+			expected: types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{}},
+			error:    ErrNotRegularFile,
+		},
+		{
+			name:   "TestHardlink",
+			params: map[string]interface{}{"name": link, "target": target},
+			expected: types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{
+				types.Snprintf("would create hard link %s to %s", link, target),
+			}},
+			test: true,
+		},
+		{
+			name:   "Hardlink",
+			params: map[string]interface{}{"name": link, "target": target},
+			expected: types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{
+				types.Snprintf("created hard link %s to %s", link, target),
+			}},
+		},
+		{
+			name:   "AlreadyLinked",
+			params: map[string]interface{}{"name": link, "target": target},
+			expected: types.Result{Succeeded: true, Failed: false, Notes: []fmt.Stringer{
+				types.Snprintf("%s is already a hard link to %s", link, target),
+			}},
+		},
+		{
+			name:   "Occupied",
+			params: map[string]interface{}{"name": occupied, "target": target},
+			expected: types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{
+				types.Snprintf("%s already exists; set force to replace it", occupied),
+			}},
+			error: ErrDestinationExists,
+		},
+		{
+			name:   "Force",
+			params: map[string]interface{}{"name": occupied, "target": target, "force": true},
+			expected: types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{
+				types.Snprintf("replaced %s with a hard link to %s", occupied, target),
+			}},
+		},
+		{
+			name:     "Directory",
+			params:   map[string]interface{}{"name": t.TempDir(), "target": target, "force": true},
+			expected: types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{}},
+			error:    ErrDestinationExists,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := File{id: "", method: "hardlink", params: test.params}
+			var result types.Result
+			var err error
+			if test.test {
+				result, err = f.Test(context.TODO())
+			} else {
+				result, err = f.Apply(context.TODO())
+			}
+			if !errors.Is(err, test.error) {
+				t.Fatalf("expected error `%v`, got `%v`", test.error, err)
+			}
+			compareResults(t, result, test.expected)
+			if result.Changed != test.expected.Changed {
+				t.Errorf("expected changed to be %v, got %v", test.expected.Changed, result.Changed)
+			}
+		})
+	}
+	targetInfo, _ := os.Stat(target)
+	for _, name := range []string{link, occupied} {
+		if fi, err := os.Stat(name); err != nil || !os.SameFile(fi, targetInfo) {
+			t.Errorf("expected %s to be a hard link to %s", name, target)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// keyvalue sets and removes keys in files made of `key=value` lines, such
+// as /etc/default files or sshd_config. Only the lines for the given keys
+// are touched.
+//
+// separator is written between key and value (default "="); surrounding
+// spaces are ignored when reading, and a blank separator splits on the
+// first run of whitespace. Later lines for a key which is set are removed,
+// since they would override the first. With uncomment set to a comment
+// prefix such as "#", a commented-out line for a missing key is replaced
+// rather than a new line being added. append_if_not_found (default true)
+// adds missing keys to the end of the file.
+func (f File) keyvalue(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	name, current, exists, err := f.readTarget()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	keyValues, ok := f.params["key_values"].(map[string]interface{})
+	if !ok {
+		if _, set := f.params["key_values"]; set {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, fmt.Errorf("key_values must be a map")
+		}
+		keyValues = map[string]interface{}{}
+	}
+	sep, ok := f.params["separator"].(string)
+	if !ok || sep == "" {
+		sep = "="
+	}
+	uncomment, _ := f.params["uncomment"].(string)
+	appendMissing := true
+	if v, ok := f.params["append_if_not_found"].(bool); ok {
+		appendMissing = v
+	}
+
+	split := strings.TrimSpace(sep)
+	keyOf := func(line string) (string, string, bool) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			return "", "", false
+		}
+		if split == "" {
+			fields := strings.Fields(trimmed)
+			return fields[0], strings.TrimSpace(strings.TrimPrefix(trimmed, fields[0])), true
+		}
+		key, value, ok := strings.Cut(trimmed, split)
+		if !ok {
+			return "", "", false
+		}
+		return strings.TrimSpace(key), strings.TrimSpace(value), true
+	}
+
+	lines := splitContentLines(string(current))
+	edited := false
+	for _, key := range sortedKeys(keyValues) {
+		want := fmt.Sprintf("%v", keyValues[key])
+		found := false
+		kept := lines[:0:0]
+		for _, line := range lines {
+			k, v, ok := keyOf(line)
+			if !ok || k != key {
+				kept = append(kept, line)
+				continue
+			}
+			if found {
+				edited = true
+				continue
+			}
+			found = true
+			if v != want {
+				line, edited = key+sep+want, true
+			}
+			kept = append(kept, line)
+		}
+		lines = kept
+		if !found && uncomment != "" {
+			for i, line := range lines {
+				trimmed := strings.TrimSpace(line)
+				if !strings.HasPrefix(trimmed, uncomment) {
+					continue
+				}
+				if k, _, ok := keyOf(strings.TrimPrefix(trimmed, uncomment)); ok && k == key {
+					lines[i], found, edited = key+sep+want, true, true
+					break
+				}
+			}
+		}
+		if !found && appendMissing {
+			lines, edited = append(lines, key+sep+want), true
+		}
+	}
+	for _, path := range f.removePaths() {
+		key := strings.Join(path, ".")
+		kept := lines[:0:0]
+		for _, line := range lines {
+			if k, _, ok := keyOf(line); ok && k == key {
+				edited = true
+				continue
+			}
+			kept = append(kept, line)
+		}
+		lines = kept
+	}
+
+	if !exists && len(lines) == 0 {
+		notes = append(notes, types.Snprintf("%s does not exist and there are no keys to add", name))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	}
+	desired := current
+	if edited || !exists {
+		desired = []byte{}
+		if len(lines) > 0 {
+			desired = []byte(strings.Join(lines, "\n") + "\n")
+		}
+	}
+	return f.writeEdited(name, current, desired, exists, test)
+}
@@ -0,0 +1,108 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		params   map[string]interface{}
+		test     bool
+		expected string
+		changed  bool
+	}{
+		{
+			name:     "Set",
+			existing: "# defaults\nGRUB_TIMEOUT=5\nGRUB_CMDLINE=\"quiet\"\n",
+			params:   map[string]interface{}{"key_values": map[string]interface{}{"GRUB_TIMEOUT": 1, "GRUB_DEFAULT": "0"}},
+			expected: "# defaults\nGRUB_TIMEOUT=1\nGRUB_CMDLINE=\"quiet\"\nGRUB_DEFAULT=0\n",
+			changed:  true,
+		},
+		{
+			name:     "Unchanged",
+			existing: "a = 1\nb=2",
+			params:   map[string]interface{}{"key_values": map[string]interface{}{"a": "1", "b": 2}},
+			expected: "a = 1\nb=2",
+		},
+		{
+			name:     "Whitespace",
+			existing: "Port 22\n#PermitRootLogin yes\nPasswordAuthentication yes\n",
+			params: map[string]interface{}{
+				"separator":  " ",
+				"uncomment":  "#",
+				"key_values": map[string]interface{}{"PermitRootLogin": "no", "PasswordAuthentication": "no"},
+			},
+			expected: "Port 22\nPermitRootLogin no\nPasswordAuthentication no\n",
+			changed:  true,
+		},
+		{
+			name:     "Duplicates",
+			existing: "a=1\nb=2\na=3\n",
+			params:   map[string]interface{}{"key_values": map[string]interface{}{"a": "1"}},
+			expected: "a=1\nb=2\n",
+			changed:  true,
+		},
+		{
+			name:     "NoAppend",
+			existing: "a=1\n",
+			params:   map[string]interface{}{"append_if_not_found": false, "key_values": map[string]interface{}{"b": "2"}},
+			expected: "a=1\n",
+		},
+		{
+			name:     "Remove",
+			existing: "net.ipv4.ip_forward = 1\nvm.swappiness = 10\n",
+			params:   map[string]interface{}{"remove": []interface{}{"net.ipv4.ip_forward"}},
+			expected: "vm.swappiness = 10\n",
+			changed:  true,
+		},
+		{
+			name:     "Create",
+			params:   map[string]interface{}{"key_values": map[string]interface{}{"a": "1"}},
+			expected: "a=1\n",
+			changed:  true,
+		},
+		{
+			name:     "Test",
+			existing: "a=1\n",
+			params:   map[string]interface{}{"key_values": map[string]interface{}{"a": "2"}},
+			test:     true,
+			expected: "a=1\n",
+			changed:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "settings")
+			if test.existing != "" {
+				if err := os.WriteFile(name, []byte(test.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			test.params["name"] = name
+			f := File{id: test.name, method: "keyvalue", params: test.params}
+			apply := f.Apply
+			if test.test {
+				apply = f.Test
+			}
+			res, err := apply(context.Background())
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if res.Changed != test.changed {
+				t.Errorf("expected changed to be %v, got %v: %v", test.changed, res.Changed, res.Notes)
+			}
+			content, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != test.expected {
+				t.Errorf("expected\n%s\ngot\n%s", test.expected, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+var ErrInvalidLineMode = errors.New("invalid line mode")
+
+// lineAnchor finds the first line matching the regular expression in the
+// named property, or returns -1 if the property is unset.
+func (f File) lineAnchor(prop string, lines []string) (int, error) {
+	if s, _ := f.params[prop].(string); s == "" {
+		return -1, nil
+	}
+	re, err := f.compilePattern(prop)
+	if err != nil {
+		return -1, err
+	}
+	for i, line := range lines {
+		if re.MatchString(line) {
+			return i, nil
+		}
+	}
+	return -1, errors.Join(ErrPatternNotFound, fmt.Errorf("no line matches %s %s", prop, re))
+}
+
+// leadingSpace returns the indentation of line.
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// line manages a single line of name. Lines are compared with surrounding
+// whitespace ignored. The modes are:
+//
+//   - ensure: content is the line directly after the line matching after,
+//     or directly before the one matching before. Without either, content
+//     must appear somewhere and is added at location if it doesn't.
+//   - insert: content is added after, before or at location (start or
+//     end, the default) unless it is already in the file.
+//   - replace: lines matching match are replaced with content.
+//   - delete: lines matching match, or equal to content, are removed.
+//
+// With indent (default true), added and replacing lines take the
+// indentation of the line they are placed by.
+func (f File) line(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	name, current, exists, err := f.readTarget()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if !exists {
+		notes = append(notes, types.Snprintf("%s does not exist", name))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, types.ErrFileNotFound
+	}
+	content, _ := f.params["content"].(string)
+	content = strings.TrimRight(content, "\r\n")
+	mode, _ := f.params["mode"].(string)
+	indent := true
+	if v, ok := f.params["indent"].(bool); ok {
+		indent = v
+	}
+	location, _ := f.params["location"].(string)
+	switch location {
+	case "", "end", "start":
+	default:
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, fmt.Errorf("location must be start or end, not %s", location)
+	}
+	if content == "" && mode != "delete" {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, types.ErrMissingContent
+	}
+
+	lines := splitContentLines(string(current))
+	want := strings.TrimSpace(content)
+	present := func() bool {
+		for _, l := range lines {
+			if strings.TrimSpace(l) == want {
+				return true
+			}
+		}
+		return false
+	}
+	insertAt := func(i int, like string) {
+		l := content
+		if indent && like != "" {
+			l = leadingSpace(like) + strings.TrimLeft(content, " \t")
+		}
+		lines = append(lines[:i], append([]string{l}, lines[i:]...)...)
+	}
+	// place adds content after or before the anchor lines, or at location;
+	// with ensure, only the line next to the anchor counts as present.
+	place := func(ensure bool) error {
+		after, err := f.lineAnchor("after", lines)
+		if err != nil {
+			return err
+		}
+		before, err := f.lineAnchor("before", lines)
+		if err != nil {
+			return err
+		}
+		switch {
+		case after >= 0:
+			if ensure && after+1 < len(lines) && strings.TrimSpace(lines[after+1]) == want {
+				return nil
+			}
+			if !ensure && present() {
+				return nil
+			}
+			insertAt(after+1, lines[after])
+		case before >= 0:
+			if ensure && before > 0 && strings.TrimSpace(lines[before-1]) == want {
+				return nil
+			}
+			if !ensure && present() {
+				return nil
+			}
+			insertAt(before, lines[before])
+		case present():
+		case location == "start":
+			insertAt(0, "")
+		default:
+			insertAt(len(lines), "")
+		}
+		return nil
+	}
+
+	original := strings.Join(lines, "\n")
+	switch mode {
+	case "ensure":
+		err = place(true)
+	case "insert":
+		err = place(false)
+	case "replace", "delete":
+		var re *regexp.Regexp
+		if s, _ := f.params["match"].(string); s != "" {
+			if re, err = f.compilePattern("match"); err != nil {
+				break
+			}
+		} else if mode == "replace" {
+			err = fmt.Errorf("match is required to replace lines")
+			break
+		} else if content == "" {
+			err = types.ErrMissingContent
+			break
+		}
+		kept := lines[:0:0]
+		for _, l := range lines {
+			matches := strings.TrimSpace(l) == want
+			if re != nil {
+				matches = re.MatchString(l)
+			}
+			switch {
+			case !matches:
+				kept = append(kept, l)
+			case mode == "replace" && indent:
+				kept = append(kept, leadingSpace(l)+strings.TrimLeft(content, " \t"))
+			case mode == "replace":
+				kept = append(kept, content)
+			}
+		}
+		lines = kept
+	default:
+		err = errors.Join(ErrInvalidLineMode, fmt.Errorf("mode %q is not one of ensure, insert, replace or delete", mode))
+	}
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+
+	desired := current
+	if strings.Join(lines, "\n") != original {
+		desired = []byte{}
+		if len(lines) > 0 {
+			desired = []byte(strings.Join(lines, "\n") + "\n")
+		}
+	}
+	return f.writeEdited(name, current, desired, exists, test)
+}
@@ -0,0 +1,144 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		params   map[string]interface{}
+		test     bool
+		expected string
+		changed  bool
+		error    error
+	}{
+		{
+			name:     "EnsureAfter",
+			existing: "[Service]\n    Type=simple\n    ExecStart=/bin/app\n",
+			params:   map[string]interface{}{"mode": "ensure", "content": "User=app", "after": `^\s*Type=`},
+			expected: "[Service]\n    Type=simple\n    User=app\n    ExecStart=/bin/app\n",
+			changed:  true,
+		},
+		{
+			name:     "EnsureBefore",
+			existing: "a\nc\n",
+			params:   map[string]interface{}{"mode": "ensure", "content": "b", "before": "^c$"},
+			expected: "a\nb\nc\n",
+			changed:  true,
+		},
+		{
+			name:     "EnsureEnd",
+			existing: "a\n",
+			params:   map[string]interface{}{"mode": "ensure", "content": "b"},
+			expected: "a\nb\n",
+			changed:  true,
+		},
+		{
+			name:     "EnsurePresent",
+			existing: "a\n  b\n",
+			params:   map[string]interface{}{"mode": "ensure", "content": "b"},
+			expected: "a\n  b\n",
+		},
+		{
+			name:     "InsertStart",
+			existing: "a\n",
+			params:   map[string]interface{}{"mode": "insert", "content": "#!/bin/sh", "location": "start"},
+			expected: "#!/bin/sh\na\n",
+			changed:  true,
+		},
+		{
+			name:     "InsertPresent",
+			existing: "a\nb\nc\n",
+			params:   map[string]interface{}{"mode": "insert", "content": "b", "after": "^c$"},
+			expected: "a\nb\nc\n",
+		},
+		{
+			name:     "Replace",
+			existing: "  PermitRootLogin yes\nPort 22\n",
+			params:   map[string]interface{}{"mode": "replace", "content": "PermitRootLogin no", "match": "^\\s*PermitRootLogin"},
+			expected: "  PermitRootLogin no\nPort 22\n",
+			changed:  true,
+		},
+		{
+			name:     "DeleteMatch",
+			existing: "a\n# b\nc\n# d\n",
+			params:   map[string]interface{}{"mode": "delete", "match": "^#"},
+			expected: "a\nc\n",
+			changed:  true,
+		},
+		{
+			name:     "DeleteContent",
+			existing: "a\n b \n",
+			params:   map[string]interface{}{"mode": "delete", "content": "b"},
+			expected: "a\n",
+			changed:  true,
+		},
+		{
+			name:     "Test",
+			existing: "a\n",
+			params:   map[string]interface{}{"mode": "ensure", "content": "b"},
+			test:     true,
+			expected: "a\n",
+			changed:  true,
+		},
+		{
+			name:     "AnchorNotFound",
+			existing: "a\n",
+			params:   map[string]interface{}{"mode": "ensure", "content": "b", "after": "^x$"},
+			error:    ErrPatternNotFound,
+		},
+		{
+			name:     "BadMode",
+			existing: "a\n",
+			params:   map[string]interface{}{"mode": "upsert", "content": "b"},
+			error:    ErrInvalidLineMode,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "settings")
+			if err := os.WriteFile(name, []byte(test.existing), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			test.params["name"] = name
+			f := File{id: test.name, method: "line", params: test.params}
+			apply := f.Apply
+			if test.test {
+				apply = f.Test
+			}
+			res, err := apply(context.Background())
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if res.Changed != test.changed {
+				t.Errorf("expected changed to be %v, got %v: %v", test.changed, res.Changed, res.Notes)
+			}
+			content, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != test.expected {
+				t.Errorf("expected\n%s\ngot\n%s", test.expected, content)
+			}
+			if test.test {
+				return
+			}
+			res, err = f.Apply(context.Background())
+			if err != nil || res.Changed {
+				t.Errorf("expected a second run to change nothing, got %v %v", err, res.Notes)
+			}
+		})
+	}
+}
@@ -1,37 +1,326 @@
 package file
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/cook"
 	"github.com/gogrlx/grlx/types"
 )
 
+// managed ensures name has the contents of source, and optionally the
+// given ownership and mode.
+//
+// if template is true, the source is rendered with the recipe template engine
+// (with context as its data) before it is compared or written
+// if create is false, a missing file is left missing
+// if replace is false, an existing file keeps its contents and only its
+// ownership and mode are managed
+// if backup is set, the previous contents are copied aside before being
+// replaced; true keeps name.bak, a string names the backup path
+// if follow_symlinks is false and name is a symlink, the link itself is
+// replaced with a regular file
+// if makedirs is true, missing parent directories are created with dir_mode
+// if show_changes is true (the default), a diff of the contents is reported
 func (f File) managed(ctx context.Context, test bool) (types.Result, error) {
-	// TODO
-	// "name": "string", "source": "string", "source_hash": "string", "user": "string",
-	// "group": "string", "mode": "string", "attrs": "string", "template": "bool",
-	// "makedirs": "bool", "dir_mode": "string", "replace": "bool", "backup": "string", "show_changes": "bool",
-	// "create":          "bool",
-	// "follow_symlinks": "bool", "skip_verify": "bool",
-
-	return f.undef()
+	notes := []fmt.Stringer{}
 	name, ok := f.params["name"].(string)
 	if !ok {
 		return types.Result{
-			Succeeded: false, Failed: true,
+			Succeeded: false, Failed: true, Notes: notes,
 		}, types.ErrMissingName
 	}
 	name = filepath.Clean(name)
-	if name == "" {
+	if name == "" || name == "." {
 		return types.Result{
-			Succeeded: false, Failed: true,
+			Succeeded: false, Failed: true, Notes: notes,
 		}, types.ErrMissingName
 	}
 	if name == "/" {
 		return types.Result{
-			Succeeded: false, Failed: true,
+			Succeeded: false, Failed: true, Notes: notes,
 		}, types.ErrModifyRoot
 	}
-	return f.undef()
+	if src, ok := f.params["source"].(string); !ok || src == "" {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, types.ErrMissingSource
+	}
+	create, replace, followSymlinks := true, true, true
+	if v, ok := f.params["create"].(bool); ok {
+		create = v
+	}
+	if v, ok := f.params["replace"].(bool); ok {
+		replace = v
+	}
+	if v, ok := f.params["follow_symlinks"].(bool); ok {
+		followSymlinks = v
+	}
+	makedirs, _ := f.params["makedirs"].(bool)
+	owner, err := f.parseOwnership("mode")
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+
+	// work out what is there at the moment
+	exists := false
+	isLink := false
+	var current []byte
+	if fi, err := os.Lstat(name); err == nil {
+		exists = true
+		if fi.Mode()&os.ModeSymlink != 0 {
+			isLink = true
+			if followSymlinks {
+				target, err := filepath.EvalSymlinks(name)
+				if err != nil {
+					return types.Result{
+						Succeeded: false, Failed: true, Notes: notes,
+					}, err
+				}
+				name, isLink = target, false
+				fi, err = os.Stat(name)
+				if err != nil {
+					return types.Result{
+						Succeeded: false, Failed: true, Notes: notes,
+					}, err
+				}
+			}
+		}
+		if fi.IsDir() {
+			notes = append(notes, types.Snprintf("%s is a directory", name))
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, fmt.Errorf("cannot manage directory %s as a file", name)
+		}
+		if !isLink {
+			current, err = os.ReadFile(name)
+			if err != nil {
+				return types.Result{
+					Succeeded: false, Failed: true, Notes: notes,
+				}, err
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	if !exists && !create {
+		notes = append(notes, types.Snprintf("%s does not exist and create is false", name))
+		return types.Result{
+			Succeeded: true, Failed: false, Notes: notes,
+		}, nil
+	}
+
+	// work out what should be there
+	var desired []byte
+	if !exists || replace {
+		cachedPaths, cacheRes, err := f.cacheSources(ctx, test)
+		notes = append(notes, cacheRes.Notes...)
+		if test && errors.Is(err, ErrNotCached) {
+			return uncachedResult(name, exists, notes), nil
+		}
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+		desired, err = f.renderSources(name, cachedPaths)
+		if err != nil {
+			notes = append(notes, types.Snprintf("failed to render %s", name))
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+	}
+
+	changed := false
+	contentChanged := (!exists || replace) && (isLink || !bytes.Equal(current, desired))
+	if contentChanged {
+		changed = true
+		dirNotes, err := f.ensureParent(name, makedirs, test)
+		notes = append(notes, dirNotes...)
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+		diff := f.diffNotes(name, current, desired, exists && !isLink)
+		if test {
+			notes = append(notes, diff...)
+			if exists {
+				notes = append(notes, types.Snprintf("%s would be updated", name))
+			} else {
+				notes = append(notes, types.Snprintf("%s would be created", name))
+			}
+		} else {
+			if exists && !isLink {
+				backupNote, err := f.backup(name)
+				if err != nil {
+					return types.Result{
+						Succeeded: false, Failed: true, Notes: notes,
+					}, err
+				}
+				if backupNote != nil {
+					notes = append(notes, backupNote)
+				}
+			}
+			if err = writeFileAtomic(name, desired, isLink); err != nil {
+				return types.Result{
+					Succeeded: false, Failed: true, Notes: notes,
+				}, err
+			}
+			notes = append(notes, diff...)
+			if exists {
+				notes = append(notes, types.Snprintf("%s has been updated", name))
+			} else {
+				notes = append(notes, types.Snprintf("%s has been created", name))
+			}
+		}
+	}
+	ownerChanged, ownerNotes, err := owner.apply(name, test)
+	notes = append(notes, ownerNotes...)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Changed: changed, Notes: notes,
+		}, err
+	}
+	changed = changed || ownerChanged
+	if !changed {
+		notes = append(notes, types.Snprintf("%s is already in the correct state", name))
+	}
+	return types.Result{
+		Succeeded: true, Failed: false,
+		Changed: changed, Notes: notes,
+	}, nil
+}
+
+// renderSources concatenates the cached sources, rendering them as
+// templates if requested.
+func (f File) renderSources(name string, cachedPaths []string) ([]byte, error) {
+	var content bytes.Buffer
+	for _, p := range cachedPaths {
+		src, err := os.Open(p)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(&content, src)
+		src.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if tmpl, _ := f.params["template"].(bool); !tmpl {
+		return content.Bytes(), nil
+	}
+	data, _ := f.params["context"].(map[string]interface{})
+	return cook.RenderTemplate(config.SproutID, name, content.Bytes(), data)
+}
+
+// ensureParent makes sure the directory name will be written into exists.
+func (f File) ensureParent(name string, makedirs, test bool) ([]fmt.Stringer, error) {
+	var notes []fmt.Stringer
+	dir := filepath.Dir(name)
+	_, err := os.Stat(dir)
+	if err == nil {
+		return notes, nil
+	}
+	if !os.IsNotExist(err) {
+		return notes, err
+	}
+	if !makedirs {
+		return notes, errors.Join(types.ErrPathNotFound, fmt.Errorf("parent directory %s does not exist and makedirs is false", dir))
+	}
+	dirMode := os.FileMode(0o755)
+	if v, ok := f.params["dir_mode"]; ok && v != nil {
+		dirMode, err = parseMode(v)
+		if err != nil {
+			return notes, err
+		}
+	}
+	if test {
+		notes = append(notes, types.Snprintf("would create directory %s", dir))
+		return notes, nil
+	}
+	if err = os.MkdirAll(dir, dirMode); err != nil {
+		return notes, err
+	}
+	notes = append(notes, types.Snprintf("created directory %s", dir))
+	return notes, nil
+}
+
+// backup copies name aside if the backup property asks for it. The
+// schema decodes backup as a string, so "true" and "false" keep their
+// boolean meaning rather than naming a backup file.
+func (f File) backup(name string) (fmt.Stringer, error) {
+	backupName := ""
+	switch b := f.params["backup"].(type) {
+	case bool:
+		if b {
+			backupName = name + ".bak"
+		}
+	case string:
+		switch b {
+		case "true":
+			backupName = name + ".bak"
+		case "false":
+		default:
+			backupName = b
+		}
+	}
+	if backupName == "" {
+		return nil, nil
+	}
+	contents, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(backupName, contents, fi.Mode()&os.ModePerm); err != nil {
+		return nil, err
+	}
+	return types.Snprintf("backed up %s to %s", name, backupName), nil
+}
+
+// WriteFileAtomic replaces name with contents so that readers never see a
+// partial file, keeping the mode of any existing file. It is used by
+// ingredients which manage system configuration files.
+func WriteFileAtomic(name string, contents []byte) error {
+	return writeFileAtomic(name, contents, false)
+}
+
+// writeFileAtomic writes contents to a temporary file beside name and
+// renames it into place, keeping the mode of any existing file.
+func writeFileAtomic(name string, contents []byte, replaceLink bool) error {
+	mode := os.FileMode(0o644)
+	if fi, err := os.Stat(name); err == nil && !replaceLink {
+		mode = fi.Mode() & os.ModePerm
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(contents)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
 }
@@ -4,36 +4,47 @@ import (
 	"context"
 	"crypto/md5"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/ingredients"
 	"github.com/gogrlx/grlx/types"
 )
 
 func TestManaged(t *testing.T) {
-	// TODO: Determine how to set up the farmer local file system path
 	tempDir := t.TempDir()
-	existingFile := filepath.Join(tempDir, "managed-file")
-	f, err := os.Create(existingFile)
-	if err != nil {
+	config.CacheDir = filepath.Join(tempDir, "cache")
+	if err := os.MkdirAll(config.CacheDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		config.CacheDir = ""
+	}()
+	sourceContent := "This is the managed file content\n"
+	source := filepath.Join(tempDir, "managed-source")
+	if err := os.WriteFile(source, []byte(sourceContent), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	defer f.Close()
-	f.WriteString("This is the existing file content")
-	h := md5.New()
-	if _, err := io.Copy(h, f); err != nil {
+	hashString := fmt.Sprintf("md5:%x", md5.Sum([]byte(sourceContent)))
+	templateSource := filepath.Join(tempDir, "managed-template")
+	if err := os.WriteFile(templateSource, []byte("listen {{ .port }}\n"), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	existingFileHash := h.Sum(nil)
-	hashString := fmt.Sprintf("md5:%x", existingFileHash)
+
+	existingFile := filepath.Join(tempDir, "managed-file")
+	upToDateFile := filepath.Join(tempDir, "up-to-date")
+	missingFile := filepath.Join(tempDir, "missing-file")
+	nestedFile := filepath.Join(tempDir, "nested", "dir", "managed-file")
+	templateFile := filepath.Join(tempDir, "templated")
 	tests := []struct {
 		name     string
 		params   map[string]interface{}
 		expected types.Result
 		error    error
 		test     bool
+		content  string
 	}{
 		{
 			name: "incorrect name",
@@ -61,94 +72,172 @@ func TestManaged(t *testing.T) {
 			error: types.ErrModifyRoot,
 		},
 		{
-			name: "Simple case",
+			name: "missing source",
+			params: map[string]interface{}{
+				"name": existingFile,
+			},
+			expected: types.Result{
+				Succeeded: false,
+				Failed:    true,
+				Notes:     []fmt.Stringer{},
+			},
+			error: types.ErrMissingSource,
+		},
+		{
+			name: "Simple case test",
 			params: map[string]interface{}{
 				"name":        existingFile,
-				"source":      "grlx://test/managed-file",
+				"source":      source,
 				"skip_verify": true,
 			},
 			expected: types.Result{
 				Succeeded: true,
 				Failed:    false,
 				Changed:   true,
-				// TODO: Notes not implemented yet for file.managed
-				Notes: []fmt.Stringer{},
+				Notes: []fmt.Stringer{
+					types.Snprintf("%s would be cached", skipVerifyDest(source)),
+					types.Snprintf("%s would be updated", existingFile),
+				},
 			},
-			error: nil,
-			test:  false,
+			test:    true,
+			content: "This is the existing file content",
 		},
 		{
 			name: "Simple case with backup",
 			params: map[string]interface{}{
-				"name":        existingFile,
-				"source":      "grlx://test/managed-file",
-				"skip_verify": true,
-				"backup":      true,
+				"name":         existingFile,
+				"source":       source,
+				"skip_verify":  true,
+				"backup":       true,
+				"show_changes": false,
 			},
 			expected: types.Result{
 				Succeeded: true,
 				Failed:    false,
 				Changed:   true,
-				// TODO: Notes not implemented yet for file.managed
-				Notes: []fmt.Stringer{},
+				Notes: []fmt.Stringer{
+					types.Snprintf("%s has been cached", skipVerifyDest(source)),
+					types.Snprintf("backed up %s to %s.bak", existingFile, existingFile),
+					types.Snprintf("%s has been updated", existingFile),
+				},
 			},
-			error: nil,
-			test:  false,
+			content: sourceContent,
 		},
 		{
 			name: "Simple case with source_hash",
 			params: map[string]interface{}{
-				"name":        existingFile,
-				"source":      "grlx://test/managed-file",
+				"name":        upToDateFile,
+				"source":      source,
 				"source_hash": hashString,
 			},
+			expected: types.Result{
+				Succeeded: true,
+				Failed:    false,
+				Changed:   false,
+				Notes: []fmt.Stringer{
+					types.Snprintf("%s has been cached", filepath.Join(config.CacheDir, hashString)),
+					types.Snprintf("%s is already in the correct state", upToDateFile),
+				},
+			},
+			content: sourceContent,
+		},
+		{
+			name: "Simple case with mode",
+			params: map[string]interface{}{
+				"name":        upToDateFile,
+				"source":      source,
+				"source_hash": hashString,
+				"mode":        0o600,
+			},
 			expected: types.Result{
 				Succeeded: true,
 				Failed:    false,
 				Changed:   true,
-				// TODO: Notes not implemented yet for file.managed
-				Notes: []fmt.Stringer{},
+				Notes: []fmt.Stringer{
+					types.Snprintf("chmod %s to 0600", upToDateFile),
+				},
 			},
-			error: nil,
-			test:  false,
+			content: sourceContent,
 		},
 		{
-			// TODO: Verify that this is the expected behavior
 			name: "Simple case no create",
 			params: map[string]interface{}{
-				"name":        existingFile,
-				"source":      "grlx://test/managed-file",
+				"name":        missingFile,
+				"source":      source,
 				"source_hash": hashString,
 				"create":      false,
 			},
+			expected: types.Result{
+				Succeeded: true,
+				Failed:    false,
+				Changed:   false,
+				Notes: []fmt.Stringer{
+					types.Snprintf("%s does not exist and create is false", missingFile),
+				},
+			},
+		},
+		{
+			name: "missing parent directory",
+			params: map[string]interface{}{
+				"name":        nestedFile,
+				"source":      source,
+				"source_hash": hashString,
+			},
+			expected: types.Result{
+				Succeeded: false,
+				Failed:    true,
+				Notes:     []fmt.Stringer{},
+			},
+			error: fmt.Errorf("%w\n%w", types.ErrPathNotFound, fmt.Errorf("parent directory %s does not exist and makedirs is false", filepath.Dir(nestedFile))),
+		},
+		{
+			name: "makedirs",
+			params: map[string]interface{}{
+				"name":        nestedFile,
+				"source":      source,
+				"source_hash": hashString,
+				"makedirs":    true,
+			},
 			expected: types.Result{
 				Succeeded: true,
 				Failed:    false,
 				Changed:   true,
-				// TODO: Notes not implemented yet for file.managed
-				Notes: []fmt.Stringer{},
+				Notes: []fmt.Stringer{
+					types.Snprintf("created directory %s", filepath.Dir(nestedFile)),
+					types.Snprintf("--- /dev/null\n+++ %s\n@@ -0,0 +1 @@\n+This is the managed file content", nestedFile),
+					types.Snprintf("%s has been created", nestedFile),
+				},
 			},
-			error: nil,
-			test:  false,
+			content: sourceContent,
 		},
 		{
-			name: "External case",
+			name: "template",
 			params: map[string]interface{}{
-				"name":        existingFile,
-				"source":      "https://releases.grlx.dev/linux/amd64/v1.0.0/grlx",
-				"source_hash": "md5:0f9847d3b437488309329463b1454f40",
+				"name":         templateFile,
+				"source":       templateSource,
+				"skip_verify":  true,
+				"template":     true,
+				"context":      map[string]interface{}{"port": 8080},
+				"show_changes": false,
 			},
 			expected: types.Result{
 				Succeeded: true,
 				Failed:    false,
 				Changed:   true,
-				// TODO: Notes not implemented yet for file.managed
-				Notes: []fmt.Stringer{},
+				Notes: []fmt.Stringer{
+					types.Snprintf("%s has been cached", skipVerifyDest(templateSource)),
+					types.Snprintf("%s has been created", templateFile),
+				},
 			},
-			error: nil,
-			test:  false,
+			content: "listen 8080\n",
 		},
 	}
+	if err := os.WriteFile(existingFile, []byte("This is the existing file content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(upToDateFile, []byte(sourceContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			f := File{
@@ -157,10 +246,68 @@ func TestManaged(t *testing.T) {
 				params: test.params,
 			}
 			result, err := f.managed(context.TODO(), test.test)
-			if test.error != nil && err.Error() != test.error.Error() {
+			if test.error != nil && (err == nil || err.Error() != test.error.Error()) {
 				t.Errorf("expected error %v, got %v", test.error, err)
+			} else if test.error == nil && err != nil {
+				t.Errorf("expected no error, got %v", err)
 			}
 			compareResults(t, result, test.expected)
+			if test.content != "" {
+				name := test.params["name"].(string)
+				b, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != test.content {
+					t.Errorf("expected %s to contain %q, got %q", name, test.content, string(b))
+				}
+			}
 		})
 	}
 }
+
+// backup: true must survive the schema, which decodes backup as a string
+func TestManagedBackupDecoded(t *testing.T) {
+	tempDir := t.TempDir()
+	config.CacheDir = filepath.Join(tempDir, "cache")
+	if err := os.MkdirAll(config.CacheDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		config.CacheDir = ""
+	}()
+	source := filepath.Join(tempDir, "source")
+	if err := os.WriteFile(source, []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, backup := range []bool{true, false} {
+		name := filepath.Join(tempDir, fmt.Sprintf("managed-%v", backup))
+		if err := os.WriteFile(name, []byte("old\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cooker, err := ingredients.NewRecipeCooker("backup", "file", "managed", map[string]interface{}{
+			"name":        name,
+			"source":      source,
+			"source_hash": fmt.Sprintf("md5:%x", md5.Sum([]byte("new\n"))),
+			"backup":      backup,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = cooker.Apply(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(name + ".bak")
+		if backup && string(b) != "old\n" {
+			t.Errorf("expected %s.bak to hold the old contents, got %q, %v", name, b, err)
+		} else if !backup && !os.IsNotExist(err) {
+			t.Errorf("expected no backup of %s, got %v", name, err)
+		}
+		for _, stray := range []string{"true", "false"} {
+			if _, err = os.Stat(stray); err == nil {
+				os.Remove(stray)
+				t.Errorf("expected no backup written to %s", stray)
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// ownership is the desired owner, group and mode of a path. Unset fields
+// are left alone.
+type ownership struct {
+	user    string
+	group   string
+	uid     int
+	gid     int
+	mode    os.FileMode
+	hasMode bool
+}
+
+// parseMode reads a mode property. Strings are octal, as in "644", while
+// numbers have already been read by YAML or JSON, so an unquoted 0644
+// arrives as 420 and is used as it is. An unquoted 644 can't be told
+// apart from 0o1204, so numbers above 0o777 are refused.
+func parseMode(v interface{}) (os.FileMode, error) {
+	var mode uint64
+	switch v := v.(type) {
+	case string:
+		var err error
+		mode, err = strconv.ParseUint(v, 8, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid mode %s: %w", v, err)
+		}
+	case int:
+		if v < 0 || v > 0o777 {
+			return 0, fmt.Errorf("invalid mode %d, write it as 0644 or quote it", v)
+		}
+		mode = uint64(v)
+	case float64:
+		if v < 0 || v > 0o777 || v != float64(int(v)) {
+			return 0, fmt.Errorf("invalid mode %v, write it as 0644 or quote it", v)
+		}
+		mode = uint64(v)
+	default:
+		return 0, fmt.Errorf("invalid mode %v", v)
+	}
+	return os.FileMode(mode) & os.ModePerm, nil
+}
+
+// parseOwnership builds an ownership from the user, group and modeKey
+// properties, resolving names to IDs.
+func (f File) parseOwnership(modeKey string) (ownership, error) {
+	o := ownership{uid: -1, gid: -1}
+	if name, ok := f.params["user"].(string); ok && name != "" {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return o, err
+		}
+		uid, err := strconv.Atoi(u.Uid)
+		if err != nil {
+			return o, err
+		}
+		o.user, o.uid = name, uid
+	}
+	if name, ok := f.params["group"].(string); ok && name != "" {
+		g, err := user.LookupGroup(name)
+		if err != nil {
+			return o, err
+		}
+		gid, err := strconv.Atoi(g.Gid)
+		if err != nil {
+			return o, err
+		}
+		o.group, o.gid = name, gid
+	}
+	if v, ok := f.params[modeKey]; ok && v != nil {
+		mode, err := parseMode(v)
+		if err != nil {
+			return o, err
+		}
+		o.mode, o.hasMode = mode, true
+	}
+	return o, nil
+}
+
+// preserveFrom fills in the fields of o which are unset from fi, so that
+// a copy keeps the owner, group and mode of its source.
+func (o ownership) preserveFrom(fi os.FileInfo) ownership {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		if o.uid == -1 {
+			o.uid, o.user = int(st.Uid), strconv.Itoa(int(st.Uid))
+			if u, err := user.LookupId(o.user); err == nil {
+				o.user = u.Username
+			}
+		}
+		if o.gid == -1 {
+			o.gid, o.group = int(st.Gid), strconv.Itoa(int(st.Gid))
+			if g, err := user.LookupGroupId(o.group); err == nil {
+				o.group = g.Name
+			}
+		}
+	}
+	if !o.hasMode {
+		o.mode, o.hasMode = fi.Mode()&os.ModePerm, true
+	}
+	return o
+}
+
+// apply brings the ownership and mode of name in line with o, only
+// touching what differs. In test mode the changes are reported but not
+// made. A path that does not exist yet is reported as if it had no owner,
+// so test mode can describe what would happen to a file about to be
+// created.
+func (o ownership) apply(name string, test bool) (bool, []fmt.Stringer, error) {
+	var notes []fmt.Stringer
+	curUID, curGID := -1, -1
+	var curMode os.FileMode
+	fi, err := os.Stat(name)
+	if err == nil {
+		curMode = fi.Mode() & os.ModePerm
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+			curUID, curGID = int(st.Uid), int(st.Gid)
+		}
+	} else if !os.IsNotExist(err) || !test {
+		return false, notes, err
+	}
+	changed := false
+	chownUID, chownGID := -1, -1
+	if o.uid != -1 && o.uid != curUID {
+		chownUID = o.uid
+		if test {
+			notes = append(notes, types.Snprintf("would chown %s to %s", name, o.user))
+		} else {
+			notes = append(notes, types.Snprintf("chown %s to %s", name, o.user))
+		}
+	}
+	if o.gid != -1 && o.gid != curGID {
+		chownGID = o.gid
+		if test {
+			notes = append(notes, types.Snprintf("would chgrp %s to %s", name, o.group))
+		} else {
+			notes = append(notes, types.Snprintf("chgrp %s to %s", name, o.group))
+		}
+	}
+	if chownUID != -1 || chownGID != -1 {
+		changed = true
+		if !test {
+			if err := os.Chown(name, chownUID, chownGID); err != nil {
+				return false, notes, err
+			}
+		}
+	}
+	if o.hasMode && o.mode != curMode {
+		changed = true
+		if test {
+			notes = append(notes, types.Snprintf("would chmod %s to %04o", name, o.mode))
+		} else {
+			if err := os.Chmod(name, o.mode); err != nil {
+				return false, notes, err
+			}
+			notes = append(notes, types.Snprintf("chmod %s to %04o", name, o.mode))
+		}
+	}
+	return changed, notes, nil
+}
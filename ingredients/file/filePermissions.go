@@ -0,0 +1,68 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// permissions sets the owner, group and mode of an existing path without
+// touching its contents. With recurse, everything under a directory is
+// changed too; symlinks are skipped rather than followed. Directories
+// take dir_mode and files file_mode, both falling back to mode.
+func (f File) permissions(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	name, err := f.pathParam("name", types.ErrMissingName)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	recurse, _ := f.params["recurse"].(bool)
+	owners := map[bool]ownership{}
+	for isDir, key := range map[bool]string{true: "dir_mode", false: "file_mode"} {
+		if _, ok := f.params[key]; !ok {
+			key = "mode"
+		}
+		if owners[isDir], err = f.parseOwnership(key); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+	}
+	info, err := os.Stat(name)
+	if os.IsNotExist(err) {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, errors.Join(types.ErrFileNotFound, fmt.Errorf("%s does not exist", name))
+	} else if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+
+	changed := false
+	apply := func(p string, isDir bool) error {
+		c, pathNotes, err := owners[isDir].apply(p, test)
+		notes = append(notes, pathNotes...)
+		changed = changed || c
+		return err
+	}
+	if !recurse || !info.IsDir() {
+		err = apply(name, info.IsDir())
+	} else {
+		err = filepath.WalkDir(name, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type()&fs.ModeSymlink != 0 {
+				return nil
+			}
+			return apply(p, d.IsDir())
+		})
+	}
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Changed: changed, Notes: notes}, err
+	}
+	if !changed {
+		notes = append(notes, types.Snprintf("permissions of %s are already correct", name))
+	}
+	return types.Result{Succeeded: true, Failed: false, Changed: changed, Notes: notes}, nil
+}
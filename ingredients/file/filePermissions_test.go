@@ -0,0 +1,150 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+func TestPermissions(t *testing.T) {
+	tempDir := t.TempDir()
+	single := filepath.Join(tempDir, "single")
+	os.WriteFile(single, []byte("hello\n"), 0o600)
+	tree := filepath.Join(tempDir, "tree")
+	sub := filepath.Join(tree, "sub")
+	os.MkdirAll(sub, 0o700)
+	os.Chmod(tree, 0o700)
+	leaf := filepath.Join(sub, "leaf")
+	os.WriteFile(leaf, []byte("leaf\n"), 0o600)
+	os.Symlink(single, filepath.Join(tree, "link"))
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		expected types.Result
+		modes    map[string]os.FileMode
+		error    error
+		test     bool
+	}{
+		{
+			name:     "DoesNotExist",
+			params:   map[string]interface{}{"name": filepath.Join(tempDir, "nothing"), "mode": "644"},
+			expected: types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{}},
+			error:    types.ErrFileNotFound,
+		},
+		{
+			name:   "TestMode",
+			params: map[string]interface{}{"name": single, "mode": "644"},
+			expected: types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{
+				types.Snprintf("would chmod %s to 0644", single),
+			}},
+			modes: map[string]os.FileMode{single: 0o600},
+			test:  true,
+		},
+		{
+			name:   "Mode",
+			params: map[string]interface{}{"name": single, "mode": 0o644},
+			expected: types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{
+				types.Snprintf("chmod %s to 0644", single),
+			}},
+			modes: map[string]os.FileMode{single: 0o644},
+		},
+		{
+			name:   "AlreadyCorrect",
+			params: map[string]interface{}{"name": single, "mode": "0644"},
+			expected: types.Result{Succeeded: true, Failed: false, Notes: []fmt.Stringer{
+				types.Snprintf("permissions of %s are already correct", single),
+			}},
+			modes: map[string]os.FileMode{single: 0o644},
+		},
+		{
+			name:   "Recurse",
+			params: map[string]interface{}{"name": tree, "recurse": true, "dir_mode": "755", "file_mode": "640"},
+			expected: types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{
+				types.Snprintf("chmod %s to 0755", tree),
+				types.Snprintf("chmod %s to 0755", sub),
+				types.Snprintf("chmod %s to 0640", leaf),
+			}},
+			modes: map[string]os.FileMode{tree: 0o755, sub: 0o755, leaf: 0o640, single: 0o644},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := File{id: "", method: "permissions", params: test.params}
+			var result types.Result
+			var err error
+			if test.test {
+				result, err = f.Test(context.TODO())
+			} else {
+				result, err = f.Apply(context.TODO())
+			}
+			if !errors.Is(err, test.error) {
+				t.Fatalf("expected error `%v`, got `%v`", test.error, err)
+			}
+			compareResults(t, result, test.expected)
+			if result.Changed != test.expected.Changed {
+				t.Errorf("expected changed to be %v, got %v", test.expected.Changed, result.Changed)
+			}
+			for name, mode := range test.modes {
+				fi, _ := os.Stat(name)
+				if fi.Mode().Perm() != mode {
+					t.Errorf("expected %s to have mode %04o, got %04o", name, mode, fi.Mode().Perm())
+				}
+			}
+		})
+	}
+}
+
+func TestPermissionsDecodedMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		recipe   string
+		expected os.FileMode
+		error    error
+	}{
+		{name: "LeadingZero", recipe: "mode: 0644", expected: 0o644},
+		{name: "Octal", recipe: "mode: 0o640", expected: 0o640},
+		{name: "Quoted", recipe: `mode: "644"`, expected: 0o644},
+		{name: "NoLeadingZero", recipe: "mode: 644", error: ingredients.ErrInvalidProperty},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "file")
+			if err := os.WriteFile(name, []byte("hello\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			params := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(test.recipe), &params); err != nil {
+				t.Fatal(err)
+			}
+			params["name"] = name
+			cooker, err := ingredients.NewRecipeCooker("mode", "file", "permissions", params)
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = cooker.Apply(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			fi, err := os.Stat(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Mode().Perm() != test.expected {
+				t.Errorf("expected mode %04o, got %04o", test.expected, fi.Mode().Perm())
+			}
+		})
+	}
+}
@@ -2,17 +2,19 @@ package file
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/gogrlx/grlx/types"
 )
 
+// prepend adds any lines of text, source or sources which are not already
+// in the file to the start of it, creating the file if needed.
 func (f File) prepend(ctx context.Context, test bool) (types.Result, error) {
 	// TODO
-	// "name": "string", "text": "[]string", "makedirs": "bool",
-	// "source": "string", "source_hash": "string",
-	// "template": "bool", "sources": "[]string",
+	// "template": "bool",
 	notes := []fmt.Stringer{}
 
 	name, ok := f.params["name"].(string)
@@ -32,6 +34,59 @@ func (f File) prepend(ctx context.Context, test bool) (types.Result, error) {
 			Succeeded: false, Failed: true, Notes: notes,
 		}, types.ErrModifyRoot
 	}
-
-	return f.undef()
+	res, missing, err := f.contains(ctx, test)
+	notes = append(notes, res.Notes...)
+	if err == nil {
+		return types.Result{
+			Succeeded: res.Succeeded, Failed: res.Failed,
+			Changed: res.Changed, Notes: notes,
+		}, err
+	}
+	var current []byte
+	exists := true
+	switch {
+	case os.IsNotExist(err):
+		exists = false
+		makedirs, _ := f.params["makedirs"].(bool)
+		dirNotes, err := f.ensureParent(name, makedirs, test)
+		notes = append(notes, dirNotes...)
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+	case errors.Is(err, types.ErrMissingContent):
+		current, err = os.ReadFile(name)
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+	default:
+		return types.Result{
+			Succeeded: false, Failed: true,
+			Changed: false, Notes: notes,
+		}, err
+	}
+	desired := append(missing.Bytes(), current...)
+	diff := f.diffNotes(name, current, desired, exists)
+	if test {
+		notes = append(notes, diff...)
+		notes = append(notes, types.Snprintf("would prepend %v", name))
+		return types.Result{
+			Succeeded: true, Failed: false,
+			Changed: true, Notes: notes,
+		}, nil
+	}
+	if err = writeFileAtomic(name, desired, false); err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	notes = append(notes, diff...)
+	notes = append(notes, types.Snprintf("prepended %v", name))
+	return types.Result{
+		Succeeded: true, Failed: false,
+		Changed: true, Notes: notes,
+	}, nil
 }
@@ -0,0 +1,273 @@
+package file
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/types"
+)
+
+var ErrNotListable = errors.New("source cannot be listed as a directory")
+
+// globList reads a property holding one glob pattern or a list of them.
+func (f File) globList(prop string) ([]string, error) {
+	patterns := []string{}
+	switch v := f.params[prop].(type) {
+	case nil:
+	case string:
+		patterns = append(patterns, v)
+	case []string:
+		patterns = append(patterns, v...)
+	case []interface{}:
+		for _, p := range v {
+			patterns = append(patterns, fmt.Sprintf("%v", p))
+		}
+	default:
+		return nil, fmt.Errorf("%s must be a glob pattern or a list of them", prop)
+	}
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %s: %w", prop, p, err)
+		}
+	}
+	return patterns, nil
+}
+
+// matchesAny reports whether the slash-separated relative path rel, or any
+// directory above it, matches one of patterns. Patterns without a slash
+// are matched against each name in the path, so `*.swp` or `.git` match at
+// any depth.
+func matchesAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		for sub := rel; sub != "."; sub = path.Dir(sub) {
+			target := sub
+			if !strings.Contains(p, "/") {
+				target = path.Base(sub)
+			}
+			if ok, _ := path.Match(p, target); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cleanDir removes everything under name which is neither in keep (or a
+// directory leading to something in keep) nor excluded.
+func (f File) cleanDir(name string, keep map[string]bool, exclude []string, test bool) (bool, []fmt.Stringer, error) {
+	var notes []fmt.Stringer
+	keepDirs := map[string]bool{}
+	for rel := range keep {
+		for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+			keepDirs[dir] = true
+		}
+	}
+	changed := false
+	err := filepath.WalkDir(name, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == name {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if p == name {
+			return nil
+		}
+		rel, err := filepath.Rel(name, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if keep[rel] || keepDirs[rel] {
+			return nil
+		}
+		if matchesAny(exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		changed = true
+		if test {
+			notes = append(notes, types.Snprintf("%s would be removed", p))
+		} else {
+			if err = os.RemoveAll(p); err != nil {
+				return err
+			}
+			notes = append(notes, types.Snprintf("%s has been removed", p))
+		}
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return changed, notes, err
+}
+
+// recurse mirrors the directory tree at source into name. source must come
+// from a file provider which can list directories. Only files matching
+// include (if set) and not matching exclude are copied; with clean, files
+// under name which aren't in the source are removed, unless excluded.
+//
+// Files are written with file_mode, directories created with dir_mode, and
+// both are given user and group. With template, each file is rendered as
+// a template. Every file that changes gets its own notes.
+func (f File) recurse(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	name, ok := f.params["name"].(string)
+	if !ok {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, types.ErrMissingName
+	}
+	name = filepath.Clean(name)
+	if name == "" || name == "." {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, types.ErrMissingName
+	}
+	if name == "/" {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, types.ErrModifyRoot
+	}
+	source, ok := f.params["source"].(string)
+	if !ok || source == "" {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, types.ErrMissingSource
+	}
+	source = strings.TrimSuffix(source, "/")
+	include, err := f.globList("include")
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	exclude, err := f.globList("exclude")
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	dirOwner, err := f.parseOwnership("dir_mode")
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+
+	// source files are staged in the cache, keyed by source, so that each
+	// can be compared with its destination
+	sum := sha256.Sum256([]byte(source))
+	stage := filepath.Join(config.CacheDir, "recurse-"+hex.EncodeToString(sum[:8]))
+	fp, err := NewFileProvider(f.id, source, stage, "", f.params)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	lister, ok := fp.(types.DirectoryProvider)
+	if !ok {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, errors.Join(ErrNotListable, fmt.Errorf("the provider for %s cannot list directories", source))
+	}
+	listed, err := lister.List(ctx)
+	if err != nil {
+		notes = append(notes, types.Snprintf("failed to list %s", source))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	files := []string{}
+	for _, rel := range listed {
+		if (len(include) > 0 && !matchesAny(include, rel)) || matchesAny(exclude, rel) {
+			continue
+		}
+		files = append(files, rel)
+	}
+	sort.Strings(files)
+
+	changed := false
+	if _, err = os.Stat(name); os.IsNotExist(err) {
+		changed = true
+		if test {
+			notes = append(notes, types.Snprintf("would create directory %s", name))
+		} else {
+			if err = os.MkdirAll(name, 0o755); err != nil {
+				return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+			}
+			notes = append(notes, types.Snprintf("created directory %s", name))
+		}
+	} else if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+
+	keep := map[string]bool{}
+	dirs := map[string]bool{name: true}
+	for _, rel := range files {
+		keep[rel] = true
+		dest := filepath.Join(name, filepath.FromSlash(rel))
+		for dir := filepath.Dir(dest); dir != name; dir = filepath.Dir(dir) {
+			dirs[dir] = true
+		}
+		staged := filepath.Join(stage, filepath.FromSlash(rel))
+		if err = os.MkdirAll(filepath.Dir(staged), 0o700); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Changed: changed, Notes: notes}, err
+		}
+		p, err := NewFileProvider(f.id, source+"/"+rel, staged, "", f.params)
+		if err == nil {
+			err = p.Download(ctx)
+		}
+		if err != nil {
+			notes = append(notes, types.Snprintf("failed to fetch %s", source+"/"+rel))
+			return types.Result{Succeeded: false, Failed: true, Changed: changed, Notes: notes}, err
+		}
+		desired, err := f.renderSources(dest, []string{staged})
+		if err != nil {
+			notes = append(notes, types.Snprintf("failed to render %s", dest))
+			return types.Result{Succeeded: false, Failed: true, Changed: changed, Notes: notes}, err
+		}
+		params := map[string]interface{}{"name": dest, "makedirs": true}
+		for _, k := range []string{"user", "group", "dir_mode", "backup", "show_changes"} {
+			if v, ok := f.params[k]; ok {
+				params[k] = v
+			}
+		}
+		if v, ok := f.params["file_mode"]; ok {
+			params["mode"] = v
+		}
+		target := File{id: f.id, method: "recurse", params: params}
+		current, err := os.ReadFile(dest)
+		exists := err == nil
+		if err != nil && !os.IsNotExist(err) {
+			return types.Result{Succeeded: false, Failed: true, Changed: changed, Notes: notes}, err
+		}
+		res, err := target.writeEdited(dest, current, desired, exists, test)
+		if res.Changed || err != nil {
+			notes = append(notes, res.Notes...)
+		}
+		if err != nil {
+			return types.Result{Succeeded: false, Failed: true, Changed: changed, Notes: notes}, err
+		}
+		changed = changed || res.Changed
+	}
+
+	sortedDirs := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sortedDirs = append(sortedDirs, dir)
+	}
+	sort.Strings(sortedDirs)
+	for _, dir := range sortedDirs {
+		dirChanged, dirNotes, err := dirOwner.apply(dir, test)
+		notes = append(notes, dirNotes...)
+		if err != nil {
+			return types.Result{Succeeded: false, Failed: true, Changed: changed, Notes: notes}, err
+		}
+		changed = changed || dirChanged
+	}
+
+	if clean, _ := f.params["clean"].(bool); clean {
+		cleaned, cleanNotes, err := f.cleanDir(name, keep, exclude, test)
+		notes = append(notes, cleanNotes...)
+		if err != nil {
+			return types.Result{Succeeded: false, Failed: true, Changed: changed, Notes: notes}, err
+		}
+		changed = changed || cleaned
+	}
+	if !changed {
+		notes = append(notes, types.Snprintf("%s is already in the correct state", name))
+	}
+	return types.Result{Succeeded: true, Failed: false, Changed: changed, Notes: notes}, nil
+}
@@ -0,0 +1,275 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	nats "github.com/nats-io/nats.go"
+
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/ingredients/file/farmer"
+)
+
+// writeTree creates the files in tree, keyed by slash-separated path,
+// under root.
+func writeTree(t *testing.T, root string, tree map[string]string) {
+	t.Helper()
+	for rel, content := range tree {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree returns the files under root, keyed by slash-separated path.
+func readTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	tree := map[string]string{}
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		tree[filepath.ToSlash(rel)] = string(b)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestRecurse(t *testing.T) {
+	config.CacheDir = t.TempDir()
+	t.Cleanup(func() {
+		config.CacheDir = ""
+	})
+	source := map[string]string{
+		"nginx.conf":          "worker_processes 4;\n",
+		"sites/default.conf":  "server {}\n",
+		"sites/app.conf.swp":  "swap\n",
+		"sites/app/site.conf": "server { listen 8080; }\n",
+	}
+	tests := []struct {
+		name     string
+		existing map[string]string
+		params   map[string]interface{}
+		test     bool
+		expected map[string]string
+		changed  bool
+		error    error
+	}{
+		{
+			name:   "Create",
+			params: map[string]interface{}{"exclude": "*.swp"},
+			expected: map[string]string{
+				"nginx.conf":          "worker_processes 4;\n",
+				"sites/default.conf":  "server {}\n",
+				"sites/app/site.conf": "server { listen 8080; }\n",
+			},
+			changed: true,
+		},
+		{
+			name:     "Include",
+			existing: map[string]string{"old.conf": "x\n"},
+			params:   map[string]interface{}{"include": []interface{}{"sites/app"}},
+			expected: map[string]string{
+				"old.conf":            "x\n",
+				"sites/app/site.conf": "server { listen 8080; }\n",
+			},
+			changed: true,
+		},
+		{
+			name: "Clean",
+			existing: map[string]string{
+				"nginx.conf":        "worker_processes 1;\n",
+				"sites/old.conf":    "old\n",
+				"stale/a.conf":      "stale\n",
+				"keep/local.conf":   "local\n",
+				"sites/default.bak": "backup\n",
+			},
+			params: map[string]interface{}{"clean": true, "exclude": []interface{}{"*.swp", "keep", "*.bak"}},
+			expected: map[string]string{
+				"nginx.conf":          "worker_processes 4;\n",
+				"sites/default.conf":  "server {}\n",
+				"sites/app/site.conf": "server { listen 8080; }\n",
+				"keep/local.conf":     "local\n",
+				"sites/default.bak":   "backup\n",
+			},
+			changed: true,
+		},
+		{
+			name:     "Test",
+			existing: map[string]string{"extra": "x\n"},
+			params:   map[string]interface{}{"clean": true},
+			test:     true,
+			expected: map[string]string{"extra": "x\n"},
+			changed:  true,
+		},
+		{
+			name:   "NotListable",
+			params: map[string]interface{}{"source": "https://example.com/sites/"},
+			error:  ErrNotListable,
+		},
+		{
+			name:   "FarmerNotConnected",
+			params: map[string]interface{}{"source": "grlx://nginx/sites/"},
+			error:  farmer.ErrNotConnected,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := filepath.Join(t.TempDir(), "src")
+			writeTree(t, src, source)
+			dest := filepath.Join(t.TempDir(), "dest")
+			if test.existing != nil {
+				writeTree(t, dest, test.existing)
+			}
+			test.params["name"] = dest
+			if _, ok := test.params["source"]; !ok {
+				test.params["source"] = src + "/"
+			}
+			f := File{id: test.name, method: "recurse", params: test.params}
+			apply := f.Apply
+			if test.test {
+				apply = f.Test
+			}
+			res, err := apply(context.Background())
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if res.Changed != test.changed {
+				t.Errorf("expected changed to be %v, got %v: %v", test.changed, res.Changed, res.Notes)
+			}
+			got := map[string]string{}
+			if _, err := os.Stat(dest); err == nil {
+				got = readTree(t, dest)
+			}
+			if len(got) != len(test.expected) {
+				t.Errorf("expected files %v, got %v", test.expected, got)
+			}
+			for rel, content := range test.expected {
+				if got[rel] != content {
+					t.Errorf("expected %s to hold %q, got %q", rel, content, got[rel])
+				}
+			}
+			if test.test {
+				return
+			}
+			res, err = f.Apply(context.Background())
+			if err != nil || res.Changed {
+				t.Errorf("expected a second run to change nothing, got %v %v", err, res.Notes)
+			}
+		})
+	}
+}
+
+func TestRecurseNotes(t *testing.T) {
+	config.CacheDir = t.TempDir()
+	t.Cleanup(func() {
+		config.CacheDir = ""
+	})
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, map[string]string{"a": "new\n", "b": "same\n"})
+	dest := t.TempDir()
+	writeTree(t, dest, map[string]string{"a": "old\n", "b": "same\n", "c": "gone\n"})
+	f := File{id: "notes", method: "recurse", params: map[string]interface{}{
+		"name": dest, "source": src, "clean": true, "show_changes": false,
+	}}
+	res, err := f.Test(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := []string{}
+	for _, n := range res.Notes {
+		notes = append(notes, strings.TrimPrefix(n.String(), dest+"/"))
+	}
+	sort.Strings(notes)
+	expected := []string{"a would be updated", "c would be removed"}
+	if strings.Join(notes, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected notes %v, got %v", expected, notes)
+	}
+}
+
+func TestRecurseFarmerSource(t *testing.T) {
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	defer srv.Shutdown()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server did not start")
+	}
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := nats.NewEncodedConn(nc, nats.JSON_ENCODER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err = farmer.Serve(conn); err != nil {
+		t.Fatal(err)
+	}
+	farmer.RegisterEC(conn)
+	defer farmer.RegisterEC(nil)
+	recipeDir, cacheDir, sproutID := config.RecipeDir, config.CacheDir, config.SproutID
+	t.Cleanup(func() {
+		config.RecipeDir, config.CacheDir, config.SproutID = recipeDir, cacheDir, sproutID
+	})
+	config.RecipeDir = t.TempDir()
+	config.CacheDir = t.TempDir()
+	config.SproutID = "web-03"
+	writeTree(t, config.RecipeDir, map[string]string{
+		"nginx/sites/default.conf": "server {}\n",
+		"nginx/sites/app/app.conf": "server { listen 8080; }\n",
+		"nginx/nginx.conf":         "worker_processes 4;\n",
+	})
+
+	dest := filepath.Join(t.TempDir(), "sites")
+	f := File{id: "farmer", method: "recurse", params: map[string]interface{}{"name": dest, "source": "grlx://nginx/sites/"}}
+	res, err := f.Apply(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !res.Changed {
+		t.Errorf("expected a change, got %v", res.Notes)
+	}
+	expected := map[string]string{"default.conf": "server {}\n", "app/app.conf": "server { listen 8080; }\n"}
+	got := readTree(t, dest)
+	if len(got) != len(expected) {
+		t.Errorf("expected files %v, got %v", expected, got)
+	}
+	for rel, content := range expected {
+		if got[rel] != content {
+			t.Errorf("expected %s to hold %q, got %q", rel, content, got[rel])
+		}
+	}
+	res, err = f.Apply(context.Background())
+	if err != nil || res.Changed {
+		t.Errorf("expected a second run to change nothing, got %v %v", err, res.Notes)
+	}
+}
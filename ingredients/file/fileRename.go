@@ -0,0 +1,82 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// rename moves source to name. Once source is gone and name exists, the
+// move is taken to have happened already. An existing name is only
+// replaced if force is set. Regular files are copied and removed when
+// source and name are on different filesystems.
+func (f File) rename(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	name, err := f.pathParam("name", types.ErrMissingName)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	source, err := f.pathParam("source", types.ErrMissingSource)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if name == source {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, fmt.Errorf("cannot rename %s onto itself", name)
+	}
+	force, _ := f.params["force"].(bool)
+	makedirs, _ := f.params["makedirs"].(bool)
+	srcInfo, srcErr := os.Lstat(source)
+	if srcErr != nil && !os.IsNotExist(srcErr) {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, srcErr
+	}
+	_, destErr := os.Lstat(name)
+	if destErr != nil && !os.IsNotExist(destErr) {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, destErr
+	}
+	switch {
+	case os.IsNotExist(srcErr) && destErr == nil:
+		notes = append(notes, types.Snprintf("%s has already been moved to %s", source, name))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	case os.IsNotExist(srcErr):
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, errors.Join(types.ErrFileNotFound, fmt.Errorf("source %s does not exist", source))
+	case destErr == nil && !force:
+		notes = append(notes, types.Snprintf("%s already exists; set force to replace it", name))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, ErrDestinationExists
+	}
+
+	parentNotes, err := f.ensureParent(name, makedirs, test)
+	notes = append(notes, parentNotes...)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if test {
+		if destErr == nil {
+			notes = append(notes, types.Snprintf("would replace %s", name))
+		}
+		notes = append(notes, types.Snprintf("would move %s to %s", source, name))
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+	if destErr == nil {
+		// rename only replaces files and empty directories by itself
+		if err = os.RemoveAll(name); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		notes = append(notes, types.Snprintf("removed %s", name))
+	}
+	err = os.Rename(source, name)
+	if errors.Is(err, syscall.EXDEV) && srcInfo.Mode().IsRegular() {
+		if err = copyFile(source, name, srcInfo.Mode()&os.ModePerm); err == nil {
+			err = os.Remove(source)
+		}
+	}
+	if err != nil {
+		notes = append(notes, types.Snprintf("failed to move %s to %s", source, name))
+		return types.Result{Succeeded: false, Failed: true, Changed: destErr == nil, Notes: notes}, err
+	}
+	notes = append(notes, types.Snprintf("moved %s to %s", source, name))
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+}
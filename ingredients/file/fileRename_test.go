@@ -0,0 +1,106 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+func TestRename(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "source")
+	os.WriteFile(source, []byte("hello\n"), 0o644)
+	moved := filepath.Join(tempDir, "moved")
+	occupied := filepath.Join(tempDir, "occupied")
+	os.WriteFile(occupied, []byte("goodbye\n"), 0o644)
+	other := filepath.Join(tempDir, "other")
+	os.WriteFile(other, []byte("other\n"), 0o644)
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		expected types.Result
+		error    error
+		test     bool
+	}{
+		{
+			name:     "RenameRoot",
+			params:   map[string]interface{}{"name": "/", "source": source},
+			expected: types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{}},
+			error:    types.ErrModifyRoot,
+		},
+		{
+			name:   "TestRename",
+			params: map[string]interface{}{"name": moved, "source": source},
+			expected: types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{
+				types.Snprintf("would move %s to %s", source, moved),
+			}},
+			test: true,
+		},
+		{
+			name:   "Rename",
+			params: map[string]interface{}{"name": moved, "source": source},
+			expected: types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{
+				types.Snprintf("moved %s to %s", source, moved),
+			}},
+		},
+		{
+			name:   "AlreadyRenamed",
+			params: map[string]interface{}{"name": moved, "source": source},
+			expected: types.Result{Succeeded: true, Failed: false, Notes: []fmt.Stringer{
+				types.Snprintf("%s has already been moved to %s", source, moved),
+			}},
+		},
+		{
+			name:     "NeitherExists",
+			params:   map[string]interface{}{"name": filepath.Join(tempDir, "nowhere"), "source": source},
+			expected: types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{}},
+			error:    types.ErrFileNotFound,
+		},
+		{
+			name:   "DestinationExists",
+			params: map[string]interface{}{"name": occupied, "source": other},
+			expected: types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{
+				types.Snprintf("%s already exists; set force to replace it", occupied),
+			}},
+			error: ErrDestinationExists,
+		},
+		{
+			name:   "Force",
+			params: map[string]interface{}{"name": occupied, "source": other, "force": true},
+			expected: types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{
+				types.Snprintf("removed %s", occupied),
+				types.Snprintf("moved %s to %s", other, occupied),
+			}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := File{id: "", method: "rename", params: test.params}
+			var result types.Result
+			var err error
+			if test.test {
+				result, err = f.Test(context.TODO())
+			} else {
+				result, err = f.Apply(context.TODO())
+			}
+			if !errors.Is(err, test.error) {
+				t.Fatalf("expected error `%v`, got `%v`", test.error, err)
+			}
+			compareResults(t, result, test.expected)
+			if result.Changed != test.expected.Changed {
+				t.Errorf("expected changed to be %v, got %v", test.expected.Changed, result.Changed)
+			}
+		})
+	}
+	if content, _ := os.ReadFile(moved); string(content) != "hello\n" {
+		t.Errorf("expected %s to hold the moved file, got %q", moved, content)
+	}
+	if content, _ := os.ReadFile(occupied); string(content) != "other\n" {
+		t.Errorf("expected %s to be replaced, got %q", occupied, content)
+	}
+}
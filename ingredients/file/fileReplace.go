@@ -0,0 +1,174 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrInvalidFlag     = errors.New("invalid regular expression flag")
+	ErrInvalidCount    = errors.New("count must be a whole number")
+	ErrPatternNotFound = errors.New("pattern not found")
+)
+
+// regexFlags maps the flag names used in recipes to Go's inline flags.
+var regexFlags = map[string]string{
+	"IGNORECASE": "i",
+	"MULTILINE":  "m",
+	"DOTALL":     "s",
+	"UNGREEDY":   "U",
+}
+
+// compilePattern compiles the named property, with the flags property
+// applied to it.
+func (f File) compilePattern(prop string) (*regexp.Regexp, error) {
+	pattern, ok := f.params[prop].(string)
+	if !ok || pattern == "" {
+		return nil, fmt.Errorf("%s must be a regular expression", prop)
+	}
+	var flags []string
+	switch v := f.params["flags"].(type) {
+	case []interface{}:
+		for _, flag := range v {
+			flags = append(flags, fmt.Sprintf("%v", flag))
+		}
+	case []string:
+		flags = v
+	case string:
+		flags = strings.Split(v, ",")
+	}
+	inline := ""
+	for _, flag := range flags {
+		flag = strings.ToUpper(strings.TrimSpace(flag))
+		if flag == "" {
+			continue
+		}
+		letter, ok := regexFlags[flag]
+		if !ok {
+			return nil, errors.Join(ErrInvalidFlag, fmt.Errorf("flag %s is not one of IGNORECASE, MULTILINE, DOTALL or UNGREEDY", flag))
+		}
+		if !strings.Contains(inline, letter) {
+			inline += letter
+		}
+	}
+	if inline != "" {
+		pattern = "(?" + inline + ")" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// replaceCount reads the count property; 0 means every match.
+func (f File) replaceCount() (int, error) {
+	var count int
+	switch v := f.params["count"].(type) {
+	case nil:
+		return 0, nil
+	case int:
+		count = v
+	case float64:
+		count = int(v)
+		if float64(count) != v {
+			return 0, ErrInvalidCount
+		}
+	case string:
+		var err error
+		if count, err = strconv.Atoi(v); err != nil {
+			return 0, errors.Join(ErrInvalidCount, err)
+		}
+	default:
+		return 0, ErrInvalidCount
+	}
+	if count < 0 {
+		return 0, ErrInvalidCount
+	}
+	return count, nil
+}
+
+// replaceMatches replaces the first count matches of re in content with
+// repl, which may refer to groups as $1 or ${name}. It returns the new
+// content and the number of matches.
+func replaceMatches(re *regexp.Regexp, content, repl string, count int) (string, int) {
+	n := -1
+	if count > 0 {
+		n = count
+	}
+	matches := re.FindAllStringSubmatchIndex(content, n)
+	if len(matches) == 0 {
+		return content, 0
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(content[last:m[0]])
+		b.Write(re.ExpandString(nil, repl, content, m))
+		last = m[1]
+	}
+	b.WriteString(content[last:])
+	return b.String(), len(matches)
+}
+
+// replace edits name in place, replacing matches of the regular expression
+// in pattern with repl. count limits the number of replacements, and flags
+// (IGNORECASE, MULTILINE, DOTALL, UNGREEDY) change how pattern matches.
+// Matches beyond count are left for later runs to replace.
+//
+// If nothing matches, append_if_not_found or prepend_if_not_found add
+// not_found_content (by default repl) to the file, unless it is already
+// there, so that a replacement which no longer matches its own pattern is
+// still only made once.
+func (f File) replace(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	name, current, exists, err := f.readTarget()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if !exists {
+		notes = append(notes, types.Snprintf("%s does not exist", name))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, types.ErrFileNotFound
+	}
+	re, err := f.compilePattern("pattern")
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	repl, ok := f.params["repl"].(string)
+	if !ok {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, fmt.Errorf("repl must be a string")
+	}
+	count, err := f.replaceCount()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+
+	edited, matched := replaceMatches(re, string(current), repl, count)
+	if matched == 0 {
+		appendMissing, _ := f.params["append_if_not_found"].(bool)
+		prependMissing, _ := f.params["prepend_if_not_found"].(bool)
+		missing, ok := f.params["not_found_content"].(string)
+		if !ok || missing == "" {
+			missing = repl
+		}
+		missing = strings.TrimSuffix(missing, "\n")
+		switch {
+		case !appendMissing && !prependMissing:
+			notes = append(notes, types.Snprintf("pattern %s was not found in %s", re, name))
+		case strings.Contains(edited, missing):
+			notes = append(notes, types.Snprintf("pattern %s was not found, but %s already holds its replacement", re, name))
+		case prependMissing:
+			edited = missing + "\n" + edited
+		default:
+			if edited != "" && !strings.HasSuffix(edited, "\n") {
+				edited += "\n"
+			}
+			edited += missing + "\n"
+		}
+	}
+	res, err := f.writeEdited(name, current, []byte(edited), exists, test)
+	res.Notes = append(notes, res.Notes...)
+	return res, err
+}
@@ -0,0 +1,148 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+func TestReplace(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		params   map[string]interface{}
+		test     bool
+		expected string
+		changed  bool
+		error    error
+		// a second run changes the file again
+		repeats bool
+	}{
+		{
+			name:     "All",
+			existing: "listen 80;\nlisten 8080;\n",
+			params:   map[string]interface{}{"pattern": `listen (\d+);`, "repl": "listen $1 ssl;"},
+			expected: "listen 80 ssl;\nlisten 8080 ssl;\n",
+			changed:  true,
+		},
+		{
+			name:     "Count",
+			existing: "a a a\n",
+			params:   map[string]interface{}{"pattern": "a", "repl": "b", "count": 2},
+			expected: "b b a\n",
+			changed:  true,
+			repeats:  true,
+		},
+		{
+			name:     "Flags",
+			existing: "Debug=on\n",
+			params:   map[string]interface{}{"pattern": "^debug=.*$", "repl": "debug=off", "flags": []interface{}{"ignorecase", "MULTILINE"}},
+			expected: "debug=off\n",
+			changed:  true,
+		},
+		{
+			name:     "Unchanged",
+			existing: "debug=off\n",
+			params:   map[string]interface{}{"pattern": "debug=.*", "repl": "debug=off"},
+			expected: "debug=off\n",
+		},
+		{
+			name:     "NotFound",
+			existing: "a=1\n",
+			params:   map[string]interface{}{"pattern": "b=.*", "repl": "b=2"},
+			expected: "a=1\n",
+		},
+		{
+			name:     "Append",
+			existing: "a=1",
+			params:   map[string]interface{}{"pattern": "^#?b=.*$", "repl": "b=2", "flags": "MULTILINE", "append_if_not_found": true},
+			expected: "a=1\nb=2\n",
+			changed:  true,
+		},
+		{
+			name:     "AppendOnce",
+			existing: "a=1\nexport B=2\n",
+			params:   map[string]interface{}{"pattern": "^B=.*$", "repl": "B=2", "not_found_content": "export B=2", "flags": "MULTILINE", "append_if_not_found": true},
+			expected: "a=1\nexport B=2\n",
+		},
+		{
+			name:     "Prepend",
+			existing: "a=1\n",
+			params:   map[string]interface{}{"pattern": "b=.*", "repl": "b=2", "prepend_if_not_found": true},
+			expected: "b=2\na=1\n",
+			changed:  true,
+		},
+		{
+			name:     "Test",
+			existing: "a=1\n",
+			params:   map[string]interface{}{"pattern": "1", "repl": "2"},
+			test:     true,
+			expected: "a=1\n",
+			changed:  true,
+		},
+		{
+			name:     "BadFlag",
+			existing: "a=1\n",
+			params:   map[string]interface{}{"pattern": "1", "repl": "2", "flags": []interface{}{"VERBOSE"}},
+			error:    ErrInvalidFlag,
+		},
+		{
+			name:     "BadCount",
+			existing: "a=1\n",
+			params:   map[string]interface{}{"pattern": "1", "repl": "2", "count": -1},
+			error:    ErrInvalidCount,
+		},
+		{
+			name:   "Missing",
+			params: map[string]interface{}{"pattern": "1", "repl": "2"},
+			error:  types.ErrFileNotFound,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "settings")
+			if test.existing != "" {
+				if err := os.WriteFile(name, []byte(test.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			test.params["name"] = name
+			f := File{id: test.name, method: "replace", params: test.params}
+			apply := f.Apply
+			if test.test {
+				apply = f.Test
+			}
+			res, err := apply(context.Background())
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if res.Changed != test.changed {
+				t.Errorf("expected changed to be %v, got %v: %v", test.changed, res.Changed, res.Notes)
+			}
+			content, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != test.expected {
+				t.Errorf("expected\n%s\ngot\n%s", test.expected, content)
+			}
+			if test.test || test.repeats {
+				return
+			}
+			res, err = f.Apply(context.Background())
+			if err != nil || res.Changed {
+				t.Errorf("expected a second run to change nothing, got %v %v", err, res.Notes)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrUnknownFormat    = errors.New("unknown serialization format")
+	ErrUnsupportedValue = errors.New("value cannot be stored in this format")
+)
+
+// document is a config file parsed into a form which can be edited and
+// written back. Formats which can keep comments and key order do.
+type document interface {
+	// set writes every value of dataset, merging nested maps into the
+	// existing ones. With prune, keys missing from dataset are removed,
+	// so that the document holds exactly dataset.
+	set(dataset map[string]interface{}, prune bool) error
+	// remove deletes the key at path, if it exists.
+	remove(path []string)
+	// data returns the parsed contents, for comparing before and after.
+	data() (interface{}, error)
+	render() ([]byte, error)
+}
+
+// serializeFormat returns the format property, or guesses it from the
+// file extension.
+func (f File) serializeFormat(name string) (string, error) {
+	format, _ := f.params["format"].(string)
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".json":
+			format = "json"
+		case ".yaml", ".yml":
+			format = "yaml"
+		case ".toml":
+			format = "toml"
+		case ".ini", ".cfg", ".conf":
+			format = "ini"
+		}
+	}
+	switch format {
+	case "json", "yaml", "toml", "ini":
+		return format, nil
+	case "":
+		return "", errors.Join(ErrUnknownFormat, fmt.Errorf("cannot guess the format of %s, set format", name))
+	default:
+		return "", errors.Join(ErrUnknownFormat, fmt.Errorf("format %s is not one of json, yaml, toml or ini", format))
+	}
+}
+
+func loadDocument(format string, content []byte) (document, error) {
+	switch format {
+	case "json", "yaml":
+		return loadNodeDocument(content, format == "json")
+	case "toml":
+		return loadTOMLDocument(content)
+	case "ini":
+		return loadINIDocument(content), nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// readTarget reads the file an editing method works on, which may not
+// exist yet.
+func (f File) readTarget() (string, []byte, bool, error) {
+	name, ok := f.params["name"].(string)
+	if !ok {
+		return "", nil, false, types.ErrMissingName
+	}
+	name = filepath.Clean(name)
+	if name == "" || name == "." {
+		return "", nil, false, types.ErrMissingName
+	}
+	if name == "/" {
+		return "", nil, false, types.ErrModifyRoot
+	}
+	current, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		return name, nil, false, nil
+	}
+	if err != nil {
+		return name, nil, false, err
+	}
+	return name, current, true, nil
+}
+
+// removePaths reads the remove property as dotted key paths.
+func (f File) removePaths() [][]string {
+	paths := [][]string{}
+	switch v := f.params["remove"].(type) {
+	case []interface{}:
+		for _, p := range v {
+			paths = append(paths, strings.Split(fmt.Sprintf("%v", p), "."))
+		}
+	case []string:
+		for _, p := range v {
+			paths = append(paths, strings.Split(p, "."))
+		}
+	case string:
+		paths = append(paths, strings.Split(v, "."))
+	}
+	return paths
+}
+
+// serialize edits a JSON, YAML, TOML or INI file. The keys in dataset are
+// set, merging into nested maps, and the keys in remove (as dotted paths)
+// are deleted. Unless merge is true, keys which are not in dataset are
+// removed as well, so the file holds exactly dataset.
+//
+// The file is only written if its data changes; formatting differences
+// alone are left alone. Comments and key order survive in YAML, JSON (which
+// has no comments) and INI files, but not in TOML files, which are
+// rewritten in a canonical form when they change.
+func (f File) serialize(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	name, current, exists, err := f.readTarget()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	format, err := f.serializeFormat(name)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	dataset, ok := f.params["dataset"].(map[string]interface{})
+	if !ok {
+		if _, set := f.params["dataset"]; set {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, fmt.Errorf("dataset must be a map")
+		}
+		dataset = map[string]interface{}{}
+	}
+	merge, _ := f.params["merge"].(bool)
+	doc, err := loadDocument(format, current)
+	if err != nil {
+		notes = append(notes, types.Snprintf("failed to parse %s as %s", name, format))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	before, err := doc.data()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if err = doc.set(dataset, !merge); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	for _, path := range f.removePaths() {
+		doc.remove(path)
+	}
+	after, err := doc.data()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	desired := current
+	if !exists || !sameData(before, after) {
+		if desired, err = doc.render(); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+	}
+	return f.writeEdited(name, current, desired, exists, test)
+}
+
+// writeEdited writes the edited contents of name if they differ from the
+// current ones, then applies the user, group and mode properties.
+func (f File) writeEdited(name string, current, desired []byte, exists, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	modeKey := "mode"
+	if f.method == "line" {
+		// line uses mode for what to do with the line
+		modeKey = "file_mode"
+	}
+	owner, err := f.parseOwnership(modeKey)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	changed := !exists || !bytes.Equal(current, desired)
+	if changed {
+		makedirs, _ := f.params["makedirs"].(bool)
+		dirNotes, err := f.ensureParent(name, makedirs, test)
+		notes = append(notes, dirNotes...)
+		if err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		diff := f.diffNotes(name, current, desired, exists)
+		if test {
+			notes = append(notes, diff...)
+			if exists {
+				notes = append(notes, types.Snprintf("%s would be updated", name))
+			} else {
+				notes = append(notes, types.Snprintf("%s would be created", name))
+			}
+		} else {
+			if exists {
+				backupNote, err := f.backup(name)
+				if err != nil {
+					return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+				}
+				if backupNote != nil {
+					notes = append(notes, backupNote)
+				}
+			}
+			if err = writeFileAtomic(name, desired, false); err != nil {
+				return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+			}
+			notes = append(notes, diff...)
+			if exists {
+				notes = append(notes, types.Snprintf("%s has been updated", name))
+			} else {
+				notes = append(notes, types.Snprintf("%s has been created", name))
+			}
+		}
+	}
+	ownerChanged, ownerNotes, err := owner.apply(name, test)
+	notes = append(notes, ownerNotes...)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Changed: changed, Notes: notes}, err
+	}
+	changed = changed || ownerChanged
+	if !changed {
+		notes = append(notes, types.Snprintf("%s is already in the correct state", name))
+	}
+	return types.Result{Succeeded: true, Failed: false, Changed: changed, Notes: notes}, nil
+}
+
+// sameData compares parsed documents. Both sides are passed through JSON
+// first, so that an int from a recipe matches the int64 or float64 a
+// parser produced for the same number.
+func sameData(a, b interface{}) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func normalize(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var n interface{}
+	if err = json.Unmarshal(b, &n); err != nil {
+		return v
+	}
+	return n
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeMaps sets every value of src in dst, recursing into maps present
+// in both.
+func mergeMaps(dst, src map[string]interface{}, prune bool) {
+	for k, v := range src {
+		sub, isMap := v.(map[string]interface{})
+		existing, hasMap := dst[k].(map[string]interface{})
+		if isMap && hasMap {
+			mergeMaps(existing, sub, prune)
+			continue
+		}
+		dst[k] = v
+	}
+	if prune {
+		for k := range dst {
+			if _, ok := src[k]; !ok {
+				delete(dst, k)
+			}
+		}
+	}
+}
+
+func removeFromMap(m map[string]interface{}, path []string) {
+	for len(path) > 1 {
+		sub, ok := m[path[0]].(map[string]interface{})
+		if !ok {
+			return
+		}
+		m, path = sub, path[1:]
+	}
+	if len(path) == 1 {
+		delete(m, path[0])
+	}
+}
+
+// tomlDocument holds a decoded TOML file. The TOML encoder has no way to
+// keep comments, so they are lost when the file changes.
+type tomlDocument struct {
+	m map[string]interface{}
+}
+
+func loadTOMLDocument(content []byte) (*tomlDocument, error) {
+	m := map[string]interface{}{}
+	if _, err := toml.Decode(string(content), &m); err != nil {
+		return nil, err
+	}
+	return &tomlDocument{m: m}, nil
+}
+
+func (d *tomlDocument) set(dataset map[string]interface{}, prune bool) error {
+	mergeMaps(d.m, dataset, prune)
+	return nil
+}
+
+func (d *tomlDocument) remove(path []string) {
+	removeFromMap(d.m, path)
+}
+
+func (d *tomlDocument) data() (interface{}, error) {
+	// a copy, since set and remove change d.m in place
+	return normalize(d.m), nil
+}
+
+func (d *tomlDocument) render() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(d.m); err != nil {
+		return nil, errors.Join(ErrUnsupportedValue, err)
+	}
+	return buf.Bytes(), nil
+}
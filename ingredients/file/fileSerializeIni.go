@@ -0,0 +1,256 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+)
+
+// iniDocument edits an INI file line by line, so that comments, blank
+// lines and the order of everything it doesn't touch are kept. Keys before
+// the first section header are global.
+type iniDocument struct {
+	lines []string
+}
+
+func loadINIDocument(content []byte) *iniDocument {
+	return &iniDocument{lines: splitContentLines(string(content))}
+}
+
+// splitContentLines splits content into lines without their newlines.
+func splitContentLines(content string) []string {
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return []string{}
+	}
+	return strings.Split(content, "\n")
+}
+
+func iniSection(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+		return strings.TrimSpace(trimmed[1 : len(trimmed)-1]), true
+	}
+	return "", false
+}
+
+func iniKey(line string) (string, string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+		return "", "", false
+	}
+	key, value, ok := strings.Cut(trimmed, "=")
+	if !ok {
+		return "", "", false
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value), true
+}
+
+// sectionRange returns the lines holding the keys of section, from after
+// its header to the next header. The global section always exists.
+func (d *iniDocument) sectionRange(section string) (int, int, bool) {
+	start, found := 0, section == ""
+	for i, line := range d.lines {
+		name, ok := iniSection(line)
+		if !ok {
+			continue
+		}
+		if found {
+			return start, i, true
+		}
+		if name == section {
+			start, found = i+1, true
+		}
+	}
+	if !found {
+		return 0, 0, false
+	}
+	return start, len(d.lines), true
+}
+
+// separator copies the spacing around = used elsewhere in the file.
+func (d *iniDocument) separator() string {
+	for _, line := range d.lines {
+		if _, _, ok := iniKey(line); ok && !strings.Contains(line, " = ") {
+			return "="
+		} else if ok {
+			return " = "
+		}
+	}
+	return " = "
+}
+
+func (d *iniDocument) setKey(section, key, value string) {
+	start, end, ok := d.sectionRange(section)
+	if !ok {
+		if len(d.lines) > 0 {
+			d.lines = append(d.lines, "")
+		}
+		d.lines = append(d.lines, "["+section+"]", key+d.separator()+value)
+		return
+	}
+	for i := start; i < end; i++ {
+		k, v, ok := iniKey(d.lines[i])
+		if !ok || k != key {
+			continue
+		}
+		if v != value {
+			// keep the key and the spacing around =
+			line := d.lines[i]
+			eq := strings.Index(line, "=") + 1
+			rest := line[eq:]
+			d.lines[i] = line[:eq] + rest[:len(rest)-len(strings.TrimLeft(rest, " \t"))] + value
+		}
+		return
+	}
+	// add the key after the last non-blank line of the section
+	at := end
+	for at > start && strings.TrimSpace(d.lines[at-1]) == "" {
+		at--
+	}
+	d.lines = append(d.lines[:at], append([]string{key + d.separator() + value}, d.lines[at:]...)...)
+}
+
+func (d *iniDocument) removeKey(section, key string) {
+	start, end, ok := d.sectionRange(section)
+	if !ok {
+		return
+	}
+	for i := end - 1; i >= start; i-- {
+		if k, _, ok := iniKey(d.lines[i]); ok && k == key {
+			d.lines = append(d.lines[:i], d.lines[i+1:]...)
+		}
+	}
+}
+
+func (d *iniDocument) removeSection(section string) {
+	start, end, ok := d.sectionRange(section)
+	if !ok || section == "" {
+		return
+	}
+	// the blank lines before the next section go with this one; at the
+	// end of the file, take those separating it from the previous section
+	header := start - 1
+	for end == len(d.lines) && header > 0 && strings.TrimSpace(d.lines[header-1]) == "" {
+		header--
+	}
+	d.lines = append(d.lines[:header], d.lines[end:]...)
+}
+
+// keys returns the keys of section in the order they appear.
+func (d *iniDocument) keys(section string) []string {
+	start, end, _ := d.sectionRange(section)
+	keys := []string{}
+	for _, line := range d.lines[start:end] {
+		if k, _, ok := iniKey(line); ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (d *iniDocument) sections() []string {
+	sections := []string{}
+	for _, line := range d.lines {
+		if name, ok := iniSection(line); ok {
+			sections = append(sections, name)
+		}
+	}
+	return sections
+}
+
+func iniValue(v interface{}) (string, error) {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}, []string, nil:
+		return "", fmt.Errorf("%w: INI values must be strings, numbers or booleans, not %v", ErrUnsupportedValue, v)
+	}
+	return fmt.Sprintf("%v", v), nil
+}
+
+// set treats maps at the top of dataset as sections and everything else
+// as global keys.
+func (d *iniDocument) set(dataset map[string]interface{}, prune bool) error {
+	for _, k := range sortedKeys(dataset) {
+		section, ok := dataset[k].(map[string]interface{})
+		if !ok {
+			value, err := iniValue(dataset[k])
+			if err != nil {
+				return err
+			}
+			d.setKey("", k, value)
+			continue
+		}
+		for _, key := range sortedKeys(section) {
+			value, err := iniValue(section[key])
+			if err != nil {
+				return fmt.Errorf("%w in section %s", err, k)
+			}
+			d.setKey(k, key, value)
+		}
+		if prune {
+			for _, key := range d.keys(k) {
+				if _, ok := section[key]; !ok {
+					d.removeKey(k, key)
+				}
+			}
+		}
+	}
+	if prune {
+		for _, key := range d.keys("") {
+			if _, ok := dataset[key]; !ok {
+				d.removeKey("", key)
+			}
+		}
+		for _, section := range d.sections() {
+			if _, ok := dataset[section].(map[string]interface{}); !ok {
+				d.removeSection(section)
+			}
+		}
+	}
+	return nil
+}
+
+// remove deletes a section, a global key or, given section.key, a key in
+// a section. Section names may contain dots, so the key is the part after
+// the last one.
+func (d *iniDocument) remove(path []string) {
+	if len(path) == 1 {
+		if _, _, ok := d.sectionRange(path[0]); ok && path[0] != "" {
+			d.removeSection(path[0])
+			return
+		}
+		d.removeKey("", path[0])
+		return
+	}
+	d.removeKey(strings.Join(path[:len(path)-1], "."), path[len(path)-1])
+}
+
+func (d *iniDocument) data() (interface{}, error) {
+	m := map[string]interface{}{}
+	section := ""
+	for _, line := range d.lines {
+		if name, ok := iniSection(line); ok {
+			section = name
+			if _, ok := m[name].(map[string]interface{}); !ok {
+				m[name] = map[string]interface{}{}
+			}
+			continue
+		}
+		k, v, ok := iniKey(line)
+		if !ok {
+			continue
+		}
+		if section == "" {
+			m[k] = v
+		} else {
+			m[section].(map[string]interface{})[k] = v
+		}
+	}
+	return m, nil
+}
+
+func (d *iniDocument) render() ([]byte, error) {
+	if len(d.lines) == 0 {
+		return []byte{}, nil
+	}
+	return []byte(strings.Join(d.lines, "\n") + "\n"), nil
+}
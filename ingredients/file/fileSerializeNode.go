@@ -0,0 +1,235 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonNumber matches scalars which can be copied into JSON output as they
+// were written.
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// nodeDocument holds a YAML or JSON file as a yaml.Node tree, which keeps
+// comments and key order. JSON is a subset of YAML, so the same parser
+// reads both; JSON is written back by emitJSON.
+type nodeDocument struct {
+	doc    *yaml.Node
+	json   bool
+	indent string
+}
+
+func loadNodeDocument(content []byte, isJSON bool) (*nodeDocument, error) {
+	d := &nodeDocument{json: isJSON, indent: detectIndent(content)}
+	if len(bytes.TrimSpace(content)) == 0 {
+		d.doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+		return d, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("the top level of the document must be a map")
+	}
+	d.doc = &doc
+	return d, nil
+}
+
+// detectIndent returns the indentation of the first indented line, or two
+// spaces.
+func detectIndent(content []byte) string {
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) && !strings.HasPrefix(trimmed, "#") {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
+
+func (d *nodeDocument) root() *yaml.Node {
+	return d.doc.Content[0]
+}
+
+// lookupKey returns the index of the value for key in a mapping node, or -1.
+func lookupKey(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func (d *nodeDocument) set(dataset map[string]interface{}, prune bool) error {
+	return setNode(d.root(), dataset, prune)
+}
+
+func setNode(mapping *yaml.Node, dataset map[string]interface{}, prune bool) error {
+	for _, k := range sortedKeys(dataset) {
+		v := dataset[k]
+		i := lookupKey(mapping, k)
+		if sub, ok := v.(map[string]interface{}); ok && i != -1 && mapping.Content[i].Kind == yaml.MappingNode {
+			if err := setNode(mapping.Content[i], sub, prune); err != nil {
+				return err
+			}
+			continue
+		}
+		if i != -1 {
+			var current interface{}
+			if err := mapping.Content[i].Decode(&current); err == nil && sameData(current, v) {
+				// leave the value as it was written, quotes and all
+				continue
+			}
+		}
+		value := &yaml.Node{}
+		if err := value.Encode(v); err != nil {
+			return errors.Join(ErrUnsupportedValue, err)
+		}
+		if i == -1 {
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}
+			mapping.Content = append(mapping.Content, key, value)
+			continue
+		}
+		old := mapping.Content[i]
+		value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+		mapping.Content[i] = value
+	}
+	if prune {
+		kept := []*yaml.Node{}
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if _, ok := dataset[mapping.Content[i].Value]; ok {
+				kept = append(kept, mapping.Content[i], mapping.Content[i+1])
+			}
+		}
+		mapping.Content = kept
+	}
+	return nil
+}
+
+func (d *nodeDocument) remove(path []string) {
+	mapping := d.root()
+	for len(path) > 1 {
+		i := lookupKey(mapping, path[0])
+		if i == -1 || mapping.Content[i].Kind != yaml.MappingNode {
+			return
+		}
+		mapping, path = mapping.Content[i], path[1:]
+	}
+	if i := lookupKey(mapping, path[0]); i != -1 {
+		mapping.Content = append(mapping.Content[:i-1], mapping.Content[i+1:]...)
+	}
+}
+
+func (d *nodeDocument) data() (interface{}, error) {
+	var v interface{}
+	err := d.root().Decode(&v)
+	return v, err
+}
+
+func (d *nodeDocument) render() ([]byte, error) {
+	var buf bytes.Buffer
+	if d.json {
+		if err := emitJSON(&buf, d.root(), d.indent, 0); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+		return buf.Bytes(), nil
+	}
+	enc := yaml.NewEncoder(&buf)
+	width := len(strings.ReplaceAll(d.indent, "\t", "  "))
+	if width < 2 {
+		width = 2
+	}
+	enc.SetIndent(width)
+	if err := enc.Encode(d.doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// emitJSON writes node as indented JSON, keeping the key order of the
+// tree.
+func emitJSON(buf *bytes.Buffer, node *yaml.Node, indent string, level int) error {
+	pad := strings.Repeat(indent, level)
+	switch node.Kind {
+	case yaml.AliasNode:
+		return emitJSON(buf, node.Alias, indent, level)
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			buf.WriteString(pad + indent)
+			if err := writeJSONValue(buf, node.Content[i].Value); err != nil {
+				return err
+			}
+			buf.WriteString(": ")
+			if err := emitJSON(buf, node.Content[i+1], indent, level+1); err != nil {
+				return err
+			}
+			if i+2 < len(node.Content) {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(pad + "}")
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, item := range node.Content {
+			buf.WriteString(pad + indent)
+			if err := emitJSON(buf, item, indent, level+1); err != nil {
+				return err
+			}
+			if i+1 < len(node.Content) {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(pad + "]")
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!str":
+			return writeJSONValue(buf, node.Value)
+		case "!!int", "!!float":
+			if jsonNumber.MatchString(node.Value) {
+				buf.WriteString(node.Value)
+				return nil
+			}
+		}
+		var v interface{}
+		if err := node.Decode(&v); err != nil {
+			return err
+		}
+		return writeJSONValue(buf, v)
+	default:
+		return fmt.Errorf("cannot write YAML node kind %d as JSON", node.Kind)
+	}
+	return nil
+}
+
+func writeJSONValue(buf *bytes.Buffer, v interface{}) error {
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return errors.Join(ErrUnsupportedValue, err)
+	}
+	buf.Write(bytes.TrimSuffix(out.Bytes(), []byte("\n")))
+	return nil
+}
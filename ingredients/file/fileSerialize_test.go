@@ -0,0 +1,202 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSerialize(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		existing string
+		params   map[string]interface{}
+		test     bool
+		expected string
+		changed  bool
+		error    error
+	}{
+		{
+			name:     "YAMLMerge",
+			file:     "config.yaml",
+			existing: "# service settings\nserver:\n  host: localhost # bind address\n  port: 80\nlog: info\n",
+			params: map[string]interface{}{
+				"merge":   true,
+				"dataset": map[string]interface{}{"server": map[string]interface{}{"port": 8080, "tls": true}},
+			},
+			expected: "# service settings\nserver:\n  host: localhost # bind address\n  port: 8080\n  tls: true\nlog: info\n",
+			changed:  true,
+		},
+		{
+			name:     "YAMLReplace",
+			file:     "config.yml",
+			existing: "a: 1\nb: 2 # keep me\nc: 3\n",
+			params:   map[string]interface{}{"dataset": map[string]interface{}{"b": 2, "d": "x"}},
+			expected: "b: 2 # keep me\nd: x\n",
+			changed:  true,
+		},
+		{
+			name:     "YAMLRemove",
+			file:     "config.yaml",
+			existing: "server:\n  host: localhost\n  port: 80\n",
+			params:   map[string]interface{}{"merge": true, "remove": []interface{}{"server.port", "missing.key"}},
+			expected: "server:\n  host: localhost\n",
+			changed:  true,
+		},
+		{
+			name:     "YAMLUnchanged",
+			file:     "config.yaml",
+			existing: "server:   {port: \"8080\"}\n",
+			params:   map[string]interface{}{"merge": true, "dataset": map[string]interface{}{"server": map[string]interface{}{"port": "8080"}}},
+			expected: "server:   {port: \"8080\"}\n",
+		},
+		{
+			name:     "JSON",
+			file:     "package.json",
+			existing: "{\n    \"name\": \"app\",\n    \"version\": \"1.0.0\",\n    \"scripts\": {\"test\": \"go test\"},\n    \"ratio\": 1.50\n}\n",
+			params: map[string]interface{}{
+				"merge":   true,
+				"dataset": map[string]interface{}{"version": "1.1.0", "private": true, "scripts": map[string]interface{}{"build": "go build"}},
+			},
+			expected: "{\n    \"name\": \"app\",\n    \"version\": \"1.1.0\",\n    \"scripts\": {\n        \"test\": \"go test\",\n        \"build\": \"go build\"\n    },\n    \"ratio\": 1.50,\n    \"private\": true\n}\n",
+			changed:  true,
+		},
+		{
+			name:     "JSONCreate",
+			file:     "new.json",
+			params:   map[string]interface{}{"dataset": map[string]interface{}{"list": []interface{}{"a", 1}, "html": "<b>"}},
+			expected: "{\n  \"html\": \"<b>\",\n  \"list\": [\n    \"a\",\n    1\n  ]\n}\n",
+			changed:  true,
+		},
+		{
+			name:     "TOML",
+			file:     "config.toml",
+			existing: "title = \"app\"\n\n[database]\nport = 5432\n",
+			params:   map[string]interface{}{"merge": true, "dataset": map[string]interface{}{"database": map[string]interface{}{"user": "app"}}},
+			expected: "title = \"app\"\n\n[database]\nport = 5432\nuser = \"app\"\n",
+			changed:  true,
+		},
+		{
+			name:     "TOMLUnchanged",
+			file:     "config.toml",
+			existing: "# comment\n[database]\nport = 5432\n",
+			params:   map[string]interface{}{"merge": true, "dataset": map[string]interface{}{"database": map[string]interface{}{"port": 5432}}},
+			expected: "# comment\n[database]\nport = 5432\n",
+		},
+		{
+			name:     "INI",
+			file:     "php.ini",
+			existing: "; global\ndebug=false\n\n[mysql]\n; connection\nhost=db\nport=3306\n\n[old]\nx=1\n",
+			params: map[string]interface{}{
+				"merge":   true,
+				"dataset": map[string]interface{}{"debug": true, "mysql": map[string]interface{}{"port": 3307, "user": "app"}, "cache": map[string]interface{}{"size": "64M"}},
+				"remove":  []interface{}{"old"},
+			},
+			expected: "; global\ndebug=true\n\n[mysql]\n; connection\nhost=db\nport=3307\nuser=app\n\n[cache]\nsize=64M\n",
+			changed:  true,
+		},
+		{
+			name:     "INIReplace",
+			file:     "app.conf",
+			existing: "[main]\n# the name\nname = a\nextra = b\n\n[gone]\nx = 1\n",
+			params:   map[string]interface{}{"dataset": map[string]interface{}{"main": map[string]interface{}{"name": "a"}}},
+			expected: "[main]\n# the name\nname = a\n",
+			changed:  true,
+		},
+		{
+			name:     "Test",
+			file:     "config.yaml",
+			existing: "a: 1\n",
+			params:   map[string]interface{}{"merge": true, "dataset": map[string]interface{}{"a": 2}},
+			test:     true,
+			expected: "a: 1\n",
+			changed:  true,
+		},
+		{
+			name:   "UnknownFormat",
+			file:   "config.txt",
+			params: map[string]interface{}{"dataset": map[string]interface{}{"a": 1}},
+			error:  ErrUnknownFormat,
+		},
+		{
+			name:     "NestedINI",
+			file:     "a.ini",
+			existing: "",
+			params:   map[string]interface{}{"dataset": map[string]interface{}{"s": map[string]interface{}{"k": map[string]interface{}{}}}},
+			error:    ErrUnsupportedValue,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), test.file)
+			if test.existing != "" {
+				if err := os.WriteFile(name, []byte(test.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			test.params["name"] = name
+			f := File{id: test.name, method: "serialize", params: test.params}
+			var err error
+			var changed bool
+			if test.test {
+				res, testErr := f.Test(context.Background())
+				err, changed = testErr, res.Changed
+			} else {
+				res, applyErr := f.Apply(context.Background())
+				err, changed = applyErr, res.Changed
+			}
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if changed != test.changed {
+				t.Errorf("expected changed to be %v, got %v", test.changed, changed)
+			}
+			content, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != test.expected {
+				t.Errorf("expected\n%s\ngot\n%s", test.expected, content)
+			}
+		})
+	}
+}
+
+func TestSerializeNotes(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(name, []byte("{\"a\": 1}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := File{id: "notes", method: "serialize", params: map[string]interface{}{
+		"name": name, "merge": true, "dataset": map[string]interface{}{"a": 2},
+	}}
+	res, err := f.Test(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Notes) != 2 || !strings.Contains(res.Notes[0].String(), "+  \"a\": 2") ||
+		res.Notes[1].String() != name+" would be updated" {
+		t.Errorf("unexpected notes %v", res.Notes)
+	}
+	res, err = f.Apply(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = f.Apply(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Changed || len(res.Notes) != 1 || res.Notes[0].String() != name+" is already in the correct state" {
+		t.Errorf("expected a second run to change nothing, got %v", res.Notes)
+	}
+}
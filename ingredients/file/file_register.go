@@ -1,6 +1,7 @@
 package file
 
 import (
+	"github.com/gogrlx/grlx/ingredients/file/farmer"
 	"github.com/gogrlx/grlx/ingredients/file/http"
 	"github.com/gogrlx/grlx/ingredients/file/local"
 	"github.com/gogrlx/grlx/types"
@@ -8,6 +9,7 @@ import (
 
 func init() {
 	provMap = make(map[string]types.FileProvider)
+	RegisterProvider(farmer.FarmerFile{})
 	RegisterProvider(http.HTTPFile{})
 	// RegisterProvider(s3.S3File{})
 	RegisterProvider(local.LocalFile{})
@@ -2,9 +2,11 @@ package file
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 
+	"github.com/gogrlx/grlx/ingredients"
 	"github.com/gogrlx/grlx/types"
 )
 
@@ -31,6 +33,38 @@ func TestRecipeStepUsage(t *testing.T) {
 	})
 }
 
+func TestRegisteredMethods(t *testing.T) {
+	_, methods := File{}.Methods()
+	for _, method := range methods {
+		if _, err := ingredients.SchemaFor("file", method); err != nil {
+			t.Errorf("file.%s: %v", method, err)
+		}
+	}
+	cooker, err := ingredients.NewRecipeCooker("absent", "file", "absent", map[string]interface{}{
+		"name": "/tmp/grlx-test-absent",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cooker.Test(context.Background()); err != nil {
+		t.Error(err)
+	}
+	err = ingredients.ValidateStep(types.Step{
+		ID: "managed", Ingredient: "file", Method: "managed",
+		Properties: map[string]interface{}{"name": "/tmp/grlx-test-managed", "source": "https://example.com/managed", "skip_verify": true},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	err = ingredients.ValidateStep(types.Step{
+		ID: "replace", Ingredient: "file", Method: "replace",
+		Properties: map[string]interface{}{"name": "/tmp/grlx-test-replace", "pattern": "a", "repl": "b", "count": "all"},
+	})
+	if !errors.Is(err, ingredients.ErrInvalidProperty) {
+		t.Errorf("expected error %v for a count that isn't a number, got %v", ingredients.ErrInvalidProperty, err)
+	}
+}
+
 func TestDest(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -52,7 +86,7 @@ func TestDest(t *testing.T) {
 				"name":        "testFile",
 				"skip_verify": true,
 			},
-			out:   "skip_testFile",
+			out:   skipVerifyDest("testFile"),
 			error: nil,
 		},
 		{
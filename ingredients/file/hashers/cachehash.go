@@ -25,14 +25,18 @@ func (cf CacheFile) Verify(ctx context.Context) (bool, error) {
 		return false, err
 	}
 	defer f.Close()
-	if cf.HashType == "" {
-		cf.HashType = GuessHashType(cf.Hash)
+	hashType, digest, err := ParseHashSpec(cf.Hash)
+	if err != nil {
+		return false, err
+	}
+	if cf.HashType != "" {
+		hashType = normalizeHashType(cf.HashType)
 	}
-	hf, err := GetHashFunc(cf.HashType)
+	hf, err := GetHashFunc(hashType)
 	if err != nil {
 		return false, err
 	}
-	hash, matches, err := hf(f, cf.Hash)
+	hash, matches, err := hf(f, digest)
 	if err != nil {
 		return false, errors.Join(err, types.ErrHashMismatch, fmt.Errorf("recipe step %s: hash for %s failed: expected %s but found %s", cf.ID, cf.Destination, cf.Hash, hash))
 	}
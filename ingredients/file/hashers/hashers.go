@@ -3,13 +3,19 @@ package hashers
 import (
 	"crypto/md5"
 	"crypto/sha1"
+	"crypto/sha256"
 	"crypto/sha512"
 	"errors"
 	"fmt"
+	"hash"
 	"hash/crc32"
 	"io"
 	"os"
+	"strings"
 	"sync"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
 )
 
 // TODO add Close() call
@@ -20,6 +26,7 @@ var (
 	hfTex                 sync.Mutex
 	ErrHashFuncExists     = fmt.Errorf("hasher already exists")
 	ErrorHashFuncNotFound = fmt.Errorf("hasher not found")
+	ErrInvalidHashSpec    = fmt.Errorf("invalid hash specification")
 )
 
 func init() {
@@ -27,8 +34,17 @@ func init() {
 	hashFuncs = make(map[string]HashFunc)
 	hashFuncs["md5"] = MD5
 	hashFuncs["sha1"] = SHA1
+	hashFuncs["sha224"] = SHA224
 	hashFuncs["sha256"] = SHA256
+	hashFuncs["sha384"] = SHA384
 	hashFuncs["sha512"] = SHA512
+	hashFuncs["sha3-224"] = SHA3_224
+	hashFuncs["sha3-256"] = SHA3_256
+	hashFuncs["sha3-384"] = SHA3_384
+	hashFuncs["sha3-512"] = SHA3_512
+	hashFuncs["blake2b-256"] = BLAKE2b256
+	hashFuncs["blake2b-384"] = BLAKE2b384
+	hashFuncs["blake2b-512"] = BLAKE2b512
 	hashFuncs["crc"] = CRC32
 	hfTex.Unlock()
 }
@@ -52,8 +68,121 @@ func GetHashFunc(hashType string) (HashFunc, error) {
 	return nil, errors.Join(ErrorHashFuncNotFound, fmt.Errorf("hasher %s not found", hashType))
 }
 
+// aliases maps the spellings commonly found in recipes and checksum
+// files onto the names hashers are registered under.
+var aliases = map[string]string{
+	"crc32":    "crc",
+	"sha-1":    "sha1",
+	"sha-224":  "sha224",
+	"sha-256":  "sha256",
+	"sha-384":  "sha384",
+	"sha-512":  "sha512",
+	"sha3_224": "sha3-224",
+	"sha3_256": "sha3-256",
+	"sha3_384": "sha3-384",
+	"sha3_512": "sha3-512",
+	"blake2b":  "blake2b-512",
+	"b2":       "blake2b-512",
+}
+
+func normalizeHashType(hashType string) string {
+	hashType = strings.ToLower(strings.TrimSpace(hashType))
+	if alias, ok := aliases[hashType]; ok {
+		return alias
+	}
+	return hashType
+}
+
+// ParseHashSpec splits a hash specification into its algorithm and hex
+// digest. Specifications may be written as `algo=hex`, `algo:hex` or as a
+// bare hex digest, in which case the algorithm is guessed from the digest
+// length.
+func ParseHashSpec(spec string) (string, string, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return "", "", errors.Join(ErrInvalidHashSpec, fmt.Errorf("empty hash"))
+	}
+	hashType, digest := "", spec
+	if i := strings.IndexAny(spec, "=:"); i >= 0 {
+		hashType, digest = normalizeHashType(spec[:i]), spec[i+1:]
+	}
+	digest = strings.ToLower(strings.TrimSpace(digest))
+	if !isHex(digest) {
+		return "", "", errors.Join(ErrInvalidHashSpec, fmt.Errorf("hash %s is not a hex digest", spec))
+	}
+	if hashType == "" {
+		hashType = guessFromLength(digest)
+		if hashType == "unknown" {
+			return "", "", errors.Join(ErrInvalidHashSpec, fmt.Errorf("cannot determine the algorithm of a %d character digest", len(digest)))
+		}
+	}
+	if n, ok := digestLengths[hashType]; ok && len(digest) != n {
+		return "", "", errors.Join(ErrInvalidHashSpec, fmt.Errorf("%s digests are %d characters long, got %d", hashType, n, len(digest)))
+	}
+	return hashType, digest, nil
+}
+
+// digestLengths holds the hex digest length of each built in hasher, so
+// that a truncated hash is rejected instead of never matching.
+var digestLengths = map[string]int{
+	"crc":         8,
+	"md5":         32,
+	"sha1":        40,
+	"sha224":      56,
+	"sha256":      64,
+	"sha384":      96,
+	"sha512":      128,
+	"sha3-224":    56,
+	"sha3-256":    64,
+	"sha3-384":    96,
+	"sha3-512":    128,
+	"blake2b-256": 64,
+	"blake2b-384": 96,
+	"blake2b-512": 128,
+}
+
+// GuessHashType returns the algorithm named by a hash specification, or
+// guesses it from the length of a bare digest. Where several algorithms
+// produce digests of the same length, the SHA-2 family is assumed.
 func GuessHashType(hash string) string {
-	return "unknown"
+	hashType, _, err := ParseHashSpec(hash)
+	if err != nil {
+		return "unknown"
+	}
+	return hashType
+}
+
+func guessFromLength(digest string) string {
+	switch len(digest) {
+	case 8:
+		return "crc"
+	case 32:
+		return "md5"
+	case 40:
+		return "sha1"
+	case 56:
+		return "sha224"
+	case 64:
+		return "sha256"
+	case 96:
+		return "sha384"
+	case 128:
+		return "sha512"
+	default:
+		return "unknown"
+	}
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
 }
 
 // Given a filename, return a reader for the file
@@ -66,56 +195,70 @@ func FileToReader(file string) (io.Reader, error) {
 	return f, nil
 }
 
-func MD5(file io.ReadCloser, expected string) (string, bool, error) {
+func sum(h hash.Hash, file io.ReadCloser, expected string) (string, bool, error) {
 	var actual string
-	md5 := md5.New()
-	if _, err := io.Copy(md5, file); err != nil {
+	if _, err := io.Copy(h, file); err != nil {
 		return actual, false, err
 	}
-	actual = fmt.Sprintf("%x", md5.Sum(nil))
-	return actual, actual == expected, nil
+	actual = fmt.Sprintf("%x", h.Sum(nil))
+	return actual, actual == strings.ToLower(expected), nil
+}
+
+func MD5(file io.ReadCloser, expected string) (string, bool, error) {
+	return sum(md5.New(), file, expected)
 }
 
 func SHA1(file io.ReadCloser, expected string) (string, bool, error) {
-	var actual string
-	sha1 := sha1.New()
-	if _, err := io.Copy(sha1, file); err != nil {
-		return actual, false, err
-	}
-	actual = fmt.Sprintf("%x", sha1.Sum(nil))
-	return actual, actual == expected, nil
+	return sum(sha1.New(), file, expected)
+}
+
+func SHA224(file io.ReadCloser, expected string) (string, bool, error) {
+	return sum(sha256.New224(), file, expected)
 }
 
 func SHA256(file io.ReadCloser, expected string) (string, bool, error) {
-	var actual string
-	sha1 := sha1.New()
-	if _, err := io.Copy(sha1, file); err != nil {
-		return actual, false, err
-	}
-	actual = fmt.Sprintf("%x", sha1.Sum(nil))
+	return sum(sha256.New(), file, expected)
+}
 
-	return actual, actual == expected, nil
+func SHA384(file io.ReadCloser, expected string) (string, bool, error) {
+	return sum(sha512.New384(), file, expected)
 }
 
 func SHA512(file io.ReadCloser, expected string) (string, bool, error) {
-	var actual string
-	sha512 := sha512.New()
-	if _, err := io.Copy(sha512, file); err != nil {
-		return actual, false, err
-	}
-	actual = fmt.Sprintf("%x", sha512.Sum(nil))
+	return sum(sha512.New(), file, expected)
+}
 
-	return actual, actual == expected, nil
+func SHA3_224(file io.ReadCloser, expected string) (string, bool, error) {
+	return sum(sha3.New224(), file, expected)
 }
 
-func CRC32(file io.ReadCloser, expected string) (string, bool, error) {
-	var actual string
-	table := crc32.IEEETable
-	crc := crc32.New(table)
-	if _, err := io.Copy(crc, file); err != nil {
-		return actual, false, err
-	}
-	actual = fmt.Sprintf("%x", crc.Sum(nil))
+func SHA3_256(file io.ReadCloser, expected string) (string, bool, error) {
+	return sum(sha3.New256(), file, expected)
+}
+
+func SHA3_384(file io.ReadCloser, expected string) (string, bool, error) {
+	return sum(sha3.New384(), file, expected)
+}
+
+func SHA3_512(file io.ReadCloser, expected string) (string, bool, error) {
+	return sum(sha3.New512(), file, expected)
+}
 
-	return actual, actual == expected, nil
+func BLAKE2b256(file io.ReadCloser, expected string) (string, bool, error) {
+	h, _ := blake2b.New256(nil)
+	return sum(h, file, expected)
+}
+
+func BLAKE2b384(file io.ReadCloser, expected string) (string, bool, error) {
+	h, _ := blake2b.New384(nil)
+	return sum(h, file, expected)
+}
+
+func BLAKE2b512(file io.ReadCloser, expected string) (string, bool, error) {
+	h, _ := blake2b.New512(nil)
+	return sum(h, file, expected)
+}
+
+func CRC32(file io.ReadCloser, expected string) (string, bool, error) {
+	return sum(crc32.NewIEEE(), file, expected)
 }
@@ -0,0 +1,124 @@
+package hashers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseHashSpec(t *testing.T) {
+	sha256Digest := "4ea5c508a6566e76240543f8feb06fd457777be39549c4016436afda65d2330e"
+	cases := []struct {
+		spec     string
+		hashType string
+		digest   string
+		err      error
+	}{
+		{spec: "sha256=" + sha256Digest, hashType: "sha256", digest: sha256Digest},
+		{spec: "sha256:" + sha256Digest, hashType: "sha256", digest: sha256Digest},
+		{spec: "SHA-256=" + strings.ToUpper(sha256Digest), hashType: "sha256", digest: sha256Digest},
+		{spec: sha256Digest, hashType: "sha256", digest: sha256Digest},
+		{spec: "md5:3a760fae784d30a1b50e304e97a17355", hashType: "md5", digest: "3a760fae784d30a1b50e304e97a17355"},
+		{spec: "3a760fae784d30a1b50e304e97a17355", hashType: "md5", digest: "3a760fae784d30a1b50e304e97a17355"},
+		{spec: "blake2b=" + sha256Digest + sha256Digest, hashType: "blake2b-512", digest: sha256Digest + sha256Digest},
+		{spec: "blake2b=abcd", err: ErrInvalidHashSpec},
+		{spec: "sha256=abc", err: ErrInvalidHashSpec},
+		{spec: "md5=" + sha256Digest, err: ErrInvalidHashSpec},
+		{spec: "", err: ErrInvalidHashSpec},
+		{spec: "sha256=nothex", err: ErrInvalidHashSpec},
+		{spec: "abc", err: ErrInvalidHashSpec},
+	}
+	for _, tc := range cases {
+		t.Run(tc.spec, func(t *testing.T) {
+			hashType, digest, err := ParseHashSpec(tc.spec)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if hashType != tc.hashType || digest != tc.digest {
+				t.Errorf("expected %s %s, got %s %s", tc.hashType, tc.digest, hashType, digest)
+			}
+		})
+	}
+}
+
+func TestHashFuncs(t *testing.T) {
+	// digests of "testData"
+	cases := map[string]string{
+		"md5":         "3a760fae784d30a1b50e304e97a17355",
+		"sha1":        "5cf9c17f05c44a5a6aca1a2d61de2fadfee70cb3",
+		"sha224":      "8dfc70d59e215252c2d3e8a38e02cc32a606d85d26a64fcae2963490",
+		"sha256":      "ba477a0ac57e10dd90bb5bf0289c5990fe839c619b26fde7c2aac62f526d4113",
+		"sha384":      "82e2ff27298bb4832c86703991f7c4e2f01d9dcac2aa39b13351d2db3d600e9e9dc9d13b539ac040269b9c263579d6de",
+		"sha3-256":    "0d18e0fb138347a5e37aa24a96ee19cb708c3372e9611fd2579ab17d58085cb7",
+		"blake2b-512": "e8c221398e93bffd4983173b9f9afab5ba53c79bba41e6a90d1e5158be3e856354f7316962b9fff2e76a36f86878266e49a531762cab3aa749f3914424abe741",
+	}
+	for hashType, expected := range cases {
+		t.Run(hashType, func(t *testing.T) {
+			hf, err := GetHashFunc(hashType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, matches, err := hf(io.NopCloser(strings.NewReader("testData")), expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !matches {
+				t.Errorf("expected %s, got %s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestVerifySpec(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(dest, []byte("testData"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, spec := range []string{
+		"md5:3a760fae784d30a1b50e304e97a17355",
+		"sha256=ba477a0ac57e10dd90bb5bf0289c5990fe839c619b26fde7c2aac62f526d4113",
+		"BA477A0AC57E10DD90BB5BF0289C5990FE839C619B26FDE7C2AAC62F526D4113",
+	} {
+		ok, err := CacheFile{ID: "test", Destination: dest, Hash: spec}.Verify(context.Background())
+		if err != nil || !ok {
+			t.Errorf("expected %s to verify, got %v %v", spec, ok, err)
+		}
+	}
+}
+
+func TestLookupHashFile(t *testing.T) {
+	gnu := `# comment
+ba477a0ac57e10dd90bb5bf0289c5990fe839c619b26fde7c2aac62f526d4113  grlx-linux-amd64
+0000000000000000000000000000000000000000000000000000000000000000 *dist/grlx-linux-arm64
+`
+	bsd := `SHA256 (grlx-linux-amd64) = ba477a0ac57e10dd90bb5bf0289c5990fe839c619b26fde7c2aac62f526d4113
+`
+	cases := []struct {
+		name     string
+		contents string
+		file     string
+		expected string
+		err      error
+	}{
+		{name: "gnu", contents: gnu, file: "/releases/grlx-linux-amd64", expected: "ba477a0ac57e10dd90bb5bf0289c5990fe839c619b26fde7c2aac62f526d4113"},
+		{name: "gnu binary marker", contents: gnu, file: "grlx-linux-arm64", expected: strings.Repeat("0", 64)},
+		{name: "bsd", contents: bsd, file: "grlx-linux-amd64", expected: "sha256=ba477a0ac57e10dd90bb5bf0289c5990fe839c619b26fde7c2aac62f526d4113"},
+		{name: "lone digest", contents: "3a760fae784d30a1b50e304e97a17355\n", file: "anything", expected: "3a760fae784d30a1b50e304e97a17355"},
+		{name: "missing", contents: gnu, file: "grlx-darwin-arm64", err: ErrHashNotInFile},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, err := LookupHashFile(strings.NewReader(tc.contents), tc.file)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if spec != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, spec)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package hashers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var ErrHashNotInFile = errors.New("no hash found for file in checksum file")
+
+// LookupHashFile finds the hash for filename in a checksum file such as
+// SHA256SUMS and returns it as a hash specification. Both the GNU
+// (`<hex>  <name>`, `<hex> *<name>`) and BSD (`SHA256 (<name>) = <hex>`)
+// formats are understood, as is a file containing nothing but one digest.
+// Entries are matched on their base name.
+func LookupHashFile(r io.Reader, filename string) (string, error) {
+	filename = path.Base(filename)
+	var lone []string
+	lines := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines++
+		// BSD style: ALGO (name) = digest
+		if open := strings.Index(line, " ("); open > 0 {
+			if closeIdx := strings.LastIndex(line, ") = "); closeIdx > open {
+				name := line[open+2 : closeIdx]
+				if path.Base(name) == filename {
+					return normalizeHashType(line[:open]) + "=" + strings.TrimSpace(line[closeIdx+4:]), nil
+				}
+				continue
+			}
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 1 {
+			lone = fields
+			continue
+		}
+		name := strings.TrimPrefix(strings.Join(fields[1:], " "), "*")
+		if path.Base(name) == filename {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if lines == 1 && len(lone) == 1 {
+		return lone[0], nil
+	}
+	return "", errors.Join(ErrHashNotInFile, fmt.Errorf("no hash for %s found in checksum file", filename))
+}
@@ -2,16 +2,32 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	httpc "net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"time"
 
-	// "github.com/gogrlx/grlx/ingredients/file"
 	"github.com/gogrlx/grlx/ingredients/file/hashers"
 	"github.com/gogrlx/grlx/types"
 )
 
+var (
+	ErrUnexpectedStatus = errors.New("unexpected HTTP status code")
+	ErrInvalidCABundle  = errors.New("no certificates could be loaded from the CA bundle")
+)
+
+const (
+	defaultRetryDelay = time.Second
+	defaultTimeout    = 5 * time.Minute
+)
+
 type HTTPFile struct {
 	ID          string
 	Source      string
@@ -20,44 +36,222 @@ type HTTPFile struct {
 	Props       map[string]interface{}
 }
 
-func (hf HTTPFile) Download(ctx context.Context) error {
-	dest, err := os.Create(hf.Destination)
+// cacheMeta is stored next to a downloaded file so that later
+// downloads can be made conditional on the remote file having changed.
+type cacheMeta struct {
+	Source       string `json:"source"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+func (hf HTTPFile) metaPath() string {
+	return hf.Destination + ".grlx-meta"
+}
+
+func (hf HTTPFile) readMeta() (cacheMeta, bool) {
+	var meta cacheMeta
+	b, err := os.ReadFile(hf.metaPath())
+	if err != nil {
+		return meta, false
+	}
+	if err = json.Unmarshal(b, &meta); err != nil {
+		return meta, false
+	}
+	// a cached file from a different source can't be reused
+	if meta.Source != hf.Source {
+		return meta, false
+	}
+	return meta, true
+}
+
+func (hf HTTPFile) writeMeta(res *httpc.Response) error {
+	meta := cacheMeta{
+		Source:       hf.Source,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+	}
+	if meta.ETag == "" && meta.LastModified == "" {
+		os.Remove(hf.metaPath())
+		return nil
+	}
+	b, err := json.Marshal(meta)
 	if err != nil {
 		return err
 	}
-	defer dest.Close()
+	return os.WriteFile(hf.metaPath(), b, 0o644)
+}
 
-	method := httpc.MethodGet
-	if hf.Props["method"] != nil {
-		if m, okM := hf.Props["method"].(string); okM {
-			method = m
+func (hf HTTPFile) client() (*httpc.Client, error) {
+	transport := httpc.DefaultTransport.(*httpc.Transport).Clone()
+	if proxy, ok := hf.Props["proxy"].(string); ok && proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("invalid proxy %s", proxy))
 		}
+		transport.Proxy = httpc.ProxyURL(proxyURL)
+	}
+	if caBundle, ok := hf.Props["ca_bundle"].(string); ok && caBundle != "" {
+		pem, err := os.ReadFile(caBundle)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Join(ErrInvalidCABundle, fmt.Errorf("invalid CA bundle %s", caBundle))
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+	timeout := defaultTimeout
+	if t, ok := durationProp(hf.Props["timeout"]); ok {
+		timeout = t
+	}
+	return &httpc.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+func (hf HTTPFile) newRequest(ctx context.Context) (*httpc.Request, error) {
+	method := httpc.MethodGet
+	if m, ok := hf.Props["method"].(string); ok && m != "" {
+		method = m
 	}
-	// TODO add headers, other body settings, etc here
 	req, err := httpc.NewRequestWithContext(ctx, method, hf.Source, nil)
+	if err != nil {
+		return nil, err
+	}
+	if headers, ok := hf.Props["headers"].(map[string]interface{}); ok {
+		for k, v := range headers {
+			req.Header.Set(k, fmt.Sprintf("%v", v))
+		}
+	}
+	if token, ok := hf.Props["bearer_token"].(string); ok && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if username, ok := hf.Props["username"].(string); ok && username != "" {
+		password, _ := hf.Props["password"].(string)
+		req.SetBasicAuth(username, password)
+	}
+	// only ask for a conditional response if we still have the file
+	// the metadata refers to
+	if _, err := os.Stat(hf.Destination); err == nil {
+		if meta, ok := hf.readMeta(); ok {
+			if meta.ETag != "" {
+				req.Header.Set("If-None-Match", meta.ETag)
+			}
+			if meta.LastModified != "" {
+				req.Header.Set("If-Modified-Since", meta.LastModified)
+			}
+		}
+	}
+	return req, nil
+}
+
+// Download fetches the source into the destination. The body is written to
+// a temporary file next to the destination and renamed into place once the
+// transfer has completed and matched the expected hash, so a failed
+// download never truncates an existing file.
+func (hf HTTPFile) Download(ctx context.Context) error {
+	client, err := hf.client()
 	if err != nil {
 		return err
 	}
-	res, err := httpc.DefaultClient.Do(req)
+	retries := 0
+	if r, ok := hf.Props["retries"].(int); ok && r > 0 {
+		retries = r
+	}
+	retryDelay := defaultRetryDelay
+	if d, ok := durationProp(hf.Props["retry_delay"]); ok {
+		retryDelay = d
+	}
+	for attempt := 0; ; attempt++ {
+		err = hf.download(ctx, client)
+		if err == nil || attempt >= retries || !retryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+// statusError records the status code of a failed request so that
+// the retry loop can tell server errors from client errors.
+type statusError struct {
+	code int
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status code %d", e.code)
+}
+
+func (e statusError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+func retryable(err error) bool {
+	if errors.Is(err, types.ErrHashMismatch) {
+		return false
+	}
+	var se statusError
+	if errors.As(err, &se) {
+		return se.code >= 500 || se.code == httpc.StatusTooManyRequests
+	}
+	return true
+}
+
+func (hf HTTPFile) download(ctx context.Context, client *httpc.Client) error {
+	req, err := hf.newRequest(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := client.Do(req)
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
+	if res.StatusCode == httpc.StatusNotModified {
+		return nil
+	}
 	expectedCode := httpc.StatusOK
-	if hf.Props["expectedCode"] != nil {
-		if ec, okEC := hf.Props["expectedCode"].(int); okEC {
-			expectedCode = ec
-		}
+	if ec, ok := hf.Props["expectedCode"].(int); ok {
+		expectedCode = ec
 	}
 	if res.StatusCode != expectedCode {
-		// TODO standardize this error message
-		return fmt.Errorf("unexpected HTTP status code %d", res.StatusCode)
+		return statusError{code: res.StatusCode}
 	}
-	_, err = io.Copy(dest, res.Body)
+	tmp, err := os.CreateTemp(filepath.Dir(hf.Destination), "."+filepath.Base(hf.Destination)+".*")
 	if err != nil {
 		return err
 	}
-	return nil
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, res.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if hf.Hash != "" {
+		// never move a body that doesn't match into the cache, and forget
+		// the validators so the next attempt isn't answered with a 304
+		valid, err := hf.cacheFile(tmp.Name()).Verify(ctx)
+		if err == nil && !valid {
+			err = errors.Join(types.ErrHashMismatch, fmt.Errorf("%s does not match hash %s", hf.Source, hf.Hash))
+		}
+		if err != nil {
+			os.Remove(hf.metaPath())
+			return err
+		}
+	}
+	if err = os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), hf.Destination); err != nil {
+		return err
+	}
+	return hf.writeMeta(res)
 }
 
 func (hf HTTPFile) Properties() (map[string]interface{}, error) {
@@ -76,6 +270,11 @@ func (hf HTTPFile) Protocols() []string {
 }
 
 func (lf HTTPFile) Verify(ctx context.Context) (bool, error) {
+	return lf.cacheFile(lf.Destination).Verify(ctx)
+}
+
+// cacheFile describes the file at path as holding the expected hash.
+func (lf HTTPFile) cacheFile(path string) hashers.CacheFile {
 	hashType := ""
 	if lf.Props["hashType"] == nil {
 		hashType = hashers.GuessHashType(lf.Hash)
@@ -84,15 +283,21 @@ func (lf HTTPFile) Verify(ctx context.Context) (bool, error) {
 	} else {
 		hashType = ht
 	}
-	cf := hashers.CacheFile{
+	return hashers.CacheFile{
 		ID:          lf.ID,
-		Destination: lf.Destination,
+		Destination: path,
 		Hash:        lf.Hash,
 		HashType:    hashType,
 	}
-	return cf.Verify(ctx)
 }
 
-// func init() {
-// 	file.RegisterProvider(HTTPFile{})
-// }
+// durationProp reads a duration property such as "30s". The schema has
+// already formatted any number of seconds as a duration.
+func durationProp(v interface{}) (time.Duration, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, false
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil
+}
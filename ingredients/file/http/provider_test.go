@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+
+	"github.com/gogrlx/grlx/types"
 )
 
 type testServer struct{}
@@ -63,3 +67,190 @@ func TestDownload(t *testing.T) {
 		}(tc)
 	}
 }
+
+// conditionalServer counts requests under a lock, since the server's
+// goroutines write the counts while the test reads them.
+type conditionalServer struct {
+	mu        sync.Mutex
+	hits      int
+	fullHits  int
+	failFirst int
+}
+
+// counts returns the number of requests and of full downloads served.
+func (h *conditionalServer) counts() (int, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.hits, h.fullHits
+}
+
+func (h *conditionalServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hits++
+	if h.failFirst > 0 {
+		h.failFirst--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	switch r.URL.Path {
+	case "/auth":
+		if user, pass, ok := r.BasicAuth(); !ok || user != "grlx" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	case "/bearer":
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	case "/header":
+		if r.Header.Get("X-Grlx") != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	case "/missing":
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Header.Get("If-None-Match") == `"v1"` {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.fullHits++
+	w.Header().Set("ETag", `"v1"`)
+	w.Write([]byte("testData"))
+}
+
+func startServer(t *testing.T, h http.Handler) string {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: h}
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Close() })
+	return fmt.Sprintf("http://localhost:%d", listener.Addr().(*net.TCPAddr).Port)
+}
+
+func TestDownloadOptions(t *testing.T) {
+	td := t.TempDir()
+	type tCase struct {
+		name    string
+		path    string
+		props   map[string]interface{}
+		wantErr error
+	}
+	cases := []tCase{
+		{name: "basic auth", path: "/auth", props: map[string]interface{}{"username": "grlx", "password": "secret"}},
+		{name: "bad basic auth", path: "/auth", props: map[string]interface{}{"username": "grlx"}, wantErr: ErrUnexpectedStatus},
+		{name: "bearer", path: "/bearer", props: map[string]interface{}{"bearer_token": "token"}},
+		{name: "headers", path: "/header", props: map[string]interface{}{"headers": map[string]interface{}{"X-Grlx": 1}}},
+		{name: "expected code", path: "/missing", props: map[string]interface{}{"expectedCode": 404}},
+		{name: "missing ca bundle", path: "/", props: map[string]interface{}{"ca_bundle": filepath.Join(td, "missing.pem")}, wantErr: os.ErrNotExist},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := startServer(t, &conditionalServer{})
+			dst := filepath.Join(t.TempDir(), "dst")
+			hf, _ := (HTTPFile{}).Parse(tc.name, host+tc.path, dst, "", tc.props)
+			err := hf.Download(context.Background())
+			if tc.wantErr == nil && err != nil {
+				t.Errorf("want no error, got %v", err)
+			} else if !errors.Is(err, tc.wantErr) {
+				t.Errorf("want error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDownloadRetries(t *testing.T) {
+	h := &conditionalServer{failFirst: 2}
+	host := startServer(t, h)
+	dst := filepath.Join(t.TempDir(), "dst")
+	hf, _ := (HTTPFile{}).Parse("retries", host+"/", dst, "", map[string]interface{}{
+		"retries": 2, "retry_delay": "1ms",
+	})
+	if err := hf.Download(context.Background()); err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if hits, _ := h.counts(); hits != 3 {
+		t.Errorf("want 3 requests, got %d", hits)
+	}
+}
+
+func TestDownloadKeepsExistingOnFailure(t *testing.T) {
+	host := startServer(t, &conditionalServer{})
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := os.WriteFile(dst, []byte("existing"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hf, _ := (HTTPFile{}).Parse("failure", host+"/missing", dst, "", nil)
+	if err := hf.Download(context.Background()); !errors.Is(err, ErrUnexpectedStatus) {
+		t.Fatalf("want error %v, got %v", ErrUnexpectedStatus, err)
+	}
+	b, _ := os.ReadFile(dst)
+	if string(b) != "existing" {
+		t.Errorf("want destination to be untouched, got %q", string(b))
+	}
+	entries, _ := os.ReadDir(filepath.Dir(dst))
+	if len(entries) != 1 {
+		t.Errorf("want no temporary files left behind, got %d entries", len(entries))
+	}
+}
+
+func TestDownloadConditional(t *testing.T) {
+	h := &conditionalServer{}
+	host := startServer(t, h)
+	dst := filepath.Join(t.TempDir(), "dst")
+	hf, _ := (HTTPFile{}).Parse("conditional", host+"/", dst, "", nil)
+	for i := 0; i < 2; i++ {
+		if err := hf.Download(context.Background()); err != nil {
+			t.Fatalf("want no error, got %v", err)
+		}
+	}
+	if hits, fullHits := h.counts(); hits != 2 || fullHits != 1 {
+		t.Errorf("want 2 requests and 1 full download, got %d and %d", hits, fullHits)
+	}
+	// if the file disappears the metadata must not be trusted
+	os.Remove(dst)
+	if err := hf.Download(context.Background()); err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if _, fullHits := h.counts(); fullHits != 2 {
+		t.Errorf("want 2 full downloads, got %d", fullHits)
+	}
+}
+
+func TestDownloadHashMismatch(t *testing.T) {
+	h := &conditionalServer{}
+	host := startServer(t, h)
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := os.WriteFile(dst, []byte("existing"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hf, _ := (HTTPFile{}).Parse("mismatch", host+"/", dst, "md5=00000000000000000000000000000000", map[string]interface{}{
+		"retries": 2, "retry_delay": "1ms",
+	})
+	meta := hf.(HTTPFile).metaPath()
+	if err := os.WriteFile(meta, []byte(fmt.Sprintf(`{"source":%q,"etag":"\"v0\""}`, host+"/")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := hf.Download(context.Background()); !errors.Is(err, types.ErrHashMismatch) {
+		t.Fatalf("want error %v, got %v", types.ErrHashMismatch, err)
+	}
+	if hits, _ := h.counts(); hits != 1 {
+		t.Errorf("want a mismatch not to be retried, got %d requests", hits)
+	}
+	b, _ := os.ReadFile(dst)
+	if string(b) != "existing" {
+		t.Errorf("want destination to be untouched, got %q", string(b))
+	}
+	if _, err := os.Stat(meta); !os.IsNotExist(err) {
+		t.Errorf("want cache metadata to be dropped, got %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(dst))
+	if len(entries) != 1 {
+		t.Errorf("want no temporary files left behind, got %d entries", len(entries))
+	}
+}
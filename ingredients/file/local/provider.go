@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 
 	"github.com/gogrlx/grlx/ingredients/file/hashers"
 	"github.com/gogrlx/grlx/types"
@@ -19,15 +21,18 @@ type LocalFile struct {
 }
 
 func (lf LocalFile) Download(ctx context.Context) error {
-	ok, err := lf.Verify(ctx)
-	// if verification failed because the file doesn't exist,
-	// that's ok. Otherwise, return the error.
-	if !errors.Is(err, types.ErrFileNotFound) {
-		return err
-	}
-	// if the file exists and the hash matches, we're done.
-	if ok {
-		return nil
+	// without a hash there is nothing to verify, so always copy
+	if lf.Hash != "" {
+		ok, err := lf.Verify(ctx)
+		// if the file exists and the hash matches, we're done.
+		if ok {
+			return nil
+		}
+		// if verification failed because the file doesn't exist,
+		// that's ok. Otherwise, return the error.
+		if err != nil && !errors.Is(err, types.ErrFileNotFound) {
+			return err
+		}
 	}
 	// otherwise, "download" the file.
 	f, err := os.Open(lf.Source)
@@ -44,10 +49,41 @@ func (lf LocalFile) Download(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if lf.Hash == "" {
+		return nil
+	}
 	_, err = lf.Verify(ctx)
 	return err
 }
 
+// List walks the source directory, returning its regular files. Symlinks
+// are followed only at the top, so a source may link to a directory.
+func (lf LocalFile) List(ctx context.Context) ([]string, error) {
+	root, err := filepath.EvalSymlinks(lf.Source)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
+
 func (lf LocalFile) Properties() (map[string]interface{}, error) {
 	return lf.Props, nil
 }
@@ -0,0 +1,195 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrGitMethodUndefined = errors.New("git method undefined")
+	ErrGitFailed          = errors.New("git command failed")
+	ErrUnknownRevision    = errors.New("revision not found in repository")
+	ErrNotRepository      = errors.New("target is not a git repository")
+	ErrLocalChanges       = errors.New("target has local changes")
+)
+
+type Git struct {
+	id     string
+	method string
+	params map[string]interface{}
+}
+
+func (g Git) Parse(id, method string, params map[string]interface{}) (types.RecipeCooker, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return Git{
+		id: id, method: method,
+		params: params,
+	}, nil
+}
+
+func (g Git) validate() error {
+	set, err := g.PropertiesForMethod(g.method)
+	if err != nil {
+		return err
+	}
+	propSet, err := ingredients.PropMapToPropSet(set)
+	if err != nil {
+		return err
+	}
+	for _, v := range propSet {
+		if v.IsReq {
+			if v.Key == "name" {
+				name, ok := g.params[v.Key].(string)
+				if !ok {
+					return types.ErrMissingName
+				}
+				if name == "" {
+					return types.ErrMissingName
+				}
+
+			} else {
+				if _, ok := g.params[v.Key]; !ok {
+					return fmt.Errorf("missing required property %s", v.Key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (g Git) Test(ctx context.Context) (types.Result, error) {
+	switch g.method {
+	case "config":
+		return g.config(ctx, true)
+	case "latest":
+		return g.latest(ctx, true)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrGitMethodUndefined, fmt.Errorf("method %s undefined", g.method))
+	}
+}
+
+func (g Git) Apply(ctx context.Context) (types.Result, error) {
+	switch g.method {
+	case "config":
+		return g.config(ctx, false)
+	case "latest":
+		return g.latest(ctx, false)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrGitMethodUndefined, fmt.Errorf("method %s undefined", g.method))
+	}
+}
+
+// command runs git with args in dir, as the runas user and with the
+// identity file if those are set, and returns its trimmed output.
+func (g Git) command(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	env := []string{}
+	if runas, _ := g.params["runas"].(string); runas != "" {
+		u, err := user.Lookup(runas)
+		if err != nil {
+			return "", errors.Join(err, fmt.Errorf("invalid user %s; user must exist", runas))
+		}
+		uid, err := strconv.Atoi(u.Uid)
+		if err != nil || uid > math.MaxInt32 {
+			return "", fmt.Errorf("UID %s of %s is invalid", u.Uid, runas)
+		}
+		gid, err := strconv.Atoi(u.Gid)
+		if err != nil || gid > math.MaxInt32 {
+			return "", fmt.Errorf("GID %s of %s is invalid", u.Gid, runas)
+		}
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+		cmd.SysProcAttr.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+		// git reads the user's own config and known_hosts
+		env = append(env, "HOME="+u.HomeDir, "USER="+u.Username)
+	}
+	if identity, _ := g.params["identity"].(string); identity != "" {
+		env = append(env, "GIT_SSH_COMMAND=ssh -i "+identity+" -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new")
+	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	// never wait on a password prompt
+	cmd.Env = append(cmd.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return strings.TrimSpace(string(out)), errors.Join(ErrGitFailed,
+			fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String())), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (g Git) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	runas := ingredients.MethodPropsSet{
+		ingredients.MethodProps{Key: "runas", Type: "string", IsReq: false, Description: "the user to run git as"},
+	}
+	switch method {
+	case "config":
+		return append(ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the config key, such as user.email"},
+			ingredients.MethodProps{Key: "value", Type: "string", IsReq: false, Description: "the value to set; required unless unset is true"},
+			ingredients.MethodProps{Key: "unset", Type: "bool", IsReq: false, Description: "remove the key instead of setting it"},
+			ingredients.MethodProps{Key: "repo", Type: "string", IsReq: false, Description: "the repository whose config to edit; the global config of the runas user if unset"},
+			ingredients.MethodProps{Key: "system", Type: "bool", IsReq: false, Description: "edit the system-wide config instead"},
+		}, runas...), nil
+	case "latest":
+		return append(ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the URL of the repository"},
+			ingredients.MethodProps{Key: "target", Type: "string", IsReq: true, Description: "the directory to clone into"},
+			ingredients.MethodProps{Key: "rev", Type: "string", IsReq: false, Description: "the branch, tag or commit to check out (default the remote's default branch)"},
+			ingredients.MethodProps{Key: "remote", Type: "string", IsReq: false, Description: "the name of the remote (default origin)"},
+			ingredients.MethodProps{Key: "depth", Type: "int", IsReq: false, Description: "make a shallow clone with this many commits"},
+			ingredients.MethodProps{Key: "force_reset", Type: "bool", IsReq: false, Description: "discard local changes to tracked files"},
+			ingredients.MethodProps{Key: "force_clone", Type: "bool", IsReq: false, Description: "replace a target which is not a git repository"},
+			ingredients.MethodProps{Key: "submodules", Type: "bool", IsReq: false, Description: "check out submodules recursively"},
+			ingredients.MethodProps{Key: "identity", Type: "string", IsReq: false, Description: "the SSH private key to fetch with"},
+		}, runas...), nil
+	default:
+		return nil, errors.Join(ErrGitMethodUndefined, fmt.Errorf("method %s undefined", method))
+	}
+}
+
+func (g Git) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := g.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (g Git) Methods() (string, []string) {
+	return "git", []string{"config", "latest"}
+}
+
+func (g Git) Properties() (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(g.params)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+func init() {
+	ingredients.RegisterAllMethods(Git{})
+}
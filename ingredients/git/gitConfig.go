@@ -0,0 +1,78 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// config sets name to value in the config of repo, or in the global config
+// of the runas user when repo is unset. system edits /etc/gitconfig
+// instead, and unset removes the key.
+func (g Git) config(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := g.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	name, _ := g.params["name"].(string)
+	value, hasValue := g.params["value"].(string)
+	unset, _ := g.params["unset"].(bool)
+	if !hasValue && !unset {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, fmt.Errorf("value is required unless unset is true")
+	}
+	repo, _ := g.params["repo"].(string)
+	scope := []string{"config", "--global"}
+	where := "the global config"
+	if system, _ := g.params["system"].(bool); system {
+		scope, where = []string{"config", "--system"}, "the system config"
+	} else if repo != "" {
+		scope, where = []string{"config", "--local"}, repo
+	}
+	args := func(extra ...string) []string {
+		return append(append([]string{}, scope...), extra...)
+	}
+
+	current, err := g.command(ctx, repo, args("--get", name)...)
+	isSet := err == nil
+	var exitErr *exec.ExitError
+	// git config --get exits 1 when the key is unset
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+
+	if unset {
+		if !isSet {
+			notes = append(notes, types.Snprintf("%s is already unset in %s", name, where))
+			return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+		}
+		if test {
+			notes = append(notes, types.Snprintf("%s would be unset in %s", name, where))
+			return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+		}
+		if _, err = g.command(ctx, repo, args("--unset-all", name)...); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		notes = append(notes, types.Snprintf("%s has been unset in %s", name, where))
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+
+	if isSet && current == value {
+		notes = append(notes, types.Snprintf("%s is already set to %s in %s", name, value, where))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	}
+	if test {
+		notes = append(notes, types.Snprintf("%s would be set to %s in %s", name, value, where))
+	} else {
+		if _, err = g.command(ctx, repo, args("--replace-all", name, value)...); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		notes = append(notes, types.Snprintf("%s has been set to %s in %s", name, value, where))
+	}
+	if isSet {
+		notes = append(notes, types.Snprintf("%s: %s -> %s", name, current, value))
+	}
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+}
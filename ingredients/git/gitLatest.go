@@ -0,0 +1,328 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// revision is what rev resolved to on the remote. branch is set when the
+// checkout should be on a branch rather than a detached HEAD. commit may
+// be abbreviated when rev named a commit directly.
+type revision struct {
+	branch string
+	tag    string
+	commit string
+}
+
+func (r revision) String() string {
+	switch {
+	case r.branch != "":
+		return "branch " + r.branch
+	case r.tag != "":
+		return "tag " + r.tag
+	default:
+		return "commit " + r.commit
+	}
+}
+
+// resolve asks the remote what rev points at. HEAD is followed to the
+// remote's default branch; a name is tried as a branch, then as a tag;
+// anything else that looks like a commit hash is taken as one.
+func (g Git) resolve(ctx context.Context, repo, rev string) (revision, error) {
+	// annotated tags are only peeled to their commit when asked for
+	out, err := g.command(ctx, "", "ls-remote", "--symref", repo, rev, rev+"^{}")
+	if err != nil {
+		return revision{}, err
+	}
+	refs := map[string]string{}
+	symref := ""
+	for _, line := range strings.Split(out, "\n") {
+		sha, ref, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		if target, isSym := strings.CutPrefix(sha, "ref: "); isSym {
+			if ref == "HEAD" {
+				symref = target
+			}
+			continue
+		}
+		refs[ref] = sha
+	}
+	if rev == "HEAD" {
+		if branch, ok := strings.CutPrefix(symref, "refs/heads/"); ok && refs["HEAD"] != "" {
+			return revision{branch: branch, commit: refs["HEAD"]}, nil
+		}
+		if refs["HEAD"] != "" {
+			return revision{commit: refs["HEAD"]}, nil
+		}
+		return revision{}, errors.Join(ErrUnknownRevision, fmt.Errorf("%s has no HEAD", repo))
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(rev, "refs/heads/"), "refs/tags/")
+	if sha, ok := refs["refs/heads/"+name]; ok && !strings.HasPrefix(rev, "refs/tags/") {
+		return revision{branch: name, commit: sha}, nil
+	}
+	if sha, ok := refs["refs/tags/"+name+"^{}"]; ok {
+		return revision{tag: name, commit: sha}, nil
+	}
+	if sha, ok := refs["refs/tags/"+name]; ok {
+		return revision{tag: name, commit: sha}, nil
+	}
+	if commitPattern.MatchString(rev) {
+		return revision{commit: rev}, nil
+	}
+	return revision{}, errors.Join(ErrUnknownRevision, fmt.Errorf("%s not found in %s", rev, repo))
+}
+
+// state is what the target currently has checked out.
+type state struct {
+	commit string
+	branch string
+	url    string
+	dirty  bool
+}
+
+func (g Git) current(ctx context.Context, target, remote string) (state, error) {
+	var s state
+	top, err := g.command(ctx, target, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return s, errors.Join(ErrNotRepository, err)
+	}
+	if resolved, _ := filepath.EvalSymlinks(target); filepath.Clean(top) != resolved {
+		return s, errors.Join(ErrNotRepository, fmt.Errorf("%s is inside the repository %s", target, top))
+	}
+	// an empty repository has no HEAD yet
+	s.commit, _ = g.command(ctx, target, "rev-parse", "--verify", "-q", "HEAD")
+	s.branch, _ = g.command(ctx, target, "symbolic-ref", "-q", "--short", "HEAD")
+	s.url, _ = g.command(ctx, target, "config", "--get", "remote."+remote+".url")
+	status, err := g.command(ctx, target, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return s, err
+	}
+	s.dirty = status != ""
+	return s, nil
+}
+
+// matches reports whether the checkout is already at want.
+func (s state) matches(want revision) bool {
+	if s.commit == "" || !strings.HasPrefix(s.commit, want.commit) {
+		return false
+	}
+	if want.branch != "" {
+		return s.branch == want.branch
+	}
+	return s.branch == ""
+}
+
+// latest clones name into target, or fetches into an existing clone, and
+// checks out rev: a branch (which is checked out as a local branch of the
+// same name), a tag or a commit. Without rev, the remote's default branch
+// is used. Local changes to tracked files stop the checkout unless
+// force_reset is set, in which case they are discarded.
+//
+// The commit before and after are reported as a "revision: old -> new"
+// note. In test mode the remote is still asked where rev points, so the
+// note shows the commit that would be checked out.
+func (g Git) latest(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := g.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	repo, _ := g.params["name"].(string)
+	target, ok := g.params["target"].(string)
+	if !ok || target == "" {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, types.ErrMissingTarget
+	}
+	target = filepath.Clean(target)
+	if target == "/" {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, types.ErrModifyRoot
+	}
+	rev, _ := g.params["rev"].(string)
+	if rev == "" {
+		rev = "HEAD"
+	}
+	remote, _ := g.params["remote"].(string)
+	if remote == "" {
+		remote = "origin"
+	}
+	depth := 0
+	switch v := g.params["depth"].(type) {
+	case int:
+		depth = v
+	case float64:
+		depth = int(v)
+	case string:
+		var err error
+		if depth, err = strconv.Atoi(v); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, fmt.Errorf("invalid depth %s", v)
+		}
+	}
+	forceReset, _ := g.params["force_reset"].(bool)
+	forceClone, _ := g.params["force_clone"].(bool)
+	submodules, _ := g.params["submodules"].(bool)
+
+	want, err := g.resolve(ctx, repo, rev)
+	if err != nil {
+		notes = append(notes, types.Snprintf("failed to resolve %s in %s", rev, repo))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+
+	clone := false
+	entries, err := os.ReadDir(target)
+	switch {
+	case os.IsNotExist(err):
+		clone = true
+	case err != nil:
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	case len(entries) == 0:
+		clone = true
+	}
+	var cur state
+	if !clone {
+		cur, err = g.current(ctx, target, remote)
+		if errors.Is(err, ErrNotRepository) && forceClone {
+			clone = true
+			if test {
+				notes = append(notes, types.Snprintf("%s is not a git repository and would be removed", target))
+			} else {
+				if err = os.RemoveAll(target); err != nil {
+					return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+				}
+				notes = append(notes, types.Snprintf("removed %s, which was not a git repository", target))
+			}
+		} else if err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+	}
+
+	if clone {
+		if test {
+			notes = append(notes, types.Snprintf("would clone %s into %s at %s", repo, target, want))
+			notes = append(notes, types.Snprintf("revision: %s", want.commit))
+			return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+		}
+		args := []string{"clone", "--origin", remote}
+		if depth > 0 {
+			args = append(args, "--depth", strconv.Itoa(depth))
+		}
+		if want.branch != "" {
+			args = append(args, "--branch", want.branch)
+		} else if want.tag != "" {
+			args = append(args, "--branch", want.tag)
+		}
+		if _, err = g.command(ctx, "", append(args, "--", repo, target)...); err != nil {
+			notes = append(notes, types.Snprintf("failed to clone %s into %s", repo, target))
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		if err = g.checkout(ctx, target, remote, want, false, submodules); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Changed: true, Notes: notes}, err
+		}
+		commit, err := g.command(ctx, target, "rev-parse", "HEAD")
+		if err != nil {
+			return types.Result{Succeeded: false, Failed: true, Changed: true, Notes: notes}, err
+		}
+		notes = append(notes, types.Snprintf("cloned %s into %s at %s", repo, target, want))
+		notes = append(notes, types.Snprintf("revision: %s", commit))
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+
+	urlChanged := cur.url != repo
+	moved := !cur.matches(want)
+	reset := cur.dirty && forceReset
+	if !urlChanged && !moved && !reset {
+		notes = append(notes, types.Snprintf("%s is already at %s (%s)", target, want, cur.commit))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	}
+	if moved && cur.dirty && !forceReset {
+		notes = append(notes, types.Snprintf("%s has local changes; set force_reset to discard them", target))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, ErrLocalChanges
+	}
+	if test {
+		if urlChanged {
+			notes = append(notes, types.Snprintf("remote %s: %s -> %s", remote, cur.url, repo))
+		}
+		if reset {
+			notes = append(notes, types.Snprintf("local changes in %s would be discarded", target))
+		}
+		notes = append(notes, types.Snprintf("would update %s to %s", target, want))
+		notes = append(notes, types.Snprintf("revision: %s -> %s", cur.commit, want.commit))
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+
+	if urlChanged {
+		verb := "set-url"
+		if cur.url == "" {
+			verb = "add"
+		}
+		if _, err = g.command(ctx, target, "remote", verb, remote, repo); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		notes = append(notes, types.Snprintf("remote %s: %s -> %s", remote, cur.url, repo))
+	}
+	args := []string{"fetch", "--tags", "--force"}
+	if depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+	if _, err = g.command(ctx, target, append(args, remote)...); err != nil {
+		notes = append(notes, types.Snprintf("failed to fetch %s", repo))
+		return types.Result{Succeeded: false, Failed: true, Changed: urlChanged, Notes: notes}, err
+	}
+	if err = g.checkout(ctx, target, remote, want, forceReset, submodules); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Changed: true, Notes: notes}, err
+	}
+	commit, err := g.command(ctx, target, "rev-parse", "HEAD")
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Changed: true, Notes: notes}, err
+	}
+	if reset {
+		notes = append(notes, types.Snprintf("discarded local changes in %s", target))
+	}
+	notes = append(notes, types.Snprintf("updated %s to %s", target, want))
+	notes = append(notes, types.Snprintf("revision: %s -> %s", cur.commit, commit))
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+}
+
+// checkout moves the fetched clone in target to want.
+func (g Git) checkout(ctx context.Context, target, remote string, want revision, force, submodules bool) error {
+	commit, err := g.command(ctx, target, "rev-parse", "--verify", "-q", want.commit+"^{commit}")
+	if err != nil {
+		return errors.Join(ErrUnknownRevision, fmt.Errorf("%s was not fetched", want))
+	}
+	args := []string{"checkout", "-q"}
+	if force {
+		args = append(args, "--force")
+	}
+	if want.branch != "" {
+		args = append(args, "-B", want.branch, commit)
+	} else {
+		args = append(args, "--detach", commit)
+	}
+	if _, err = g.command(ctx, target, args...); err != nil {
+		return err
+	}
+	if force {
+		if _, err = g.command(ctx, target, "reset", "-q", "--hard", commit); err != nil {
+			return err
+		}
+	}
+	if want.branch != "" {
+		// tracking is a convenience for people working in the clone
+		g.command(ctx, target, "branch", "-q", "--set-upstream-to="+remote+"/"+want.branch, want.branch)
+	}
+	if submodules {
+		if _, err = g.command(ctx, target, "submodule", "update", "--init", "--recursive"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
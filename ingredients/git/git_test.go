@@ -0,0 +1,291 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitEnv isolates git from the user's config and lets submodules be
+// cloned over file://.
+func gitEnv(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "grlx")
+	t.Setenv("GIT_AUTHOR_EMAIL", "grlx@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "grlx")
+	t.Setenv("GIT_COMMITTER_EMAIL", "grlx@example.com")
+	t.Setenv("GIT_CONFIG_COUNT", "2")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+	t.Setenv("GIT_CONFIG_KEY_1", "init.defaultBranch")
+	t.Setenv("GIT_CONFIG_VALUE_1", "main")
+}
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commit writes content to name in repo and commits it, returning the
+// new commit.
+func commit(t *testing.T, repo, name, content string) string {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run(t, repo, "add", name)
+	run(t, repo, "commit", "-q", "-m", "update "+name)
+	return run(t, repo, "rev-parse", "HEAD")
+}
+
+func notesOf(t *testing.T, g Git, test bool) ([]string, bool, error) {
+	t.Helper()
+	apply := g.Apply
+	if test {
+		apply = g.Test
+	}
+	res, err := apply(context.Background())
+	notes := []string{}
+	for _, n := range res.Notes {
+		notes = append(notes, n.String())
+	}
+	return notes, res.Changed, err
+}
+
+func TestLatest(t *testing.T) {
+	gitEnv(t)
+	upstream := t.TempDir()
+	run(t, upstream, "init", "-q")
+	first := commit(t, upstream, "app.conf", "v1\n")
+	run(t, upstream, "tag", "-a", "v1", "-m", "v1")
+	run(t, upstream, "branch", "dev")
+	url := "file://" + upstream
+	target := filepath.Join(t.TempDir(), "app")
+	latest := func(props map[string]interface{}) Git {
+		props["name"], props["target"] = url, target
+		return Git{id: "app", method: "latest", params: props}
+	}
+	head := func() string {
+		return run(t, target, "rev-parse", "HEAD")
+	}
+
+	t.Run("TestClone", func(t *testing.T) {
+		notes, changed, err := notesOf(t, latest(map[string]interface{}{}), true)
+		if err != nil || !changed {
+			t.Fatalf("expected a change, got %v %v", err, notes)
+		}
+		want := []string{"would clone " + url + " into " + target + " at branch main", "revision: " + first}
+		if strings.Join(notes, "|") != strings.Join(want, "|") {
+			t.Errorf("expected notes %v, got %v", want, notes)
+		}
+		if _, err := os.Stat(target); !os.IsNotExist(err) {
+			t.Errorf("expected %s not to be created", target)
+		}
+	})
+	t.Run("Clone", func(t *testing.T) {
+		notes, changed, err := notesOf(t, latest(map[string]interface{}{}), false)
+		if err != nil || !changed {
+			t.Fatalf("expected a change, got %v %v", err, notes)
+		}
+		if head() != first || run(t, target, "symbolic-ref", "--short", "HEAD") != "main" {
+			t.Errorf("expected main at %s", first)
+		}
+	})
+	t.Run("Unchanged", func(t *testing.T) {
+		notes, changed, err := notesOf(t, latest(map[string]interface{}{}), false)
+		if err != nil || changed {
+			t.Fatalf("expected no change, got %v %v", err, notes)
+		}
+	})
+	second := commit(t, upstream, "app.conf", "v2\n")
+	t.Run("TestUpdate", func(t *testing.T) {
+		notes, changed, err := notesOf(t, latest(map[string]interface{}{"rev": "main"}), true)
+		if err != nil || !changed {
+			t.Fatalf("expected a change, got %v %v", err, notes)
+		}
+		if notes[len(notes)-1] != "revision: "+first+" -> "+second {
+			t.Errorf("expected the old and new commit, got %v", notes)
+		}
+		if head() != first {
+			t.Errorf("expected test mode to leave %s alone", target)
+		}
+	})
+	t.Run("Update", func(t *testing.T) {
+		notes, changed, err := notesOf(t, latest(map[string]interface{}{"rev": "main"}), false)
+		if err != nil || !changed {
+			t.Fatalf("expected a change, got %v %v", err, notes)
+		}
+		if head() != second || notes[len(notes)-1] != "revision: "+first+" -> "+second {
+			t.Errorf("expected %s, got %s: %v", second, head(), notes)
+		}
+	})
+	t.Run("Tag", func(t *testing.T) {
+		if _, _, err := notesOf(t, latest(map[string]interface{}{"rev": "v1"}), false); err != nil {
+			t.Fatal(err)
+		}
+		if head() != first {
+			t.Errorf("expected the tagged commit %s, got %s", first, head())
+		}
+		if notes, changed, _ := notesOf(t, latest(map[string]interface{}{"rev": "v1"}), false); changed {
+			t.Errorf("expected checking out the tag again to change nothing: %v", notes)
+		}
+	})
+	t.Run("Commit", func(t *testing.T) {
+		if _, _, err := notesOf(t, latest(map[string]interface{}{"rev": second[:10]}), false); err != nil {
+			t.Fatal(err)
+		}
+		if head() != second {
+			t.Errorf("expected %s, got %s", second, head())
+		}
+	})
+	t.Run("Branch", func(t *testing.T) {
+		if _, _, err := notesOf(t, latest(map[string]interface{}{"rev": "dev"}), false); err != nil {
+			t.Fatal(err)
+		}
+		if head() != first || run(t, target, "symbolic-ref", "--short", "HEAD") != "dev" {
+			t.Errorf("expected dev at %s", first)
+		}
+	})
+	t.Run("LocalChanges", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(target, "app.conf"), []byte("local\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, _, err := notesOf(t, latest(map[string]interface{}{"rev": "main"}), false)
+		if !errors.Is(err, ErrLocalChanges) {
+			t.Fatalf("expected %v, got %v", ErrLocalChanges, err)
+		}
+		notes, changed, err := notesOf(t, latest(map[string]interface{}{"rev": "main", "force_reset": true}), false)
+		if err != nil || !changed {
+			t.Fatalf("expected a change, got %v %v", err, notes)
+		}
+		b, _ := os.ReadFile(filepath.Join(target, "app.conf"))
+		if head() != second || string(b) != "v2\n" {
+			t.Errorf("expected a clean checkout of %s, got %s with %q", second, head(), b)
+		}
+	})
+	t.Run("UnknownRevision", func(t *testing.T) {
+		_, _, err := notesOf(t, latest(map[string]interface{}{"rev": "no-such-branch"}), true)
+		if !errors.Is(err, ErrUnknownRevision) {
+			t.Errorf("expected %v, got %v", ErrUnknownRevision, err)
+		}
+	})
+	t.Run("NotRepository", func(t *testing.T) {
+		other := filepath.Join(t.TempDir(), "other")
+		if err := os.MkdirAll(other, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(other, "junk"), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		g := Git{id: "other", method: "latest", params: map[string]interface{}{"name": url, "target": other}}
+		if _, _, err := notesOf(t, g, false); !errors.Is(err, ErrNotRepository) {
+			t.Fatalf("expected %v, got %v", ErrNotRepository, err)
+		}
+		g.params["force_clone"] = true
+		if _, _, err := notesOf(t, g, false); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(other, "app.conf")); err != nil {
+			t.Errorf("expected a clone in %s: %v", other, err)
+		}
+	})
+	t.Run("Submodules", func(t *testing.T) {
+		parent := t.TempDir()
+		run(t, parent, "init", "-q")
+		run(t, parent, "submodule", "-q", "add", url, "app")
+		run(t, parent, "commit", "-q", "-m", "add app")
+		dest := filepath.Join(t.TempDir(), "parent")
+		g := Git{id: "parent", method: "latest", params: map[string]interface{}{"name": "file://" + parent, "target": dest, "submodules": true}}
+		if _, _, err := notesOf(t, g, false); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(dest, "app", "app.conf")); err != nil {
+			t.Errorf("expected the submodule to be checked out: %v", err)
+		}
+	})
+}
+
+func TestConfig(t *testing.T) {
+	gitEnv(t)
+	repo := t.TempDir()
+	run(t, repo, "init", "-q")
+	tests := []struct {
+		name    string
+		params  map[string]interface{}
+		test    bool
+		notes   []string
+		changed bool
+	}{
+		{
+			name:    "TestSet",
+			params:  map[string]interface{}{"name": "user.email", "value": "ops@example.com"},
+			test:    true,
+			notes:   []string{"user.email would be set to ops@example.com in the global config"},
+			changed: true,
+		},
+		{
+			name:    "Set",
+			params:  map[string]interface{}{"name": "user.email", "value": "ops@example.com"},
+			notes:   []string{"user.email has been set to ops@example.com in the global config"},
+			changed: true,
+		},
+		{
+			name:   "Unchanged",
+			params: map[string]interface{}{"name": "user.email", "value": "ops@example.com"},
+			notes:  []string{"user.email is already set to ops@example.com in the global config"},
+		},
+		{
+			name:    "Change",
+			params:  map[string]interface{}{"name": "user.email", "value": "dev@example.com"},
+			notes:   []string{"user.email has been set to dev@example.com in the global config", "user.email: ops@example.com -> dev@example.com"},
+			changed: true,
+		},
+		{
+			name:    "Unset",
+			params:  map[string]interface{}{"name": "user.email", "unset": true},
+			notes:   []string{"user.email has been unset in the global config"},
+			changed: true,
+		},
+		{
+			name:   "AlreadyUnset",
+			params: map[string]interface{}{"name": "user.email", "unset": true},
+			notes:  []string{"user.email is already unset in the global config"},
+		},
+		{
+			name:    "Repo",
+			params:  map[string]interface{}{"name": "core.filemode", "value": "false", "repo": repo},
+			notes:   []string{"core.filemode has been set to false in " + repo, "core.filemode: true -> false"},
+			changed: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := Git{id: test.name, method: "config", params: test.params}
+			notes, changed, err := notesOf(t, g, test.test)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if changed != test.changed {
+				t.Errorf("expected changed to be %v, got %v", test.changed, changed)
+			}
+			if strings.Join(notes, "|") != strings.Join(test.notes, "|") {
+				t.Errorf("expected notes %v, got %v", test.notes, notes)
+			}
+		})
+	}
+}
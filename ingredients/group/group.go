@@ -10,7 +10,10 @@ import (
 	"github.com/gogrlx/grlx/types"
 )
 
-var ErrGroupMethodUndefined = fmt.Errorf("group method undefined")
+var (
+	ErrGroupMethodUndefined = fmt.Errorf("group method undefined")
+	ErrConflictingMembers   = errors.New("members cannot be combined with addusers or delusers")
+)
 
 type Group struct {
 	id     string
@@ -87,26 +90,38 @@ func (g Group) Apply(ctx context.Context) (types.Result, error) {
 	}
 }
 
-func (g Group) PropertiesForMethod(method string) (map[string]string, error) {
+func (g Group) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
 	switch method {
 	case "absent":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name of the group"},
+		}, nil
 	case "exists":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name of the group"},
+		}, nil
 	case "present":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-			ingredients.MethodProps{Key: "gid", Type: "string", IsReq: false},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name of the group"},
+			ingredients.MethodProps{Key: "gid", Type: "string", IsReq: false, Description: "the group ID"},
+			ingredients.MethodProps{Key: "members", Type: "[]string", IsReq: false, Description: "the complete list of members"},
+			ingredients.MethodProps{Key: "addusers", Type: "[]string", IsReq: false, Description: "users to add to the group, leaving other members alone"},
+			ingredients.MethodProps{Key: "delusers", Type: "[]string", IsReq: false, Description: "users to remove from the group, leaving other members alone"},
+			ingredients.MethodProps{Key: "system", Type: "bool", IsReq: false, Description: "create a system group"},
+		}, nil
 	default:
 		return nil, fmt.Errorf("method %s undefined", method)
 	}
 }
 
+func (g Group) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := g.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
 func (u Group) Methods() (string, []string) {
 	return "group", []string{"absent", "exists", "present"}
 }
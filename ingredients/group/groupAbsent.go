@@ -2,49 +2,38 @@ package group
 
 import (
 	"context"
-	"errors"
-	"os/exec"
+	"fmt"
 
+	"github.com/gogrlx/grlx/ingredients/accounts"
 	"github.com/gogrlx/grlx/types"
 )
 
 func (g Group) absent(ctx context.Context, test bool) (types.Result, error) {
-	var result types.Result
-	result.Succeeded = true
-	result.Failed = false
-	groupName, ok := g.params["name"].(string)
-	if !ok {
-		result.Failed = true
-		result.Succeeded = false
-		return result, errors.New("invalid group; name must be a string")
+	notes := []fmt.Stringer{}
+	if err := g.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
 	}
-	if groupExists(groupName) {
-		if test {
-			return types.Result{
-				Succeeded: true,
-				Failed:    false,
-				Notes:     append(result.Notes, types.SimpleNote("group "+groupName+" would be deleted")),
-			}, nil
-		}
-		cmd := exec.CommandContext(ctx, "groupdel", groupName)
-		err := cmd.Run()
-		if err != nil {
-			result.Failed = true
-			result.Succeeded = false
-			result.Notes = append(result.Notes, types.SimpleNote("group "+groupName+" could not be deleted"))
-			result.Notes = append(result.Notes, types.SimpleNote(err.Error()))
-			return result, err
-		}
-		if !groupExists(groupName) {
-			result.Notes = append(result.Notes, types.SimpleNote("group "+groupName+" deleted"))
-			return result, nil
+	groupName := g.params["name"].(string)
+	existing, err := lookup(groupName)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if existing == nil {
+		notes = append(notes, types.SimpleNote("group "+groupName+" already absent, nothing to do"))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	}
+	if test {
+		notes = append(notes, types.SimpleNote("group "+groupName+" would be deleted"))
+		notes = append(notes, types.Snprintf("gid: %s", existing.GID))
+		if len(existing.Members) > 0 {
+			notes = append(notes, types.Snprintf("members: %v", existing.Members))
 		}
-		result.Failed = true
-		result.Succeeded = false
-		result.Notes = append(result.Notes, types.SimpleNote("group "+groupName+" could not be deleted"))
-		return result, errors.New("group " + groupName + " could not be deleted")
-
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+	if err = accounts.Run(ctx, "groupdel", groupName); err != nil {
+		notes = append(notes, types.SimpleNote("group "+groupName+" could not be deleted"))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
 	}
-	result.Notes = append(result.Notes, types.SimpleNote("group "+groupName+" already absent, nothing to do"))
-	return result, nil
+	notes = append(notes, types.SimpleNote("group "+groupName+" deleted"))
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
 }
@@ -3,7 +3,6 @@ package group
 import (
 	"context"
 	"errors"
-	"os/user"
 
 	"github.com/gogrlx/grlx/types"
 )
@@ -30,6 +29,6 @@ func (g Group) exists(ctx context.Context, test bool) (types.Result, error) {
 }
 
 func groupExists(name string) bool {
-	_, err := user.LookupGroup(name)
-	return err == nil
+	group, err := lookup(name)
+	return err == nil && group != nil
 }
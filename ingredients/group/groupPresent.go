@@ -2,72 +2,208 @@ package group
 
 import (
 	"context"
-	"errors"
-	"os/exec"
-	"os/user"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/gogrlx/grlx/ingredients/accounts"
 	"github.com/gogrlx/grlx/types"
 )
 
-func (g Group) present(ctx context.Context, test bool) (types.Result, error) {
-	var result types.Result
+// listProp reads a list of user names, which YAML delivers as a list of
+// interfaces.
+func (g Group) listProp(key string) ([]string, bool) {
+	var list []string
+	switch v := g.params[key].(type) {
+	case []interface{}:
+		for _, u := range v {
+			list = append(list, fmt.Sprintf("%v", u))
+		}
+	case []string:
+		list = append(list, v...)
+	case string:
+		list = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	default:
+		return nil, false
+	}
+	sort.Strings(list)
+	return list, true
+}
+
+// gidProp reads the gid property, which may arrive as a string or a number.
+func (g Group) gidProp() (string, bool) {
+	switch v := g.params["gid"].(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, v != ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return fmt.Sprintf("%v", v), true
+	}
+}
+
+// lookup returns the group called name, or nil if there is none. Unlike
+// accounts.LookupGroup it never matches on the group ID.
+func lookup(name string) (*accounts.GroupEntry, error) {
+	groups, err := accounts.Groups()
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		if groups[i].Name == name {
+			return &groups[i], nil
+		}
+	}
+	return nil, nil
+}
 
-	groupName, ok := g.params["name"].(string)
-	if groupName == "" || !ok {
-		result.Failed = true
-		result.Succeeded = false
-		return result, errors.New("invalid user; name must be a string")
+// members works out the desired membership of the group from its current
+// members. members replaces the list outright, while addusers and delusers
+// adjust it.
+func (g Group) members(current []string) ([]string, bool, error) {
+	members, hasMembers := g.listProp("members")
+	add, hasAdd := g.listProp("addusers")
+	del, hasDel := g.listProp("delusers")
+	if hasMembers && (hasAdd || hasDel) {
+		return nil, false, ErrConflictingMembers
+	}
+	if hasMembers {
+		return members, true, nil
 	}
-	args := []string{groupName}
+	if !hasAdd && !hasDel {
+		return current, false, nil
+	}
+	removed := map[string]bool{}
+	for _, u := range del {
+		removed[u] = true
+	}
+	seen := map[string]bool{}
+	want := []string{}
+	for _, u := range append(append([]string{}, current...), add...) {
+		if !seen[u] && !removed[u] {
+			seen[u] = true
+			want = append(want, u)
+		}
+	}
+	sort.Strings(want)
+	return want, true, nil
+}
 
-	gid := ""
-	if gidInter, ok := g.params["gid"]; ok {
-		gid, ok = gidInter.(string)
+// membershipCommands returns the gpasswd invocations which take the group
+// from current to want.
+func membershipCommands(name string, current, want []string, replace bool) [][]string {
+	if replace {
+		return [][]string{{"-M", strings.Join(want, ","), name}}
+	}
+	has := map[string]bool{}
+	for _, u := range current {
+		has[u] = true
+	}
+	wanted := map[string]bool{}
+	cmds := [][]string{}
+	for _, u := range want {
+		wanted[u] = true
+		if !has[u] {
+			cmds = append(cmds, []string{"-a", u, name})
+		}
 	}
-	if gid != "" {
-		args = append(args, "-g"+gid)
+	for _, u := range current {
+		if !wanted[u] {
+			cmds = append(cmds, []string{"-d", u, name})
+		}
 	}
+	return cmds
+}
 
-	groupByName, err := user.LookupGroup(groupName)
+// present makes sure the group exists with the given ID and members.
+// members is the complete list of members; addusers and delusers only add
+// and remove the users they name.
+func (g Group) present(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := g.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	groupName := g.params["name"].(string)
+	existing, err := lookup(groupName)
 	if err != nil {
-		cmd := exec.CommandContext(ctx, "groupadd", args...)
-		if test {
-			result.Succeeded = true
-			result.Failed = false
-			result.Changed = true
-			result.Notes = append(result.Notes, types.SimpleNote("would have added a group by executing: "+cmd.String()))
-			return result, nil
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if existing == nil {
+		return g.create(ctx, test, groupName)
+	}
+	current := append([]string{}, existing.Members...)
+	sort.Strings(current)
+	want, managed, err := g.members(current)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	changes := []fmt.Stringer{}
+	cmds := [][]string{}
+	if gid, ok := g.gidProp(); ok && gid != existing.GID {
+		changes = append(changes, types.Snprintf("gid: %s -> %s", existing.GID, gid))
+		cmds = append(cmds, []string{"groupmod", "-g", gid, groupName})
+	}
+	if managed && strings.Join(want, ",") != strings.Join(current, ",") {
+		changes = append(changes, types.Snprintf("members: %v -> %v", current, want))
+		_, replace := g.listProp("members")
+		for _, args := range membershipCommands(groupName, current, want, replace) {
+			cmds = append(cmds, append([]string{"gpasswd"}, args...))
 		}
-		err = cmd.Run()
-		if err != nil {
-			result.Failed = true
-			result.Succeeded = false
-			return result, err
+	}
+	if len(changes) == 0 {
+		notes = append(notes, types.Snprintf("group %s is already in the correct state", groupName))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	}
+	if test {
+		notes = append(notes, types.Snprintf("would update group %s", groupName))
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: append(notes, changes...)}, nil
+	}
+	for _, cmd := range cmds {
+		if err = accounts.Run(ctx, cmd[0], cmd[1:]...); err != nil {
+			notes = append(notes, types.Snprintf("failed to update group %s", groupName))
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
 		}
-		result.Changed = true
-		return result, nil
-	}
-	if groupByName == nil || groupByName.Gid != gid {
-		cmd := exec.CommandContext(ctx, "groupmod", args...)
-		if test {
-			result.Succeeded = true
-			result.Failed = false
-			result.Changed = true
-			result.Notes = append(result.Notes, types.SimpleNote("would have modified the existing group by executing: "+cmd.String()))
-			return result, nil
+	}
+	notes = append(notes, types.Snprintf("updated group %s", groupName))
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: append(notes, changes...)}, nil
+}
+
+// create adds the group with groupadd, then sets its members.
+func (g Group) create(ctx context.Context, test bool, groupName string) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	want, managed, err := g.members([]string{})
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	args := []string{}
+	if system, _ := g.params["system"].(bool); system {
+		args = append(args, "-r")
+	}
+	if gid, ok := g.gidProp(); ok {
+		args = append(args, "-g", gid)
+	}
+	args = append(args, groupName)
+	if test {
+		notes = append(notes, types.Snprintf("would create group %s", groupName))
+		if managed && len(want) > 0 {
+			notes = append(notes, types.Snprintf("members: %v", want))
 		}
-		err = cmd.Run()
-		if err != nil {
-			result.Failed = true
-			result.Succeeded = false
-			return result, err
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+	if err = accounts.Run(ctx, "groupadd", args...); err != nil {
+		notes = append(notes, types.Snprintf("failed to create group %s", groupName))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	notes = append(notes, types.Snprintf("created group %s", groupName))
+	if managed && len(want) > 0 {
+		if err = accounts.Run(ctx, "gpasswd", "-M", strings.Join(want, ","), groupName); err != nil {
+			notes = append(notes, types.Snprintf("failed to set the members of group %s", groupName))
+			return types.Result{Succeeded: false, Failed: true, Changed: true, Notes: notes}, err
 		}
-		result.Changed = true
-		return result, nil
-	}
-	result.Succeeded = true
-	result.Failed = false
-	result.Changed = false
-	result.Notes = append(result.Notes, types.SimpleNote("group already exists"))
-	return result, nil
+		notes = append(notes, types.Snprintf("members: %v", want))
+	}
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
 }
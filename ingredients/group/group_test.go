@@ -0,0 +1,182 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gogrlx/grlx/ingredients/accounts"
+	"github.com/gogrlx/grlx/types"
+)
+
+// setup points the accounts package at a temporary group database and
+// records the commands which would have been run.
+func setup(t *testing.T) *[]string {
+	t.Helper()
+	groupFile, run := accounts.GroupFile, accounts.Run
+	t.Cleanup(func() { accounts.GroupFile, accounts.Run = groupFile, run })
+	accounts.GroupFile = filepath.Join(t.TempDir(), "group")
+	content := "root:x:0:\nwheel:x:10:alice,bob\ndocker:x:999:\n"
+	if err := os.WriteFile(accounts.GroupFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	commands := &[]string{}
+	accounts.Run = func(ctx context.Context, name string, args ...string) error {
+		*commands = append(*commands, strings.TrimSpace(name+" "+strings.Join(args, " ")))
+		return nil
+	}
+	return commands
+}
+
+func TestGroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		params   map[string]interface{}
+		test     bool
+		notes    []string
+		changed  bool
+		commands []string
+		error    error
+	}{
+		{
+			name:     "Create",
+			method:   "present",
+			params:   map[string]interface{}{"name": "app", "gid": 1500, "system": true, "members": []interface{}{"carol", "alice"}},
+			notes:    []string{"created group app", "members: [alice carol]"},
+			changed:  true,
+			commands: []string{"groupadd -r -g 1500 app", "gpasswd -M alice,carol app"},
+		},
+		{
+			name:     "CreateWithoutGID",
+			method:   "present",
+			params:   map[string]interface{}{"name": "app", "gid": ""},
+			notes:    []string{"created group app"},
+			changed:  true,
+			commands: []string{"groupadd app"},
+		},
+		{
+			name:    "CreateTest",
+			method:  "present",
+			params:  map[string]interface{}{"name": "app", "addusers": []interface{}{"alice"}},
+			test:    true,
+			notes:   []string{"would create group app", "members: [alice]"},
+			changed: true,
+		},
+		{
+			name:   "Unchanged",
+			method: "present",
+			params: map[string]interface{}{"name": "wheel", "gid": "10", "members": []interface{}{"bob", "alice"}},
+			notes:  []string{"group wheel is already in the correct state"},
+		},
+		{
+			name:   "NoGID",
+			method: "present",
+			params: map[string]interface{}{"name": "docker"},
+			notes:  []string{"group docker is already in the correct state"},
+		},
+		{
+			name:     "Members",
+			method:   "present",
+			params:   map[string]interface{}{"name": "wheel", "gid": "11", "members": []interface{}{"alice", "carol"}},
+			notes:    []string{"updated group wheel", "gid: 10 -> 11", "members: [alice bob] -> [alice carol]"},
+			changed:  true,
+			commands: []string{"groupmod -g 11 wheel", "gpasswd -M alice,carol wheel"},
+		},
+		{
+			name:     "AddDelUsers",
+			method:   "present",
+			params:   map[string]interface{}{"name": "wheel", "addusers": []interface{}{"carol", "alice"}, "delusers": []interface{}{"bob", "dave"}},
+			notes:    []string{"updated group wheel", "members: [alice bob] -> [alice carol]"},
+			changed:  true,
+			commands: []string{"gpasswd -a carol wheel", "gpasswd -d bob wheel"},
+		},
+		{
+			name:   "AlreadyAdded",
+			method: "present",
+			params: map[string]interface{}{"name": "wheel", "addusers": []interface{}{"alice"}, "delusers": []interface{}{"carol"}},
+			notes:  []string{"group wheel is already in the correct state"},
+		},
+		{
+			name:    "UpdateTest",
+			method:  "present",
+			params:  map[string]interface{}{"name": "docker", "addusers": []interface{}{"alice"}},
+			test:    true,
+			notes:   []string{"would update group docker", "members: [] -> [alice]"},
+			changed: true,
+		},
+		{
+			name:   "Conflicting",
+			method: "present",
+			params: map[string]interface{}{"name": "wheel", "members": []interface{}{"alice"}, "addusers": []interface{}{"bob"}},
+			error:  ErrConflictingMembers,
+		},
+		{
+			name:     "Absent",
+			method:   "absent",
+			params:   map[string]interface{}{"name": "wheel"},
+			notes:    []string{"group wheel deleted"},
+			changed:  true,
+			commands: []string{"groupdel wheel"},
+		},
+		{
+			name:    "AbsentTest",
+			method:  "absent",
+			params:  map[string]interface{}{"name": "wheel"},
+			test:    true,
+			notes:   []string{"group wheel would be deleted", "gid: 10", "members: [alice bob]"},
+			changed: true,
+		},
+		{
+			name:   "AlreadyAbsent",
+			method: "absent",
+			params: map[string]interface{}{"name": "app"},
+			notes:  []string{"group app already absent, nothing to do"},
+		},
+		{
+			name:   "Exists",
+			method: "exists",
+			params: map[string]interface{}{"name": "docker"},
+			notes:  []string{"group docker exists"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			commands := setup(t)
+			g := Group{id: test.name, method: test.method, params: test.params}
+			var res types.Result
+			var err error
+			if test.test {
+				res, err = g.Test(context.Background())
+			} else {
+				res, err = g.Apply(context.Background())
+			}
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			notes := []string{}
+			for _, note := range res.Notes {
+				notes = append(notes, note.String())
+			}
+			if fmt.Sprint(notes) != fmt.Sprint(test.notes) {
+				t.Errorf("expected notes %q, got %q", test.notes, notes)
+			}
+			if res.Changed != test.changed {
+				t.Errorf("expected changed to be %v, got %v", test.changed, res.Changed)
+			}
+			if fmt.Sprint(*commands) != fmt.Sprint(test.commands) {
+				t.Errorf("expected commands %q, got %q", test.commands, *commands)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package host
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrHostMethodUndefined = errors.New("host method undefined")
+	ErrInvalidHostname     = errors.New("invalid hostname")
+	ErrInvalidIP           = errors.New("invalid IP address")
+)
+
+const hostsFile = "/etc/hosts"
+
+// root is prepended to every path the ingredient touches, so that tests can
+// work against a temporary directory.
+var root = "/"
+
+// Host manages the entries for a hostname in /etc/hosts.
+type Host struct {
+	id     string
+	method string
+	params map[string]interface{}
+}
+
+func (h Host) Parse(id, method string, params map[string]interface{}) (types.RecipeCooker, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return Host{
+		id: id, method: method,
+		params: params,
+	}, nil
+}
+
+func (h Host) validate() error {
+	set, err := h.PropertiesForMethod(h.method)
+	if err != nil {
+		return err
+	}
+	propSet, err := ingredients.PropMapToPropSet(set)
+	if err != nil {
+		return err
+	}
+	for _, v := range propSet {
+		if v.IsReq {
+			if v.Key == "name" {
+				name, ok := h.params[v.Key].(string)
+				if !ok {
+					return types.ErrMissingName
+				}
+				if name == "" {
+					return types.ErrMissingName
+				}
+
+			} else {
+				if _, ok := h.params[v.Key]; !ok {
+					return fmt.Errorf("missing required property %s", v.Key)
+				}
+			}
+		}
+	}
+	name := h.params["name"].(string)
+	if strings.ContainsAny(name, " \t\n#") {
+		return errors.Join(ErrInvalidHostname, fmt.Errorf("invalid hostname %q", name))
+	}
+	return nil
+}
+
+func (h Host) Test(ctx context.Context) (types.Result, error) {
+	switch h.method {
+	case "absent":
+		return h.absent(ctx, true)
+	case "present":
+		return h.present(ctx, true)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrHostMethodUndefined, fmt.Errorf("method %s undefined", h.method))
+	}
+}
+
+func (h Host) Apply(ctx context.Context) (types.Result, error) {
+	switch h.method {
+	case "absent":
+		return h.absent(ctx, false)
+	case "present":
+		return h.present(ctx, false)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrHostMethodUndefined, fmt.Errorf("method %s undefined", h.method))
+	}
+}
+
+// hostPath maps a system path to where it lives under root.
+func hostPath(p string) string {
+	return filepath.Join(root, filepath.FromSlash(p))
+}
+
+// ips reads the ip property, which may be a single address or a list,
+// returning the addresses in canonical form.
+func (h Host) ips() ([]string, error) {
+	var raw []string
+	switch v := h.params["ip"].(type) {
+	case nil:
+	case string:
+		raw = []string{v}
+	case []string:
+		raw = v
+	case []interface{}:
+		for _, ip := range v {
+			raw = append(raw, fmt.Sprintf("%v", ip))
+		}
+	default:
+		return nil, errors.Join(ErrInvalidIP, fmt.Errorf("ip must be an address or a list of them, not %v", v))
+	}
+	ips := []string{}
+	for _, ip := range raw {
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return nil, errors.Join(ErrInvalidIP, fmt.Errorf("invalid IP address %q", ip))
+		}
+		ips = append(ips, parsed.String())
+	}
+	return ips, nil
+}
+
+// readHosts returns the contents of /etc/hosts, and whether it exists.
+func readHosts() ([]byte, bool, error) {
+	b, err := os.ReadFile(hostPath(hostsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+func (h Host) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	switch method {
+	case "absent":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the hostname to remove"},
+			ingredients.MethodProps{Key: "ip", Type: "[]string", IsReq: false, Description: "only remove the hostname from these addresses"},
+		}, nil
+	case "present":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the hostname"},
+			ingredients.MethodProps{Key: "ip", Type: "[]string", IsReq: true, Description: "the addresses the hostname resolves to"},
+			ingredients.MethodProps{Key: "clean", Type: "bool", IsReq: false, Description: "remove the hostname from any other addresses"},
+		}, nil
+	default:
+		return nil, errors.Join(ErrHostMethodUndefined, fmt.Errorf("method %s undefined", method))
+	}
+}
+
+func (h Host) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := h.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (h Host) Methods() (string, []string) {
+	return "host", []string{"absent", "present"}
+}
+
+func (h Host) Properties() (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(h.params)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+func init() {
+	ingredients.RegisterAllMethods(Host{})
+}
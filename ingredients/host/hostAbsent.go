@@ -0,0 +1,37 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// absent removes name from every entry, or only from the entries for the
+// addresses in ip if it is set. Entries left without names are dropped.
+// Changes are shown as a diff.
+func (h Host) absent(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := h.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	name := h.params["name"].(string)
+	ips, err := h.ips()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	current, exists, err := readHosts()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+
+	entries := parseHosts(current)
+	for _, e := range entries {
+		if len(ips) > 0 && !slices.Contains(ips, e.ip) {
+			continue
+		}
+		e.remove(name)
+	}
+	return writeHosts(current, renderHosts(prune(entries)), exists, test, notes, "%s is already absent", name)
+}
@@ -0,0 +1,80 @@
+package host
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/gogrlx/grlx/ingredients/file"
+	"github.com/gogrlx/grlx/types"
+)
+
+// present makes name resolve to each address in ip. The name is added to
+// the first existing entry for an address, or a new entry is appended.
+// With clean set, the name is also removed from other addresses, and
+// entries left without names are dropped. Changes are shown as a diff.
+func (h Host) present(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := h.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	name := h.params["name"].(string)
+	ips, err := h.ips()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if len(ips) == 0 {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, fmt.Errorf("%w: ip must name at least one address", ErrInvalidIP)
+	}
+	clean, _ := h.params["clean"].(bool)
+	current, exists, err := readHosts()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+
+	entries := parseHosts(current)
+	for _, ip := range ips {
+		if slices.ContainsFunc(entries, func(e *entry) bool { return e.ip == ip && e.has(name) }) {
+			continue
+		}
+		if i := slices.IndexFunc(entries, func(e *entry) bool { return e.ip == ip }); i >= 0 {
+			entries[i].names = append(entries[i].names, name)
+			entries[i].edited = true
+		} else {
+			entries = append(entries, &entry{ip: ip, names: []string{name}, edited: true})
+		}
+	}
+	if clean {
+		for _, e := range entries {
+			if !slices.Contains(ips, e.ip) {
+				e.remove(name)
+			}
+		}
+		entries = prune(entries)
+	}
+	return writeHosts(current, renderHosts(entries), exists, test, notes, "%s already resolves to %v", name, ips)
+}
+
+// writeHosts replaces /etc/hosts with desired if it differs from
+// current, adding a diff to notes. If nothing changes, the note built
+// from format and args is reported instead.
+func writeHosts(current, desired []byte, exists, test bool, notes []fmt.Stringer, format string, args ...any) (types.Result, error) {
+	if bytes.Equal(current, desired) {
+		return types.Result{Succeeded: true, Failed: false, Notes: []fmt.Stringer{types.Snprintf(format, args...)}}, nil
+	}
+	oldName := hostsFile
+	if !exists {
+		oldName = "/dev/null"
+	}
+	diff := types.SimpleNote(file.UnifiedDiff(oldName, hostsFile, current, desired))
+	if test {
+		notes = append(notes, types.Snprintf("would update %s", hostsFile), diff)
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+	if err := file.WriteFileAtomic(hostPath(hostsFile), desired); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	notes = append(notes, types.Snprintf("updated %s", hostsFile), diff)
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+}
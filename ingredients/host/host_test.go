@@ -0,0 +1,127 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const hosts = `127.0.0.1	localhost
+# the database
+10.0.0.5	db db.internal # primary
+::1	localhost ip6-localhost
+`
+
+func TestHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		params  map[string]interface{}
+		hosts   string
+		test    bool
+		changed bool
+		want    string
+		error   error
+	}{
+		{
+			name: "PresentNewEntry", method: "present",
+			params: map[string]interface{}{"name": "web", "ip": "10.0.0.6"}, hosts: hosts,
+			changed: true, want: hosts + "10.0.0.6\tweb\n",
+		},
+		{
+			name: "PresentExistingAddress", method: "present",
+			params: map[string]interface{}{"name": "db-primary", "ip": "10.0.0.5"}, hosts: hosts,
+			changed: true, want: "127.0.0.1\tlocalhost\n# the database\n10.0.0.5\tdb db.internal db-primary # primary\n::1\tlocalhost ip6-localhost\n",
+		},
+		{
+			name: "PresentAlready", method: "present",
+			params: map[string]interface{}{"name": "DB", "ip": []interface{}{"10.0.0.5"}}, hosts: hosts,
+			want: hosts,
+		},
+		{
+			name: "PresentClean", method: "present",
+			params: map[string]interface{}{"name": "db", "ip": []string{"10.0.0.7", "fd00::7"}, "clean": true}, hosts: hosts,
+			changed: true, want: "127.0.0.1\tlocalhost\n# the database\n10.0.0.5\tdb.internal # primary\n::1\tlocalhost ip6-localhost\n10.0.0.7\tdb\nfd00::7\tdb\n",
+		},
+		{
+			name: "PresentNoFile", method: "present",
+			params:  map[string]interface{}{"name": "web", "ip": "10.0.0.6"},
+			changed: true, want: "10.0.0.6\tweb\n",
+		},
+		{
+			name: "PresentTest", method: "present",
+			params: map[string]interface{}{"name": "web", "ip": "10.0.0.6"}, hosts: hosts,
+			test: true, changed: true, want: hosts,
+		},
+		{
+			name: "Absent", method: "absent",
+			params: map[string]interface{}{"name": "localhost"}, hosts: hosts,
+			changed: true, want: "# the database\n10.0.0.5\tdb db.internal # primary\n::1\tip6-localhost\n",
+		},
+		{
+			name: "AbsentAddress", method: "absent",
+			params: map[string]interface{}{"name": "localhost", "ip": "0:0::1"}, hosts: hosts,
+			changed: true, want: "127.0.0.1\tlocalhost\n# the database\n10.0.0.5\tdb db.internal # primary\n::1\tip6-localhost\n",
+		},
+		{
+			name: "AbsentAlready", method: "absent",
+			params: map[string]interface{}{"name": "web"}, hosts: hosts,
+			want: hosts,
+		},
+		{name: "InvalidIP", method: "present", params: map[string]interface{}{"name": "web", "ip": "10.0.0"}, error: ErrInvalidIP},
+		{name: "InvalidHostname", method: "present", params: map[string]interface{}{"name": "web db", "ip": "10.0.0.6"}, error: ErrInvalidHostname},
+		{name: "BadMethod", method: "managed", params: map[string]interface{}{"name": "web"}, error: ErrHostMethodUndefined},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := root
+			t.Cleanup(func() { root = r })
+			root = t.TempDir()
+			if test.hosts != "" {
+				if err := os.MkdirAll(filepath.Dir(hostPath(hostsFile)), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(hostPath(hostsFile), []byte(test.hosts), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			} else if err := os.MkdirAll(hostPath("/etc"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			h := Host{id: test.name, method: test.method, params: test.params}
+			apply := h.Apply
+			if test.test {
+				apply = h.Test
+			}
+			res, err := apply(context.Background())
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !res.Succeeded || res.Changed != test.changed {
+				t.Errorf("unexpected result %+v", res)
+			}
+			got, _ := os.ReadFile(hostPath(hostsFile))
+			if string(got) != test.want {
+				t.Errorf("expected hosts\n%s\ngot\n%s", test.want, got)
+			}
+			if test.changed && len(res.Notes) != 2 {
+				t.Errorf("expected a note and a diff, got %v", res.Notes)
+			}
+			if test.test {
+				return
+			}
+			// a second run has nothing left to do
+			res, err = apply(context.Background())
+			if err != nil || res.Changed {
+				t.Errorf("expected a rerun not to change anything, got %+v, %v", res, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package host
+
+import (
+	"net"
+	"slices"
+	"strings"
+)
+
+// entry is a line of /etc/hosts. Lines without an address and a name,
+// such as comments, only have raw set and are written back unchanged, as
+// are entries which haven't been edited.
+type entry struct {
+	raw     string
+	ip      string
+	names   []string
+	comment string
+	edited  bool
+}
+
+func parseHosts(content []byte) []*entry {
+	entries := []*entry{}
+	if len(content) == 0 {
+		return entries
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+		e := &entry{raw: line}
+		fields, comment, _ := strings.Cut(line, "#")
+		if f := strings.Fields(fields); len(f) >= 2 {
+			e.ip, e.names, e.comment = f[0], f[1:], comment
+			// compare addresses in canonical form
+			if parsed := net.ParseIP(e.ip); parsed != nil {
+				e.ip = parsed.String()
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func renderHosts(entries []*entry) []byte {
+	var b strings.Builder
+	for _, e := range entries {
+		if !e.edited {
+			b.WriteString(e.raw + "\n")
+			continue
+		}
+		b.WriteString(e.ip + "\t" + strings.Join(e.names, " "))
+		if e.comment != "" {
+			b.WriteString(" #" + e.comment)
+		}
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
+
+// has reports whether the entry maps name to an address.
+func (e *entry) has(name string) bool {
+	return slices.ContainsFunc(e.names, func(n string) bool {
+		return strings.EqualFold(n, name)
+	})
+}
+
+// remove drops name from the entry.
+func (e *entry) remove(name string) {
+	if !e.has(name) {
+		return
+	}
+	e.names = slices.DeleteFunc(e.names, func(n string) bool {
+		return strings.EqualFold(n, name)
+	})
+	e.edited = true
+}
+
+// prune drops entries left without any names.
+func prune(entries []*entry) []*entry {
+	return slices.DeleteFunc(entries, func(e *entry) bool {
+		return e.ip != "" && len(e.names) == 0
+	})
+}
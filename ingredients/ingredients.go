@@ -30,11 +30,18 @@ func init() {
 	ingMap = make(IngredientMap)
 }
 
+// MethodProps describes one property of an ingredient method. Type is
+// one of string, []string, bool, map, int, duration or list, a list whose
+// entries are left as they are. An optional
+// property which is left out of a step takes Default, and a string
+// property with an Enum must be one of its values.
 type MethodProps struct {
 	Key         string
 	Type        string
 	IsReq       bool
 	Description string
+	Default     interface{}
+	Enum        []string
 }
 
 type MethodPropsSet []MethodProps
@@ -63,6 +70,16 @@ func PropMapToPropSet(pmap map[string]string) (MethodPropsSet, error) {
 		case "[]string":
 			fallthrough
 		case "bool":
+			fallthrough
+		case "map":
+			fallthrough
+		case "int":
+			fallthrough
+		case "duration":
+			fallthrough
+		case "mode":
+			fallthrough
+		case "list":
 			propset = append(propset, MethodProps{Key: k, Type: split[0], IsReq: isReq})
 		default:
 			return nil, fmt.Errorf("invalid Type value for key %s", k)
@@ -98,6 +115,14 @@ func RegisterAllMethods(step types.RecipeCooker) {
 	}
 }
 
+// IsRegistered reports whether an ingredient of that name is registered.
+func IsRegistered(name types.Ingredient) bool {
+	ingTex.Lock()
+	defer ingTex.Unlock()
+	_, ok := ingMap[name]
+	return ok
+}
+
 var (
 	ErrUnknownIngredient = errors.New("unknown ingredient")
 	ErrUnknownMethod     = errors.New("unknown method")
@@ -110,6 +135,14 @@ func NewRecipeCooker(id types.StepID, ingredient types.Ingredient, method string
 	log.Trace(ingMap)
 	if r, ok := ingMap[ingredient]; ok {
 		if ing, ok := r[method]; ok {
+			schema, err := schemaOf(ing, method)
+			if err != nil {
+				return nil, err
+			}
+			params, err = schema.Decode(params)
+			if err != nil {
+				return nil, err
+			}
 			return ing.Parse(string(id), method, params)
 		}
 		return nil, ErrUnknownMethod
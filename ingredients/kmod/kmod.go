@@ -0,0 +1,176 @@
+package kmod
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrKmodMethodUndefined = errors.New("kmod method undefined")
+	ErrInvalidModule       = errors.New("invalid module name")
+)
+
+const modulesLoadDir = "/etc/modules-load.d"
+
+// root is prepended to every path the ingredient touches, so that tests can
+// work against a temporary directory.
+var root = "/"
+
+// modprobe loads a module; tests replace it to avoid touching the kernel.
+var modprobe = func(ctx context.Context, name string) error {
+	out, err := exec.CommandContext(ctx, "modprobe", "--", name).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// Kmod manages kernel modules.
+type Kmod struct {
+	id     string
+	method string
+	params map[string]interface{}
+}
+
+func (k Kmod) Parse(id, method string, params map[string]interface{}) (types.RecipeCooker, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return Kmod{
+		id: id, method: method,
+		params: params,
+	}, nil
+}
+
+func (k Kmod) validate() error {
+	set, err := k.PropertiesForMethod(k.method)
+	if err != nil {
+		return err
+	}
+	propSet, err := ingredients.PropMapToPropSet(set)
+	if err != nil {
+		return err
+	}
+	for _, v := range propSet {
+		if v.IsReq {
+			if v.Key == "name" {
+				name, ok := k.params[v.Key].(string)
+				if !ok {
+					return types.ErrMissingName
+				}
+				if name == "" {
+					return types.ErrMissingName
+				}
+
+			} else {
+				if _, ok := k.params[v.Key]; !ok {
+					return fmt.Errorf("missing required property %s", v.Key)
+				}
+			}
+		}
+	}
+	name := k.params["name"].(string)
+	if name == "." || name == ".." || strings.HasPrefix(name, "-") || strings.ContainsAny(name, "/\\ \t\n#") {
+		return errors.Join(ErrInvalidModule, fmt.Errorf("invalid module name %q", name))
+	}
+	return nil
+}
+
+func (k Kmod) Test(ctx context.Context) (types.Result, error) {
+	switch k.method {
+	case "loaded":
+		return k.loaded(ctx, true)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrKmodMethodUndefined, fmt.Errorf("method %s undefined", k.method))
+	}
+}
+
+func (k Kmod) Apply(ctx context.Context) (types.Result, error) {
+	switch k.method {
+	case "loaded":
+		return k.loaded(ctx, false)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrKmodMethodUndefined, fmt.Errorf("method %s undefined", k.method))
+	}
+}
+
+// hostPath maps a system path to where it lives under root.
+func hostPath(p string) string {
+	return filepath.Join(root, filepath.FromSlash(p))
+}
+
+// isLoaded reports whether the module is loaded, or built into the
+// kernel. The kernel treats - and _ in module names as the same.
+func isLoaded(name string) (bool, error) {
+	name = strings.ReplaceAll(name, "-", "_")
+	modules, err := os.ReadFile(hostPath("/proc/modules"))
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	for _, line := range bytes.Split(modules, []byte("\n")) {
+		if fields := strings.Fields(string(line)); len(fields) > 0 && fields[0] == name {
+			return true, nil
+		}
+	}
+	// built in modules only show up here
+	if _, err = os.Stat(hostPath(path.Join("/sys/module", name))); err == nil {
+		return true, nil
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+	return false, nil
+}
+
+func (k Kmod) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	switch method {
+	case "loaded":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the module to load"},
+			ingredients.MethodProps{Key: "persist", Type: "bool", IsReq: false, Description: "load the module at boot through /etc/modules-load.d (default true)"},
+		}, nil
+	default:
+		return nil, errors.Join(ErrKmodMethodUndefined, fmt.Errorf("method %s undefined", method))
+	}
+}
+
+func (k Kmod) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := k.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (k Kmod) Methods() (string, []string) {
+	return "kmod", []string{"loaded"}
+}
+
+func (k Kmod) Properties() (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(k.params)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+func init() {
+	ingredients.RegisterAllMethods(Kmod{})
+}
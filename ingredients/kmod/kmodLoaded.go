@@ -0,0 +1,78 @@
+package kmod
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/gogrlx/grlx/ingredients/file"
+	"github.com/gogrlx/grlx/types"
+)
+
+// loaded loads the module with modprobe if it isn't already loaded, and
+// unless persist is false, lists it in a file of its own in
+// /etc/modules-load.d so that it is loaded at boot. Changes to the file
+// are shown as a diff.
+func (k Kmod) loaded(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := k.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	name := k.params["name"].(string)
+	persist := true
+	if v, ok := k.params["persist"].(bool); ok {
+		persist = v
+	}
+	loaded, err := isLoaded(name)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	conf := path.Join(modulesLoadDir, name+".conf")
+	desired := []byte(name + "\n")
+	current, err := os.ReadFile(hostPath(conf))
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	persisted := !persist || (exists && bytes.Equal(current, desired))
+	if loaded && persisted {
+		notes = append(notes, types.Snprintf("module %s is already loaded", name))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	}
+	oldName := conf
+	if !exists {
+		oldName = "/dev/null"
+	}
+	diff := types.SimpleNote(file.UnifiedDiff(oldName, conf, current, desired))
+
+	if test {
+		if !loaded {
+			notes = append(notes, types.Snprintf("would load module %s", name))
+		}
+		if !persisted {
+			notes = append(notes, types.Snprintf("would persist module %s to %s", name, conf), diff)
+		}
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+	changed := false
+	if !loaded {
+		if err = modprobe(ctx, name); err != nil {
+			notes = append(notes, types.Snprintf("failed to load module %s", name))
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		changed = true
+		notes = append(notes, types.Snprintf("loaded module %s", name))
+	}
+	if !persisted {
+		if err = os.MkdirAll(hostPath(modulesLoadDir), 0o755); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Changed: changed, Notes: notes}, err
+		}
+		if err = file.WriteFileAtomic(hostPath(conf), desired); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Changed: changed, Notes: notes}, err
+		}
+		notes = append(notes, types.Snprintf("persisted module %s to %s", name, conf), diff)
+	}
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+}
@@ -0,0 +1,100 @@
+package kmod
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoaded(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    map[string]interface{}
+		modules   string
+		builtin   string
+		conf      string
+		test      bool
+		changed   bool
+		probed    bool
+		persisted bool
+		error     error
+	}{
+		{name: "Load", params: map[string]interface{}{"name": "br_netfilter"}, changed: true, probed: true, persisted: true},
+		{name: "AlreadyLoaded", params: map[string]interface{}{"name": "overlay"}, modules: "overlay 151552 0 - Live 0x0000000000000000\n", conf: "overlay\n", persisted: true},
+		{name: "Persist", params: map[string]interface{}{"name": "nf-conntrack"}, modules: "nf_conntrack 172032 1 - Live 0x0000000000000000\n", changed: true, persisted: true},
+		{name: "Builtin", params: map[string]interface{}{"name": "loop", "persist": false}, builtin: "loop"},
+		{name: "NoPersist", params: map[string]interface{}{"name": "dummy", "persist": false}, changed: true, probed: true},
+		{name: "Test", params: map[string]interface{}{"name": "dummy"}, test: true, changed: true},
+		{name: "ProbeFails", params: map[string]interface{}{"name": "missing"}, probed: true, error: os.ErrNotExist},
+		{name: "InvalidName", params: map[string]interface{}{"name": "../dummy"}, error: ErrInvalidModule},
+		{name: "Option", params: map[string]interface{}{"name": "-r"}, error: ErrInvalidModule},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, m := root, modprobe
+			t.Cleanup(func() { root, modprobe = r, m })
+			root = t.TempDir()
+			if err := os.MkdirAll(hostPath("/proc"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(hostPath("/proc/modules"), []byte(test.modules), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if test.builtin != "" {
+				if err := os.MkdirAll(hostPath("/sys/module/"+test.builtin), 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			name, _ := test.params["name"].(string)
+			conf := hostPath(filepath.Join(modulesLoadDir, name+".conf"))
+			if test.conf != "" {
+				if err := os.MkdirAll(filepath.Dir(conf), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(conf, []byte(test.conf), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			probed := false
+			modprobe = func(ctx context.Context, module string) error {
+				probed = true
+				if module == "missing" {
+					return os.ErrNotExist
+				}
+				return nil
+			}
+
+			k := Kmod{id: test.name, method: "loaded", params: test.params}
+			apply := k.Apply
+			if test.test {
+				apply = k.Test
+			}
+			res, err := apply(context.Background())
+			if probed != test.probed {
+				t.Errorf("expected modprobe to be called: %t", test.probed)
+			}
+			if test.error != nil {
+				if !errors.Is(err, test.error) || !res.Failed {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !res.Succeeded || res.Changed != test.changed {
+				t.Errorf("unexpected result %+v", res)
+			}
+			got, err := os.ReadFile(conf)
+			if test.persisted {
+				if err != nil || string(got) != name+"\n" {
+					t.Errorf("expected %s to list %s, got %q, %v", conf, name, got, err)
+				}
+			} else if !os.IsNotExist(err) {
+				t.Errorf("expected %s not to be written", conf)
+			}
+		})
+	}
+}
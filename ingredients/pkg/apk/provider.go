@@ -0,0 +1,155 @@
+package apk
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gogrlx/grlx/ingredients/pkg"
+	"github.com/gogrlx/grlx/types"
+)
+
+// worldFile lists the packages apk has been asked for, along with any
+// version constraints.
+var worldFile = "/etc/apk/world"
+
+type ApkPackages struct {
+	id     string
+	method string
+	props  map[string]interface{}
+}
+
+func init() {
+	pkg.RegisterProvider(ApkPackages{})
+}
+
+func (a ApkPackages) Properties() (map[string]interface{}, error) {
+	return a.props, nil
+}
+
+func (a ApkPackages) Parse(id, method string, properties map[string]interface{}) (types.PackageProvider, error) {
+	if properties == nil {
+		properties = make(map[string]interface{})
+	}
+	return ApkPackages{id: id, method: method, props: properties}, nil
+}
+
+// parseList finds the version of name in the output of apk list, whose
+// lines look like "name-1.2.3-r0 x86_64 {origin} (license) [installed]".
+func parseList(out, name string) string {
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		version, ok := strings.CutPrefix(fields[0], name+"-")
+		// the name itself may contain dashes, so make sure what is left
+		// is a version and not the rest of a longer package name
+		if ok && len(version) > 0 && version[0] >= '0' && version[0] <= '9' && strings.Count(version, "-") == 1 {
+			return version
+		}
+	}
+	return ""
+}
+
+func (a ApkPackages) Version(ctx context.Context, name string) (string, error) {
+	out, err := pkg.Run(ctx, nil, "apk", "list", "--installed", name)
+	if err != nil {
+		return "", err
+	}
+	return parseList(out, name), nil
+}
+
+func (a ApkPackages) Candidate(ctx context.Context, name string) (string, error) {
+	out, err := pkg.Run(ctx, nil, "apk", "list", "--available", name)
+	if err != nil {
+		return "", err
+	}
+	return parseList(out, name), nil
+}
+
+func (a ApkPackages) Refresh(ctx context.Context) error {
+	_, err := pkg.Run(ctx, nil, "apk", "update", "-q")
+	return err
+}
+
+func (a ApkPackages) Install(ctx context.Context, pkgs []types.Package) error {
+	args := []string{"add", "-q"}
+	for _, p := range pkgs {
+		held, err := a.IsHeld(ctx, p.Name)
+		if err != nil {
+			return err
+		}
+		switch {
+		case p.Version == "":
+			args = append(args, p.Name)
+		case held:
+			// keep the package pinned at its new version
+			args = append(args, p.Name+"="+p.Version)
+		default:
+			// install the exact version without leaving a pin in the world
+			// file, which would hold the package
+			args = append(args, p.Name+"~"+p.Version)
+		}
+	}
+	_, err := pkg.Run(ctx, nil, "apk", args...)
+	return err
+}
+
+func (a ApkPackages) Remove(ctx context.Context, names []string) error {
+	_, err := pkg.Run(ctx, nil, "apk", append([]string{"del", "-q"}, names...)...)
+	return err
+}
+
+func (a ApkPackages) Purge(ctx context.Context, names []string) error {
+	_, err := pkg.Run(ctx, nil, "apk", append([]string{"del", "-q", "--purge"}, names...)...)
+	return err
+}
+
+// Hold pins each package to its installed version in the world file.
+func (a ApkPackages) Hold(ctx context.Context, names []string) error {
+	args := []string{"add", "-q"}
+	for _, name := range names {
+		version, err := a.Version(ctx, name)
+		if err != nil {
+			return err
+		}
+		args = append(args, name+"="+version)
+	}
+	_, err := pkg.Run(ctx, nil, "apk", args...)
+	return err
+}
+
+func (a ApkPackages) Unhold(ctx context.Context, names []string) error {
+	_, err := pkg.Run(ctx, nil, "apk", append([]string{"add", "-q"}, names...)...)
+	return err
+}
+
+func (a ApkPackages) IsHeld(ctx context.Context, name string) (bool, error) {
+	f, err := os.Open(worldFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.HasPrefix(strings.TrimSpace(scanner.Text()), name+"=") {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+func (a ApkPackages) ManagerName() string {
+	return "apk"
+}
+
+func (a ApkPackages) IsManager() bool {
+	_, err := exec.LookPath("apk")
+	return err == nil
+}
@@ -0,0 +1,139 @@
+package apt
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+
+	"github.com/gogrlx/grlx/ingredients/pkg"
+	"github.com/gogrlx/grlx/types"
+)
+
+// env keeps apt and dpkg from prompting, and from replacing configuration
+// files which have been changed locally.
+var env = []string{"DEBIAN_FRONTEND=noninteractive", "APT_LISTCHANGES_FRONTEND=none"}
+
+var aptOpts = []string{
+	"-y", "-q",
+	"-o", "Dpkg::Options::=--force-confdef",
+	"-o", "Dpkg::Options::=--force-confold",
+}
+
+type AptPackages struct {
+	id     string
+	method string
+	props  map[string]interface{}
+}
+
+func init() {
+	pkg.RegisterProvider(AptPackages{})
+}
+
+func (a AptPackages) Properties() (map[string]interface{}, error) {
+	return a.props, nil
+}
+
+func (a AptPackages) Parse(id, method string, properties map[string]interface{}) (types.PackageProvider, error) {
+	if properties == nil {
+		properties = make(map[string]interface{})
+	}
+	return AptPackages{id: id, method: method, props: properties}, nil
+}
+
+func (a AptPackages) Version(ctx context.Context, name string) (string, error) {
+	out, err := pkg.Run(ctx, env, "dpkg-query", "-W", "-f=${db:Status-Status}\t${Version}", name)
+	if err != nil {
+		// dpkg-query exits non-zero for packages it has never heard of
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", nil
+		}
+		return "", err
+	}
+	status, version, _ := strings.Cut(strings.TrimSpace(out), "\t")
+	if status != "installed" {
+		return "", nil
+	}
+	return version, nil
+}
+
+func (a AptPackages) Candidate(ctx context.Context, name string) (string, error) {
+	out, err := pkg.Run(ctx, env, "apt-cache", "policy", name)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if candidate, ok := strings.CutPrefix(strings.TrimSpace(line), "Candidate:"); ok {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "(none)" {
+				return "", nil
+			}
+			return candidate, nil
+		}
+	}
+	return "", nil
+}
+
+func (a AptPackages) Refresh(ctx context.Context) error {
+	_, err := pkg.Run(ctx, env, "apt-get", "-q", "update")
+	return err
+}
+
+func (a AptPackages) Install(ctx context.Context, pkgs []types.Package) error {
+	args := append([]string{"install"}, aptOpts...)
+	// allow pinned versions to be older than what is installed
+	args = append(args, "--allow-downgrades")
+	for _, p := range pkgs {
+		if p.Version != "" {
+			args = append(args, p.Name+"="+p.Version)
+		} else {
+			args = append(args, p.Name)
+		}
+	}
+	_, err := pkg.Run(ctx, env, "apt-get", args...)
+	return err
+}
+
+func (a AptPackages) Remove(ctx context.Context, names []string) error {
+	_, err := pkg.Run(ctx, env, "apt-get", append(append([]string{"remove"}, aptOpts...), names...)...)
+	return err
+}
+
+func (a AptPackages) Purge(ctx context.Context, names []string) error {
+	_, err := pkg.Run(ctx, env, "apt-get", append(append([]string{"purge"}, aptOpts...), names...)...)
+	return err
+}
+
+func (a AptPackages) Hold(ctx context.Context, names []string) error {
+	_, err := pkg.Run(ctx, env, "apt-mark", append([]string{"hold"}, names...)...)
+	return err
+}
+
+func (a AptPackages) Unhold(ctx context.Context, names []string) error {
+	_, err := pkg.Run(ctx, env, "apt-mark", append([]string{"unhold"}, names...)...)
+	return err
+}
+
+func (a AptPackages) IsHeld(ctx context.Context, name string) (bool, error) {
+	out, err := pkg.Run(ctx, env, "apt-mark", "showhold", name)
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (a AptPackages) ManagerName() string {
+	return "apt"
+}
+
+func (a AptPackages) IsManager() bool {
+	_, aptErr := exec.LookPath("apt-get")
+	_, dpkgErr := exec.LookPath("dpkg-query")
+	return aptErr == nil && dpkgErr == nil
+}
@@ -0,0 +1,153 @@
+package dnf
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+
+	"github.com/gogrlx/grlx/ingredients/pkg"
+	"github.com/gogrlx/grlx/types"
+)
+
+type DnfPackages struct {
+	id     string
+	method string
+	props  map[string]interface{}
+}
+
+func init() {
+	pkg.RegisterProvider(DnfPackages{})
+}
+
+func (d DnfPackages) Properties() (map[string]interface{}, error) {
+	return d.props, nil
+}
+
+func (d DnfPackages) Parse(id, method string, properties map[string]interface{}) (types.PackageProvider, error) {
+	if properties == nil {
+		properties = make(map[string]interface{})
+	}
+	return DnfPackages{id: id, method: method, props: properties}, nil
+}
+
+func (d DnfPackages) Version(ctx context.Context, name string) (string, error) {
+	out, err := pkg.Run(ctx, nil, "rpm", "-q", "--qf", "%{VERSION}-%{RELEASE}\n", name)
+	if err != nil {
+		// rpm exits non-zero when the package is not installed
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", nil
+		}
+		return "", err
+	}
+	// multilib systems may list a package once per architecture
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return strings.TrimSpace(lines[len(lines)-1]), nil
+}
+
+func (d DnfPackages) Candidate(ctx context.Context, name string) (string, error) {
+	out, err := pkg.Run(ctx, nil, "dnf", "-q", "repoquery", "--latest-limit", "1", "--qf", "%{version}-%{release}\n", name)
+	if err != nil {
+		return "", err
+	}
+	version := ""
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			version = line
+		}
+	}
+	return version, nil
+}
+
+func (d DnfPackages) Refresh(ctx context.Context) error {
+	_, err := pkg.Run(ctx, nil, "dnf", "-q", "makecache", "--refresh")
+	return err
+}
+
+func (d DnfPackages) Install(ctx context.Context, pkgs []types.Package) error {
+	specs := []string{}
+	for _, p := range pkgs {
+		if p.Version != "" {
+			specs = append(specs, p.Name+"-"+p.Version)
+		} else {
+			specs = append(specs, p.Name)
+		}
+	}
+	if _, err := pkg.Run(ctx, nil, "dnf", append([]string{"-y", "-q", "install"}, specs...)...); err != nil {
+		return err
+	}
+	// dnf install won't move an installed package to an older version, so
+	// anything still not at its pinned version needs downgrading
+	var downgrade []string
+	for i, p := range pkgs {
+		if p.Version == "" {
+			continue
+		}
+		current, err := d.Version(ctx, p.Name)
+		if err != nil {
+			return err
+		}
+		if current != p.Version {
+			downgrade = append(downgrade, specs[i])
+		}
+	}
+	if len(downgrade) > 0 {
+		if _, err := pkg.Run(ctx, nil, "dnf", append([]string{"-y", "-q", "downgrade"}, downgrade...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d DnfPackages) Remove(ctx context.Context, names []string) error {
+	_, err := pkg.Run(ctx, nil, "dnf", append([]string{"-y", "-q", "remove"}, names...)...)
+	return err
+}
+
+// Purge is the same as Remove, since rpm removes unmodified configuration
+// files along with the package.
+func (d DnfPackages) Purge(ctx context.Context, names []string) error {
+	return d.Remove(ctx, names)
+}
+
+func (d DnfPackages) Hold(ctx context.Context, names []string) error {
+	_, err := pkg.Run(ctx, nil, "dnf", append([]string{"-q", "versionlock", "add"}, names...)...)
+	if err != nil {
+		return errors.Join(pkg.ErrHoldUnsupported, err)
+	}
+	return nil
+}
+
+func (d DnfPackages) Unhold(ctx context.Context, names []string) error {
+	_, err := pkg.Run(ctx, nil, "dnf", append([]string{"-q", "versionlock", "delete"}, names...)...)
+	if err != nil {
+		return errors.Join(pkg.ErrHoldUnsupported, err)
+	}
+	return nil
+}
+
+func (d DnfPackages) IsHeld(ctx context.Context, name string) (bool, error) {
+	out, err := pkg.Run(ctx, nil, "dnf", "-q", "versionlock", "list")
+	if err != nil {
+		return false, errors.Join(pkg.ErrHoldUnsupported, err)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		// entries look like name-0:1.2-3.fc40.*
+		line = strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(line, name+"-"); ok && len(rest) > 0 && rest[0] >= '0' && rest[0] <= '9' {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (d DnfPackages) ManagerName() string {
+	return "dnf"
+}
+
+func (d DnfPackages) IsManager() bool {
+	_, dnfErr := exec.LookPath("dnf")
+	_, rpmErr := exec.LookPath("rpm")
+	return dnfErr == nil && rpmErr == nil
+}
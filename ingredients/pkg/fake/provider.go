@@ -0,0 +1,167 @@
+// Package fake provides an in-memory package manager for testing the pkg
+// ingredient. It is never detected as the system package manager, so it
+// must be registered explicitly and selected with the provider property.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// Repo is the shared state of a fake package manager.
+type Repo struct {
+	mutex sync.Mutex
+	// Available maps package names to every version that can be installed,
+	// newest last
+	Available map[string][]string
+	Installed map[string]string
+	Held      map[string]bool
+	// Transactions records each call which changed the system
+	Transactions []string
+	Refreshes    int
+}
+
+type FakePackages struct {
+	repo  *Repo
+	props map[string]interface{}
+}
+
+// New returns a provider backed by repo.
+func New(repo *Repo) FakePackages {
+	if repo.Available == nil {
+		repo.Available = map[string][]string{}
+	}
+	if repo.Installed == nil {
+		repo.Installed = map[string]string{}
+	}
+	if repo.Held == nil {
+		repo.Held = map[string]bool{}
+	}
+	return FakePackages{repo: repo}
+}
+
+func (f FakePackages) Properties() (map[string]interface{}, error) {
+	return f.props, nil
+}
+
+func (f FakePackages) Parse(id, method string, properties map[string]interface{}) (types.PackageProvider, error) {
+	return FakePackages{repo: f.repo, props: properties}, nil
+}
+
+func (f FakePackages) Version(ctx context.Context, name string) (string, error) {
+	f.repo.mutex.Lock()
+	defer f.repo.mutex.Unlock()
+	return f.repo.Installed[name], nil
+}
+
+func (f FakePackages) Candidate(ctx context.Context, name string) (string, error) {
+	f.repo.mutex.Lock()
+	defer f.repo.mutex.Unlock()
+	versions := f.repo.Available[name]
+	if len(versions) == 0 {
+		return "", nil
+	}
+	return versions[len(versions)-1], nil
+}
+
+func (f FakePackages) Refresh(ctx context.Context) error {
+	f.repo.mutex.Lock()
+	defer f.repo.mutex.Unlock()
+	f.repo.Refreshes++
+	return nil
+}
+
+func (f FakePackages) Install(ctx context.Context, pkgs []types.Package) error {
+	f.repo.mutex.Lock()
+	defer f.repo.mutex.Unlock()
+	specs := []string{}
+	for _, pkg := range pkgs {
+		versions := f.repo.Available[pkg.Name]
+		if len(versions) == 0 {
+			return fmt.Errorf("package %s not found", pkg.Name)
+		}
+		version := versions[len(versions)-1]
+		if pkg.Version != "" {
+			found := false
+			for _, v := range versions {
+				if v == pkg.Version {
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("version %s of package %s not found", pkg.Version, pkg.Name)
+			}
+			version = pkg.Version
+		}
+		if f.repo.Held[pkg.Name] && f.repo.Installed[pkg.Name] != version {
+			return fmt.Errorf("package %s is held", pkg.Name)
+		}
+		specs = append(specs, pkg.Name+"="+version)
+	}
+	for _, spec := range specs {
+		name, version, _ := strings.Cut(spec, "=")
+		f.repo.Installed[name] = version
+	}
+	f.repo.Transactions = append(f.repo.Transactions, "install "+strings.Join(specs, " "))
+	return nil
+}
+
+func (f FakePackages) remove(verb string, names []string) error {
+	f.repo.mutex.Lock()
+	defer f.repo.mutex.Unlock()
+	for _, name := range names {
+		delete(f.repo.Installed, name)
+		delete(f.repo.Held, name)
+	}
+	f.repo.Transactions = append(f.repo.Transactions, verb+" "+strings.Join(names, " "))
+	return nil
+}
+
+func (f FakePackages) Remove(ctx context.Context, names []string) error {
+	return f.remove("remove", names)
+}
+
+func (f FakePackages) Purge(ctx context.Context, names []string) error {
+	return f.remove("purge", names)
+}
+
+func (f FakePackages) Hold(ctx context.Context, names []string) error {
+	f.repo.mutex.Lock()
+	defer f.repo.mutex.Unlock()
+	for _, name := range names {
+		f.repo.Held[name] = true
+	}
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+	f.repo.Transactions = append(f.repo.Transactions, "hold "+strings.Join(sorted, " "))
+	return nil
+}
+
+func (f FakePackages) Unhold(ctx context.Context, names []string) error {
+	f.repo.mutex.Lock()
+	defer f.repo.mutex.Unlock()
+	for _, name := range names {
+		delete(f.repo.Held, name)
+	}
+	f.repo.Transactions = append(f.repo.Transactions, "unhold "+strings.Join(names, " "))
+	return nil
+}
+
+func (f FakePackages) IsHeld(ctx context.Context, name string) (bool, error) {
+	f.repo.mutex.Lock()
+	defer f.repo.mutex.Unlock()
+	return f.repo.Held[name], nil
+}
+
+func (f FakePackages) ManagerName() string {
+	return "fake"
+}
+
+func (f FakePackages) IsManager() bool {
+	return false
+}
@@ -0,0 +1,127 @@
+package pacman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/gogrlx/grlx/ingredients/pkg"
+	"github.com/gogrlx/grlx/types"
+)
+
+type PacmanPackages struct {
+	id     string
+	method string
+	props  map[string]interface{}
+}
+
+func init() {
+	pkg.RegisterProvider(PacmanPackages{})
+}
+
+func (p PacmanPackages) Properties() (map[string]interface{}, error) {
+	return p.props, nil
+}
+
+func (p PacmanPackages) Parse(id, method string, properties map[string]interface{}) (types.PackageProvider, error) {
+	if properties == nil {
+		properties = make(map[string]interface{})
+	}
+	return PacmanPackages{id: id, method: method, props: properties}, nil
+}
+
+func (p PacmanPackages) Version(ctx context.Context, name string) (string, error) {
+	out, err := pkg.Run(ctx, nil, "pacman", "-Q", name)
+	if err != nil {
+		// pacman exits non-zero when the package is not installed
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", nil
+		}
+		return "", err
+	}
+	fields := strings.Fields(out)
+	if len(fields) < 2 || fields[0] != name {
+		return "", nil
+	}
+	return fields[1], nil
+}
+
+func (p PacmanPackages) Candidate(ctx context.Context, name string) (string, error) {
+	out, err := pkg.Run(ctx, nil, "pacman", "-Si", name)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", nil
+		}
+		return "", err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(key) == "Version" {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", nil
+}
+
+func (p PacmanPackages) Refresh(ctx context.Context) error {
+	_, err := pkg.Run(ctx, nil, "pacman", "-Sy", "--noconfirm")
+	return err
+}
+
+// Install installs packages from the sync repositories, which only carry
+// the latest version of each package, so other versions can't be pinned.
+func (p PacmanPackages) Install(ctx context.Context, pkgs []types.Package) error {
+	args := []string{"-S", "--noconfirm", "--needed"}
+	for _, pk := range pkgs {
+		if pk.Version != "" {
+			candidate, err := p.Candidate(ctx, pk.Name)
+			if err != nil {
+				return err
+			}
+			if candidate != pk.Version {
+				return errors.Join(pkg.ErrPinUnsupported, fmt.Errorf("%s %s is not the version in the repositories (%s)", pk.Name, pk.Version, candidate))
+			}
+		}
+		args = append(args, pk.Name)
+	}
+	_, err := pkg.Run(ctx, nil, "pacman", args...)
+	return err
+}
+
+func (p PacmanPackages) Remove(ctx context.Context, names []string) error {
+	_, err := pkg.Run(ctx, nil, "pacman", append([]string{"-R", "--noconfirm"}, names...)...)
+	return err
+}
+
+// Purge also removes the configuration files pacman saved as .pacsave and
+// dependencies nothing else needs.
+func (p PacmanPackages) Purge(ctx context.Context, names []string) error {
+	_, err := pkg.Run(ctx, nil, "pacman", append([]string{"-Rns", "--noconfirm"}, names...)...)
+	return err
+}
+
+// Hold is not supported, as pacman only reads IgnorePkg from pacman.conf.
+func (p PacmanPackages) Hold(ctx context.Context, names []string) error {
+	return pkg.ErrHoldUnsupported
+}
+
+func (p PacmanPackages) Unhold(ctx context.Context, names []string) error {
+	return pkg.ErrHoldUnsupported
+}
+
+func (p PacmanPackages) IsHeld(ctx context.Context, name string) (bool, error) {
+	return false, nil
+}
+
+func (p PacmanPackages) ManagerName() string {
+	return "pacman"
+}
+
+func (p PacmanPackages) IsManager() bool {
+	_, err := exec.LookPath("pacman")
+	return err == nil
+}
@@ -0,0 +1,212 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrPkgMethodUndefined = errors.New("pkg method undefined")
+	ErrNoPackages         = errors.New("one of name or pkgs must be set")
+)
+
+type Pkg struct {
+	id     string
+	method string
+	params map[string]interface{}
+}
+
+func (p Pkg) Parse(id, method string, params map[string]interface{}) (types.RecipeCooker, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return Pkg{
+		id: id, method: method,
+		params: params,
+	}, nil
+}
+
+func (p Pkg) validate() error {
+	set, err := p.PropertiesForMethod(p.method)
+	if err != nil {
+		return err
+	}
+	propSet, err := ingredients.PropMapToPropSet(set)
+	if err != nil {
+		return err
+	}
+	for _, v := range propSet {
+		if v.IsReq {
+			if v.Key == "name" {
+				name, ok := p.params[v.Key].(string)
+				if !ok {
+					return types.ErrMissingName
+				}
+				if name == "" {
+					return types.ErrMissingName
+				}
+
+			} else {
+				if _, ok := p.params[v.Key]; !ok {
+					return fmt.Errorf("missing required property %s", v.Key)
+				}
+			}
+		}
+	}
+	// a step manages either the package in name or the ones in pkgs
+	name, _ := p.params["name"].(string)
+	if name == "" && len(p.pkgList()) == 0 {
+		return ErrNoPackages
+	}
+	return nil
+}
+
+// pkgList returns the entries of pkgs, which may have been decoded as a
+// list of strings.
+func (p Pkg) pkgList() []interface{} {
+	switch pkgs := p.params["pkgs"].(type) {
+	case []interface{}:
+		return pkgs
+	case []string:
+		list := make([]interface{}, len(pkgs))
+		for i, pkg := range pkgs {
+			list[i] = pkg
+		}
+		return list
+	}
+	return nil
+}
+
+// packages returns the packages a step refers to: the entries of pkgs if
+// it is set, otherwise name pinned to version. Entries of pkgs may be
+// plain names, "name=version" strings, or single-key maps of name to
+// version.
+func (p Pkg) packages() ([]types.Package, error) {
+	pkgsI := p.pkgList()
+	if len(pkgsI) == 0 {
+		name, _ := p.params["name"].(string)
+		if name == "" {
+			return nil, types.ErrMissingName
+		}
+		version := ""
+		if v, ok := p.params["version"]; ok && v != nil {
+			version = fmt.Sprintf("%v", v)
+		}
+		return []types.Package{{Name: name, Version: version}}, nil
+	}
+	pkgs := []types.Package{}
+	for _, entry := range pkgsI {
+		switch entry := entry.(type) {
+		case string:
+			name, version, _ := strings.Cut(entry, "=")
+			if name == "" {
+				return nil, fmt.Errorf("invalid package %q", entry)
+			}
+			pkgs = append(pkgs, types.Package{Name: name, Version: version})
+		case map[string]interface{}:
+			names := make([]string, 0, len(entry))
+			for name := range entry {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				version := ""
+				if entry[name] != nil {
+					version = fmt.Sprintf("%v", entry[name])
+				}
+				pkgs = append(pkgs, types.Package{Name: name, Version: version})
+			}
+		default:
+			return nil, fmt.Errorf("invalid package %v", entry)
+		}
+	}
+	return pkgs, nil
+}
+
+func (p Pkg) Test(ctx context.Context) (types.Result, error) {
+	switch p.method {
+	case "installed":
+		return p.installed(ctx, true)
+	case "latest":
+		return p.latest(ctx, true)
+	case "removed":
+		return p.removed(ctx, true, false)
+	case "purged":
+		return p.removed(ctx, true, true)
+	case "held":
+		return p.held(ctx, true)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrPkgMethodUndefined, fmt.Errorf("method %s undefined", p.method))
+	}
+}
+
+func (p Pkg) Apply(ctx context.Context) (types.Result, error) {
+	switch p.method {
+	case "installed":
+		return p.installed(ctx, false)
+	case "latest":
+		return p.latest(ctx, false)
+	case "removed":
+		return p.removed(ctx, false, false)
+	case "purged":
+		return p.removed(ctx, false, true)
+	case "held":
+		return p.held(ctx, false)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrPkgMethodUndefined, fmt.Errorf("method %s undefined", p.method))
+	}
+}
+
+func (p Pkg) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	common := ingredients.MethodPropsSet{
+		ingredients.MethodProps{Key: "name", Type: "string", IsReq: false, Description: "the package to manage; required unless pkgs is set"},
+		ingredients.MethodProps{Key: "pkgs", Type: "list", IsReq: false, Description: "a list of packages to manage together, optionally as name=version"},
+		ingredients.MethodProps{Key: "refresh", Type: "bool", IsReq: false, Description: "refresh the package metadata first"},
+		ingredients.MethodProps{Key: "provider", Type: "string", IsReq: false, Description: "the package manager to use instead of the detected one"},
+	}
+	switch method {
+	case "installed", "held":
+		return append(common,
+			ingredients.MethodProps{Key: "version", Type: "string", IsReq: false, Description: "the version to install; a trailing * matches any version with that prefix"},
+		), nil
+	case "latest", "removed", "purged":
+		return common, nil
+	default:
+		return nil, errors.Join(ErrPkgMethodUndefined, fmt.Errorf("method %s undefined", method))
+	}
+}
+
+func (p Pkg) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := p.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (p Pkg) Methods() (string, []string) {
+	return "pkg", []string{"held", "installed", "latest", "purged", "removed"}
+}
+
+func (p Pkg) Properties() (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(p.params)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+func init() {
+	ingredients.RegisterAllMethods(Pkg{})
+}
@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"context"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// held installs the packages as installed does, then holds them at their
+// installed version so that upgrades leave them alone.
+func (p Pkg) held(ctx context.Context, test bool) (types.Result, error) {
+	prov, pkgs, notes, err := p.provider(ctx, test)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	plan, install, err := planInstall(ctx, prov, pkgs)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	// packages which are about to be installed or replaced can't be held
+	// yet, and a held package can't be replaced, so release those first
+	var release []string
+	for _, t := range plan {
+		if t.action == actionReplace {
+			isHeld, err := prov.IsHeld(ctx, t.name)
+			if err != nil {
+				return types.Result{
+					Succeeded: false, Failed: true, Notes: notes,
+				}, err
+			}
+			if isHeld {
+				release = append(release, t.name)
+			}
+		}
+	}
+	changing := map[string]string{}
+	for _, t := range plan {
+		changing[t.name] = t.to
+	}
+	var holds []transaction
+	var names []string
+	for _, pkg := range pkgs {
+		version, ok := changing[pkg.Name]
+		if !ok {
+			isHeld, err := prov.IsHeld(ctx, pkg.Name)
+			if err != nil {
+				return types.Result{
+					Succeeded: false, Failed: true, Notes: notes,
+				}, err
+			}
+			if isHeld {
+				continue
+			}
+			version, err = prov.Version(ctx, pkg.Name)
+			if err != nil {
+				return types.Result{
+					Succeeded: false, Failed: true, Notes: notes,
+				}, err
+			}
+		}
+		holds = append(holds, transaction{action: actionHold, name: pkg.Name, from: version})
+		names = append(names, pkg.Name)
+	}
+	if len(plan) == 0 && len(holds) == 0 {
+		notes = append(notes, types.SimpleNote("all packages are already installed and held"))
+		return types.Result{
+			Succeeded: true, Failed: false, Notes: notes,
+		}, nil
+	}
+	if test {
+		notes = append(notes, transactionNotes(plan, true)...)
+		notes = append(notes, transactionNotes(holds, true)...)
+		return types.Result{
+			Succeeded: true, Failed: false,
+			Changed: true, Notes: notes,
+		}, nil
+	}
+	if len(release) > 0 {
+		if err = prov.Unhold(ctx, release); err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+	}
+	if len(plan) > 0 {
+		if err = prov.Install(ctx, install); err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true,
+				Changed: len(release) > 0, Notes: notes,
+			}, err
+		}
+		if err = verifyInstalled(ctx, prov, install); err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true,
+				Changed: true, Notes: notes,
+			}, err
+		}
+		notes = append(notes, transactionNotes(plan, false)...)
+	}
+	if err = prov.Hold(ctx, names); err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true,
+			Changed: true, Notes: notes,
+		}, err
+	}
+	notes = append(notes, transactionNotes(holds, false)...)
+	return types.Result{
+		Succeeded: true, Failed: false,
+		Changed: true, Notes: notes,
+	}, nil
+}
@@ -0,0 +1,129 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// provider validates the step and returns its package manager, refreshing
+// the package metadata first if asked to.
+func (p Pkg) provider(ctx context.Context, test bool) (types.PackageProvider, []types.Package, []fmt.Stringer, error) {
+	notes := []fmt.Stringer{}
+	if err := p.validate(); err != nil {
+		return nil, nil, notes, err
+	}
+	pkgs, err := p.packages()
+	if err != nil {
+		return nil, nil, notes, err
+	}
+	prov, err := NewPackageProvider(p.id, p.method, p.params)
+	if err != nil {
+		return nil, nil, notes, err
+	}
+	if refresh, _ := p.params["refresh"].(bool); refresh {
+		if test {
+			notes = append(notes, types.Snprintf("would refresh %s package metadata", prov.ManagerName()))
+		} else {
+			if err = prov.Refresh(ctx); err != nil {
+				return nil, nil, notes, err
+			}
+			notes = append(notes, types.Snprintf("refreshed %s package metadata", prov.ManagerName()))
+		}
+	}
+	return prov, pkgs, notes, nil
+}
+
+// installed makes sure every package is installed, at its pinned version
+// if one was given. All missing packages are installed in a single
+// transaction.
+func (p Pkg) installed(ctx context.Context, test bool) (types.Result, error) {
+	prov, pkgs, notes, err := p.provider(ctx, test)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	plan, install, err := planInstall(ctx, prov, pkgs)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	return applyInstall(ctx, prov, plan, install, notes, test, "all packages are already installed")
+}
+
+// latest makes sure every package is installed at the newest available
+// version.
+func (p Pkg) latest(ctx context.Context, test bool) (types.Result, error) {
+	prov, pkgs, notes, err := p.provider(ctx, test)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	var plan []transaction
+	var install []types.Package
+	for _, pkg := range pkgs {
+		current, err := prov.Version(ctx, pkg.Name)
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+		candidate, err := prov.Candidate(ctx, pkg.Name)
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+		if candidate == "" {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, fmt.Errorf("package %s is not available", pkg.Name)
+		}
+		if current == candidate {
+			continue
+		}
+		t := transaction{action: actionInstall, name: pkg.Name, from: current, to: candidate}
+		if current != "" {
+			t.action = actionUpgrade
+		}
+		plan = append(plan, t)
+		install = append(install, types.Package{Name: pkg.Name, Version: candidate})
+	}
+	return applyInstall(ctx, prov, plan, install, notes, test, "all packages are already at the latest version")
+}
+
+func applyInstall(ctx context.Context, prov types.PackageProvider, plan []transaction, install []types.Package, notes []fmt.Stringer, test bool, upToDate string) (types.Result, error) {
+	if len(plan) == 0 {
+		notes = append(notes, types.SimpleNote(upToDate))
+		return types.Result{
+			Succeeded: true, Failed: false, Notes: notes,
+		}, nil
+	}
+	if test {
+		notes = append(notes, transactionNotes(plan, true)...)
+		return types.Result{
+			Succeeded: true, Failed: false,
+			Changed: true, Notes: notes,
+		}, nil
+	}
+	if err := prov.Install(ctx, install); err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	if err := verifyInstalled(ctx, prov, install); err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true,
+			Changed: true, Notes: notes,
+		}, err
+	}
+	notes = append(notes, transactionNotes(plan, false)...)
+	return types.Result{
+		Succeeded: true, Failed: false,
+		Changed: true, Notes: notes,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// removed makes sure none of the packages are installed. If purge is set
+// their configuration files are removed as well.
+func (p Pkg) removed(ctx context.Context, test, purge bool) (types.Result, error) {
+	prov, pkgs, notes, err := p.provider(ctx, test)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	act := actionRemove
+	if purge {
+		act = actionPurge
+	}
+	var plan []transaction
+	var names []string
+	for _, pkg := range pkgs {
+		current, err := prov.Version(ctx, pkg.Name)
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+		if current == "" {
+			continue
+		}
+		plan = append(plan, transaction{action: act, name: pkg.Name, from: current})
+		names = append(names, pkg.Name)
+	}
+	if len(plan) == 0 {
+		notes = append(notes, types.SimpleNote("no packages are installed"))
+		return types.Result{
+			Succeeded: true, Failed: false, Notes: notes,
+		}, nil
+	}
+	if test {
+		notes = append(notes, transactionNotes(plan, true)...)
+		return types.Result{
+			Succeeded: true, Failed: false,
+			Changed: true, Notes: notes,
+		}, nil
+	}
+	if purge {
+		err = prov.Purge(ctx, names)
+	} else {
+		err = prov.Remove(ctx, names)
+	}
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	for _, name := range names {
+		current, err := prov.Version(ctx, name)
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true,
+				Changed: true, Notes: notes,
+			}, err
+		}
+		if current != "" {
+			return types.Result{
+				Succeeded: false, Failed: true,
+				Changed: true, Notes: notes,
+			}, fmt.Errorf("package %s is still installed", name)
+		}
+	}
+	notes = append(notes, transactionNotes(plan, false)...)
+	return types.Result{
+		Succeeded: true, Failed: false,
+		Changed: true, Notes: notes,
+	}, nil
+}
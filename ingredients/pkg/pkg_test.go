@@ -0,0 +1,376 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/ingredients/pkg/fake"
+	"github.com/gogrlx/grlx/types"
+)
+
+func TestPkg(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		params       map[string]interface{}
+		repo         *fake.Repo
+		test         bool
+		expected     types.Result
+		error        bool
+		installed    map[string]string
+		held         map[string]bool
+		transactions []string
+	}{
+		{
+			name:   "InstallBatch",
+			method: "installed",
+			params: map[string]interface{}{"name": "web", "pkgs": []interface{}{"nginx", "curl=7.1", map[string]interface{}{"git": 2.4}}},
+			repo: &fake.Repo{
+				Available: map[string][]string{"nginx": {"1.1", "1.2"}, "curl": {"7.1", "7.2"}, "git": {"2.4"}},
+			},
+			expected: types.Result{
+				Succeeded: true, Changed: true,
+				Notes: []fmt.Stringer{
+					types.SimpleNote("installed nginx 1.2"),
+					types.SimpleNote("installed curl 7.1"),
+					types.SimpleNote("installed git 2.4"),
+				},
+			},
+			installed:    map[string]string{"nginx": "1.2", "curl": "7.1", "git": "2.4"},
+			transactions: []string{"install nginx=1.2 curl=7.1 git=2.4"},
+		},
+		{
+			name:   "InstallPkgsOnly",
+			method: "installed",
+			params: map[string]interface{}{"pkgs": []interface{}{"nginx", "curl"}},
+			repo: &fake.Repo{
+				Available: map[string][]string{"nginx": {"1.2"}, "curl": {"7.2"}},
+			},
+			expected: types.Result{
+				Succeeded: true, Changed: true,
+				Notes: []fmt.Stringer{
+					types.SimpleNote("installed nginx 1.2"),
+					types.SimpleNote("installed curl 7.2"),
+				},
+			},
+			installed:    map[string]string{"nginx": "1.2", "curl": "7.2"},
+			transactions: []string{"install nginx=1.2 curl=7.2"},
+		},
+		{
+			name:   "RemovedPkgsOnly",
+			method: "removed",
+			params: map[string]interface{}{"pkgs": []interface{}{"nginx"}},
+			repo: &fake.Repo{
+				Available: map[string][]string{"nginx": {"1.2"}},
+				Installed: map[string]string{"nginx": "1.2"},
+			},
+			expected: types.Result{
+				Succeeded: true, Changed: true,
+				Notes: []fmt.Stringer{types.SimpleNote("removed nginx 1.2")},
+			},
+			installed:    map[string]string{},
+			transactions: []string{"remove nginx"},
+		},
+		{
+			name:   "InstallTest",
+			method: "installed",
+			params: map[string]interface{}{"name": "nginx", "refresh": true},
+			repo: &fake.Repo{
+				Available: map[string][]string{"nginx": {"1.1", "1.2"}},
+			},
+			test: true,
+			expected: types.Result{
+				Succeeded: true, Changed: true,
+				Notes: []fmt.Stringer{
+					types.SimpleNote("would refresh fake package metadata"),
+					types.SimpleNote("would install nginx 1.2"),
+				},
+			},
+			installed: map[string]string{},
+		},
+		{
+			name:   "AlreadyInstalled",
+			method: "installed",
+			params: map[string]interface{}{"name": "nginx"},
+			repo: &fake.Repo{
+				Available: map[string][]string{"nginx": {"1.1", "1.2"}},
+				Installed: map[string]string{"nginx": "1.1"},
+			},
+			expected: types.Result{
+				Succeeded: true,
+				Notes:     []fmt.Stringer{types.SimpleNote("all packages are already installed")},
+			},
+			installed: map[string]string{"nginx": "1.1"},
+		},
+		{
+			name:   "ReplacePinned",
+			method: "installed",
+			params: map[string]interface{}{"name": "nginx", "version": "1.1"},
+			repo: &fake.Repo{
+				Available: map[string][]string{"nginx": {"1.1", "1.2"}},
+				Installed: map[string]string{"nginx": "1.2"},
+			},
+			expected: types.Result{
+				Succeeded: true, Changed: true,
+				Notes: []fmt.Stringer{types.SimpleNote("replaced nginx 1.2 with 1.1")},
+			},
+			installed:    map[string]string{"nginx": "1.1"},
+			transactions: []string{"install nginx=1.1"},
+		},
+		{
+			name:   "PinnedPrefix",
+			method: "installed",
+			params: map[string]interface{}{"name": "nginx", "version": "1.*"},
+			repo: &fake.Repo{
+				Available: map[string][]string{"nginx": {"1.1", "1.2"}},
+				Installed: map[string]string{"nginx": "1.1"},
+			},
+			expected: types.Result{
+				Succeeded: true,
+				Notes:     []fmt.Stringer{types.SimpleNote("all packages are already installed")},
+			},
+			installed: map[string]string{"nginx": "1.1"},
+		},
+		{
+			name:   "Unavailable",
+			method: "installed",
+			params: map[string]interface{}{"name": "nginx", "version": "2.*"},
+			repo: &fake.Repo{
+				Available: map[string][]string{"nginx": {"1.1", "1.2"}},
+			},
+			expected:  types.Result{Failed: true, Notes: []fmt.Stringer{}},
+			error:     true,
+			installed: map[string]string{},
+		},
+		{
+			name:   "Latest",
+			method: "latest",
+			params: map[string]interface{}{"name": "nginx", "refresh": true},
+			repo: &fake.Repo{
+				Available: map[string][]string{"nginx": {"1.1", "1.2"}},
+				Installed: map[string]string{"nginx": "1.1"},
+			},
+			expected: types.Result{
+				Succeeded: true, Changed: true,
+				Notes: []fmt.Stringer{
+					types.SimpleNote("refreshed fake package metadata"),
+					types.SimpleNote("upgraded nginx 1.1 -> 1.2"),
+				},
+			},
+			installed:    map[string]string{"nginx": "1.2"},
+			transactions: []string{"install nginx=1.2"},
+		},
+		{
+			name:   "LatestTest",
+			method: "latest",
+			params: map[string]interface{}{"name": "nginx", "pkgs": []interface{}{"nginx", "curl"}},
+			repo: &fake.Repo{
+				Available: map[string][]string{"nginx": {"1.1", "1.2"}, "curl": {"7.2"}},
+				Installed: map[string]string{"nginx": "1.1", "curl": "7.2"},
+			},
+			test: true,
+			expected: types.Result{
+				Succeeded: true, Changed: true,
+				Notes: []fmt.Stringer{types.SimpleNote("would upgrade nginx 1.1 -> 1.2")},
+			},
+			installed: map[string]string{"nginx": "1.1", "curl": "7.2"},
+		},
+		{
+			name:   "Removed",
+			method: "removed",
+			params: map[string]interface{}{"name": "nginx", "pkgs": []interface{}{"nginx", "curl"}},
+			repo: &fake.Repo{
+				Available: map[string][]string{"nginx": {"1.2"}, "curl": {"7.2"}},
+				Installed: map[string]string{"nginx": "1.2", "git": "2.4"},
+			},
+			expected: types.Result{
+				Succeeded: true, Changed: true,
+				Notes: []fmt.Stringer{types.SimpleNote("removed nginx 1.2")},
+			},
+			installed:    map[string]string{"git": "2.4"},
+			transactions: []string{"remove nginx"},
+		},
+		{
+			name:   "AlreadyRemoved",
+			method: "removed",
+			params: map[string]interface{}{"name": "nginx"},
+			repo:   &fake.Repo{},
+			expected: types.Result{
+				Succeeded: true,
+				Notes:     []fmt.Stringer{types.SimpleNote("no packages are installed")},
+			},
+			installed: map[string]string{},
+		},
+		{
+			name:   "PurgedTest",
+			method: "purged",
+			params: map[string]interface{}{"name": "nginx"},
+			repo: &fake.Repo{
+				Installed: map[string]string{"nginx": "1.2"},
+			},
+			test: true,
+			expected: types.Result{
+				Succeeded: true, Changed: true,
+				Notes: []fmt.Stringer{types.SimpleNote("would purge nginx 1.2")},
+			},
+			installed: map[string]string{"nginx": "1.2"},
+		},
+		{
+			name:   "Purged",
+			method: "purged",
+			params: map[string]interface{}{"name": "nginx"},
+			repo: &fake.Repo{
+				Installed: map[string]string{"nginx": "1.2"},
+			},
+			expected: types.Result{
+				Succeeded: true, Changed: true,
+				Notes: []fmt.Stringer{types.SimpleNote("purged nginx 1.2")},
+			},
+			installed:    map[string]string{},
+			transactions: []string{"purge nginx"},
+		},
+		{
+			name:   "Held",
+			method: "held",
+			params: map[string]interface{}{"name": "nginx", "pkgs": []interface{}{"nginx", "curl"}},
+			repo: &fake.Repo{
+				Available: map[string][]string{"nginx": {"1.2"}, "curl": {"7.2"}},
+				Installed: map[string]string{"curl": "7.2"},
+			},
+			expected: types.Result{
+				Succeeded: true, Changed: true,
+				Notes: []fmt.Stringer{
+					types.SimpleNote("installed nginx 1.2"),
+					types.SimpleNote("held nginx 1.2"),
+					types.SimpleNote("held curl 7.2"),
+				},
+			},
+			installed:    map[string]string{"nginx": "1.2", "curl": "7.2"},
+			held:         map[string]bool{"nginx": true, "curl": true},
+			transactions: []string{"install nginx=1.2", "hold curl nginx"},
+		},
+		{
+			name:   "HeldReplace",
+			method: "held",
+			params: map[string]interface{}{"name": "nginx", "version": "1.2"},
+			repo: &fake.Repo{
+				Available: map[string][]string{"nginx": {"1.1", "1.2"}},
+				Installed: map[string]string{"nginx": "1.1"},
+				Held:      map[string]bool{"nginx": true},
+			},
+			expected: types.Result{
+				Succeeded: true, Changed: true,
+				Notes: []fmt.Stringer{
+					types.SimpleNote("replaced nginx 1.1 with 1.2"),
+					types.SimpleNote("held nginx 1.2"),
+				},
+			},
+			installed:    map[string]string{"nginx": "1.2"},
+			held:         map[string]bool{"nginx": true},
+			transactions: []string{"unhold nginx", "install nginx=1.2", "hold nginx"},
+		},
+		{
+			name:   "AlreadyHeld",
+			method: "held",
+			params: map[string]interface{}{"name": "nginx"},
+			repo: &fake.Repo{
+				Installed: map[string]string{"nginx": "1.1"},
+				Held:      map[string]bool{"nginx": true},
+			},
+			expected: types.Result{
+				Succeeded: true,
+				Notes:     []fmt.Stringer{types.SimpleNote("all packages are already installed and held")},
+			},
+			installed: map[string]string{"nginx": "1.1"},
+			held:      map[string]bool{"nginx": true},
+		},
+		{
+			name:      "MissingName",
+			method:    "installed",
+			params:    map[string]interface{}{},
+			repo:      &fake.Repo{},
+			expected:  types.Result{Failed: true, Notes: []fmt.Stringer{}},
+			error:     true,
+			installed: map[string]string{},
+		},
+	}
+	ctx := context.Background()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := test.repo
+			provTex.Lock()
+			provMap["fake"] = fake.New(repo)
+			provTex.Unlock()
+			test.params["provider"] = "fake"
+			p, err := Pkg{}.Parse(test.name, test.method, test.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res types.Result
+			if test.test {
+				res, err = p.Test(ctx)
+			} else {
+				res, err = p.Apply(ctx)
+			}
+			if test.error != (err != nil) {
+				t.Fatalf("expected error to be %v, got %v", test.error, err)
+			}
+			compareResults(t, res, test.expected)
+			if res.Changed != test.expected.Changed {
+				t.Errorf("expected changed to be %v, got %v", test.expected.Changed, res.Changed)
+			}
+			if !reflect.DeepEqual(repo.Installed, test.installed) {
+				t.Errorf("expected installed packages %v, got %v", test.installed, repo.Installed)
+			}
+			if test.held == nil {
+				test.held = map[string]bool{}
+			}
+			if !reflect.DeepEqual(repo.Held, test.held) {
+				t.Errorf("expected held packages %v, got %v", test.held, repo.Held)
+			}
+			if !reflect.DeepEqual(repo.Transactions, test.transactions) {
+				t.Errorf("expected transactions %v, got %v", test.transactions, repo.Transactions)
+			}
+		})
+	}
+}
+
+func compareResults(t *testing.T, result types.Result, expected types.Result) {
+	t.Helper()
+	if result.Succeeded != expected.Succeeded {
+		t.Errorf("expected succeeded to be %v, got %v", expected.Succeeded, result.Succeeded)
+	}
+	if result.Failed != expected.Failed {
+		t.Errorf("expected failed to be %v, got %v", expected.Failed, result.Failed)
+	}
+	if len(result.Notes) != len(expected.Notes) {
+		t.Errorf("expected %v notes, got %v. Got %v", len(expected.Notes), len(result.Notes), result.Notes)
+		return
+	}
+	for i, note := range result.Notes {
+		if note.String() != expected.Notes[i].String() {
+			t.Errorf("expected note `%v` to be `%s`, got `%s`", i, expected.Notes[i].String(), note.String())
+		}
+	}
+}
+
+func TestValidatePkgsOnly(t *testing.T) {
+	err := ingredients.ValidateStep(types.Step{
+		ID: "batch", Ingredient: "pkg", Method: "installed",
+		Properties: map[string]interface{}{"pkgs": []interface{}{"nginx", "curl=7.1"}},
+	})
+	if err != nil {
+		t.Errorf("expected pkgs without name to be valid, got %v", err)
+	}
+	p, err := Pkg{}.Parse("none", "installed", map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.(Pkg).validate(); !errors.Is(err, ErrNoPackages) {
+		t.Errorf("expected %v, got %v", ErrNoPackages, err)
+	}
+}
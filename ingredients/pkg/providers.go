@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	provTex sync.Mutex
+	provMap map[string]types.PackageProvider
+
+	ErrUnknownManager   = errors.New("unknown package manager")
+	ErrDuplicateManager = errors.New("provider for package manager already initialized")
+	ErrHoldUnsupported  = errors.New("package manager does not support holding packages")
+	ErrPinUnsupported   = errors.New("package manager does not support installing a specific version")
+	Manager             string
+)
+
+func init() {
+	provMap = make(map[string]types.PackageProvider)
+}
+
+func RegisterProvider(provider types.PackageProvider) error {
+	provTex.Lock()
+	defer provTex.Unlock()
+	var err error
+	name := provider.ManagerName()
+	if _, ok := provMap[name]; !ok {
+		provMap[name] = provider
+	} else {
+		err = errors.Join(err, fmt.Errorf("package manager %s already registered", name), ErrDuplicateManager)
+	}
+	return err
+}
+
+func guessManager() string {
+	if Manager != "" {
+		return Manager
+	}
+	names := make([]string, 0, len(provMap))
+	for name := range provMap {
+		names = append(names, name)
+	}
+	// check in a stable order in case more than one manager is present
+	sort.Strings(names)
+	for _, name := range names {
+		if provMap[name].IsManager() {
+			Manager = name
+			return Manager
+		}
+	}
+	return "unknown"
+}
+
+// NewPackageProvider returns the provider named by the provider property,
+// or the package manager detected on this system.
+func NewPackageProvider(id string, method string, params map[string]interface{}) (types.PackageProvider, error) {
+	provTex.Lock()
+	defer provTex.Unlock()
+	name, _ := params["provider"].(string)
+	if name == "" {
+		name = guessManager()
+	}
+	provider, ok := provMap[name]
+	if !ok {
+		return nil, errors.Join(ErrUnknownManager, fmt.Errorf("package manager %s not registered", name))
+	}
+	return provider.Parse(id, method, params)
+}
+
+// Run executes a package manager command, returning its standard output.
+// On failure the error includes anything the command wrote to stderr.
+func Run(ctx context.Context, env []string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		return stdout.String(), fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, msg)
+	}
+	return stdout.String(), nil
+}
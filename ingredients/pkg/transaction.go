@@ -0,0 +1,137 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+type action string
+
+const (
+	actionInstall action = "install"
+	actionUpgrade action = "upgrade"
+	actionReplace action = "replace"
+	actionRemove  action = "remove"
+	actionPurge   action = "purge"
+	actionHold    action = "hold"
+)
+
+var pastTense = map[action]string{
+	actionInstall: "installed",
+	actionUpgrade: "upgraded",
+	actionReplace: "replaced",
+	actionRemove:  "removed",
+	actionPurge:   "purged",
+	actionHold:    "held",
+}
+
+// transaction is a single planned change to a package.
+type transaction struct {
+	action action
+	name   string
+	from   string
+	to     string
+}
+
+func (t transaction) describe() string {
+	switch t.action {
+	case actionUpgrade:
+		return fmt.Sprintf("%s %s -> %s", t.name, t.from, t.to)
+	case actionReplace:
+		return fmt.Sprintf("%s %s with %s", t.name, t.from, t.to)
+	case actionInstall:
+		return strings.TrimSpace(t.name + " " + t.to)
+	default:
+		return strings.TrimSpace(t.name + " " + t.from)
+	}
+}
+
+// note describes the transaction as planned (in test mode) or as done.
+func (t transaction) note(test bool) fmt.Stringer {
+	if test {
+		return types.Snprintf("would %s %s", t.action, t.describe())
+	}
+	return types.Snprintf("%s %s", pastTense[t.action], t.describe())
+}
+
+func transactionNotes(plan []transaction, test bool) []fmt.Stringer {
+	notes := []fmt.Stringer{}
+	for _, t := range plan {
+		notes = append(notes, t.note(test))
+	}
+	return notes
+}
+
+// versionMatches reports whether an installed version satisfies want.
+// An empty want accepts any installed version and a trailing * matches
+// by prefix.
+func versionMatches(installed, want string) bool {
+	if installed == "" {
+		return false
+	}
+	if want == "" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(want, "*"); ok {
+		return strings.HasPrefix(installed, prefix)
+	}
+	return installed == want
+}
+
+// planInstall works out which of pkgs need installing, or installing at a
+// different version, to satisfy their version pins.
+func planInstall(ctx context.Context, prov types.PackageProvider, pkgs []types.Package) ([]transaction, []types.Package, error) {
+	var plan []transaction
+	var install []types.Package
+	for _, pkg := range pkgs {
+		current, err := prov.Version(ctx, pkg.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if versionMatches(current, pkg.Version) {
+			continue
+		}
+		to := pkg.Version
+		if to == "" || strings.HasSuffix(to, "*") {
+			candidate, err := prov.Candidate(ctx, pkg.Name)
+			if err != nil {
+				return nil, nil, err
+			}
+			if candidate == "" {
+				return nil, nil, fmt.Errorf("package %s is not available", pkg.Name)
+			}
+			if !versionMatches(candidate, to) {
+				return nil, nil, fmt.Errorf("package %s is not available at version %s", pkg.Name, to)
+			}
+			to = candidate
+		}
+		t := transaction{action: actionInstall, name: pkg.Name, from: current, to: to}
+		if current != "" {
+			t.action = actionReplace
+		}
+		plan = append(plan, t)
+		install = append(install, types.Package{Name: pkg.Name, Version: to})
+	}
+	return plan, install, nil
+}
+
+// verifyInstalled checks that every package in pkgs ended up at the version
+// it was installed at.
+func verifyInstalled(ctx context.Context, prov types.PackageProvider, pkgs []types.Package) error {
+	for _, pkg := range pkgs {
+		current, err := prov.Version(ctx, pkg.Name)
+		if err != nil {
+			return err
+		}
+		if !versionMatches(current, pkg.Version) {
+			if current == "" {
+				return fmt.Errorf("package %s was not installed", pkg.Name)
+			}
+			return fmt.Errorf("package %s is at version %s instead of %s", pkg.Name, current, pkg.Version)
+		}
+	}
+	return nil
+}
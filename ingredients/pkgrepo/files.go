@@ -0,0 +1,283 @@
+package pkgrepo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	aptSourcesDir = "/etc/apt/sources.list.d"
+	aptKeyringDir = "/etc/apt/keyrings"
+	yumReposDir   = "/etc/yum.repos.d"
+	rpmKeyDir     = "/etc/pki/rpm-gpg"
+)
+
+// hostPath maps a system path to where it lives under root.
+func hostPath(p string) string {
+	return filepath.Join(root, filepath.FromSlash(p))
+}
+
+// repoFile returns the system path of the file defining the repository.
+func repoFile(format, name string) string {
+	switch format {
+	case "deb822":
+		return path.Join(aptSourcesDir, name+".sources")
+	case "yum":
+		return path.Join(yumReposDir, name+".repo")
+	default:
+		return path.Join(aptSourcesDir, name+".list")
+	}
+}
+
+// repoFiles returns every file a repository of this format's package
+// manager may have been written to, so that switching between the apt
+// formats doesn't leave a duplicate definition behind.
+func repoFiles(format, name string) []string {
+	if format == "yum" {
+		return []string{repoFile("yum", name)}
+	}
+	return []string{repoFile("apt", name), repoFile("deb822", name)}
+}
+
+// keyFile returns the system path of the repository's signing key. apt
+// reads ASCII-armored keys only from files ending in .asc.
+func keyFile(format, name string, armored bool) string {
+	switch {
+	case format == "yum":
+		return path.Join(rpmKeyDir, "RPM-GPG-KEY-"+name)
+	case armored:
+		return path.Join(aptKeyringDir, name+".asc")
+	default:
+		return path.Join(aptKeyringDir, name+".gpg")
+	}
+}
+
+func keyFiles(format, name string) []string {
+	if format == "yum" {
+		return []string{keyFile("yum", name, true)}
+	}
+	return []string{keyFile("apt", name, true), keyFile("apt", name, false)}
+}
+
+func isArmored(key []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(key), []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----"))
+}
+
+// stringList reads a property holding either a single string or a list.
+func stringList(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		list := []string{}
+		for _, item := range v {
+			list = append(list, fmt.Sprintf("%v", item))
+		}
+		return list
+	case []string:
+		return v
+	default:
+		return nil
+	}
+}
+
+func (r PkgRepo) boolProp(key string, def bool) bool {
+	if v, ok := r.params[key].(bool); ok {
+		return v
+	}
+	return def
+}
+
+// render returns the contents of the repository definition, with
+// keyPath as its signing key if it is set.
+func (r PkgRepo) render(format, keyPath string) ([]byte, error) {
+	switch format {
+	case "yum":
+		return r.renderYum(keyPath)
+	case "deb822":
+		return r.renderDeb822(keyPath)
+	default:
+		return r.renderAptLine(keyPath)
+	}
+}
+
+func (r PkgRepo) aptSource() (uri, dist string, components, arches, debTypes []string, err error) {
+	uri, _ = r.params["uri"].(string)
+	dist, _ = r.params["dist"].(string)
+	if uri == "" || dist == "" {
+		return "", "", nil, nil, nil, fmt.Errorf("%w: apt repositories need a uri and a dist", ErrMissingRepoSource)
+	}
+	components = stringList(r.params["components"])
+	arches = stringList(r.params["architectures"])
+	debTypes = stringList(r.params["types"])
+	if len(debTypes) == 0 {
+		debTypes = []string{"deb"}
+	}
+	return uri, dist, components, arches, debTypes, nil
+}
+
+func (r PkgRepo) renderAptLine(keyPath string) ([]byte, error) {
+	uri, dist, components, arches, debTypes, err := r.aptSource()
+	if err != nil {
+		return nil, err
+	}
+	var opts []string
+	if len(arches) > 0 {
+		opts = append(opts, "arch="+strings.Join(arches, ","))
+	}
+	if keyPath != "" {
+		opts = append(opts, "signed-by="+keyPath)
+	}
+	prefix := ""
+	if !r.boolProp("enabled", true) {
+		prefix = "# "
+	}
+	var b strings.Builder
+	for _, t := range debTypes {
+		fields := []string{t}
+		if len(opts) > 0 {
+			fields = append(fields, "["+strings.Join(opts, " ")+"]")
+		}
+		fields = append(fields, uri, dist)
+		fields = append(fields, components...)
+		b.WriteString(prefix + strings.Join(fields, " ") + "\n")
+	}
+	return []byte(b.String()), nil
+}
+
+func (r PkgRepo) renderDeb822(keyPath string) ([]byte, error) {
+	uri, dist, components, arches, debTypes, err := r.aptSource()
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Types: %s\n", strings.Join(debTypes, " "))
+	fmt.Fprintf(&b, "URIs: %s\n", uri)
+	fmt.Fprintf(&b, "Suites: %s\n", dist)
+	if len(components) > 0 {
+		fmt.Fprintf(&b, "Components: %s\n", strings.Join(components, " "))
+	}
+	if len(arches) > 0 {
+		fmt.Fprintf(&b, "Architectures: %s\n", strings.Join(arches, " "))
+	}
+	if !r.boolProp("enabled", true) {
+		b.WriteString("Enabled: no\n")
+	}
+	if keyPath != "" {
+		fmt.Fprintf(&b, "Signed-By: %s\n", keyPath)
+	}
+	return []byte(b.String()), nil
+}
+
+func (r PkgRepo) renderYum(keyPath string) ([]byte, error) {
+	name := r.params["name"].(string)
+	type option struct{ key, value string }
+	opts := []option{}
+	humanname, _ := r.params["humanname"].(string)
+	if humanname == "" {
+		humanname = name
+	}
+	opts = append(opts, option{"name", humanname})
+	hasSource := false
+	for _, key := range []string{"baseurl", "mirrorlist", "metalink"} {
+		if v, _ := r.params[key].(string); v != "" {
+			opts = append(opts, option{key, v})
+			hasSource = true
+		}
+	}
+	if !hasSource {
+		return nil, fmt.Errorf("%w: yum repositories need a baseurl, mirrorlist or metalink", ErrMissingRepoSource)
+	}
+	enabled := "1"
+	if !r.boolProp("enabled", true) {
+		enabled = "0"
+	}
+	opts = append(opts, option{"enabled", enabled})
+	gpgkey, _ := r.params["gpgkey"].(string)
+	if keyPath != "" {
+		gpgkey = "file://" + keyPath
+	}
+	if r.boolProp("gpgcheck", gpgkey != "") {
+		opts = append(opts, option{"gpgcheck", "1"})
+	} else {
+		opts = append(opts, option{"gpgcheck", "0"})
+	}
+	if gpgkey != "" {
+		opts = append(opts, option{"gpgkey", gpgkey})
+	}
+	// extra options may override the ones above
+	extra, _ := r.params["options"].(map[string]interface{})
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := fmt.Sprintf("%v", extra[key])
+		if b, ok := extra[key].(bool); ok {
+			value = "0"
+			if b {
+				value = "1"
+			}
+		}
+		replaced := false
+		for i := range opts {
+			if opts[i].key == key {
+				opts[i].value = value
+				replaced = true
+			}
+		}
+		if !replaced {
+			opts = append(opts, option{key, value})
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s]\n", name)
+	for _, o := range opts {
+		fmt.Fprintf(&b, "%s=%s\n", o.key, o.value)
+	}
+	return []byte(b.String()), nil
+}
+
+// writeFile atomically replaces the file at the system path p.
+func writeFile(p string, content []byte) error {
+	dest := hostPath(p)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// readFile returns the contents of the file at the system path p, and
+// whether it exists.
+func readFile(p string) ([]byte, bool, error) {
+	b, err := os.ReadFile(hostPath(p))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return b, true, nil
+}
@@ -0,0 +1,186 @@
+package pkgrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/ingredients/pkg"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrPkgRepoMethodUndefined = errors.New("pkgrepo method undefined")
+	ErrInvalidRepoName        = errors.New("repository name must be a plain file name")
+	ErrUnknownRepoFormat      = errors.New("unknown repository format")
+	ErrMissingRepoSource      = errors.New("repository has no source")
+)
+
+// root is prepended to every path the ingredient touches, so that tests can
+// work against a temporary directory.
+var root = "/"
+
+type PkgRepo struct {
+	id     string
+	method string
+	params map[string]interface{}
+}
+
+func (r PkgRepo) Parse(id, method string, params map[string]interface{}) (types.RecipeCooker, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return PkgRepo{
+		id: id, method: method,
+		params: params,
+	}, nil
+}
+
+func (r PkgRepo) validate() error {
+	set, err := r.PropertiesForMethod(r.method)
+	if err != nil {
+		return err
+	}
+	propSet, err := ingredients.PropMapToPropSet(set)
+	if err != nil {
+		return err
+	}
+	for _, v := range propSet {
+		if v.IsReq {
+			if v.Key == "name" {
+				name, ok := r.params[v.Key].(string)
+				if !ok {
+					return types.ErrMissingName
+				}
+				if name == "" {
+					return types.ErrMissingName
+				}
+
+			} else {
+				if _, ok := r.params[v.Key]; !ok {
+					return fmt.Errorf("missing required property %s", v.Key)
+				}
+			}
+		}
+	}
+	name := r.params["name"].(string)
+	// the name is used in file paths, so it must not be able to leave the
+	// repository and key directories
+	if name == "." || strings.Contains(name, "..") || strings.ContainsAny(name, "/\\") {
+		return errors.Join(ErrInvalidRepoName, fmt.Errorf("invalid repository name %q", name))
+	}
+	return nil
+}
+
+// format returns the repository format named by the format property, or
+// the one used by the detected package manager, along with that manager.
+func (r PkgRepo) format() (string, types.PackageProvider, error) {
+	prov, err := pkg.NewPackageProvider(r.id, r.method, r.params)
+	if err != nil {
+		return "", nil, err
+	}
+	format, _ := r.params["format"].(string)
+	if format == "" {
+		switch prov.ManagerName() {
+		case "apt":
+			format = "apt"
+		case "dnf":
+			format = "yum"
+		default:
+			return "", nil, errors.Join(ErrUnknownRepoFormat, fmt.Errorf("package manager %s has no repository format", prov.ManagerName()))
+		}
+	}
+	switch format {
+	case "apt", "deb822", "yum":
+		return format, prov, nil
+	default:
+		return "", nil, errors.Join(ErrUnknownRepoFormat, fmt.Errorf("repository format %s is not supported", format))
+	}
+}
+
+func (r PkgRepo) Test(ctx context.Context) (types.Result, error) {
+	switch r.method {
+	case "managed":
+		return r.managed(ctx, true)
+	case "absent":
+		return r.absent(ctx, true)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrPkgRepoMethodUndefined, fmt.Errorf("method %s undefined", r.method))
+	}
+}
+
+func (r PkgRepo) Apply(ctx context.Context) (types.Result, error) {
+	switch r.method {
+	case "managed":
+		return r.managed(ctx, false)
+	case "absent":
+		return r.absent(ctx, false)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrPkgRepoMethodUndefined, fmt.Errorf("method %s undefined", r.method))
+	}
+}
+
+func (r PkgRepo) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	common := ingredients.MethodPropsSet{
+		ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the repository id, which is also used to name its files"},
+		ingredients.MethodProps{Key: "format", Type: "string", IsReq: false, Description: "apt, deb822 or yum; defaults to the format of the detected package manager"},
+		ingredients.MethodProps{Key: "refresh", Type: "bool", IsReq: false, Description: "refresh the package metadata when the repository changes, defaults to true"},
+		ingredients.MethodProps{Key: "provider", Type: "string", IsReq: false, Description: "the package manager to use instead of the detected one"},
+	}
+	switch method {
+	case "managed":
+		return append(common,
+			ingredients.MethodProps{Key: "uri", Type: "string", IsReq: false, Description: "the apt repository URI"},
+			ingredients.MethodProps{Key: "dist", Type: "string", IsReq: false, Description: "the apt suite, such as bookworm"},
+			ingredients.MethodProps{Key: "components", Type: "[]string", IsReq: false, Description: "the apt components, such as main"},
+			ingredients.MethodProps{Key: "architectures", Type: "[]string", IsReq: false, Description: "limit an apt repository to these architectures"},
+			ingredients.MethodProps{Key: "types", Type: "[]string", IsReq: false, Description: "deb and/or deb-src, defaults to deb"},
+			ingredients.MethodProps{Key: "baseurl", Type: "string", IsReq: false, Description: "the yum repository base URL"},
+			ingredients.MethodProps{Key: "mirrorlist", Type: "string", IsReq: false, Description: "the yum repository mirror list URL"},
+			ingredients.MethodProps{Key: "metalink", Type: "string", IsReq: false, Description: "the yum repository metalink URL"},
+			ingredients.MethodProps{Key: "humanname", Type: "string", IsReq: false, Description: "the descriptive name of a yum repository"},
+			ingredients.MethodProps{Key: "gpgkey", Type: "string", IsReq: false, Description: "the URL of a yum repository's signing key, if key_source is not set"},
+			ingredients.MethodProps{Key: "gpgcheck", Type: "bool", IsReq: false, Description: "check yum package signatures, defaults to true if a key is given"},
+			ingredients.MethodProps{Key: "options", Type: "map", IsReq: false, Description: "additional yum repository options"},
+			ingredients.MethodProps{Key: "enabled", Type: "bool", IsReq: false, Description: "whether the repository is enabled, defaults to true"},
+			ingredients.MethodProps{Key: "key_source", Type: "string", IsReq: false, Description: "the signing key, fetched through the file providers"},
+			ingredients.MethodProps{Key: "key_hash", Type: "string", IsReq: false, Description: "the hash of the signing key"},
+			ingredients.MethodProps{Key: "skip_verify", Type: "bool", IsReq: false, Description: "skip verifying the hash of the signing key"},
+		), nil
+	case "absent":
+		return common, nil
+	default:
+		return nil, errors.Join(ErrPkgRepoMethodUndefined, fmt.Errorf("method %s undefined", method))
+	}
+}
+
+func (r PkgRepo) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := r.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (r PkgRepo) Methods() (string, []string) {
+	return "pkgrepo", []string{"absent", "managed"}
+}
+
+func (r PkgRepo) Properties() (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(r.params)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+func init() {
+	ingredients.RegisterAllMethods(PkgRepo{})
+}
@@ -0,0 +1,74 @@
+package pkgrepo
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// absent removes the repository definition and its signing key, then
+// refreshes the package metadata if anything was removed.
+func (r PkgRepo) absent(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := r.validate(); err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	name := r.params["name"].(string)
+	format, prov, err := r.format()
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	removals, err := stale(append(repoFiles(format, name), keyFiles(format, name)...), "")
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	if len(removals) == 0 {
+		notes = append(notes, types.Snprintf("repository %s is already absent", name))
+		return types.Result{
+			Succeeded: true, Failed: false, Notes: notes,
+		}, nil
+	}
+	refresh := r.boolProp("refresh", true)
+	if test {
+		for _, p := range removals {
+			notes = append(notes, types.Snprintf("would remove %s", p))
+		}
+		if refresh {
+			notes = append(notes, types.Snprintf("would refresh %s package metadata", prov.ManagerName()))
+		}
+		return types.Result{
+			Succeeded: true, Failed: false,
+			Changed: true, Notes: notes,
+		}, nil
+	}
+	for i, p := range removals {
+		if err = os.Remove(hostPath(p)); err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true,
+				Changed: i > 0, Notes: notes,
+			}, err
+		}
+		notes = append(notes, types.Snprintf("removed %s", p))
+	}
+	if refresh {
+		if err = prov.Refresh(ctx); err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true,
+				Changed: true, Notes: notes,
+			}, err
+		}
+		notes = append(notes, types.Snprintf("refreshed %s package metadata", prov.ManagerName()))
+	}
+	return types.Result{
+		Succeeded: true, Failed: false,
+		Changed: true, Notes: notes,
+	}, nil
+}
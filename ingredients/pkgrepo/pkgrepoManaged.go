@@ -0,0 +1,197 @@
+package pkgrepo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gogrlx/grlx/ingredients/file"
+	"github.com/gogrlx/grlx/types"
+)
+
+// pendingWrite is a file which needs creating or updating.
+type pendingWrite struct {
+	path    string
+	content []byte
+	exists  bool
+}
+
+func (w pendingWrite) note(test bool) fmt.Stringer {
+	switch {
+	case test && w.exists:
+		return types.Snprintf("would update %s", w.path)
+	case test:
+		return types.Snprintf("would create %s", w.path)
+	case w.exists:
+		return types.Snprintf("updated %s", w.path)
+	default:
+		return types.Snprintf("created %s", w.path)
+	}
+}
+
+// planWrite returns the write needed for p to hold content, if any.
+func planWrite(p string, content []byte) (*pendingWrite, error) {
+	current, exists, err := readFile(p)
+	if err != nil {
+		return nil, err
+	}
+	if exists && bytes.Equal(current, content) {
+		return nil, nil
+	}
+	return &pendingWrite{path: p, content: content, exists: exists}, nil
+}
+
+// stale returns those of paths other than keep which exist.
+func stale(paths []string, keep string) ([]string, error) {
+	var found []string
+	for _, p := range paths {
+		if p == keep {
+			continue
+		}
+		if _, err := os.Lstat(hostPath(p)); err == nil {
+			found = append(found, p)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// managed writes the repository definition, and its signing key if
+// key_source is set, then refreshes the package metadata if either
+// changed.
+//
+// the key is fetched through the file providers, so key_hash is required
+// unless skip_verify is set
+func (r PkgRepo) managed(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := r.validate(); err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	name := r.params["name"].(string)
+	format, prov, err := r.format()
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+
+	var writes []pendingWrite
+	keyPath := ""
+	if src, _ := r.params["key_source"].(string); src != "" {
+		keyHash, _ := r.params["key_hash"].(string)
+		skipVerify, _ := r.params["skip_verify"].(bool)
+		cached, cacheNotes, err := file.CacheSource(ctx, r.id, r.params, "pkgrepo-"+name+"-key", src, keyHash, skipVerify, false)
+		notes = append(notes, cacheNotes...)
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+		key, err := os.ReadFile(cached)
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+		keyPath = keyFile(format, name, isArmored(key))
+		w, err := planWrite(keyPath, key)
+		if err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true, Notes: notes,
+			}, err
+		}
+		if w != nil {
+			writes = append(writes, *w)
+		}
+	}
+	content, err := r.render(format, keyPath)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	w, err := planWrite(repoFile(format, name), content)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	if w != nil {
+		writes = append(writes, *w)
+	}
+	staleRepos, err := stale(repoFiles(format, name), repoFile(format, name))
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	staleKeys, err := stale(keyFiles(format, name), keyPath)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	removals := append(staleRepos, staleKeys...)
+
+	if len(writes) == 0 && len(removals) == 0 {
+		notes = append(notes, types.Snprintf("repository %s is already configured", name))
+		return types.Result{
+			Succeeded: true, Failed: false, Notes: notes,
+		}, nil
+	}
+	refresh := r.boolProp("refresh", true)
+	if test {
+		for _, w := range writes {
+			notes = append(notes, w.note(true))
+		}
+		for _, p := range removals {
+			notes = append(notes, types.Snprintf("would remove %s", p))
+		}
+		if refresh {
+			notes = append(notes, types.Snprintf("would refresh %s package metadata", prov.ManagerName()))
+		}
+		return types.Result{
+			Succeeded: true, Failed: false,
+			Changed: true, Notes: notes,
+		}, nil
+	}
+	changed := false
+	// the key goes first so that the repository is never usable unsigned
+	for _, w := range writes {
+		if err = writeFile(w.path, w.content); err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true,
+				Changed: changed, Notes: notes,
+			}, err
+		}
+		changed = true
+		notes = append(notes, w.note(false))
+	}
+	for _, p := range removals {
+		if err = os.Remove(hostPath(p)); err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true,
+				Changed: changed, Notes: notes,
+			}, err
+		}
+		changed = true
+		notes = append(notes, types.Snprintf("removed %s", p))
+	}
+	if refresh {
+		if err = prov.Refresh(ctx); err != nil {
+			return types.Result{
+				Succeeded: false, Failed: true,
+				Changed: true, Notes: notes,
+			}, err
+		}
+		notes = append(notes, types.Snprintf("refreshed %s package metadata", prov.ManagerName()))
+	}
+	return types.Result{
+		Succeeded: true, Failed: false,
+		Changed: true, Notes: notes,
+	}, nil
+}
@@ -0,0 +1,259 @@
+package pkgrepo
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/ingredients/pkg"
+	"github.com/gogrlx/grlx/ingredients/pkg/fake"
+	"github.com/gogrlx/grlx/types"
+)
+
+const armoredKey = "-----BEGIN PGP PUBLIC KEY BLOCK-----\n\nmQINBGTest\n-----END PGP PUBLIC KEY BLOCK-----\n"
+
+var repo = &fake.Repo{}
+
+func init() {
+	pkg.RegisterProvider(fake.New(repo))
+}
+
+// setup points the ingredient and the file cache at a fresh temporary
+// root, returning the path of a signing key source within it.
+func setup(t *testing.T) (string, string) {
+	t.Helper()
+	tempDir := t.TempDir()
+	r, cd := root, config.CacheDir
+	t.Cleanup(func() { root, config.CacheDir = r, cd })
+	root = filepath.Join(tempDir, "root")
+	config.CacheDir = filepath.Join(tempDir, "cache")
+	if err := os.MkdirAll(config.CacheDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	key := filepath.Join(tempDir, "key.asc")
+	if err := os.WriteFile(key, []byte(armoredKey), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	repo.Refreshes = 0
+	return root, key
+}
+
+func keyHash(t *testing.T, p string) string {
+	t.Helper()
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("sha256=%x", sha256.Sum256(b))
+}
+
+func readRooted(t *testing.T, p string) string {
+	t.Helper()
+	b, err := os.ReadFile(hostPath(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func lastNote(res types.Result) string {
+	if len(res.Notes) == 0 {
+		return ""
+	}
+	return res.Notes[len(res.Notes)-1].String()
+}
+
+func TestManaged(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		withKey  bool
+		files    map[string]string
+		missing  []string
+		existing map[string]string
+		error    error
+	}{
+		{
+			name: "AptLine",
+			params: map[string]interface{}{
+				"name": "example", "format": "apt", "uri": "https://deb.example.com",
+				"dist": "bookworm", "components": []interface{}{"main", "contrib"},
+				"architectures": []interface{}{"amd64"}, "types": []interface{}{"deb", "deb-src"},
+			},
+			withKey: true,
+			files: map[string]string{
+				"/etc/apt/sources.list.d/example.list": "deb [arch=amd64 signed-by=/etc/apt/keyrings/example.asc] https://deb.example.com bookworm main contrib\n" +
+					"deb-src [arch=amd64 signed-by=/etc/apt/keyrings/example.asc] https://deb.example.com bookworm main contrib\n",
+				"/etc/apt/keyrings/example.asc": armoredKey,
+			},
+		},
+		{
+			name: "Deb822ReplacesLine",
+			params: map[string]interface{}{
+				"name": "example", "format": "deb822", "uri": "https://deb.example.com",
+				"dist": "bookworm", "components": "main",
+			},
+			withKey: true,
+			existing: map[string]string{
+				"/etc/apt/sources.list.d/example.list": "deb https://deb.example.com bookworm main\n",
+				"/etc/apt/keyrings/example.gpg":        "old key",
+			},
+			files: map[string]string{
+				"/etc/apt/sources.list.d/example.sources": "Types: deb\nURIs: https://deb.example.com\nSuites: bookworm\nComponents: main\nSigned-By: /etc/apt/keyrings/example.asc\n",
+			},
+			missing: []string{"/etc/apt/sources.list.d/example.list", "/etc/apt/keyrings/example.gpg"},
+		},
+		{
+			name: "Yum",
+			params: map[string]interface{}{
+				"name": "example", "format": "yum", "humanname": "Example Packages",
+				"baseurl": "https://rpm.example.com/$basearch", "enabled": false,
+				"options": map[string]interface{}{"skip_if_unavailable": true, "priority": 10},
+			},
+			withKey: true,
+			files: map[string]string{
+				"/etc/yum.repos.d/example.repo": "[example]\nname=Example Packages\nbaseurl=https://rpm.example.com/$basearch\nenabled=0\n" +
+					"gpgcheck=1\ngpgkey=file:///etc/pki/rpm-gpg/RPM-GPG-KEY-example\npriority=10\nskip_if_unavailable=1\n",
+				"/etc/pki/rpm-gpg/RPM-GPG-KEY-example": armoredKey,
+			},
+		},
+		{
+			name:   "InvalidName",
+			params: map[string]interface{}{"name": "../example", "format": "yum", "baseurl": "https://rpm.example.com"},
+			error:  ErrInvalidRepoName,
+		},
+		{
+			name:   "NameWithSlash",
+			params: map[string]interface{}{"name": "sub/example", "format": "apt", "uri": "https://deb.example.com", "dist": "bookworm"},
+			error:  ErrInvalidRepoName,
+		},
+		{
+			name:   "NameWithDots",
+			params: map[string]interface{}{"name": "example..list", "format": "apt", "uri": "https://deb.example.com", "dist": "bookworm"},
+			error:  ErrInvalidRepoName,
+		},
+		{
+			name:   "MissingSource",
+			params: map[string]interface{}{"name": "example", "format": "apt", "uri": "https://deb.example.com"},
+			error:  ErrMissingRepoSource,
+		},
+		{
+			name:   "UnknownFormat",
+			params: map[string]interface{}{"name": "example", "format": "zypper"},
+			error:  ErrUnknownRepoFormat,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, key := setup(t)
+			for p, content := range test.existing {
+				if err := writeFile(p, []byte(content)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			test.params["provider"] = "fake"
+			if test.withKey {
+				test.params["key_source"] = key
+				test.params["key_hash"] = keyHash(t, key)
+			}
+			r := PkgRepo{id: test.name, method: "managed", params: test.params}
+			res, err := r.Apply(context.Background())
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !res.Changed || lastNote(res) != "refreshed fake package metadata" || repo.Refreshes != 1 {
+				t.Errorf("expected a change followed by a refresh, got %v", res.Notes)
+			}
+			for p, expected := range test.files {
+				if content := readRooted(t, p); content != expected {
+					t.Errorf("expected %s to contain\n%s\ngot\n%s", p, expected, content)
+				}
+			}
+			for _, p := range test.missing {
+				if _, err := os.Lstat(hostPath(p)); err == nil {
+					t.Errorf("expected %s to be removed", p)
+				}
+			}
+
+			// nothing changes the second time, so there is no refresh
+			res, err = r.Apply(context.Background())
+			if err != nil || res.Changed {
+				t.Fatalf("expected second run to be a no-op, got %v %v", res.Notes, err)
+			}
+			if lastNote(res) != "repository example is already configured" || repo.Refreshes != 1 {
+				t.Errorf("unexpected notes %v", res.Notes)
+			}
+		})
+	}
+}
+
+func TestManagedTest(t *testing.T) {
+	setup(t)
+	params := map[string]interface{}{
+		"name": "example", "format": "yum", "provider": "fake",
+		"mirrorlist": "https://mirrors.example.com/list",
+	}
+	if err := writeFile("/etc/yum.repos.d/example.repo", []byte("[example]\n")); err != nil {
+		t.Fatal(err)
+	}
+	r := PkgRepo{id: "example", method: "managed", params: params}
+	res, err := r.Test(context.Background())
+	if err != nil || !res.Changed {
+		t.Fatalf("expected a change, got %v %v", res.Notes, err)
+	}
+	expected := []string{"would update /etc/yum.repos.d/example.repo", "would refresh fake package metadata"}
+	if fmt.Sprint(res.Notes) != fmt.Sprint(expected) {
+		t.Errorf("expected notes %v, got %v", expected, res.Notes)
+	}
+	if content := readRooted(t, "/etc/yum.repos.d/example.repo"); content != "[example]\n" || repo.Refreshes != 0 {
+		t.Errorf("expected test mode to leave the repository alone")
+	}
+}
+
+func TestAbsent(t *testing.T) {
+	setup(t)
+	for _, p := range []string{"/etc/apt/sources.list.d/example.sources", "/etc/apt/keyrings/example.asc", "/etc/apt/sources.list.d/other.list"} {
+		if err := writeFile(p, []byte("x")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	params := map[string]interface{}{"name": "example", "format": "apt", "provider": "fake", "refresh": false}
+	r := PkgRepo{id: "example", method: "absent", params: params}
+	res, err := r.Test(context.Background())
+	if err != nil || !res.Changed {
+		t.Fatalf("expected a change, got %v %v", res.Notes, err)
+	}
+	expected := []string{"would remove /etc/apt/sources.list.d/example.sources", "would remove /etc/apt/keyrings/example.asc"}
+	if fmt.Sprint(res.Notes) != fmt.Sprint(expected) {
+		t.Errorf("expected notes %v, got %v", expected, res.Notes)
+	}
+	if _, err = r.Apply(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"/etc/apt/sources.list.d/example.sources", "/etc/apt/keyrings/example.asc"} {
+		if _, err = os.Lstat(hostPath(p)); err == nil {
+			t.Errorf("expected %s to be removed", p)
+		}
+	}
+	if _, err = os.Lstat(hostPath("/etc/apt/sources.list.d/other.list")); err != nil {
+		t.Errorf("expected other repositories to be left alone: %v", err)
+	}
+	if repo.Refreshes != 0 {
+		t.Errorf("expected no refresh when refresh is false")
+	}
+	res, err = r.Apply(context.Background())
+	if err != nil || res.Changed || lastNote(res) != "repository example is already absent" {
+		t.Errorf("expected removal to be idempotent, got %v %v", res.Notes, err)
+	}
+}
@@ -0,0 +1,242 @@
+// Package plugin runs ingredients provided by executables in the sprout's
+// plugin directory, so that ingredients can be added without rebuilding
+// the sprout.
+//
+// A plugin is an executable which reads one JSON Request from stdin and
+// writes one JSON reply to stdout. At startup each plugin is asked to
+// describe itself and replies with a Description; its methods are then
+// registered like any compiled-in ingredient. Cooking a step runs the
+// plugin again with a test or apply request and the step's properties,
+// and the plugin replies with a Response. A plugin which exits non-zero,
+// runs past the configured timeout or writes too much output fails the
+// step.
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrPluginMethodUndefined = errors.New("plugin method undefined")
+	ErrInvalidDescription    = errors.New("invalid plugin description")
+	ErrInvalidResponse       = errors.New("invalid plugin response")
+	ErrOutputTooLarge        = errors.New("plugin output too large")
+	ErrTimeout               = errors.New("plugin timed out")
+	ErrPluginFailed          = errors.New("plugin failed")
+)
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// Plugin is an ingredient implemented by an external executable.
+type Plugin struct {
+	path    string
+	name    string
+	methods map[string][]Property
+
+	id     string
+	method string
+	params map[string]interface{}
+}
+
+func (p Plugin) Parse(id, method string, params map[string]interface{}) (types.RecipeCooker, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return Plugin{
+		path: p.path, name: p.name, methods: p.methods,
+		id: id, method: method,
+		params: params,
+	}, nil
+}
+
+func (p Plugin) validate() error {
+	set, err := p.PropertiesForMethod(p.method)
+	if err != nil {
+		return err
+	}
+	propSet, err := ingredients.PropMapToPropSet(set)
+	if err != nil {
+		return err
+	}
+	for _, v := range propSet {
+		if v.IsReq {
+			if v.Key == "name" {
+				name, ok := p.params[v.Key].(string)
+				if !ok {
+					return types.ErrMissingName
+				}
+				if name == "" {
+					return types.ErrMissingName
+				}
+
+			} else {
+				if _, ok := p.params[v.Key]; !ok {
+					return fmt.Errorf("missing required property %s", v.Key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (p Plugin) Test(ctx context.Context) (types.Result, error) {
+	return p.run(ctx, "test")
+}
+
+func (p Plugin) Apply(ctx context.Context) (types.Result, error) {
+	return p.run(ctx, "apply")
+}
+
+// run sends the step to the plugin and turns its response into a result.
+func (p Plugin) run(ctx context.Context, action string) (types.Result, error) {
+	if err := p.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{}}, err
+	}
+	timeout := config.PluginTimeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	var resp Response
+	err := call(ctx, p.path, timeout, Request{
+		Action:     action,
+		ID:         p.id,
+		JobID:      types.JobIDFromContext(ctx),
+		Method:     p.method,
+		Properties: p.params,
+	}, &resp)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{}}, errors.Join(ErrPluginFailed, err)
+	}
+	notes := []fmt.Stringer{}
+	for _, n := range resp.Notes {
+		notes = append(notes, types.SimpleNote(n))
+	}
+	res := types.Result{Succeeded: resp.Succeeded && !resp.Failed && resp.Error == "", Changed: resp.Changed, Notes: notes}
+	res.Failed = !res.Succeeded
+	if resp.Error != "" {
+		return res, errors.Join(ErrPluginFailed, fmt.Errorf("%s: %s", p.name, resp.Error))
+	}
+	return res, nil
+}
+
+func (p Plugin) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	props, ok := p.methods[method]
+	if !ok {
+		return nil, errors.Join(ErrPluginMethodUndefined, fmt.Errorf("method %s undefined", method))
+	}
+	set := ingredients.MethodPropsSet{}
+	for _, prop := range props {
+		set = append(set, ingredients.MethodProps{
+			Key: prop.Key, Type: prop.Type, IsReq: prop.Required,
+			Description: prop.Description, Default: prop.Default, Enum: prop.Enum,
+		})
+	}
+	return set, nil
+}
+
+func (p Plugin) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := p.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (p Plugin) Methods() (string, []string) {
+	methods := []string{}
+	for method := range p.methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return p.name, methods
+}
+
+func (p Plugin) Properties() (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(p.params)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+// Describe asks the executable at path to describe itself and returns the
+// plugin it provides.
+func Describe(ctx context.Context, path string) (Plugin, error) {
+	var desc Description
+	if err := call(ctx, path, describeTimeout, Request{Action: "describe"}, &desc); err != nil {
+		return Plugin{}, err
+	}
+	if !namePattern.MatchString(desc.Name) {
+		return Plugin{}, errors.Join(ErrInvalidDescription, fmt.Errorf("%s: invalid ingredient name %q", path, desc.Name))
+	}
+	if len(desc.Methods) == 0 {
+		return Plugin{}, errors.Join(ErrInvalidDescription, fmt.Errorf("%s: no methods", path))
+	}
+	for method, props := range desc.Methods {
+		if !namePattern.MatchString(method) {
+			return Plugin{}, errors.Join(ErrInvalidDescription, fmt.Errorf("%s: invalid method name %q", path, method))
+		}
+		for _, prop := range props {
+			if prop.Key == "" {
+				return Plugin{}, errors.Join(ErrInvalidDescription, fmt.Errorf("%s: method %s has a property without a key", path, method))
+			}
+		}
+		// PropMapToPropSet rejects the types ingredients don't understand
+		set, _ := Plugin{methods: desc.Methods}.PropertiesForMethod(method)
+		if _, err := ingredients.PropMapToPropSet(set); err != nil {
+			return Plugin{}, errors.Join(ErrInvalidDescription, fmt.Errorf("%s: method %s: %w", path, method, err))
+		}
+	}
+	return Plugin{path: path, name: desc.Name, methods: desc.Methods}, nil
+}
+
+// LoadDir describes every executable in dir and registers the plugins
+// they provide. A plugin can't replace an ingredient which is already
+// registered. Plugins which fail to load are skipped and their errors
+// returned together; a missing dir has no plugins.
+func LoadDir(ctx context.Context, dir string) ([]Plugin, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	loaded := []Plugin{}
+	var errs []error
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !info.Mode().IsRegular() || info.Mode()&0o111 == 0 {
+			continue
+		}
+		p, err := Describe(ctx, path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ingredients.IsRegistered(types.Ingredient(p.name)) {
+			errs = append(errs, fmt.Errorf("%s: ingredient %s is already registered", path, p.name))
+			continue
+		}
+		ingredients.RegisterAllMethods(p)
+		loaded = append(loaded, p)
+	}
+	return loaded, errors.Join(errs...)
+}
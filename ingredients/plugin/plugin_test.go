@@ -0,0 +1,187 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gogrlx/grlx/config"
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+const greeter = `#!/bin/sh
+in=$(cat)
+case "$in" in
+*'"action":"describe"'*)
+	echo '{"name":"greeter","methods":{"hello":[{"key":"name","type":"string","required":true,"description":"who to greet"},{"key":"loud","type":"bool"}]}}'
+	;;
+*'"action":"test"'*)
+	echo '{"succeeded":true,"changed":true,"notes":["would greet"]}'
+	;;
+*'"name":"nobody"'*)
+	echo '{"failed":true,"error":"nobody to greet"}'
+	;;
+*)
+	echo '{"succeeded":true,"changed":true,"notes":["greeted"]}'
+	;;
+esac
+`
+
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDescribe(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		name   string
+		script string
+		err    error
+	}{
+		{name: "valid", script: greeter},
+		{name: "bad json", script: "#!/bin/sh\ncat >/dev/null\necho '{'\n", err: ErrInvalidResponse},
+		{name: "bad name", script: "#!/bin/sh\ncat >/dev/null\necho '{\"name\":\"Bad Name\",\"methods\":{\"x\":[]}}'\n", err: ErrInvalidDescription},
+		{name: "no methods", script: "#!/bin/sh\ncat >/dev/null\necho '{\"name\":\"empty\"}'\n", err: ErrInvalidDescription},
+		{name: "bad type", script: "#!/bin/sh\ncat >/dev/null\necho '{\"name\":\"typed\",\"methods\":{\"x\":[{\"key\":\"n\",\"type\":\"float\"}]}}'\n", err: ErrInvalidDescription},
+		{name: "exit", script: "#!/bin/sh\ncat >/dev/null\necho broken >&2\nexit 3\n"},
+		{name: "too large", script: "#!/bin/sh\ncat >/dev/null\nhead -c 2000000 /dev/zero\n", err: ErrOutputTooLarge},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writePlugin(t, dir, filepath.Base(tc.name), tc.script)
+			p, err := Describe(context.Background(), path)
+			switch {
+			case tc.name == "exit":
+				if err == nil {
+					t.Fatal("expected an error from a failing plugin")
+				}
+			case tc.err == nil && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.err != nil && !errors.Is(err, tc.err):
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+			if tc.name != "valid" {
+				return
+			}
+			name, methods := p.Methods()
+			if name != "greeter" || len(methods) != 1 || methods[0] != "hello" {
+				t.Errorf("unexpected methods %s %v", name, methods)
+			}
+			props, err := p.PropertiesForMethod("hello")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if props["name"] != "string,req" || props["loud"] != "bool,opt" {
+				t.Errorf("unexpected properties %v", props)
+			}
+			if _, err := p.PropertiesForMethod("goodbye"); !errors.Is(err, ErrPluginMethodUndefined) {
+				t.Errorf("expected %v, got %v", ErrPluginMethodUndefined, err)
+			}
+		})
+	}
+}
+
+func TestTestApply(t *testing.T) {
+	dir := t.TempDir()
+	p, err := Describe(context.Background(), writePlugin(t, dir, "greeter", greeter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := types.WithJobID(context.Background(), "job")
+
+	step, err := p.Parse("greet", "hello", map[string]interface{}{"name": "world"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := step.Test(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Succeeded || !res.Changed || len(res.Notes) != 1 || res.Notes[0].String() != "would greet" {
+		t.Errorf("unexpected test result %+v", res)
+	}
+	res, err = step.Apply(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Succeeded || res.Failed || res.Notes[0].String() != "greeted" {
+		t.Errorf("unexpected apply result %+v", res)
+	}
+
+	step, _ = p.Parse("greet", "hello", map[string]interface{}{"name": "nobody"})
+	res, err = step.Apply(ctx)
+	if !errors.Is(err, ErrPluginFailed) || !res.Failed {
+		t.Errorf("expected a failed result, got %+v, %v", res, err)
+	}
+
+	step, _ = p.Parse("greet", "hello", nil)
+	if _, err = step.Apply(ctx); !errors.Is(err, types.ErrMissingName) {
+		t.Errorf("expected %v, got %v", types.ErrMissingName, err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	dir := t.TempDir()
+	p, err := Describe(context.Background(), writePlugin(t, dir, "greeter", greeter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.path = writePlugin(t, dir, "sleeper", "#!/bin/sh\nsleep 10\n")
+	old := config.PluginTimeout
+	config.PluginTimeout = 100 * time.Millisecond
+	defer func() { config.PluginTimeout = old }()
+
+	step, _ := p.Parse("greet", "hello", map[string]interface{}{"name": "world"})
+	start := time.Now()
+	_, err = step.Apply(context.Background())
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected %v, got %v", ErrTimeout, err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("plugin was not stopped at the timeout")
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	if _, err := LoadDir(context.Background(), filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("expected a missing dir to load nothing, got %v", err)
+	}
+
+	dir := t.TempDir()
+	writePlugin(t, dir, "greeter", greeter)
+	writePlugin(t, dir, "broken", "#!/bin/sh\nexit 1\n")
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a plugin"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadDir(context.Background(), dir)
+	if err == nil {
+		t.Error("expected an error for the broken plugin")
+	}
+	if len(loaded) != 1 {
+		t.Fatalf("expected one plugin to load, got %d", len(loaded))
+	}
+	if !ingredients.IsRegistered("greeter") {
+		t.Error("expected greeter to be registered")
+	}
+	cooker, err := ingredients.NewRecipeCooker("greet", "greeter", "hello", map[string]interface{}{"name": "world"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cooker.(Plugin); !ok {
+		t.Errorf("expected a plugin cooker, got %T", cooker)
+	}
+
+	loaded, err = LoadDir(context.Background(), dir)
+	if err == nil || len(loaded) != 0 {
+		t.Errorf("expected an already registered plugin to be refused, got %d, %v", len(loaded), err)
+	}
+}
@@ -0,0 +1,128 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ProtocolVersion is sent with every request so that plugins can reject
+// requests they don't understand.
+const ProtocolVersion = 1
+
+const (
+	// maxOutput caps how much a plugin may write to stdout
+	maxOutput = 1 << 20
+	// maxStderr caps how much of a plugin's stderr is kept for errors
+	maxStderr = 64 << 10
+	// describeTimeout bounds how long a plugin may take to describe itself
+	describeTimeout = 30 * time.Second
+	// defaultTimeout is used for test and apply if no timeout is configured
+	defaultTimeout = 10 * time.Minute
+)
+
+// Request is written to a plugin's stdin. Action is describe, test or
+// apply; the other fields are only set for test and apply.
+type Request struct {
+	Version    int                    `json:"version"`
+	Action     string                 `json:"action"`
+	ID         string                 `json:"id,omitempty"`
+	JobID      string                 `json:"jid,omitempty"`
+	Method     string                 `json:"method,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// Description is a plugin's reply to describe: its ingredient name and,
+// for each method, the properties it takes.
+type Description struct {
+	Name    string                `json:"name"`
+	Methods map[string][]Property `json:"methods"`
+}
+
+// Property describes a property of a method. Type is one of string,
+// []string, bool, map, int, duration or list, as for compiled-in
+// ingredients.
+type Property struct {
+	Key         string      `json:"key"`
+	Type        string      `json:"type"`
+	Required    bool        `json:"required"`
+	Description string      `json:"description,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	Enum        []string    `json:"enum,omitempty"`
+}
+
+// Response is a plugin's reply to test or apply. A non-empty Error fails
+// the step.
+type Response struct {
+	Succeeded bool     `json:"succeeded"`
+	Failed    bool     `json:"failed"`
+	Changed   bool     `json:"changed"`
+	Notes     []string `json:"notes,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// cappedBuffer collects up to limit bytes. Once more is written, it calls
+// overflow, which stops the plugin, and rejects the write; without
+// overflow, the rest is silently dropped.
+type cappedBuffer struct {
+	buf      bytes.Buffer
+	limit    int
+	overflow func()
+	exceeded bool
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	if c.buf.Len()+len(p) <= c.limit {
+		return c.buf.Write(p)
+	}
+	c.exceeded = true
+	if c.overflow == nil {
+		c.buf.Write(p[:c.limit-c.buf.Len()])
+		return len(p), nil
+	}
+	c.overflow()
+	return 0, ErrOutputTooLarge
+}
+
+// call runs the plugin at path with req on stdin and decodes its stdout
+// into resp. The plugin is killed if it runs past timeout or writes more
+// than maxOutput bytes.
+func call(ctx context.Context, path string, timeout time.Duration, req Request, resp interface{}) error {
+	req.Version = ProtocolVersion
+	in, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("GRLX_PLUGIN_PROTOCOL=%d", ProtocolVersion))
+	// don't wait on children that hold the pipes open after a kill
+	cmd.WaitDelay = time.Second
+	stdout := &cappedBuffer{limit: maxOutput, overflow: cancel}
+	stderr := &cappedBuffer{limit: maxStderr}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	err = cmd.Run()
+	switch {
+	case stdout.exceeded:
+		return errors.Join(ErrOutputTooLarge, fmt.Errorf("%s wrote more than %d bytes", path, maxOutput))
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return errors.Join(ErrTimeout, fmt.Errorf("%s did not finish within %s", path, timeout))
+	case err != nil:
+		if msg := strings.TrimSpace(stderr.buf.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", path, err, msg)
+		}
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err = json.Unmarshal(stdout.buf.Bytes(), resp); err != nil {
+		return errors.Join(ErrInvalidResponse, fmt.Errorf("%s: %w", path, err))
+	}
+	return nil
+}
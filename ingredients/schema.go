@@ -0,0 +1,315 @@
+package ingredients
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrUnknownProperty = errors.New("unknown property")
+	ErrMissingProperty = errors.New("missing required property")
+	ErrInvalidProperty = errors.New("invalid property")
+)
+
+// Schemer is implemented by ingredients which describe their methods with
+// a full MethodPropsSet. PropertiesForMethod only carries each property's
+// type and whether it is required, so descriptions, defaults and enums are
+// lost for ingredients which don't implement it.
+type Schemer interface {
+	MethodSchema(method string) (MethodPropsSet, error)
+}
+
+// schemaOf returns the properties of an ingredient's method.
+func schemaOf(ing types.RecipeCooker, method string) (MethodPropsSet, error) {
+	if s, ok := ing.(Schemer); ok {
+		return s.MethodSchema(method)
+	}
+	pmap, err := ing.PropertiesForMethod(method)
+	if err != nil {
+		return nil, err
+	}
+	return PropMapToPropSet(pmap)
+}
+
+// SchemaFor returns the properties of a registered ingredient's method,
+// sorted by key.
+func SchemaFor(ingredient types.Ingredient, method string) (MethodPropsSet, error) {
+	ingTex.Lock()
+	defer ingTex.Unlock()
+	r, ok := ingMap[ingredient]
+	if !ok {
+		return nil, ErrUnknownIngredient
+	}
+	ing, ok := r[method]
+	if !ok {
+		return nil, ErrUnknownMethod
+	}
+	set, err := schemaOf(ing, method)
+	if err != nil {
+		return nil, err
+	}
+	sorted := slices.Clone(set)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	return sorted, nil
+}
+
+// Decode checks params against the set and returns a copy with every value
+// converted to its declared type and defaults filled in. YAML and JSON
+// deliver lists as []interface{} and numbers as int or float64, so lists
+// of scalars become []string, numbers and bools given for strings are
+// formatted, durations given as a number of seconds are formatted as
+// durations, and modes given as numbers are formatted in octal. A lone
+// string given for a []string is kept as it is, since several ingredients
+// split it themselves. The requisites key belongs to the cook and is
+// passed through.
+func (m MethodPropsSet) Decode(params map[string]interface{}) (map[string]interface{}, error) {
+	decoded := make(map[string]interface{}, len(params))
+	props := make(map[string]MethodProps, len(m))
+	for _, prop := range m {
+		props[prop.Key] = prop
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var errs []error
+	for _, k := range keys {
+		if k == "requisites" {
+			decoded[k] = params[k]
+			continue
+		}
+		prop, ok := props[k]
+		if !ok {
+			errs = append(errs, errors.Join(ErrUnknownProperty, fmt.Errorf("unknown property %s", k)))
+			continue
+		}
+		v, err := prop.decode(params[k])
+		if err != nil {
+			errs = append(errs, errors.Join(ErrInvalidProperty, err))
+			continue
+		}
+		decoded[k] = v
+	}
+	for _, prop := range m {
+		if v, ok := params[prop.Key]; ok && v != nil {
+			continue
+		}
+		if prop.IsReq {
+			errs = append(errs, errors.Join(ErrMissingProperty, fmt.Errorf("missing required property %s", prop.Key)))
+		} else if prop.Default != nil {
+			decoded[prop.Key] = prop.Default
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return decoded, nil
+}
+
+// decode converts a single value to the property's type.
+func (p MethodProps) decode(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch p.Type {
+	case "string":
+		s, ok := scalarString(v)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a string, not %T", p.Key, v)
+		}
+		if len(p.Enum) > 0 && !slices.Contains(p.Enum, s) {
+			return nil, fmt.Errorf("%s must be one of %s, not %s", p.Key, strings.Join(p.Enum, ", "), s)
+		}
+		return s, nil
+	case "[]string":
+		switch v := v.(type) {
+		case string:
+			return v, nil
+		case []string:
+			return v, nil
+		case []interface{}:
+			list := make([]string, 0, len(v))
+			for _, item := range v {
+				s, ok := scalarString(item)
+				if !ok {
+					return nil, fmt.Errorf("%s must be a list of strings, but contains a %T", p.Key, item)
+				}
+				list = append(list, s)
+			}
+			return list, nil
+		}
+		return nil, fmt.Errorf("%s must be a list of strings, not %T", p.Key, v)
+	case "bool":
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err == nil {
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("%s must be true or false, not %v", p.Key, v)
+	case "int":
+		if i, ok := wholeNumber(v); ok {
+			return i, nil
+		}
+		if s, ok := v.(string); ok {
+			i, err := strconv.Atoi(s)
+			if err == nil {
+				return i, nil
+			}
+		}
+		return nil, fmt.Errorf("%s must be a whole number, not %v", p.Key, v)
+	case "duration":
+		switch v := v.(type) {
+		case string:
+			if _, err := time.ParseDuration(v); err == nil {
+				return v, nil
+			}
+		case int:
+			return (time.Duration(v) * time.Second).String(), nil
+		case float64:
+			return time.Duration(v * float64(time.Second)).String(), nil
+		}
+		return nil, fmt.Errorf("%s must be a duration such as 30s, not %v", p.Key, v)
+	case "mode":
+		switch v := v.(type) {
+		case string:
+			if _, err := strconv.ParseUint(v, 8, 32); err == nil {
+				return v, nil
+			}
+		case int, int64, uint64, float64:
+			// the number has already been read, so 0644 and 0o644 arrive
+			// as 420, but an unquoted 644 can't be told from 0o1204
+			mode, ok := wholeNumber(v)
+			if !ok || mode < 0 || mode > 0o777 {
+				return nil, fmt.Errorf("%s must be an octal mode, such as 0644 or \"644\", not %v", p.Key, v)
+			}
+			return fmt.Sprintf("%04o", mode), nil
+		}
+		return nil, fmt.Errorf("%s must be an octal mode, such as 0644 or \"644\", not %v", p.Key, v)
+	case "list":
+		switch v := v.(type) {
+		case []interface{}, []string:
+			return v, nil
+		}
+		return nil, fmt.Errorf("%s must be a list, not %T", p.Key, v)
+	case "map":
+		if m, ok := v.(map[string]interface{}); ok {
+			return m, nil
+		}
+		return nil, fmt.Errorf("%s must be a map, not %T", p.Key, v)
+	}
+	return nil, fmt.Errorf("%s has unknown type %s", p.Key, p.Type)
+}
+
+// wholeNumber converts a number decoded from YAML or JSON to an int.
+func wholeNumber(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), true
+		}
+	}
+	return 0, false
+}
+
+// scalarString formats a string, number or bool as a string.
+func scalarString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// ValidateStep decodes a step's properties against its ingredient's
+// schema. Steps for ingredients which aren't registered here are left
+// for the sprout to check, since a sprout may provide ingredients through
+// plugins.
+func ValidateStep(step types.Step) error {
+	schema, err := SchemaFor(step.Ingredient, step.Method)
+	if errors.Is(err, ErrUnknownIngredient) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("step %s: %s.%s: %w", step.ID, step.Ingredient, step.Method, err)
+	}
+	if _, err := schema.Decode(step.Properties); err != nil {
+		return fmt.Errorf("step %s: %s.%s: %w", step.ID, step.Ingredient, step.Method, err)
+	}
+	return nil
+}
+
+// Registered describes every registered ingredient, sorted by name, with
+// the properties of each of its methods, or the error its schema returned.
+func Registered() []types.IngredientInfo {
+	ingTex.Lock()
+	snapshot := make(IngredientMap, len(ingMap))
+	for name, methods := range ingMap {
+		snapshot[name] = maps.Clone(methods)
+	}
+	ingTex.Unlock()
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	infos := []types.IngredientInfo{}
+	for _, name := range names {
+		cookers := snapshot[types.Ingredient(name)]
+		methods := make([]string, 0, len(cookers))
+		for method := range cookers {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		info := types.IngredientInfo{Name: name, Methods: []types.MethodInfo{}}
+		for _, method := range methods {
+			mi := types.MethodInfo{Name: method, Properties: []types.PropertyInfo{}}
+			set, err := schemaOf(cookers[method], method)
+			if err != nil {
+				// a method without a schema can't be cooked, so it is
+				// reported rather than hidden
+				mi.Error = err.Error()
+				info.Methods = append(info.Methods, mi)
+				continue
+			}
+			set = slices.Clone(set)
+			sort.Slice(set, func(i, j int) bool { return set[i].Key < set[j].Key })
+			for _, prop := range set {
+				mi.Properties = append(mi.Properties, types.PropertyInfo{
+					Key: prop.Key, Type: prop.Type, Required: prop.IsReq,
+					Description: prop.Description, Default: prop.Default, Enum: prop.Enum,
+				})
+			}
+			info.Methods = append(info.Methods, mi)
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
@@ -0,0 +1,245 @@
+package ingredients
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+type schemaCooker struct{}
+
+func (schemaCooker) Apply(context.Context) (types.Result, error) { return types.Result{}, nil }
+func (schemaCooker) Test(context.Context) (types.Result, error)  { return types.Result{}, nil }
+func (schemaCooker) Properties() (map[string]interface{}, error) { return nil, nil }
+func (s schemaCooker) Parse(id, method string, properties map[string]interface{}) (types.RecipeCooker, error) {
+	return s, nil
+}
+func (schemaCooker) Methods() (string, []string) { return "schematest", []string{"run"} }
+func (s schemaCooker) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := s.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (schemaCooker) MethodSchema(method string) (MethodPropsSet, error) {
+	if method != "run" {
+		return nil, errors.New("undefined")
+	}
+	return testSchema, nil
+}
+
+// brokenCooker has a method without a schema.
+type brokenCooker struct{ schemaCooker }
+
+func (brokenCooker) Methods() (string, []string) { return "schemabroken", []string{"run"} }
+func (brokenCooker) MethodSchema(method string) (MethodPropsSet, error) {
+	return nil, errors.New("method run undefined")
+}
+
+var testSchema = MethodPropsSet{
+	MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name"},
+	MethodProps{Key: "mode", Type: "string", Enum: []string{"fast", "slow"}, Default: "fast"},
+	MethodProps{Key: "args", Type: "[]string"},
+	MethodProps{Key: "force", Type: "bool"},
+	MethodProps{Key: "count", Type: "int"},
+	MethodProps{Key: "timeout", Type: "duration", Default: "1m0s"},
+	MethodProps{Key: "env", Type: "map"},
+	MethodProps{Key: "items", Type: "list"},
+	MethodProps{Key: "perms", Type: "mode"},
+}
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		params   map[string]interface{}
+		expected map[string]interface{}
+		err      error
+	}{
+		{
+			name:     "defaults",
+			params:   map[string]interface{}{"name": "a"},
+			expected: map[string]interface{}{"name": "a", "mode": "fast", "timeout": "1m0s"},
+		},
+		{
+			name: "bad bool",
+			params: map[string]interface{}{
+				"name": 644, "mode": "slow", "args": []interface{}{"-v", 2, true},
+				"force": "yes", "count": float64(3), "timeout": 90,
+				"env": map[string]interface{}{"A": "b"}, "items": []interface{}{map[string]interface{}{"x": "1"}},
+				"requisites": []interface{}{map[string]interface{}{"require": "b"}},
+			},
+			err: ErrInvalidProperty,
+		},
+		{
+			name: "decoded",
+			params: map[string]interface{}{
+				"name": 644, "mode": "slow", "args": []interface{}{"-v", 2, true},
+				"force": "true", "count": float64(3), "timeout": 90,
+				"env": map[string]interface{}{"A": "b"}, "items": []interface{}{map[string]interface{}{"x": "1"}},
+				"requisites": []interface{}{map[string]interface{}{"require": "b"}},
+			},
+			expected: map[string]interface{}{
+				"name": "644", "mode": "slow", "args": []string{"-v", "2", "true"},
+				"force": true, "count": 3, "timeout": "1m30s",
+				"env": map[string]interface{}{"A": "b"}, "items": []interface{}{map[string]interface{}{"x": "1"}},
+				"requisites": []interface{}{map[string]interface{}{"require": "b"}},
+			},
+		},
+		{
+			name:     "lone string list",
+			params:   map[string]interface{}{"name": "a", "args": "-v", "timeout": "5s"},
+			expected: map[string]interface{}{"name": "a", "mode": "fast", "args": "-v", "timeout": "5s"},
+		},
+		{
+			name:   "missing name",
+			params: map[string]interface{}{"mode": "slow"},
+			err:    ErrMissingProperty,
+		},
+		{
+			name:   "typo",
+			params: map[string]interface{}{"name": "a", "forse": true},
+			err:    ErrUnknownProperty,
+		},
+		{
+			name:   "enum",
+			params: map[string]interface{}{"name": "a", "mode": "medium"},
+			err:    ErrInvalidProperty,
+		},
+		{
+			name:   "bad duration",
+			params: map[string]interface{}{"name": "a", "timeout": "soon"},
+			err:    ErrInvalidProperty,
+		},
+		{
+			name:   "nested list",
+			params: map[string]interface{}{"name": "a", "args": []interface{}{[]interface{}{"x"}}},
+			err:    ErrInvalidProperty,
+		},
+		{
+			name:     "mode",
+			params:   map[string]interface{}{"name": "a", "perms": 0o644},
+			expected: map[string]interface{}{"name": "a", "mode": "fast", "timeout": "1m0s", "perms": "0644"},
+		},
+		{
+			name:     "quoted mode",
+			params:   map[string]interface{}{"name": "a", "perms": "755"},
+			expected: map[string]interface{}{"name": "a", "mode": "fast", "timeout": "1m0s", "perms": "755"},
+		},
+		{
+			name:   "decimal mode",
+			params: map[string]interface{}{"name": "a", "perms": 644},
+			err:    ErrInvalidProperty,
+		},
+		{
+			name:   "fractional int",
+			params: map[string]interface{}{"name": "a", "count": 1.5},
+			err:    ErrInvalidProperty,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decoded, err := testSchema.Decode(tc.params)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected %v, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, decoded)
+			}
+		})
+	}
+}
+
+func TestValidateStep(t *testing.T) {
+	RegisterAllMethods(schemaCooker{})
+	set, err := SchemaFor("schematest", "run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set[0].Key != "args" || set[len(set)-1].Key != "timeout" {
+		t.Errorf("expected the schema sorted by key, got %v", set)
+	}
+	if _, err := SchemaFor("schematest", "walk"); !errors.Is(err, ErrUnknownMethod) {
+		t.Errorf("expected %v, got %v", ErrUnknownMethod, err)
+	}
+
+	testCases := []struct {
+		name string
+		step types.Step
+		err  error
+	}{
+		{name: "valid", step: types.Step{ID: "a", Ingredient: "schematest", Method: "run", Properties: map[string]interface{}{"name": "a"}}},
+		{name: "plugin", step: types.Step{ID: "b", Ingredient: "notcompiledin", Method: "run", Properties: map[string]interface{}{"anything": 1}}},
+		{name: "unknown method", step: types.Step{ID: "c", Ingredient: "schematest", Method: "walk"}, err: ErrUnknownMethod},
+		{name: "typo", step: types.Step{ID: "d", Ingredient: "schematest", Method: "run", Properties: map[string]interface{}{"nmae": "a"}}, err: ErrUnknownProperty},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateStep(tc.step)
+			if tc.err == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.err != nil && !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+
+	if _, err := NewRecipeCooker("e", "schematest", "run", map[string]interface{}{"name": "a", "count": "two"}); !errors.Is(err, ErrInvalidProperty) {
+		t.Errorf("expected the sprout to decode properties before Parse, got %v", err)
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	RegisterAllMethods(schemaCooker{})
+	var info *types.IngredientInfo
+	infos := Registered()
+	for i := range infos {
+		if i > 0 && infos[i-1].Name >= infos[i].Name {
+			t.Errorf("expected ingredients sorted by name, got %s before %s", infos[i-1].Name, infos[i].Name)
+		}
+		if infos[i].Name == "schematest" {
+			info = &infos[i]
+		}
+	}
+	if info == nil {
+		t.Fatal("expected schematest to be listed")
+	}
+	if len(info.Methods) != 1 || info.Methods[0].Name != "run" {
+		t.Fatalf("unexpected methods %v", info.Methods)
+	}
+	props := info.Methods[0].Properties
+	if len(props) != len(testSchema) || props[0].Key != "args" {
+		t.Fatalf("expected the properties sorted by key, got %v", props)
+	}
+	for _, prop := range props {
+		if prop.Key == "mode" && (prop.Default != "fast" || len(prop.Enum) != 2) {
+			t.Errorf("expected the default and enum of mode, got %+v", prop)
+		}
+		if prop.Key == "name" && (!prop.Required || prop.Description != "the name") {
+			t.Errorf("expected name to be required and described, got %+v", prop)
+		}
+	}
+	if testSchema[0].Key != "name" {
+		t.Error("expected the ingredient's schema to be left unsorted")
+	}
+	RegisterAllMethods(brokenCooker{})
+	for _, info := range Registered() {
+		if info.Name != "schemabroken" {
+			continue
+		}
+		if len(info.Methods) != 1 || info.Methods[0].Error != "method run undefined" {
+			t.Errorf("expected the schema error to be reported, got %+v", info.Methods)
+		}
+	}
+}
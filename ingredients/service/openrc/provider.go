@@ -0,0 +1,127 @@
+package openrc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/gogrlx/grlx/ingredients/service"
+	"github.com/gogrlx/grlx/types"
+)
+
+// runlevelDir holds a directory per runlevel, each containing links to the
+// services started in it.
+var runlevelDir = "/etc/runlevels"
+
+type OpenRCService struct {
+	id       string
+	name     string
+	method   string
+	runlevel string
+	props    map[string]interface{}
+}
+
+func (s OpenRCService) Properties() (map[string]interface{}, error) {
+	return s.props, nil
+}
+
+func init() {
+	service.RegisterProvider(OpenRCService{})
+}
+
+func (s OpenRCService) Parse(id, method string, properties map[string]interface{}) (types.ServiceProvider, error) {
+	name, runlevel := "", "default"
+	if properties == nil {
+		properties = make(map[string]interface{})
+	}
+	if nameI, ok := properties["name"].(string); !ok || nameI == "" {
+		return nil, types.ErrMissingName
+	} else {
+		name = nameI
+	}
+	if rl, ok := properties["runlevel"].(string); ok && rl != "" {
+		runlevel = rl
+	}
+	return OpenRCService{id: id, name: name, method: method, runlevel: runlevel, props: properties}, nil
+}
+
+func (s OpenRCService) Start(ctx context.Context) error {
+	_, err := service.Run(ctx, "rc-service", s.name, "start")
+	return err
+}
+
+func (s OpenRCService) Stop(ctx context.Context) error {
+	_, err := service.Run(ctx, "rc-service", s.name, "stop")
+	return err
+}
+
+func (s OpenRCService) Restart(ctx context.Context) error {
+	_, err := service.Run(ctx, "rc-service", s.name, "restart")
+	return err
+}
+
+func (s OpenRCService) Status(ctx context.Context) (string, error) {
+	out, err := service.Run(ctx, "rc-service", s.name, "status")
+	// stopped and crashed services exit non-zero, but still have a status
+	if err != nil && service.ExitCode(err) > 0 {
+		return out, nil
+	}
+	return out, err
+}
+
+func (s OpenRCService) IsRunning(ctx context.Context) (bool, error) {
+	_, err := service.Run(ctx, "rc-service", s.name, "status")
+	if err == nil {
+		return true, nil
+	}
+	if service.ExitCode(err) > 0 {
+		return false, nil
+	}
+	return false, err
+}
+
+func (s OpenRCService) Enable(ctx context.Context) error {
+	_, err := service.Run(ctx, "rc-update", "add", s.name, s.runlevel)
+	return err
+}
+
+func (s OpenRCService) Disable(ctx context.Context) error {
+	_, err := service.Run(ctx, "rc-update", "del", s.name, s.runlevel)
+	return err
+}
+
+func (s OpenRCService) IsEnabled(ctx context.Context) (bool, error) {
+	_, err := os.Lstat(filepath.Join(runlevelDir, s.runlevel, s.name))
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (s OpenRCService) Mask(ctx context.Context) error {
+	return service.ErrMaskUnsupported
+}
+
+func (s OpenRCService) Unmask(ctx context.Context) error {
+	return service.ErrMaskUnsupported
+}
+
+func (s OpenRCService) IsMasked(ctx context.Context) (bool, error) {
+	return false, service.ErrMaskUnsupported
+}
+
+func (s OpenRCService) InitName() string {
+	return "openrc"
+}
+
+// IsInit checks for the state directory OpenRC creates once it has booted
+// the system.
+func (s OpenRCService) IsInit() bool {
+	if _, err := os.Stat("/run/openrc/softlevel"); err == nil {
+		return true
+	}
+	return false
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/gogrlx/grlx/config"
@@ -14,9 +16,10 @@ var (
 	provTex sync.Mutex
 	provMap map[string]types.ServiceProvider
 
-	ErrUnknownInit   = errors.New("unknown init system")
-	ErrDuplicateInit = errors.New("provider for init system already initilaized")
-	Init             string
+	ErrUnknownInit     = errors.New("unknown init system")
+	ErrDuplicateInit   = errors.New("provider for init system already initilaized")
+	ErrMaskUnsupported = errors.New("init system does not support masking services")
+	Init               string
 )
 
 func init() {
@@ -51,9 +54,16 @@ func guessInit() string {
 		return "systemd"
 	}
 
-	for _, initSys := range provMap {
-		if initSys.IsInit() {
-			Init = initSys.InitName()
+	names := make([]string, 0, len(provMap))
+	for name := range provMap {
+		names = append(names, name)
+	}
+	// ask the providers in a stable order, as more than one may be
+	// installed side by side
+	sort.Strings(names)
+	for _, name := range names {
+		if provMap[name].IsInit() {
+			Init = name
 			return Init
 		}
 	}
@@ -62,15 +72,21 @@ func guessInit() string {
 	if err != nil {
 		return "unknown"
 	}
-	return string(f)
+	return strings.TrimSpace(string(f))
 }
 
+// NewServiceProvider returns the provider for the init system named by the
+// provider property, or for the detected init system.
 func NewServiceProvider(id string, method string, params map[string]interface{}) (types.ServiceProvider, error) {
 	provTex.Lock()
 	defer provTex.Unlock()
-	provider, ok := provMap[guessInit()]
+	name, _ := params["provider"].(string)
+	if name == "" {
+		name = guessInit()
+	}
+	provider, ok := provMap[name]
 	if !ok {
-		return nil, ErrUnknownInit
+		return nil, errors.Join(ErrUnknownInit, fmt.Errorf("init system %s not registered", name))
 	}
 	return provider.Parse(id, method, params)
 }
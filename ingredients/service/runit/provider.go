@@ -0,0 +1,169 @@
+package runit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gogrlx/grlx/ingredients/service"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	// svDir holds the definitions of every available service.
+	svDir = "/etc/sv"
+	// runsvDirs are the directories runsvdir may supervise, in the order
+	// they are looked for; a service is enabled by linking it into one.
+	runsvDirs = []string{"/var/service", "/etc/service", "/service"}
+)
+
+type RunitService struct {
+	id     string
+	name   string
+	method string
+	props  map[string]interface{}
+}
+
+func (s RunitService) Properties() (map[string]interface{}, error) {
+	return s.props, nil
+}
+
+func init() {
+	service.RegisterProvider(RunitService{})
+}
+
+func (s RunitService) Parse(id, method string, properties map[string]interface{}) (types.ServiceProvider, error) {
+	name := ""
+	if properties == nil {
+		properties = make(map[string]interface{})
+	}
+	if nameI, ok := properties["name"].(string); !ok || nameI == "" {
+		return nil, types.ErrMissingName
+	} else {
+		name = nameI
+	}
+	return RunitService{id: id, name: name, method: method, props: properties}, nil
+}
+
+// runsvDir returns the directory supervised by runsvdir on this system.
+func runsvDir() (string, error) {
+	for _, dir := range runsvDirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("no runsvdir service directory found in %s", strings.Join(runsvDirs, ", "))
+}
+
+// supervised returns the path sv uses to control the service.
+func (s RunitService) supervised() (string, error) {
+	dir, err := runsvDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, s.name), nil
+}
+
+func (s RunitService) sv(ctx context.Context, command string) (string, error) {
+	p, err := s.supervised()
+	if err != nil {
+		return "", err
+	}
+	return service.Run(ctx, "sv", command, p)
+}
+
+func (s RunitService) Start(ctx context.Context) error {
+	_, err := s.sv(ctx, "up")
+	return err
+}
+
+func (s RunitService) Stop(ctx context.Context) error {
+	_, err := s.sv(ctx, "down")
+	return err
+}
+
+func (s RunitService) Restart(ctx context.Context) error {
+	_, err := s.sv(ctx, "restart")
+	return err
+}
+
+func (s RunitService) Status(ctx context.Context) (string, error) {
+	return s.sv(ctx, "status")
+}
+
+// IsRunning reports whether sv status shows the service as up. Services
+// which aren't enabled aren't supervised, so they are never running.
+func (s RunitService) IsRunning(ctx context.Context) (bool, error) {
+	enabled, err := s.IsEnabled(ctx)
+	if err != nil || !enabled {
+		return false, err
+	}
+	out, err := s.sv(ctx, "status")
+	if err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(out, "run:"), nil
+}
+
+func (s RunitService) Enable(ctx context.Context) error {
+	p, err := s.supervised()
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(svDir, s.name)
+	if _, err = os.Stat(target); err != nil {
+		return fmt.Errorf("service %s is not defined in %s: %w", s.name, svDir, err)
+	}
+	return os.Symlink(target, p)
+}
+
+func (s RunitService) Disable(ctx context.Context) error {
+	p, err := s.supervised()
+	if err != nil {
+		return err
+	}
+	return os.Remove(p)
+}
+
+func (s RunitService) IsEnabled(ctx context.Context) (bool, error) {
+	p, err := s.supervised()
+	if err != nil {
+		return false, err
+	}
+	if _, err = os.Lstat(p); err == nil {
+		return true, nil
+	} else if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (s RunitService) Mask(ctx context.Context) error {
+	return service.ErrMaskUnsupported
+}
+
+func (s RunitService) Unmask(ctx context.Context) error {
+	return service.ErrMaskUnsupported
+}
+
+func (s RunitService) IsMasked(ctx context.Context) (bool, error) {
+	return false, service.ErrMaskUnsupported
+}
+
+func (s RunitService) InitName() string {
+	return "runit"
+}
+
+// IsInit checks whether runit is PID 1, or has left the runtime directory
+// it creates when booting the system.
+func (s RunitService) IsInit() bool {
+	if comm, err := os.ReadFile("/proc/1/comm"); err == nil && strings.TrimSpace(string(comm)) == "runit" {
+		return true
+	}
+	if _, err := os.Stat("/run/runit"); err == nil {
+		return true
+	}
+	return false
+}
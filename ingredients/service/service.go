@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/gogrlx/grlx/ingredients"
@@ -20,9 +21,20 @@ func init() {
 }
 
 func (s Service) Apply(ctx context.Context) (types.Result, error) {
+	return s.converge(ctx, false)
+}
+
+func (s Service) Test(ctx context.Context) (types.Result, error) {
+	return s.converge(ctx, true)
+}
+
+// converge brings the service into the state named by the method. In test
+// mode the provider is only queried, and the notes describe what would
+// change.
+func (s Service) converge(ctx context.Context, test bool) (types.Result, error) {
 	sp, err := NewServiceProvider(s.id, s.method, s.properties)
 	if err != nil {
-		return types.Result{}, err
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
 	}
 	switch s.method {
 	case "masked":
@@ -30,36 +42,24 @@ func (s Service) Apply(ctx context.Context) (types.Result, error) {
 		if isMasked, err = sp.IsMasked(ctx); err != nil {
 			return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
 		} else if !isMasked {
-			err = sp.Mask(ctx)
-			if err != nil {
-				return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
-			}
-			return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s has been masked", s.name))}}, err
-
+			return s.change(test, "masked", func() error { return sp.Mask(ctx) })
 		}
 		return types.Result{Succeeded: true, Failed: false, Changed: false, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s is already masked.", s.name))}}, err
 	case "unmasked":
 		var isMasked bool
-		if isMasked, err = sp.IsMasked(ctx); err != nil {
+		if isMasked, err = sp.IsMasked(ctx); err != nil && !errors.Is(err, ErrMaskUnsupported) {
 			return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
 		} else if isMasked {
-			err = sp.Unmask(ctx)
-			if err != nil {
-				return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
-			}
-			return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s has been unmasked", s.name))}}, err
+			return s.change(test, "unmasked", func() error { return sp.Unmask(ctx) })
 		}
-		return types.Result{Succeeded: true, Failed: false, Changed: false, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s is already unmasked.", s.name))}}, err
+		// init systems without masking never have masked services
+		return types.Result{Succeeded: true, Failed: false, Changed: false, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s is already unmasked.", s.name))}}, nil
 	case "running":
 		var isRunning bool
 		if isRunning, err = sp.IsRunning(ctx); err != nil {
 			return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
 		} else if !isRunning {
-			err = sp.Start(ctx)
-			if err != nil {
-				return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
-			}
-			return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s has been started", s.name))}}, err
+			return s.change(test, "started", func() error { return sp.Start(ctx) })
 		}
 		return types.Result{Succeeded: true, Failed: false, Changed: false, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s is already running.", s.name))}}, err
 	case "stopped":
@@ -67,11 +67,7 @@ func (s Service) Apply(ctx context.Context) (types.Result, error) {
 		if isRunning, err = sp.IsRunning(ctx); err != nil {
 			return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
 		} else if isRunning {
-			err = sp.Stop(ctx)
-			if err != nil {
-				return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
-			}
-			return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s has been stopped", s.name))}}, err
+			return s.change(test, "stopped", func() error { return sp.Stop(ctx) })
 		}
 		return types.Result{Succeeded: true, Failed: false, Changed: false, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s is already stopped.", s.name))}}, err
 	case "enabled":
@@ -79,11 +75,7 @@ func (s Service) Apply(ctx context.Context) (types.Result, error) {
 		if isEnabled, err = sp.IsEnabled(ctx); err != nil {
 			return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
 		} else if !isEnabled {
-			err = sp.Enable(ctx)
-			if err != nil {
-				return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
-			}
-			return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s has been enabled", s.name))}}, err
+			return s.change(test, "enabled", func() error { return sp.Enable(ctx) })
 		}
 		return types.Result{Succeeded: true, Failed: false, Changed: false, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s is already enabled.", s.name))}}, err
 	case "disabled":
@@ -91,36 +83,26 @@ func (s Service) Apply(ctx context.Context) (types.Result, error) {
 		if isEnabled, err = sp.IsEnabled(ctx); err != nil {
 			return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
 		} else if isEnabled {
-			err = sp.Disable(ctx)
-			if err != nil {
-				return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
-			}
-			return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s has been disabled", s.name))}}, err
+			return s.change(test, "disabled", func() error { return sp.Disable(ctx) })
 		}
 		return types.Result{Succeeded: true, Failed: false, Changed: false, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s is already disabled.", s.name))}}, err
 	case "restarted":
-		err = sp.Restart(ctx)
-		if err != nil {
-			return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
-		}
-		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s has been restarted", s.name))}}, err
+		return s.change(test, "restarted", func() error { return sp.Restart(ctx) })
 	default:
-		return types.Result{}, types.ErrInvalidMethod
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, types.ErrInvalidMethod
 	}
 }
 
-func (s Service) Test(context.Context) (types.Result, error) {
-	// TODO implement test applies
-	switch s.method {
-	case "masked":
-	case "unmasked":
-	case "running":
-	case "stopped":
-	case "enabled":
-	case "disabled":
-	case "restarted":
+// change runs action, or in test mode reports that the service would be
+// put into state instead.
+func (s Service) change(test bool, state string, action func() error) (types.Result, error) {
+	if test {
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s would be %s", s.name, state))}}, nil
 	}
-	return types.Result{}, nil
+	if err := action(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil}, err
+	}
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: []fmt.Stringer{types.SimpleNote(fmt.Sprintf("%s has been %s", s.name, state))}}, nil
 }
 
 func (s Service) Properties() (map[string]interface{}, error) {
@@ -165,6 +147,19 @@ func (s Service) Methods() (string, []string) {
 	}
 }
 
+func (s Service) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	return ingredients.MethodPropsSet{
+		ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name of the service"},
+		ingredients.MethodProps{Key: "provider", Type: "string", IsReq: false, Description: "the init system to use instead of the detected one", Enum: []string{"systemd", "openrc", "runit", "sysvinit"}},
+		ingredients.MethodProps{Key: "userMode", Type: "bool", IsReq: false, Description: "manage a systemd user service"},
+		ingredients.MethodProps{Key: "runlevel", Type: "string", IsReq: false, Description: "the OpenRC runlevel to enable the service in, defaults to default"},
+	}, nil
+}
+
 func (s Service) PropertiesForMethod(method string) (map[string]string, error) {
-	return nil, nil
+	set, err := s.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
 }
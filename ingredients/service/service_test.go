@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// fakeState is shared by every fakeService parsed from the same provider.
+type fakeState struct {
+	running, enabled, masked bool
+	noMask                   bool
+	calls                    []string
+}
+
+type fakeService struct {
+	state *fakeState
+	props map[string]interface{}
+}
+
+func (f fakeService) Properties() (map[string]interface{}, error) { return f.props, nil }
+func (f fakeService) Parse(id, method string, properties map[string]interface{}) (types.ServiceProvider, error) {
+	return fakeService{state: f.state, props: properties}, nil
+}
+
+func (f fakeService) call(name string, apply func()) error {
+	f.state.calls = append(f.state.calls, name)
+	apply()
+	return nil
+}
+
+func (f fakeService) Start(context.Context) error {
+	return f.call("start", func() { f.state.running = true })
+}
+
+func (f fakeService) Stop(context.Context) error {
+	return f.call("stop", func() { f.state.running = false })
+}
+
+func (f fakeService) Restart(context.Context) error {
+	return f.call("restart", func() { f.state.running = true })
+}
+
+func (f fakeService) Enable(context.Context) error {
+	return f.call("enable", func() { f.state.enabled = true })
+}
+
+func (f fakeService) Disable(context.Context) error {
+	return f.call("disable", func() { f.state.enabled = false })
+}
+
+func (f fakeService) Mask(context.Context) error {
+	if f.state.noMask {
+		return ErrMaskUnsupported
+	}
+	return f.call("mask", func() { f.state.masked = true })
+}
+
+func (f fakeService) Unmask(context.Context) error {
+	return f.call("unmask", func() { f.state.masked = false })
+}
+
+func (f fakeService) Status(context.Context) (string, error)  { return "", nil }
+func (f fakeService) IsRunning(context.Context) (bool, error) { return f.state.running, nil }
+func (f fakeService) IsEnabled(context.Context) (bool, error) { return f.state.enabled, nil }
+func (f fakeService) InitName() string                        { return "fake" }
+func (f fakeService) IsInit() bool                            { return false }
+func (f fakeService) IsMasked(context.Context) (bool, error) {
+	if f.state.noMask {
+		return false, ErrMaskUnsupported
+	}
+	return f.state.masked, nil
+}
+
+var state = &fakeState{}
+
+func init() {
+	RegisterProvider(fakeService{state: state})
+}
+
+func TestService(t *testing.T) {
+	tests := []struct {
+		method  string
+		state   fakeState
+		test    bool
+		changed bool
+		note    string
+		calls   []string
+		error   error
+	}{
+		{method: "running", test: true, changed: true, note: "web would be started"},
+		{method: "running", changed: true, note: "web has been started", calls: []string{"start"}},
+		{method: "running", state: fakeState{running: true}, test: true, note: "web is already running."},
+		{method: "stopped", state: fakeState{running: true}, test: true, changed: true, note: "web would be stopped"},
+		{method: "stopped", test: true, note: "web is already stopped."},
+		{method: "enabled", test: true, changed: true, note: "web would be enabled"},
+		{method: "enabled", state: fakeState{enabled: true}, note: "web is already enabled."},
+		{method: "disabled", state: fakeState{enabled: true}, test: true, changed: true, note: "web would be disabled"},
+		{method: "disabled", state: fakeState{enabled: true}, changed: true, note: "web has been disabled", calls: []string{"disable"}},
+		{method: "masked", test: true, changed: true, note: "web would be masked"},
+		{method: "masked", state: fakeState{masked: true}, test: true, note: "web is already masked."},
+		{method: "unmasked", state: fakeState{masked: true}, test: true, changed: true, note: "web would be unmasked"},
+		{method: "restarted", state: fakeState{running: true}, test: true, changed: true, note: "web would be restarted"},
+		{method: "restarted", changed: true, note: "web has been restarted", calls: []string{"restart"}},
+		{method: "unmasked", state: fakeState{noMask: true}, note: "web is already unmasked."},
+		{method: "masked", state: fakeState{noMask: true}, test: true, error: ErrMaskUnsupported},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s-%v-%v", test.method, test.test, test.state), func(t *testing.T) {
+			*state = test.state
+			s, err := Service{}.Parse("web", test.method, map[string]interface{}{"name": "web", "provider": "fake"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res types.Result
+			if test.test {
+				res, err = s.Test(context.Background())
+			} else {
+				res, err = s.Apply(context.Background())
+			}
+			if test.error != nil {
+				if !errors.Is(err, test.error) || !res.Failed {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if res.Changed != test.changed {
+				t.Errorf("expected changed to be %v, got %v", test.changed, res.Changed)
+			}
+			if len(res.Notes) != 1 || res.Notes[0].String() != test.note {
+				t.Errorf("expected note %q, got %v", test.note, res.Notes)
+			}
+			if fmt.Sprint(state.calls) != fmt.Sprint(test.calls) {
+				t.Errorf("expected calls %v, got %v", test.calls, state.calls)
+			}
+		})
+	}
+}
+
+func TestUnknownProvider(t *testing.T) {
+	s, err := Service{}.Parse("web", "running", map[string]interface{}{"name": "web", "provider": "upstart"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Test(context.Background()); !errors.Is(err, ErrUnknownInit) {
+		t.Errorf("expected %v, got %v", ErrUnknownInit, err)
+	}
+}
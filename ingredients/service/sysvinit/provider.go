@@ -0,0 +1,169 @@
+package sysvinit
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gogrlx/grlx/ingredients/service"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	// initDir holds the init scripts.
+	initDir = "/etc/init.d"
+	// rcDirs are the directories which may hold the runlevel links, as
+	// laid out by Debian and by Red Hat.
+	rcDirs = []string{"/etc", "/etc/rc.d"}
+	// multiUserRunlevels are the runlevels a service must start in to
+	// count as enabled.
+	multiUserRunlevels = []string{"2", "3", "4", "5"}
+)
+
+type SysVService struct {
+	id     string
+	name   string
+	method string
+	props  map[string]interface{}
+}
+
+func (s SysVService) Properties() (map[string]interface{}, error) {
+	return s.props, nil
+}
+
+func init() {
+	service.RegisterProvider(SysVService{})
+}
+
+func (s SysVService) Parse(id, method string, properties map[string]interface{}) (types.ServiceProvider, error) {
+	name := ""
+	if properties == nil {
+		properties = make(map[string]interface{})
+	}
+	if nameI, ok := properties["name"].(string); !ok || nameI == "" || strings.Contains(nameI, "/") {
+		return nil, types.ErrMissingName
+	} else {
+		name = nameI
+	}
+	return SysVService{id: id, name: name, method: method, props: properties}, nil
+}
+
+func (s SysVService) script(ctx context.Context, command string) (string, error) {
+	return service.Run(ctx, filepath.Join(initDir, s.name), command)
+}
+
+func (s SysVService) Start(ctx context.Context) error {
+	_, err := s.script(ctx, "start")
+	return err
+}
+
+func (s SysVService) Stop(ctx context.Context) error {
+	_, err := s.script(ctx, "stop")
+	return err
+}
+
+func (s SysVService) Restart(ctx context.Context) error {
+	_, err := s.script(ctx, "restart")
+	return err
+}
+
+func (s SysVService) Status(ctx context.Context) (string, error) {
+	out, err := s.script(ctx, "status")
+	// LSB scripts exit non-zero for anything but a running service
+	if err != nil && service.ExitCode(err) > 0 {
+		return out, nil
+	}
+	return out, err
+}
+
+// IsRunning relies on the LSB convention of status exiting 0 only when
+// the service is running.
+func (s SysVService) IsRunning(ctx context.Context) (bool, error) {
+	_, err := s.script(ctx, "status")
+	if err == nil {
+		return true, nil
+	}
+	if service.ExitCode(err) > 0 {
+		return false, nil
+	}
+	return false, err
+}
+
+// Enable uses whichever of update-rc.d (Debian) and chkconfig (Red Hat)
+// the system has.
+func (s SysVService) Enable(ctx context.Context) error {
+	if _, err := exec.LookPath("update-rc.d"); err == nil {
+		if _, err = service.Run(ctx, "update-rc.d", s.name, "defaults"); err != nil {
+			return err
+		}
+		_, err = service.Run(ctx, "update-rc.d", s.name, "enable")
+		return err
+	}
+	if _, err := service.Run(ctx, "chkconfig", "--add", s.name); err != nil {
+		return err
+	}
+	_, err := service.Run(ctx, "chkconfig", s.name, "on")
+	return err
+}
+
+func (s SysVService) Disable(ctx context.Context) error {
+	if _, err := exec.LookPath("update-rc.d"); err == nil {
+		_, err = service.Run(ctx, "update-rc.d", s.name, "disable")
+		return err
+	}
+	_, err := service.Run(ctx, "chkconfig", s.name, "off")
+	return err
+}
+
+// IsEnabled looks for a start link to the script in any multi-user
+// runlevel.
+func (s SysVService) IsEnabled(ctx context.Context) (bool, error) {
+	for _, dir := range rcDirs {
+		for _, level := range multiUserRunlevels {
+			links, err := filepath.Glob(filepath.Join(dir, "rc"+level+".d", "S[0-9][0-9]"+s.name))
+			if err != nil {
+				return false, err
+			}
+			if len(links) > 0 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (s SysVService) Mask(ctx context.Context) error {
+	return service.ErrMaskUnsupported
+}
+
+func (s SysVService) Unmask(ctx context.Context) error {
+	return service.ErrMaskUnsupported
+}
+
+func (s SysVService) IsMasked(ctx context.Context) (bool, error) {
+	return false, service.ErrMaskUnsupported
+}
+
+func (s SysVService) InitName() string {
+	return "sysvinit"
+}
+
+// IsInit checks that PID 1 is init and that none of the init systems which
+// also run as init, or keep /etc/init.d for compatibility, are booted.
+func (s SysVService) IsInit() bool {
+	comm, err := os.ReadFile("/proc/1/comm")
+	if err != nil || strings.TrimSpace(string(comm)) != "init" {
+		return false
+	}
+	for _, other := range []string{"/run/systemd/system", "/run/openrc", "/run/runit"} {
+		if _, err = os.Stat(other); err == nil {
+			return false
+		}
+	}
+	if info, err := os.Stat(initDir); err != nil || !info.IsDir() {
+		return false
+	}
+	return true
+}
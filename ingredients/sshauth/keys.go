@@ -0,0 +1,267 @@
+package sshauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/gogrlx/grlx/ingredients/accounts"
+	"github.com/gogrlx/grlx/types"
+)
+
+// authorizedKey is a key as it appears in an authorized_keys file.
+type authorizedKey struct {
+	key     ssh.PublicKey
+	comment string
+	options []string
+}
+
+func (a authorizedKey) String() string {
+	var b strings.Builder
+	if len(a.options) > 0 {
+		b.WriteString(strings.Join(a.options, ",") + " ")
+	}
+	b.WriteString(a.key.Type() + " " + base64.StdEncoding.EncodeToString(a.key.Marshal()))
+	if a.comment != "" {
+		b.WriteString(" " + a.comment)
+	}
+	return b.String()
+}
+
+// parseLine returns the key on a line of an authorized_keys file, or nil
+// for blank lines, comments and anything else which isn't a key.
+func parseLine(line string) *authorizedKey {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+	key, comment, options, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return nil
+	}
+	return &authorizedKey{key: key, comment: comment, options: options}
+}
+
+// desired returns the key described by name, enc, comment and options.
+func (s SSHAuth) desired() (authorizedKey, error) {
+	name := strings.TrimSpace(s.params["name"].(string))
+	if len(strings.Fields(name)) == 1 {
+		enc, _ := s.params["enc"].(string)
+		if enc == "" {
+			enc = "ssh-ed25519"
+		}
+		name = enc + " " + name
+	}
+	parsed := parseLine(name)
+	if parsed == nil {
+		return authorizedKey{}, errors.Join(ErrInvalidKey, fmt.Errorf("cannot parse key %q", name))
+	}
+	if comment, ok := s.params["comment"].(string); ok {
+		parsed.comment = comment
+	}
+	switch options := s.params["options"].(type) {
+	case []interface{}:
+		parsed.options = []string{}
+		for _, o := range options {
+			parsed.options = append(parsed.options, fmt.Sprintf("%v", o))
+		}
+	case []string:
+		parsed.options = options
+	}
+	return *parsed, nil
+}
+
+// target returns the authorized_keys file to manage and the account which
+// should own it.
+func (s SSHAuth) target() (string, *accounts.PasswdEntry, error) {
+	userName, _ := s.params["user"].(string)
+	account, err := accounts.LookupUser(userName)
+	if err != nil {
+		return "", nil, err
+	}
+	if account == nil {
+		return "", nil, errors.Join(ErrUnknownUser, fmt.Errorf("user %s does not exist", userName))
+	}
+	config, _ := s.params["config"].(string)
+	if config == "" {
+		config = ".ssh/authorized_keys"
+	}
+	// the same tokens sshd_config accepts for AuthorizedKeysFile
+	config = strings.NewReplacer("%h", account.Home, "%u", account.Name, "%%", "%").Replace(config)
+	if !filepath.IsAbs(config) {
+		config = filepath.Join(account.Home, config)
+	}
+	return config, account, nil
+}
+
+func readLines(p string) ([]string, error) {
+	b, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.TrimSuffix(b, []byte("\n"))
+	if len(b) == 0 {
+		return nil, nil
+	}
+	return strings.Split(string(b), "\n"), nil
+}
+
+// writeLines atomically replaces the authorized_keys file, creating its
+// directory if needed. sshd refuses keys in files other users can write,
+// so both are private to, and owned by, the account.
+func writeLines(p string, lines []string, account *accounts.PasswdEntry) error {
+	uid, uidErr := strconv.Atoi(account.UID)
+	gid, gidErr := strconv.Atoi(account.GID)
+	chown := os.Geteuid() == 0 && uidErr == nil && gidErr == nil
+	dir := filepath.Dir(p)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err = os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+		if chown {
+			if err = os.Chown(dir, uid, gid); err != nil {
+				return err
+			}
+		}
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(p)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	content := ""
+	if len(lines) > 0 {
+		content = strings.Join(lines, "\n") + "\n"
+	}
+	if _, err = tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if chown {
+		if err = tmp.Chown(uid, gid); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// present adds the key to the user's authorized_keys, or replaces the
+// line holding it if its options or comment differ. Duplicate lines for
+// the same key are removed.
+func (s SSHAuth) present(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := s.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	want, err := s.desired()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	p, account, err := s.target()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	lines, err := readLines(p)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	fingerprint := ssh.FingerprintSHA256(want.key)
+	found, current := false, true
+	updated := []string{}
+	for _, line := range lines {
+		existing := parseLine(line)
+		if existing == nil || !bytes.Equal(existing.key.Marshal(), want.key.Marshal()) {
+			updated = append(updated, line)
+			continue
+		}
+		if found {
+			// drop duplicates of the key
+			current = false
+			continue
+		}
+		found = true
+		if line != want.String() {
+			current = false
+		}
+		updated = append(updated, want.String())
+	}
+	if found && current {
+		notes = append(notes, types.Snprintf("key %s is already present in %s", fingerprint, p))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	}
+	action := "updated in"
+	if !found {
+		action = "added to"
+		updated = append(updated, want.String())
+	}
+	if test {
+		notes = append(notes, types.Snprintf("key %s would be %s %s", fingerprint, action, p))
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+	if err = writeLines(p, updated, account); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	notes = append(notes, types.Snprintf("key %s has been %s %s", fingerprint, action, p))
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+}
+
+// absent removes every line holding the key from the user's
+// authorized_keys.
+func (s SSHAuth) absent(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := s.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	want, err := s.desired()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	p, account, err := s.target()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	lines, err := readLines(p)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	fingerprint := ssh.FingerprintSHA256(want.key)
+	kept := []string{}
+	for _, line := range lines {
+		if existing := parseLine(line); existing != nil && bytes.Equal(existing.key.Marshal(), want.key.Marshal()) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if len(kept) == len(lines) {
+		notes = append(notes, types.Snprintf("key %s is already absent from %s", fingerprint, p))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	}
+	if test {
+		notes = append(notes, types.Snprintf("key %s would be removed from %s", fingerprint, p))
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+	if err = writeLines(p, kept, account); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	notes = append(notes, types.Snprintf("key %s has been removed from %s", fingerprint, p))
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+}
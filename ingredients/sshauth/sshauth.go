@@ -0,0 +1,133 @@
+package sshauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrSSHAuthMethodUndefined = errors.New("ssh_auth method undefined")
+	ErrInvalidKey             = errors.New("invalid public key")
+	ErrUnknownUser            = errors.New("user does not exist")
+)
+
+type SSHAuth struct {
+	id     string
+	method string
+	params map[string]interface{}
+}
+
+func (s SSHAuth) Parse(id, method string, params map[string]interface{}) (types.RecipeCooker, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return SSHAuth{
+		id: id, method: method,
+		params: params,
+	}, nil
+}
+
+func (s SSHAuth) validate() error {
+	set, err := s.PropertiesForMethod(s.method)
+	if err != nil {
+		return err
+	}
+	propSet, err := ingredients.PropMapToPropSet(set)
+	if err != nil {
+		return err
+	}
+	for _, v := range propSet {
+		if v.IsReq {
+			if v.Key == "name" {
+				name, ok := s.params[v.Key].(string)
+				if !ok {
+					return types.ErrMissingName
+				}
+				if name == "" {
+					return types.ErrMissingName
+				}
+
+			} else {
+				if _, ok := s.params[v.Key]; !ok {
+					return fmt.Errorf("missing required property %s", v.Key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s SSHAuth) Test(ctx context.Context) (types.Result, error) {
+	switch s.method {
+	case "present":
+		return s.present(ctx, true)
+	case "absent":
+		return s.absent(ctx, true)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrSSHAuthMethodUndefined, fmt.Errorf("method %s undefined", s.method))
+	}
+}
+
+func (s SSHAuth) Apply(ctx context.Context) (types.Result, error) {
+	switch s.method {
+	case "present":
+		return s.present(ctx, false)
+	case "absent":
+		return s.absent(ctx, false)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrSSHAuthMethodUndefined, fmt.Errorf("method %s undefined", s.method))
+	}
+}
+
+func (s SSHAuth) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	common := ingredients.MethodPropsSet{
+		ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the public key, as a full authorized_keys line or just the base64 key"},
+		ingredients.MethodProps{Key: "user", Type: "string", IsReq: true, Description: "the user whose authorized_keys to manage"},
+		ingredients.MethodProps{Key: "enc", Type: "string", IsReq: false, Description: "the key type if name is just the base64 key, defaults to ssh-ed25519"},
+		ingredients.MethodProps{Key: "config", Type: "string", IsReq: false, Description: "the authorized_keys file, relative to the user's home unless absolute; defaults to .ssh/authorized_keys"},
+	}
+	switch method {
+	case "present":
+		return append(common,
+			ingredients.MethodProps{Key: "comment", Type: "string", IsReq: false, Description: "the comment to store with the key"},
+			ingredients.MethodProps{Key: "options", Type: "[]string", IsReq: false, Description: "options such as from=\"10.0.0.0/8\" or no-pty"},
+		), nil
+	case "absent":
+		return common, nil
+	default:
+		return nil, errors.Join(ErrSSHAuthMethodUndefined, fmt.Errorf("method %s undefined", method))
+	}
+}
+
+func (s SSHAuth) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := s.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (s SSHAuth) Methods() (string, []string) {
+	return "ssh_auth", []string{"absent", "present"}
+}
+
+func (s SSHAuth) Properties() (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(s.params)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+func init() {
+	ingredients.RegisterAllMethods(SSHAuth{})
+}
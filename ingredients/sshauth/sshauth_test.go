@@ -0,0 +1,210 @@
+package sshauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/gogrlx/grlx/ingredients/accounts"
+	"github.com/gogrlx/grlx/types"
+)
+
+// setup gives a user named deploy a temporary home directory.
+func setup(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	passwd := accounts.PasswdFile
+	t.Cleanup(func() { accounts.PasswdFile = passwd })
+	accounts.PasswdFile = filepath.Join(t.TempDir(), "passwd")
+	entry := fmt.Sprintf("deploy:x:%d:%d::%s:/bin/sh\n", os.Getuid(), os.Getgid(), home)
+	if err := os.WriteFile(accounts.PasswdFile, []byte(entry), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+func newKey(t *testing.T) (string, string) {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(key.Marshal()), ssh.FingerprintSHA256(key)
+}
+
+func TestSSHAuth(t *testing.T) {
+	blob, fingerprint := newKey(t)
+	other, _ := newKey(t)
+	tests := []struct {
+		name     string
+		method   string
+		params   map[string]interface{}
+		existing string
+		test     bool
+		config   string
+		expected string
+		notes    []string
+		changed  bool
+		error    error
+	}{
+		{
+			name:     "Add",
+			method:   "present",
+			params:   map[string]interface{}{"name": blob, "user": "deploy", "comment": "ci"},
+			existing: "# keys\nssh-ed25519 " + other + " laptop\n",
+			expected: "# keys\nssh-ed25519 " + other + " laptop\nssh-ed25519 " + blob + " ci\n",
+			notes:    []string{"key " + fingerprint + " has been added to .ssh/authorized_keys"},
+			changed:  true,
+		},
+		{
+			name:     "CreateFile",
+			method:   "present",
+			params:   map[string]interface{}{"name": "ssh-ed25519 " + blob + " ci", "user": "deploy"},
+			expected: "ssh-ed25519 " + blob + " ci\n",
+			notes:    []string{"key " + fingerprint + " has been added to .ssh/authorized_keys"},
+			changed:  true,
+		},
+		{
+			name:     "Options",
+			method:   "present",
+			params:   map[string]interface{}{"name": blob, "user": "deploy", "options": []interface{}{`from="10.0.0.0/8"`, "no-pty"}},
+			existing: "ssh-ed25519 " + blob + "\nssh-ed25519 " + blob + " duplicate\n",
+			expected: `from="10.0.0.0/8",no-pty ssh-ed25519 ` + blob + "\n",
+			notes:    []string{"key " + fingerprint + " has been updated in .ssh/authorized_keys"},
+			changed:  true,
+		},
+		{
+			name:     "Unchanged",
+			method:   "present",
+			params:   map[string]interface{}{"name": blob, "user": "deploy", "comment": "ci"},
+			existing: "ssh-ed25519 " + blob + " ci\n",
+			expected: "ssh-ed25519 " + blob + " ci\n",
+			notes:    []string{"key " + fingerprint + " is already present in .ssh/authorized_keys"},
+		},
+		{
+			name:     "Test",
+			method:   "present",
+			params:   map[string]interface{}{"name": blob, "user": "deploy"},
+			test:     true,
+			expected: "",
+			notes:    []string{"key " + fingerprint + " would be added to .ssh/authorized_keys"},
+			changed:  true,
+		},
+		{
+			name:     "Config",
+			method:   "present",
+			params:   map[string]interface{}{"name": blob, "user": "deploy", "config": "%h/.ssh/authorized_keys2"},
+			config:   ".ssh/authorized_keys2",
+			expected: "ssh-ed25519 " + blob + "\n",
+			notes:    []string{"key " + fingerprint + " has been added to .ssh/authorized_keys2"},
+			changed:  true,
+		},
+		{
+			name:     "Absent",
+			method:   "absent",
+			params:   map[string]interface{}{"name": blob, "user": "deploy"},
+			existing: "ssh-ed25519 " + blob + " ci\nssh-ed25519 " + other + " laptop\n",
+			expected: "ssh-ed25519 " + other + " laptop\n",
+			notes:    []string{"key " + fingerprint + " has been removed from .ssh/authorized_keys"},
+			changed:  true,
+		},
+		{
+			name:   "AlreadyAbsent",
+			method: "absent",
+			params: map[string]interface{}{"name": blob, "user": "deploy"},
+			notes:  []string{"key " + fingerprint + " is already absent from .ssh/authorized_keys"},
+		},
+		{
+			name:   "InvalidKey",
+			method: "present",
+			params: map[string]interface{}{"name": "not-a-key", "user": "deploy"},
+			error:  ErrInvalidKey,
+		},
+		{
+			name:   "UnknownUser",
+			method: "present",
+			params: map[string]interface{}{"name": blob, "user": "nobody"},
+			error:  ErrUnknownUser,
+		},
+		{
+			name:   "MissingName",
+			method: "absent",
+			params: map[string]interface{}{"user": "deploy"},
+			error:  types.ErrMissingName,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			home := setup(t)
+			config := test.config
+			if config == "" {
+				config = ".ssh/authorized_keys"
+			}
+			target := filepath.Join(home, config)
+			if test.existing != "" {
+				if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(target, []byte(test.existing), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := SSHAuth{id: test.name, method: test.method, params: test.params}
+			var res types.Result
+			var err error
+			if test.test {
+				res, err = s.Test(context.Background())
+			} else {
+				res, err = s.Apply(context.Background())
+			}
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			notes := []string{}
+			for _, note := range res.Notes {
+				notes = append(notes, strings.ReplaceAll(note.String(), home+"/", ""))
+			}
+			if fmt.Sprint(notes) != fmt.Sprint(test.notes) {
+				t.Errorf("expected notes %v, got %v", test.notes, notes)
+			}
+			if res.Changed != test.changed {
+				t.Errorf("expected changed to be %v, got %v", test.changed, res.Changed)
+			}
+			content, err := os.ReadFile(target)
+			if test.expected == "" {
+				if err == nil && len(content) > 0 {
+					t.Errorf("expected %s to be empty or absent, got\n%s", target, content)
+				}
+				return
+			}
+			if string(content) != test.expected {
+				t.Errorf("expected %s to contain\n%s\ngot\n%s", target, test.expected, content)
+			}
+			info, err := os.Stat(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0o600 {
+				t.Errorf("expected %s to have mode 0600, got %v", target, info.Mode().Perm())
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package sysctl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrSysctlMethodUndefined = errors.New("sysctl method undefined")
+	ErrInvalidKey            = errors.New("invalid sysctl key")
+	ErrUnknownKey            = errors.New("unknown sysctl key")
+	ErrInvalidValue          = errors.New("invalid sysctl value")
+	ErrInvalidConfig         = errors.New("invalid sysctl config file")
+)
+
+const (
+	sysctlDir     = "/etc/sysctl.d"
+	defaultConfig = "99-grlx.conf"
+	procSys       = "/proc/sys"
+)
+
+// root is prepended to every path the ingredient touches, so that tests can
+// work against a temporary directory.
+var root = "/"
+
+type Sysctl struct {
+	id     string
+	method string
+	params map[string]interface{}
+}
+
+func (s Sysctl) Parse(id, method string, params map[string]interface{}) (types.RecipeCooker, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return Sysctl{
+		id: id, method: method,
+		params: params,
+	}, nil
+}
+
+func (s Sysctl) validate() error {
+	set, err := s.PropertiesForMethod(s.method)
+	if err != nil {
+		return err
+	}
+	propSet, err := ingredients.PropMapToPropSet(set)
+	if err != nil {
+		return err
+	}
+	for _, v := range propSet {
+		if v.IsReq {
+			if v.Key == "name" {
+				name, ok := s.params[v.Key].(string)
+				if !ok {
+					return types.ErrMissingName
+				}
+				if name == "" {
+					return types.ErrMissingName
+				}
+
+			} else {
+				if _, ok := s.params[v.Key]; !ok {
+					return fmt.Errorf("missing required property %s", v.Key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s Sysctl) Test(ctx context.Context) (types.Result, error) {
+	switch s.method {
+	case "present":
+		return s.present(ctx, true)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrSysctlMethodUndefined, fmt.Errorf("method %s undefined", s.method))
+	}
+}
+
+func (s Sysctl) Apply(ctx context.Context) (types.Result, error) {
+	switch s.method {
+	case "present":
+		return s.present(ctx, false)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrSysctlMethodUndefined, fmt.Errorf("method %s undefined", s.method))
+	}
+}
+
+// hostPath maps a system path to where it lives under root.
+func hostPath(p string) string {
+	return filepath.Join(root, filepath.FromSlash(p))
+}
+
+// procPath returns the path of key relative to /proc/sys. Keys are
+// separated by dots, or by slashes, in which case dots are part of the
+// names, as in net/ipv4/conf/eth0.100/forwarding.
+func procPath(key string) (string, error) {
+	p := key
+	if !strings.Contains(key, "/") {
+		p = strings.ReplaceAll(key, ".", "/")
+	}
+	for _, elem := range strings.Split(p, "/") {
+		if elem == "" || elem == "." || elem == ".." || strings.ContainsAny(elem, " \t=#;") {
+			return "", errors.Join(ErrInvalidKey, fmt.Errorf("invalid sysctl key %q", key))
+		}
+	}
+	return p, nil
+}
+
+// value formats the value property the way the kernel prints it; lists,
+// for parameters with several fields, are joined with spaces.
+func (s Sysctl) value() (string, error) {
+	var fields []string
+	switch v := s.params["value"].(type) {
+	case string:
+		fields = strings.Fields(v)
+	case int:
+		fields = []string{strconv.Itoa(v)}
+	case float64:
+		fields = []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case bool:
+		fields = []string{"0"}
+		if v {
+			fields = []string{"1"}
+		}
+	case []interface{}:
+		for _, f := range v {
+			fields = append(fields, fmt.Sprintf("%v", f))
+		}
+	case []string:
+		fields = v
+	default:
+		return "", errors.Join(ErrInvalidValue, fmt.Errorf("unsupported value %v", v))
+	}
+	value := strings.Join(fields, " ")
+	if value == "" || strings.ContainsAny(value, "\n#;") {
+		return "", errors.Join(ErrInvalidValue, fmt.Errorf("invalid value %q", value))
+	}
+	return value, nil
+}
+
+// configFile returns the system path of the file the setting is persisted
+// to. A bare file name is taken to be in /etc/sysctl.d.
+func (s Sysctl) configFile() (string, error) {
+	name, _ := s.params["config"].(string)
+	if name == "" {
+		name = defaultConfig
+	}
+	if !path.IsAbs(name) {
+		if strings.Contains(name, "/") {
+			return "", errors.Join(ErrInvalidConfig, fmt.Errorf("config %s must be a file name or an absolute path", name))
+		}
+		name = path.Join(sysctlDir, name)
+	}
+	if !strings.HasSuffix(name, ".conf") {
+		return "", errors.Join(ErrInvalidConfig, fmt.Errorf("config %s must end in .conf to be read at boot", name))
+	}
+	return path.Clean(name), nil
+}
+
+// readFile returns the contents of the file at the system path p, and
+// whether it exists.
+func readFile(p string) ([]byte, bool, error) {
+	b, err := os.ReadFile(hostPath(p))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+func (s Sysctl) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	switch method {
+	case "present":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the kernel parameter, such as net.ipv4.ip_forward"},
+			ingredients.MethodProps{Key: "value", Type: "string", IsReq: true, Description: "the value to set; parameters with several fields take a list"},
+			ingredients.MethodProps{Key: "config", Type: "string", IsReq: false, Description: "the file to persist the setting to, in /etc/sysctl.d unless absolute (default 99-grlx.conf)"},
+		}, nil
+	default:
+		return nil, errors.Join(ErrSysctlMethodUndefined, fmt.Errorf("method %s undefined", method))
+	}
+}
+
+func (s Sysctl) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := s.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (s Sysctl) Methods() (string, []string) {
+	return "sysctl", []string{"present"}
+}
+
+func (s Sysctl) Properties() (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(s.params)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+func init() {
+	ingredients.RegisterAllMethods(Sysctl{})
+}
@@ -0,0 +1,131 @@
+package sysctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gogrlx/grlx/ingredients/file"
+	"github.com/gogrlx/grlx/types"
+)
+
+// persist returns config with key set to value. The first line setting
+// the parameter is rewritten if needed, and any later ones are dropped,
+// since the last one read would win. If no line sets it, the setting is
+// appended.
+func persist(config []byte, key, proc, value string) []byte {
+	lines := []string{}
+	if len(config) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(config), "\n"), "\n")
+	}
+	out := []string{}
+	found := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		k, v, ok := strings.Cut(trimmed, "=")
+		if !ok || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			out = append(out, line)
+			continue
+		}
+		// a leading - tells systemd-sysctl to ignore errors setting the key
+		if p, err := procPath(strings.TrimPrefix(strings.TrimSpace(k), "-")); err != nil || p != proc {
+			out = append(out, line)
+			continue
+		}
+		if found {
+			continue
+		}
+		found = true
+		if strings.Join(strings.Fields(v), " ") == value {
+			out = append(out, line)
+		} else {
+			out = append(out, key+" = "+value)
+		}
+	}
+	if !found {
+		out = append(out, key+" = "+value)
+	}
+	return []byte(strings.Join(out, "\n") + "\n")
+}
+
+// present sets a kernel parameter at runtime through /proc/sys and
+// persists it to a file in /etc/sysctl.d so that it is set again at boot.
+// Changes to the file are shown as a diff.
+func (s Sysctl) present(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := s.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	key, _ := s.params["name"].(string)
+	proc, err := procPath(key)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	value, err := s.value()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	config, err := s.configFile()
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+
+	runtimePath := path.Join(procSys, proc)
+	b, err := os.ReadFile(hostPath(runtimePath))
+	if os.IsNotExist(err) {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, errors.Join(ErrUnknownKey, fmt.Errorf("the kernel has no parameter %s", key))
+	} else if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	running := strings.Join(strings.Fields(string(b)), " ")
+	current, exists, err := readFile(config)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	desired := persist(current, key, proc, value)
+	persisted := exists && bytes.Equal(current, desired)
+	applied := running == value
+	if persisted && applied {
+		notes = append(notes, types.Snprintf("%s is already set to %s", key, value))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	}
+	oldName := config
+	if !exists {
+		oldName = "/dev/null"
+	}
+	diff := types.SimpleNote(file.UnifiedDiff(oldName, config, current, desired))
+
+	if test {
+		if !applied {
+			notes = append(notes, types.Snprintf("would set %s: %s -> %s", key, running, value))
+		}
+		if !persisted {
+			notes = append(notes, types.Snprintf("would persist %s to %s", key, config), diff)
+		}
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+	changed := false
+	if !persisted {
+		if err = os.MkdirAll(filepath.Dir(hostPath(config)), 0o755); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		if err = file.WriteFileAtomic(hostPath(config), desired); err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		changed = true
+		notes = append(notes, types.Snprintf("persisted %s to %s", key, config), diff)
+	}
+	if !applied {
+		if err = os.WriteFile(hostPath(runtimePath), []byte(value+"\n"), 0o644); err != nil {
+			notes = append(notes, types.Snprintf("failed to set %s", key))
+			return types.Result{Succeeded: false, Failed: true, Changed: changed, Notes: notes}, err
+		}
+		notes = append(notes, types.Snprintf("set %s: %s -> %s", key, running, value))
+	}
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+}
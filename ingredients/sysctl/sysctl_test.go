@@ -0,0 +1,141 @@
+package sysctl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setup points the ingredient at a fresh temporary root with the given
+// kernel parameters and sysctl config files.
+func setup(t *testing.T, params, files map[string]string) {
+	t.Helper()
+	r := root
+	t.Cleanup(func() { root = r })
+	root = t.TempDir()
+	for p, content := range params {
+		files[filepath.Join(procSys, p)] = content
+	}
+	for p, content := range files {
+		if err := os.MkdirAll(filepath.Dir(hostPath(p)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(hostPath(p), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPresent(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]interface{}
+		kernel  map[string]string
+		files   map[string]string
+		test    bool
+		changed bool
+		want    map[string]string
+		error   error
+	}{
+		{
+			name:    "New",
+			params:  map[string]interface{}{"name": "net.ipv4.ip_forward", "value": 1},
+			kernel:  map[string]string{"net/ipv4/ip_forward": "0\n"},
+			changed: true,
+			want: map[string]string{
+				"/proc/sys/net/ipv4/ip_forward": "1\n",
+				"/etc/sysctl.d/99-grlx.conf":    "net.ipv4.ip_forward = 1\n",
+			},
+		},
+		{
+			name:   "AlreadySet",
+			params: map[string]interface{}{"name": "vm.swappiness", "value": "10"},
+			kernel: map[string]string{"vm/swappiness": "10\n"},
+			files:  map[string]string{"/etc/sysctl.d/99-grlx.conf": "# tuning\nvm.swappiness=10\n"},
+			want:   map[string]string{"/etc/sysctl.d/99-grlx.conf": "# tuning\nvm.swappiness=10\n"},
+		},
+		{
+			name:    "UpdateConfig",
+			params:  map[string]interface{}{"name": "vm/swappiness", "value": "10", "config": "tuning.conf"},
+			kernel:  map[string]string{"vm/swappiness": "10\n"},
+			files:   map[string]string{"/etc/sysctl.d/tuning.conf": "kernel.pid_max = 65536\nvm.swappiness = 60\n-vm.swappiness = 30\n"},
+			changed: true,
+			want:    map[string]string{"/etc/sysctl.d/tuning.conf": "kernel.pid_max = 65536\nvm/swappiness = 10\n"},
+		},
+		{
+			name:    "MultipleFields",
+			params:  map[string]interface{}{"name": "net.ipv4.tcp_rmem", "value": []interface{}{4096, 87380, 6291456}},
+			kernel:  map[string]string{"net/ipv4/tcp_rmem": "4096\t131072\t6291456\n"},
+			changed: true,
+			want: map[string]string{
+				"/proc/sys/net/ipv4/tcp_rmem": "4096 87380 6291456\n",
+				"/etc/sysctl.d/99-grlx.conf":  "net.ipv4.tcp_rmem = 4096 87380 6291456\n",
+			},
+		},
+		{
+			name:    "Test",
+			params:  map[string]interface{}{"name": "net.ipv4.ip_forward", "value": true},
+			kernel:  map[string]string{"net/ipv4/ip_forward": "0\n"},
+			test:    true,
+			changed: true,
+			want:    map[string]string{"/proc/sys/net/ipv4/ip_forward": "0\n"},
+		},
+		{name: "UnknownKey", params: map[string]interface{}{"name": "net.ipv4.nonsense", "value": 1}, error: ErrUnknownKey},
+		{name: "InvalidKey", params: map[string]interface{}{"name": "net..ipv4", "value": 1}, error: ErrInvalidKey},
+		{name: "Traversal", params: map[string]interface{}{"name": "../../etc/passwd", "value": 1}, error: ErrInvalidKey},
+		{name: "InvalidConfig", params: map[string]interface{}{"name": "vm.swappiness", "value": 1, "config": "tuning"}, kernel: map[string]string{"vm/swappiness": "60\n"}, error: ErrInvalidConfig},
+		{name: "MissingValue", params: map[string]interface{}{"name": "vm.swappiness"}, error: errors.New("missing required property value")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.files == nil {
+				test.files = map[string]string{}
+			}
+			setup(t, test.kernel, test.files)
+			s := Sysctl{id: test.name, method: "present", params: test.params}
+			apply := s.Apply
+			if test.test {
+				apply = s.Test
+			}
+			res, err := apply(context.Background())
+			if test.error != nil {
+				if err == nil || (!errors.Is(err, test.error) && err.Error() != test.error.Error()) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !res.Succeeded || res.Changed != test.changed {
+				t.Errorf("unexpected result %+v", res)
+			}
+			for p, want := range test.want {
+				got, err := os.ReadFile(hostPath(p))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != want {
+					t.Errorf("expected %s to hold %q, got %q", p, want, got)
+				}
+			}
+			if test.test {
+				if _, err := os.Stat(hostPath("/etc/sysctl.d/99-grlx.conf")); !os.IsNotExist(err) {
+					t.Error("expected test mode not to write the config")
+				}
+			}
+			if test.changed {
+				notes := []string{}
+				for _, n := range res.Notes {
+					notes = append(notes, n.String())
+				}
+				if !strings.Contains(strings.Join(notes, "\n"), "+++ ") {
+					t.Errorf("expected a diff in the notes, got %v", notes)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package systemd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/taigrr/systemctl"
+
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrSystemdMethodUndefined = errors.New("systemd method undefined")
+	ErrInvalidUnitName        = errors.New("invalid unit name")
+	ErrConflictingContent     = errors.New("only one of sections, contents and source may be set")
+)
+
+// unitTypes are the suffixes systemd accepts for unit files.
+var unitTypes = []string{
+	".automount", ".device", ".mount", ".path", ".scope", ".service",
+	".slice", ".socket", ".swap", ".target", ".timer",
+}
+
+// daemonReload makes the system or user manager reread its unit files.
+var daemonReload = func(ctx context.Context, userMode bool) error {
+	return systemctl.DaemonReload(ctx, systemctl.Options{UserMode: userMode})
+}
+
+type Systemd struct {
+	id     string
+	method string
+	params map[string]interface{}
+}
+
+func (s Systemd) Parse(id, method string, params map[string]interface{}) (types.RecipeCooker, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return Systemd{
+		id: id, method: method,
+		params: params,
+	}, nil
+}
+
+func (s Systemd) validate() error {
+	set, err := s.PropertiesForMethod(s.method)
+	if err != nil {
+		return err
+	}
+	propSet, err := ingredients.PropMapToPropSet(set)
+	if err != nil {
+		return err
+	}
+	for _, v := range propSet {
+		if v.IsReq {
+			if v.Key == "name" {
+				name, ok := s.params[v.Key].(string)
+				if !ok {
+					return types.ErrMissingName
+				}
+				if name == "" {
+					return types.ErrMissingName
+				}
+
+			} else {
+				if _, ok := s.params[v.Key]; !ok {
+					return fmt.Errorf("missing required property %s", v.Key)
+				}
+			}
+		}
+	}
+	name := s.params["name"].(string)
+	if strings.ContainsAny(name, "/\\") || !hasUnitType(name) {
+		return errors.Join(ErrInvalidUnitName, fmt.Errorf("%q is not a unit name, such as example.service", name))
+	}
+	dropin, _ := s.params["dropin"].(string)
+	if s.method == "dropin" && dropin == "" {
+		return errors.Join(ErrInvalidUnitName, errors.New("dropin must name the drop-in"))
+	}
+	if strings.ContainsAny(dropin, "/\\") || dropin == "." || dropin == ".." {
+		return errors.Join(ErrInvalidUnitName, fmt.Errorf("%q is not a drop-in name", dropin))
+	}
+	return nil
+}
+
+func hasUnitType(name string) bool {
+	ext := path.Ext(name)
+	for _, t := range unitTypes {
+		if ext == t && len(name) > len(ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Systemd) Test(ctx context.Context) (types.Result, error) {
+	switch s.method {
+	case "unit", "dropin":
+		return s.unit(ctx, true)
+	case "absent":
+		return s.absent(ctx, true)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrSystemdMethodUndefined, fmt.Errorf("method %s undefined", s.method))
+	}
+}
+
+func (s Systemd) Apply(ctx context.Context) (types.Result, error) {
+	switch s.method {
+	case "unit", "dropin":
+		return s.unit(ctx, false)
+	case "absent":
+		return s.absent(ctx, false)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrSystemdMethodUndefined, fmt.Errorf("method %s undefined", s.method))
+	}
+}
+
+func (s Systemd) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	common := ingredients.MethodPropsSet{
+		ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the unit, such as example.service"},
+		ingredients.MethodProps{Key: "userMode", Type: "bool", IsReq: false, Description: "manage a unit of the user's service manager instead of the system's"},
+		ingredients.MethodProps{Key: "reload", Type: "bool", IsReq: false, Description: "run daemon-reload when anything changes, defaults to true"},
+	}
+	content := ingredients.MethodPropsSet{
+		ingredients.MethodProps{Key: "sections", Type: "map", IsReq: false, Description: "the unit's sections, each a map of keys to a value or a list of values"},
+		ingredients.MethodProps{Key: "contents", Type: "string", IsReq: false, Description: "the complete file"},
+		ingredients.MethodProps{Key: "source", Type: "string", IsReq: false, Description: "fetch the file through the file providers"},
+		ingredients.MethodProps{Key: "source_hash", Type: "string", IsReq: false, Description: "the hash of source"},
+		ingredients.MethodProps{Key: "skip_verify", Type: "bool", IsReq: false, Description: "skip verifying the hash of source"},
+	}
+	dropin := ingredients.MethodProps{Key: "dropin", Type: "string", IsReq: false, Description: "the name of a drop-in in the unit's .d directory; .conf is added if missing"}
+	switch method {
+	case "unit":
+		return append(common, content...), nil
+	case "dropin":
+		dropin.IsReq = true
+		return append(append(common, dropin), content...), nil
+	case "absent":
+		return append(common, dropin), nil
+	default:
+		return nil, errors.Join(ErrSystemdMethodUndefined, fmt.Errorf("method %s undefined", method))
+	}
+}
+
+func (s Systemd) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := s.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (s Systemd) Methods() (string, []string) {
+	return "systemd", []string{"absent", "dropin", "unit"}
+}
+
+func (s Systemd) Properties() (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(s.params)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+func init() {
+	ingredients.RegisterAllMethods(Systemd{})
+}
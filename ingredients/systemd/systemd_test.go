@@ -0,0 +1,226 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogrlx/grlx/types"
+)
+
+// setup points the ingredient at temporary unit directories and counts
+// daemon-reloads instead of running them.
+func setup(t *testing.T) (string, string, *[]bool) {
+	t.Helper()
+	tempDir := t.TempDir()
+	sys, user, reload := systemUnitDir, userUnitDir, daemonReload
+	t.Cleanup(func() { systemUnitDir, userUnitDir, daemonReload = sys, user, reload })
+	systemUnitDir = filepath.Join(tempDir, "system")
+	userDir := filepath.Join(tempDir, "user")
+	userUnitDir = func() (string, error) { return userDir, nil }
+	reloads := &[]bool{}
+	daemonReload = func(ctx context.Context, userMode bool) error {
+		*reloads = append(*reloads, userMode)
+		return nil
+	}
+	return systemUnitDir, userDir, reloads
+}
+
+func TestUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		params   map[string]interface{}
+		existing string
+		test     bool
+		path     string
+		expected string
+		notes    []string
+		changed  bool
+		reloads  []bool
+		error    error
+	}{
+		{
+			name:   "Sections",
+			method: "unit",
+			params: map[string]interface{}{
+				"name": "app.service",
+				"sections": map[string]interface{}{
+					"Install": map[string]interface{}{"WantedBy": "multi-user.target"},
+					"Service": map[string]interface{}{
+						"ExecStart":   "/usr/bin/app --serve",
+						"Environment": []interface{}{"A=1", "B=2"},
+						"Restart":     "always",
+					},
+					"Unit": map[string]interface{}{"Description": "App", "After": "network.target"},
+				},
+			},
+			path: "system/app.service",
+			expected: "[Unit]\nAfter=network.target\nDescription=App\n\n" +
+				"[Service]\nEnvironment=A=1\nEnvironment=B=2\nExecStart=/usr/bin/app --serve\nRestart=always\n\n" +
+				"[Install]\nWantedBy=multi-user.target\n",
+			notes:   []string{"system/app.service has been created", "ran daemon-reload"},
+			changed: true,
+			reloads: []bool{false},
+		},
+		{
+			name:   "DropIn",
+			method: "dropin",
+			params: map[string]interface{}{
+				"name": "app.service", "dropin": "override",
+				"sections": map[string]interface{}{
+					"Service": map[string]interface{}{"ExecStart": []interface{}{"", "/usr/bin/app --debug"}},
+				},
+			},
+			path:     "system/app.service.d/override.conf",
+			expected: "[Service]\nExecStart=\nExecStart=/usr/bin/app --debug\n",
+			notes:    []string{"system/app.service.d/override.conf has been created", "ran daemon-reload"},
+			changed:  true,
+			reloads:  []bool{false},
+		},
+		{
+			name:     "UserContents",
+			method:   "unit",
+			params:   map[string]interface{}{"name": "sync.timer", "userMode": true, "contents": "[Timer]\nOnCalendar=hourly"},
+			existing: "[Timer]\nOnCalendar=daily\n",
+			path:     "user/sync.timer",
+			expected: "[Timer]\nOnCalendar=hourly\n",
+			notes:    []string{"user/sync.timer has been updated", "ran daemon-reload --user"},
+			changed:  true,
+			reloads:  []bool{true},
+		},
+		{
+			name:     "Unchanged",
+			method:   "unit",
+			params:   map[string]interface{}{"name": "app.service", "contents": "[Service]\nExecStart=/bin/true\n"},
+			existing: "[Service]\nExecStart=/bin/true\n",
+			path:     "system/app.service",
+			expected: "[Service]\nExecStart=/bin/true\n",
+			notes:    []string{"system/app.service is already in the correct state"},
+		},
+		{
+			name:     "Test",
+			method:   "unit",
+			params:   map[string]interface{}{"name": "app.service", "contents": "[Service]\nExecStart=/bin/false\n"},
+			existing: "[Service]\nExecStart=/bin/true\n",
+			test:     true,
+			path:     "system/app.service",
+			expected: "[Service]\nExecStart=/bin/true\n",
+			notes:    []string{"system/app.service would be updated", "would run daemon-reload"},
+			changed:  true,
+		},
+		{
+			name:     "NoReload",
+			method:   "unit",
+			params:   map[string]interface{}{"name": "app.service", "contents": "[Service]\n", "reload": false},
+			path:     "system/app.service",
+			expected: "[Service]\n",
+			notes:    []string{"system/app.service has been created"},
+			changed:  true,
+		},
+		{
+			name:     "Absent",
+			method:   "absent",
+			params:   map[string]interface{}{"name": "app.service"},
+			existing: "[Service]\n",
+			path:     "system/app.service",
+			notes:    []string{"system/app.service has been removed", "ran daemon-reload"},
+			changed:  true,
+			reloads:  []bool{false},
+		},
+		{
+			name:   "AlreadyAbsent",
+			method: "absent",
+			params: map[string]interface{}{"name": "app.service", "dropin": "override"},
+			path:   "system/app.service.d/override.conf",
+			notes:  []string{"system/app.service.d/override.conf is already absent"},
+		},
+		{
+			name:   "InvalidName",
+			method: "unit",
+			params: map[string]interface{}{"name": "../app.service", "contents": "[Service]\n"},
+			error:  ErrInvalidUnitName,
+		},
+		{
+			name:   "NoType",
+			method: "unit",
+			params: map[string]interface{}{"name": "app", "contents": "[Service]\n"},
+			error:  ErrInvalidUnitName,
+		},
+		{
+			name:   "Conflicting",
+			method: "unit",
+			params: map[string]interface{}{"name": "app.service", "contents": "[Service]\n", "sections": map[string]interface{}{}},
+			error:  ErrConflictingContent,
+		},
+		{
+			name:   "MissingContent",
+			method: "unit",
+			params: map[string]interface{}{"name": "app.service"},
+			error:  types.ErrMissingContent,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, reloads := setup(t)
+			base := filepath.Dir(systemUnitDir)
+			target := filepath.Join(base, test.path)
+			if test.existing != "" {
+				if err := writeUnit(target, []byte(test.existing)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := Systemd{id: test.name, method: test.method, params: test.params}
+			var res types.Result
+			var err error
+			if test.test {
+				res, err = s.Test(context.Background())
+			} else {
+				res, err = s.Apply(context.Background())
+			}
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			notes := []string{}
+			for _, note := range res.Notes {
+				notes = append(notes, trimBase(base, note.String()))
+			}
+			if fmt.Sprint(notes) != fmt.Sprint(test.notes) {
+				t.Errorf("expected notes %v, got %v", test.notes, notes)
+			}
+			if res.Changed != test.changed {
+				t.Errorf("expected changed to be %v, got %v", test.changed, res.Changed)
+			}
+			if fmt.Sprint(*reloads) != fmt.Sprint(test.reloads) {
+				t.Errorf("expected reloads %v, got %v", test.reloads, *reloads)
+			}
+			content, err := os.ReadFile(target)
+			if test.expected == "" {
+				if err == nil {
+					t.Errorf("expected %s to be absent", target)
+				}
+				return
+			}
+			if string(content) != test.expected {
+				t.Errorf("expected %s to contain\n%s\ngot\n%s", target, test.expected, content)
+			}
+		})
+	}
+}
+
+// trimBase strips the temporary directory from the start of a note.
+func trimBase(base, note string) string {
+	if len(note) > len(base) && note[:len(base)+1] == base+string(filepath.Separator) {
+		return note[len(base)+1:]
+	}
+	return note
+}
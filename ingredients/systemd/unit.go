@@ -0,0 +1,298 @@
+package systemd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gogrlx/grlx/ingredients/file"
+	"github.com/gogrlx/grlx/types"
+)
+
+// systemUnitDir holds unit files and drop-ins written by the administrator.
+var systemUnitDir = "/etc/systemd/system"
+
+// userUnitDir returns the directory the user's service manager reads
+// administrator unit files from.
+var userUnitDir = func() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "systemd", "user"), nil
+}
+
+func (s Systemd) userMode() bool {
+	userMode, _ := s.params["userMode"].(bool)
+	return userMode
+}
+
+// path returns where the unit file, or the drop-in if one is named, lives.
+func (s Systemd) path() (string, error) {
+	dir := systemUnitDir
+	if s.userMode() {
+		var err error
+		if dir, err = userUnitDir(); err != nil {
+			return "", err
+		}
+	}
+	name := s.params["name"].(string)
+	dropin, _ := s.params["dropin"].(string)
+	if dropin == "" || s.method == "unit" {
+		return filepath.Join(dir, name), nil
+	}
+	if !strings.HasSuffix(dropin, ".conf") {
+		dropin += ".conf"
+	}
+	return filepath.Join(dir, name+".d", dropin), nil
+}
+
+// contents returns the file described by whichever of sections, contents
+// and source is set.
+func (s Systemd) contents(ctx context.Context) ([]byte, []fmt.Stringer, error) {
+	sections, hasSections := s.params["sections"]
+	contents, hasContents := s.params["contents"].(string)
+	source, _ := s.params["source"].(string)
+	set := 0
+	for _, ok := range []bool{hasSections, hasContents, source != ""} {
+		if ok {
+			set++
+		}
+	}
+	switch {
+	case set > 1:
+		return nil, nil, ErrConflictingContent
+	case hasSections:
+		b, err := renderSections(sections)
+		return b, nil, err
+	case hasContents:
+		if !strings.HasSuffix(contents, "\n") {
+			contents += "\n"
+		}
+		return []byte(contents), nil, nil
+	case source != "":
+		srcHash, _ := s.params["source_hash"].(string)
+		skipVerify, _ := s.params["skip_verify"].(bool)
+		cached, notes, err := file.CacheSource(ctx, s.id, s.params, "systemd-"+s.params["name"].(string), source, srcHash, skipVerify, false)
+		if err != nil {
+			return nil, notes, err
+		}
+		b, err := os.ReadFile(cached)
+		return b, notes, err
+	default:
+		return nil, nil, fmt.Errorf("%w: one of sections, contents or source is required", types.ErrMissingContent)
+	}
+}
+
+// renderSections writes a unit file from a map of section names to maps
+// of keys. Keys with a list of values are repeated, so an empty first
+// entry resets a setting such as ExecStart in a drop-in. [Unit] comes
+// first and [Install] last, with the other sections and all keys sorted.
+func renderSections(sectionsI interface{}) ([]byte, error) {
+	sections, ok := sectionsI.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("sections must be a map of section names to settings, not %T", sectionsI)
+	}
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	rank := func(name string) int {
+		switch name {
+		case "Unit":
+			return 0
+		case "Install":
+			return 2
+		default:
+			return 1
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if rank(names[i]) != rank(names[j]) {
+			return rank(names[i]) < rank(names[j])
+		}
+		return names[i] < names[j]
+	})
+	var b bytes.Buffer
+	for i, name := range names {
+		settings, ok := sections[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("section %s must be a map of settings, not %T", name, sections[name])
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[%s]\n", name)
+		keys := make([]string, 0, len(settings))
+		for key := range settings {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			values, ok := settings[key].([]interface{})
+			if !ok {
+				values = []interface{}{settings[key]}
+			}
+			for _, v := range values {
+				if v == nil {
+					v = ""
+				}
+				fmt.Fprintf(&b, "%s=%v\n", key, v)
+			}
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// reload runs daemon-reload, unless the reload property is false.
+func (s Systemd) reload(ctx context.Context, test bool, notes []fmt.Stringer) ([]fmt.Stringer, error) {
+	if reload, ok := s.params["reload"].(bool); ok && !reload {
+		return notes, nil
+	}
+	command := "daemon-reload"
+	if s.userMode() {
+		command += " --user"
+	}
+	if test {
+		return append(notes, types.Snprintf("would run %s", command)), nil
+	}
+	if err := daemonReload(ctx, s.userMode()); err != nil {
+		return notes, err
+	}
+	return append(notes, types.Snprintf("ran %s", command)), nil
+}
+
+// unit writes the unit file, or one of its drop-ins, and reloads the
+// service manager if it changed. A step which watches this one with
+// onchanges can then restart the service.
+func (s Systemd) unit(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := s.validate(); err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	target, err := s.path()
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	content, contentNotes, err := s.contents(ctx)
+	notes = append(notes, contentNotes...)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	current, err := os.ReadFile(target)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	if exists && bytes.Equal(current, content) {
+		notes = append(notes, types.Snprintf("%s is already in the correct state", target))
+		return types.Result{
+			Succeeded: true, Failed: false, Notes: notes,
+		}, nil
+	}
+	verb := "created"
+	if exists {
+		verb = "updated"
+	}
+	if test {
+		notes = append(notes, types.Snprintf("%s would be %s", target, verb))
+		notes, _ = s.reload(ctx, true, notes)
+		return types.Result{
+			Succeeded: true, Failed: false,
+			Changed: true, Notes: notes,
+		}, nil
+	}
+	if err = writeUnit(target, content); err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	notes = append(notes, types.Snprintf("%s has been %s", target, verb))
+	notes, err = s.reload(ctx, false, notes)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true,
+			Changed: true, Notes: notes,
+		}, err
+	}
+	return types.Result{
+		Succeeded: true, Failed: false,
+		Changed: true, Notes: notes,
+	}, nil
+}
+
+// absent removes the unit file, or only the named drop-in, and reloads the
+// service manager if anything was removed.
+func (s Systemd) absent(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := s.validate(); err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	target, err := s.path()
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	if _, err = os.Lstat(target); os.IsNotExist(err) {
+		notes = append(notes, types.Snprintf("%s is already absent", target))
+		return types.Result{
+			Succeeded: true, Failed: false, Notes: notes,
+		}, nil
+	} else if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	if test {
+		notes = append(notes, types.Snprintf("%s would be removed", target))
+		notes, _ = s.reload(ctx, true, notes)
+		return types.Result{
+			Succeeded: true, Failed: false,
+			Changed: true, Notes: notes,
+		}, nil
+	}
+	if err = os.Remove(target); err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true, Notes: notes,
+		}, err
+	}
+	notes = append(notes, types.Snprintf("%s has been removed", target))
+	if dropin, _ := s.params["dropin"].(string); dropin != "" {
+		// tidy up the .d directory once its last drop-in is gone
+		os.Remove(filepath.Dir(target))
+	}
+	notes, err = s.reload(ctx, false, notes)
+	if err != nil {
+		return types.Result{
+			Succeeded: false, Failed: true,
+			Changed: true, Notes: notes,
+		}, err
+	}
+	return types.Result{
+		Succeeded: true, Failed: false,
+		Changed: true, Notes: notes,
+	}, nil
+}
+
+// writeUnit atomically replaces the file at p, creating its directory.
+func writeUnit(p string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return file.WriteFileAtomic(p, content)
+}
@@ -0,0 +1,237 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrTestMethodUndefined = errors.New("test method undefined")
+	ErrTestFailure         = errors.New("test state failed as configured")
+	ErrInvalidDuration     = errors.New("invalid duration")
+)
+
+// Test provides states with fixed outcomes which touch nothing on the
+// system, for exercising requisites and the cook engine while developing
+// recipes.
+type Test struct {
+	id     string
+	method string
+	params map[string]interface{}
+}
+
+func (s Test) Parse(id, method string, params map[string]interface{}) (types.RecipeCooker, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return Test{
+		id: id, method: method,
+		params: params,
+	}, nil
+}
+
+func (s Test) validate() error {
+	set, err := s.PropertiesForMethod(s.method)
+	if err != nil {
+		return err
+	}
+	propSet, err := ingredients.PropMapToPropSet(set)
+	if err != nil {
+		return err
+	}
+	for _, v := range propSet {
+		if v.IsReq {
+			if v.Key == "name" {
+				name, ok := s.params[v.Key].(string)
+				if !ok {
+					return types.ErrMissingName
+				}
+				if name == "" {
+					return types.ErrMissingName
+				}
+
+			} else {
+				if _, ok := s.params[v.Key]; !ok {
+					return fmt.Errorf("missing required property %s", v.Key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s Test) Test(ctx context.Context) (types.Result, error) {
+	return s.run(ctx, true)
+}
+
+func (s Test) Apply(ctx context.Context) (types.Result, error) {
+	return s.run(ctx, false)
+}
+
+func (s Test) run(ctx context.Context, test bool) (types.Result, error) {
+	if err := s.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{}}, err
+	}
+	switch s.method {
+	case "configurable":
+		succeed := true
+		if v, ok := s.params["result"].(bool); ok {
+			succeed = v
+		}
+		changes := true
+		if v, ok := s.params["changes"].(bool); ok {
+			changes = v
+		}
+		return s.outcome(succeed, changes, test)
+	case "fail_with_changes":
+		return s.outcome(false, true, test)
+	case "fail_without_changes":
+		return s.outcome(false, false, test)
+	case "sleep":
+		return s.sleep(ctx, test)
+	case "succeed_with_changes":
+		return s.outcome(true, true, test)
+	case "succeed_without_changes":
+		return s.outcome(true, false, test)
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrTestMethodUndefined, fmt.Errorf("method %s undefined", s.method))
+	}
+}
+
+// outcome builds the result of a state with a fixed outcome. The notes
+// come from the notes property if it is set, and otherwise describe the
+// outcome. Failures return ErrTestFailure, so they look like any other
+// failed step.
+func (s Test) outcome(succeed, changes, test bool) (types.Result, error) {
+	name, _ := s.params["name"].(string)
+	notes := []fmt.Stringer{}
+	switch v := s.params["notes"].(type) {
+	case string:
+		notes = append(notes, types.SimpleNote(v))
+	case []string:
+		for _, n := range v {
+			notes = append(notes, types.SimpleNote(n))
+		}
+	case []interface{}:
+		for _, n := range v {
+			notes = append(notes, types.Snprintf("%v", n))
+		}
+	}
+	if len(notes) == 0 {
+		verb := "succeeded"
+		if !succeed {
+			verb = "failed"
+		}
+		if test {
+			verb = "would have " + verb
+		}
+		with := "without changes"
+		if changes {
+			with = "with changes"
+		}
+		notes = append(notes, types.Snprintf("%s %s %s", name, verb, with))
+	}
+	res := types.Result{Succeeded: succeed, Failed: !succeed, Changed: changes, Notes: notes}
+	if !succeed {
+		return res, ErrTestFailure
+	}
+	return res, nil
+}
+
+// sleep waits for duration, or until ctx is cancelled, and succeeds
+// without changes. Test mode doesn't wait.
+func (s Test) sleep(ctx context.Context, test bool) (types.Result, error) {
+	name, _ := s.params["name"].(string)
+	var d time.Duration
+	switch v := s.params["duration"].(type) {
+	case string:
+		var err error
+		if d, err = time.ParseDuration(v); err != nil {
+			return types.Result{Succeeded: false, Failed: true}, errors.Join(ErrInvalidDuration, err)
+		}
+	case int:
+		d = time.Duration(v) * time.Second
+	case float64:
+		d = time.Duration(v * float64(time.Second))
+	default:
+		return types.Result{Succeeded: false, Failed: true}, errors.Join(ErrInvalidDuration, fmt.Errorf("duration must be a duration such as 5s or a number of seconds, not %v", v))
+	}
+	if d < 0 {
+		return types.Result{Succeeded: false, Failed: true}, errors.Join(ErrInvalidDuration, fmt.Errorf("duration %s is negative", d))
+	}
+	if test {
+		return types.Result{Succeeded: true, Failed: false, Notes: []fmt.Stringer{types.Snprintf("%s would sleep for %s", name, d)}}, nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return types.Result{Succeeded: true, Failed: false, Notes: []fmt.Stringer{types.Snprintf("%s slept for %s", name, d)}}, nil
+	case <-ctx.Done():
+		return types.Result{Succeeded: false, Failed: true, Notes: []fmt.Stringer{types.Snprintf("%s was interrupted", name)}}, ctx.Err()
+	}
+}
+
+func (s Test) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	switch method {
+	case "configurable":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name of the state"},
+			ingredients.MethodProps{Key: "result", Type: "bool", IsReq: false, Description: "whether the state succeeds (default true)"},
+			ingredients.MethodProps{Key: "changes", Type: "bool", IsReq: false, Description: "whether the state reports changes (default true)"},
+			ingredients.MethodProps{Key: "notes", Type: "[]string", IsReq: false, Description: "the notes to report"},
+		}, nil
+	case "fail_with_changes", "fail_without_changes", "succeed_with_changes", "succeed_without_changes":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name of the state"},
+			ingredients.MethodProps{Key: "notes", Type: "[]string", IsReq: false, Description: "the notes to report"},
+		}, nil
+	case "sleep":
+		return ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name of the state"},
+			ingredients.MethodProps{Key: "duration", Type: "duration", IsReq: true, Description: "how long to sleep, such as 5s, or a number of seconds"},
+		}, nil
+	default:
+		return nil, errors.Join(ErrTestMethodUndefined, fmt.Errorf("method %s undefined", method))
+	}
+}
+
+func (s Test) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := s.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (s Test) Methods() (string, []string) {
+	return "test", []string{
+		"configurable",
+		"fail_with_changes",
+		"fail_without_changes",
+		"sleep",
+		"succeed_with_changes",
+		"succeed_without_changes",
+	}
+}
+
+func (s Test) Properties() (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(s.params)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+func init() {
+	ingredients.RegisterAllMethods(Test{})
+}
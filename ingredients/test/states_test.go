@@ -0,0 +1,88 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gogrlx/grlx/ingredients"
+)
+
+func TestStates(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		params    map[string]interface{}
+		test      bool
+		succeeded bool
+		changed   bool
+		notes     []string
+		error     error
+	}{
+		{name: "SucceedWithout", method: "succeed_without_changes", succeeded: true, notes: []string{"step succeeded without changes"}},
+		{name: "SucceedWith", method: "succeed_with_changes", succeeded: true, changed: true, notes: []string{"step succeeded with changes"}},
+		{name: "SucceedWithTest", method: "succeed_with_changes", test: true, succeeded: true, changed: true, notes: []string{"step would have succeeded with changes"}},
+		{name: "FailWithout", method: "fail_without_changes", notes: []string{"step failed without changes"}, error: ErrTestFailure},
+		{name: "FailWith", method: "fail_with_changes", changed: true, notes: []string{"step failed with changes"}, error: ErrTestFailure},
+		{name: "Notes", method: "succeed_without_changes", params: map[string]interface{}{"notes": []interface{}{"one", 2}}, succeeded: true, notes: []string{"one", "2"}},
+		{name: "Configurable", method: "configurable", succeeded: true, changed: true, notes: []string{"step succeeded with changes"}},
+		{name: "ConfigurableFail", method: "configurable", params: map[string]interface{}{"result": false, "changes": false, "notes": "broken"}, notes: []string{"broken"}, error: ErrTestFailure},
+		{name: "Sleep", method: "sleep", params: map[string]interface{}{"duration": "10ms"}, succeeded: true, notes: []string{"step slept for 10ms"}},
+		{name: "SleepTest", method: "sleep", params: map[string]interface{}{"duration": 3600}, test: true, succeeded: true, notes: []string{"step would sleep for 1h0m0s"}},
+		{name: "SleepInvalid", method: "sleep", params: map[string]interface{}{"duration": "later"}, error: ErrInvalidDuration},
+		{name: "Undefined", method: "explode", error: ErrTestMethodUndefined},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := map[string]interface{}{"name": "step"}
+			for k, v := range test.params {
+				params[k] = v
+			}
+			cooker, err := Test{}.Parse(test.name, test.method, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			apply := cooker.Apply
+			if test.test {
+				apply = cooker.Test
+			}
+			res, err := apply(context.Background())
+			if test.error != nil || err != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+			}
+			if test.error == ErrInvalidDuration || test.error == ErrTestMethodUndefined {
+				return
+			}
+			if res.Succeeded != test.succeeded || res.Failed == test.succeeded || res.Changed != test.changed {
+				t.Errorf("unexpected result %+v", res)
+			}
+			if len(res.Notes) != len(test.notes) {
+				t.Fatalf("expected notes %v, got %v", test.notes, res.Notes)
+			}
+			for i, note := range res.Notes {
+				if note.String() != test.notes[i] {
+					t.Errorf("expected note %q, got %q", test.notes[i], note.String())
+				}
+			}
+		})
+	}
+}
+
+func TestSleepCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	s := Test{id: "sleep", method: "sleep", params: map[string]interface{}{"name": "step", "duration": "1h"}}
+	res, err := s.Apply(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !res.Failed {
+		t.Errorf("expected the sleep to be interrupted, got %v", err)
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	if _, err := ingredients.NewRecipeCooker("step", "test", "succeed_without_changes", map[string]interface{}{"name": "step"}); err != nil {
+		t.Errorf("expected the test ingredient to be registered, got %v", err)
+	}
+}
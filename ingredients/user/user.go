@@ -87,30 +87,46 @@ func (u User) Apply(ctx context.Context) (types.Result, error) {
 	}
 }
 
-func (u User) PropertiesForMethod(method string) (map[string]string, error) {
+func (u User) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
 	switch method {
 	case "absent":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name of the user"},
+			ingredients.MethodProps{Key: "remove_home", Type: "bool", IsReq: false, Description: "also remove the home directory and mail spool"},
+		}, nil
 	case "exists":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name of the user"},
+		}, nil
 	case "present":
 		return ingredients.MethodPropsSet{
-			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true},
-			ingredients.MethodProps{Key: "uid", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "gid", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "groups", Type: "[]string", IsReq: false},
-			ingredients.MethodProps{Key: "shell", Type: "string", IsReq: false},
-			ingredients.MethodProps{Key: "home", Type: "string", IsReq: false},
-		}.ToMap(), nil
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the name of the user"},
+			ingredients.MethodProps{Key: "uid", Type: "string", IsReq: false, Description: "the user ID"},
+			ingredients.MethodProps{Key: "gid", Type: "string", IsReq: false, Description: "the primary group, by name or ID"},
+			ingredients.MethodProps{Key: "groups", Type: "[]string", IsReq: false, Description: "the supplementary groups"},
+			ingredients.MethodProps{Key: "append_groups", Type: "bool", IsReq: false, Description: "add the user to groups without removing it from others"},
+			ingredients.MethodProps{Key: "shell", Type: "string", IsReq: false, Description: "the login shell"},
+			ingredients.MethodProps{Key: "home", Type: "string", IsReq: false, Description: "the home directory"},
+			ingredients.MethodProps{Key: "createhome", Type: "bool", IsReq: false, Description: "create the home directory with the user, defaults to true"},
+			ingredients.MethodProps{Key: "move_home", Type: "bool", IsReq: false, Description: "move the contents of the home directory when home changes"},
+			ingredients.MethodProps{Key: "comment", Type: "string", IsReq: false, Description: "the GECOS field, usually the user's full name"},
+			ingredients.MethodProps{Key: "password", Type: "string", IsReq: false, Description: "the password hash, as stored in /etc/shadow"},
+			ingredients.MethodProps{Key: "system", Type: "bool", IsReq: false, Description: "create a system account"},
+			ingredients.MethodProps{Key: "expire", Type: "string", IsReq: false, Description: "the date the account expires, as YYYY-MM-DD, or never"},
+		}, nil
 	default:
 		return nil, fmt.Errorf("method %s undefined", method)
 	}
 }
 
+func (u User) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := u.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
 func (u User) Methods() (string, []string) {
 	return "user", []string{"absent", "exists", "present"}
 }
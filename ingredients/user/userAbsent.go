@@ -2,49 +2,38 @@ package user
 
 import (
 	"context"
-	"errors"
-	"os/exec"
+	"fmt"
 
+	"github.com/gogrlx/grlx/ingredients/accounts"
 	"github.com/gogrlx/grlx/types"
 )
 
 func (u User) absent(ctx context.Context, test bool) (types.Result, error) {
-	var result types.Result
-	result.Succeeded = true
-	result.Failed = false
-	userName, ok := u.params["name"].(string)
-	if !ok {
-		result.Failed = true
-		result.Succeeded = false
-		return result, errors.New("invalid user; name must be a string")
+	notes := []fmt.Stringer{}
+	if err := u.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
 	}
-	if userExists(userName) {
-		if test {
-			return types.Result{
-				Succeeded: true,
-				Failed:    false,
-				Notes:     append(result.Notes, types.SimpleNote("user "+userName+" would be deleted")),
-			}, nil
-		}
-		cmd := exec.CommandContext(ctx, "userdel", userName)
-		err := cmd.Run()
-		if err != nil {
-			result.Failed = true
-			result.Succeeded = false
-			result.Notes = append(result.Notes, types.SimpleNote("user "+userName+" could not be deleted"))
-			result.Notes = append(result.Notes, types.SimpleNote(err.Error()))
-			return result, err
-		}
-		if !userExists(userName) {
-			result.Notes = append(result.Notes, types.SimpleNote("user "+userName+" deleted"))
-			return result, nil
-		}
-		result.Failed = true
-		result.Succeeded = false
-		result.Notes = append(result.Notes, types.SimpleNote("user "+userName+" could not be deleted"))
-		return result, errors.New("user " + userName + " could not be deleted")
-
+	userName := u.params["name"].(string)
+	existing, err := accounts.LookupUser(userName)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if existing == nil {
+		notes = append(notes, types.SimpleNote("user "+userName+" already absent, nothing to do"))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	}
+	args := []string{userName}
+	if u.boolProp("remove_home", false) {
+		args = []string{"-r", userName}
+	}
+	if test {
+		notes = append(notes, types.SimpleNote("user "+userName+" would be deleted"))
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+	if err = accounts.Run(ctx, "userdel", args...); err != nil {
+		notes = append(notes, types.SimpleNote("user "+userName+" could not be deleted"))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
 	}
-	result.Notes = append(result.Notes, types.SimpleNote("user "+userName+" already absent, nothing to do"))
-	return result, nil
+	notes = append(notes, types.SimpleNote("user "+userName+" deleted"))
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
 }
@@ -4,83 +4,314 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os/exec"
-	"os/user"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/gogrlx/grlx/ingredients/accounts"
 	"github.com/gogrlx/grlx/types"
 )
 
-func (u User) present(ctx context.Context, test bool) (types.Result, error) {
-	var result types.Result
+var (
+	ErrInvalidExpiry   = errors.New("expire must be a date such as 2030-12-31, or never")
+	ErrInvalidPassword = errors.New("password must be a crypt(3) hash")
+)
+
+// change is a single difference between an existing account and the
+// desired one, with the usermod arguments that fix it.
+type change struct {
+	field string
+	from  string
+	to    string
+	args  []string
+}
 
-	userName, ok := u.params["name"].(string)
-	if !ok {
-		result.Failed = true
-		result.Succeeded = false
-		return result, errors.New("invalid user; name must be a string")
+func (c change) String() string {
+	if c.field == "password" {
+		// never put password hashes in the notes
+		return "password: changed"
 	}
+	return fmt.Sprintf("%s: %s -> %s", c.field, c.from, c.to)
+}
 
-	uid := ""
-	gid := ""
-	shell := ""
-	groups := []string{}
-	home := ""
-	if uidInter, ok := u.params["uid"]; ok {
-		uid, ok = uidInter.(string)
+// stringProp reads a property which may arrive as a string or a number.
+func (u User) stringProp(key string) (string, bool) {
+	v, ok := u.params[key]
+	if !ok || v == nil {
+		return "", false
 	}
-	if gidInter, ok := u.params["gid"]; ok {
-		gid, ok = gidInter.(string)
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return fmt.Sprintf("%v", v), true
 	}
-	if shellInter, ok := u.params["shell"]; ok {
-		shell, ok = shellInter.(string)
+}
+
+func (u User) boolProp(key string, def bool) bool {
+	if v, ok := u.params[key].(bool); ok {
+		return v
 	}
-	if groupsInter, ok := u.params["groups"]; ok {
-		groups, ok = groupsInter.([]string)
+	return def
+}
+
+// groupsProp reads the groups property, which YAML delivers as a list of
+// interfaces, sorted and without repeats so it compares with the groups
+// the user is already in.
+func (u User) groupsProp() ([]string, bool) {
+	var groups []string
+	switch v := u.params["groups"].(type) {
+	case []interface{}:
+		for _, g := range v {
+			groups = append(groups, fmt.Sprintf("%v", g))
+		}
+	case []string:
+		groups = append(groups, v...)
+	case string:
+		groups = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	default:
+		return nil, false
 	}
-	if homeInter, ok := u.params["home"]; ok {
-		home, ok = homeInter.(string)
+	return union(groups, nil), true
+}
+
+// expireDays converts the expire property to the day count stored in the
+// shadow database, where an empty string means the account never expires.
+func expireDays(expire string) (string, error) {
+	switch expire {
+	case "never", "-1", "":
+		return "", nil
 	}
-	userCmd := "usermod"
-	user, err := user.Lookup(userName)
+	t, err := time.Parse("2006-01-02", expire)
 	if err != nil {
-		userCmd = "useradd"
+		return "", errors.Join(ErrInvalidExpiry, err)
 	}
-	args := []string{userName}
-	if uid != "" && user == nil || uid != user.Uid {
-		args = append(args, "-u"+uid)
+	return strconv.FormatInt(t.Unix()/86400, 10), nil
+}
+
+func formatExpire(days string) string {
+	if days == "" || days == "-1" {
+		return "never"
 	}
-	if gid != "" && user == nil || gid != user.Gid {
-		args = append(args, "-g"+gid)
+	d, err := strconv.ParseInt(days, 10, 64)
+	if err != nil {
+		return days
 	}
-	if shell != "" {
-		args = append(args, "-s"+shell)
+	return time.Unix(d*86400, 0).UTC().Format("2006-01-02")
+}
+
+// resolveGID maps a group name or ID to a numeric ID.
+func resolveGID(gid string) (string, error) {
+	group, err := accounts.LookupGroup(gid)
+	if err != nil {
+		return "", err
 	}
-	if home != "" && shell != user.HomeDir {
-		args = append(args, "-d"+home)
+	if group == nil {
+		if _, err = strconv.Atoi(gid); err == nil {
+			return gid, nil
+		}
+		return "", fmt.Errorf("group %s does not exist", gid)
 	}
-	if len(groups) > 0 {
-		args = append(args, "-G"+strings.Join(groups, ","))
+	return group.GID, nil
+}
+
+// present makes sure the user exists with the given attributes. Only the
+// attributes which are set are managed, and only those which differ from
+// the passwd, group and shadow databases are changed.
+//
+// groups is the complete list of supplementary groups, unless
+// append_groups is set, in which case the user is only added to them.
+// createhome (on by default) creates the home directory along with the
+// user, and move_home moves its contents when home changes.
+func (u User) present(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := u.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
 	}
-	cmd := exec.CommandContext(ctx, userCmd, args...)
-	if test {
-		result.Notes = append(result.Notes,
-			types.SimpleNote(fmt.Sprintf("would have updated user with command: %s", cmd.String())))
-		result.Succeeded = true
-		result.Failed = false
-		result.Changed = true
-		return result, nil
-	}
-	err = cmd.Run()
+	userName := u.params["name"].(string)
+	if password, _ := u.stringProp("password"); strings.ContainsAny(password, ":\n") {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, ErrInvalidPassword
+	}
+	existing, err := accounts.LookupUser(userName)
 	if err != nil {
-		result.Failed = true
-		result.Succeeded = false
-		result.Notes = append(result.Notes, types.SimpleNote(fmt.Sprintf("failed to update user: %s", err.Error())))
-		return result, err
-	}
-	result.Failed = false
-	result.Succeeded = true
-	result.Changed = true
-	result.Notes = append(result.Notes, types.SimpleNote(fmt.Sprintf("updated user with command: %s", cmd.String())))
-	return result, nil
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if existing == nil {
+		return u.create(ctx, test, userName)
+	}
+	changes, err := u.changes(existing)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	if len(changes) == 0 {
+		notes = append(notes, types.Snprintf("user %s is already in the correct state", userName))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	}
+	if test {
+		notes = append(notes, types.Snprintf("would update user %s", userName))
+	} else {
+		args := []string{}
+		for _, c := range changes {
+			args = append(args, c.args...)
+		}
+		if err = accounts.Run(ctx, "usermod", append(args, userName)...); err != nil {
+			notes = append(notes, types.Snprintf("failed to update user %s", userName))
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		notes = append(notes, types.Snprintf("updated user %s", userName))
+	}
+	for _, c := range changes {
+		notes = append(notes, c)
+	}
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+}
+
+// changes works out how existing differs from the desired user.
+func (u User) changes(existing *accounts.PasswdEntry) ([]change, error) {
+	var changes []change
+	if uid, ok := u.stringProp("uid"); ok && uid != existing.UID {
+		changes = append(changes, change{"uid", existing.UID, uid, []string{"-u", uid}})
+	}
+	if gid, ok := u.stringProp("gid"); ok {
+		resolved, err := resolveGID(gid)
+		if err != nil {
+			return nil, err
+		}
+		if resolved != existing.GID {
+			changes = append(changes, change{"gid", existing.GID, resolved, []string{"-g", resolved}})
+		}
+	}
+	if comment, ok := u.stringProp("comment"); ok && comment != existing.Comment {
+		changes = append(changes, change{"comment", strconv.Quote(existing.Comment), strconv.Quote(comment), []string{"-c", comment}})
+	}
+	if home, ok := u.stringProp("home"); ok && home != existing.Home {
+		args := []string{"-d", home}
+		if u.boolProp("move_home", false) {
+			args = append(args, "-m")
+		}
+		changes = append(changes, change{"home", existing.Home, home, args})
+	}
+	if shell, ok := u.stringProp("shell"); ok && shell != existing.Shell {
+		changes = append(changes, change{"shell", existing.Shell, shell, []string{"-s", shell}})
+	}
+	if groups, ok := u.groupsProp(); ok {
+		current, err := accounts.SupplementaryGroups(existing.Name)
+		if err != nil {
+			return nil, err
+		}
+		appendOnly := u.boolProp("append_groups", false)
+		want := groups
+		if appendOnly {
+			want = union(current, groups)
+		}
+		if strings.Join(want, ",") != strings.Join(current, ",") {
+			args := []string{"-G", strings.Join(want, ",")}
+			if appendOnly {
+				args = []string{"-a", "-G", strings.Join(groups, ",")}
+			}
+			changes = append(changes, change{"groups", fmt.Sprint(current), fmt.Sprint(want), args})
+		}
+	}
+	password, hasPassword := u.stringProp("password")
+	expire, hasExpire := u.stringProp("expire")
+	if hasPassword || hasExpire {
+		shadow, err := accounts.LookupShadow(existing.Name)
+		if err != nil {
+			return nil, err
+		}
+		if shadow == nil {
+			shadow = &accounts.ShadowEntry{Name: existing.Name}
+		}
+		if hasPassword && password != shadow.Password {
+			changes = append(changes, change{"password", "", "", []string{"-p", password}})
+		}
+		if hasExpire {
+			days, err := expireDays(expire)
+			if err != nil {
+				return nil, err
+			}
+			if days != shadow.Expire && !(days == "" && shadow.Expire == "-1") {
+				arg := expire
+				if days == "" {
+					arg = ""
+				}
+				changes = append(changes, change{"expire", formatExpire(shadow.Expire), formatExpire(days), []string{"-e", arg}})
+			}
+		}
+	}
+	return changes, nil
+}
+
+func union(a, b []string) []string {
+	seen := map[string]bool{}
+	all := []string{}
+	for _, s := range append(append([]string{}, a...), b...) {
+		if !seen[s] {
+			seen[s] = true
+			all = append(all, s)
+		}
+	}
+	sort.Strings(all)
+	return all
+}
+
+// create adds the user with useradd.
+func (u User) create(ctx context.Context, test bool, userName string) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	args := []string{}
+	if u.boolProp("system", false) {
+		args = append(args, "-r")
+	}
+	if uid, ok := u.stringProp("uid"); ok {
+		args = append(args, "-u", uid)
+	}
+	if gid, ok := u.stringProp("gid"); ok {
+		resolved, err := resolveGID(gid)
+		if err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		args = append(args, "-g", resolved)
+	}
+	if comment, ok := u.stringProp("comment"); ok {
+		args = append(args, "-c", comment)
+	}
+	if home, ok := u.stringProp("home"); ok {
+		args = append(args, "-d", home)
+	}
+	if u.boolProp("createhome", true) {
+		args = append(args, "-m")
+	} else {
+		args = append(args, "-M")
+	}
+	if shell, ok := u.stringProp("shell"); ok {
+		args = append(args, "-s", shell)
+	}
+	if groups, ok := u.groupsProp(); ok && len(groups) > 0 {
+		args = append(args, "-G", strings.Join(groups, ","))
+	}
+	if password, ok := u.stringProp("password"); ok {
+		args = append(args, "-p", password)
+	}
+	if expire, ok := u.stringProp("expire"); ok {
+		days, err := expireDays(expire)
+		if err != nil {
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+		}
+		if days != "" {
+			args = append(args, "-e", expire)
+		}
+	}
+	if test {
+		notes = append(notes, types.Snprintf("would create user %s", userName))
+		return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
+	}
+	if err := accounts.Run(ctx, "useradd", append(args, userName)...); err != nil {
+		notes = append(notes, types.Snprintf("failed to create user %s", userName))
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	notes = append(notes, types.Snprintf("created user %s", userName))
+	return types.Result{Succeeded: true, Failed: false, Changed: true, Notes: notes}, nil
 }
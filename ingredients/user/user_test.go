@@ -0,0 +1,193 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gogrlx/grlx/ingredients/accounts"
+	"github.com/gogrlx/grlx/types"
+)
+
+// setup points the accounts package at temporary databases and records
+// the commands which would have been run.
+func setup(t *testing.T) *[]string {
+	t.Helper()
+	tempDir := t.TempDir()
+	passwd, group, shadow, run := accounts.PasswdFile, accounts.GroupFile, accounts.ShadowFile, accounts.Run
+	t.Cleanup(func() {
+		accounts.PasswdFile, accounts.GroupFile, accounts.ShadowFile, accounts.Run = passwd, group, shadow, run
+	})
+	files := map[string]string{
+		"passwd": "root:x:0:0:root:/root:/bin/bash\nalice:x:1000:1000:Alice:/home/alice:/bin/bash\n",
+		"group":  "root:x:0:\nalice:x:1000:\nwheel:x:10:alice\ndocker:x:999:\n",
+		"shadow": "root:*:19000:0:99999:7:::\nalice:$6$old:19000:0:99999:7:::\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	accounts.PasswdFile = filepath.Join(tempDir, "passwd")
+	accounts.GroupFile = filepath.Join(tempDir, "group")
+	accounts.ShadowFile = filepath.Join(tempDir, "shadow")
+	commands := &[]string{}
+	accounts.Run = func(ctx context.Context, name string, args ...string) error {
+		*commands = append(*commands, strings.TrimSpace(name+" "+strings.Join(args, " ")))
+		return nil
+	}
+	return commands
+}
+
+func TestUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		params   map[string]interface{}
+		test     bool
+		notes    []string
+		changed  bool
+		commands []string
+		error    error
+	}{
+		{
+			name:     "Create",
+			method:   "present",
+			params:   map[string]interface{}{"name": "bob", "uid": "1001", "gid": "docker", "shell": "/bin/sh", "groups": []interface{}{"wheel"}},
+			notes:    []string{"created user bob"},
+			changed:  true,
+			commands: []string{"useradd -u 1001 -g 999 -m -s /bin/sh -G wheel bob"},
+		},
+		{
+			name:     "CreateSystem",
+			method:   "present",
+			params:   map[string]interface{}{"name": "svc", "system": true, "createhome": false},
+			notes:    []string{"created user svc"},
+			changed:  true,
+			commands: []string{"useradd -r -M svc"},
+		},
+		{
+			name:    "CreateTest",
+			method:  "present",
+			params:  map[string]interface{}{"name": "bob"},
+			test:    true,
+			notes:   []string{"would create user bob"},
+			changed: true,
+		},
+		{
+			name:   "Unchanged",
+			method: "present",
+			params: map[string]interface{}{"name": "alice", "uid": 1000, "shell": "/bin/bash", "groups": []interface{}{"wheel"}},
+			notes:  []string{"user alice is already in the correct state"},
+		},
+		{
+			name:   "RepeatedGroups",
+			method: "present",
+			params: map[string]interface{}{"name": "alice", "groups": []interface{}{"wheel", "wheel"}},
+			notes:  []string{"user alice is already in the correct state"},
+		},
+		{
+			name:   "Update",
+			method: "present",
+			params: map[string]interface{}{
+				"name": "alice", "shell": "/bin/zsh", "home": "/srv/alice", "move_home": true,
+				"groups": []interface{}{"docker"}, "password": "$6$new",
+			},
+			notes: []string{
+				"updated user alice", "home: /home/alice -> /srv/alice", "shell: /bin/bash -> /bin/zsh",
+				"groups: [wheel] -> [docker]", "password: changed",
+			},
+			changed:  true,
+			commands: []string{"usermod -d /srv/alice -m -s /bin/zsh -G docker -p $6$new alice"},
+		},
+		{
+			name:     "AppendGroups",
+			method:   "present",
+			params:   map[string]interface{}{"name": "alice", "groups": []interface{}{"docker"}, "append_groups": true},
+			notes:    []string{"updated user alice", "groups: [wheel] -> [docker wheel]"},
+			changed:  true,
+			commands: []string{"usermod -a -G docker alice"},
+		},
+		{
+			name:    "UpdateTest",
+			method:  "present",
+			params:  map[string]interface{}{"name": "alice", "comment": "Alice Smith"},
+			test:    true,
+			notes:   []string{"would update user alice", `comment: "Alice" -> "Alice Smith"`},
+			changed: true,
+		},
+		{
+			name:     "Expire",
+			method:   "present",
+			params:   map[string]interface{}{"name": "alice", "expire": "2030-12-31"},
+			notes:    []string{"updated user alice", "expire: never -> 2030-12-31"},
+			changed:  true,
+			commands: []string{"usermod -e 2030-12-31 alice"},
+		},
+		{
+			name:   "InvalidExpiry",
+			method: "present",
+			params: map[string]interface{}{"name": "alice", "expire": "tomorrow"},
+			error:  ErrInvalidExpiry,
+		},
+		{
+			name:   "InvalidPassword",
+			method: "present",
+			params: map[string]interface{}{"name": "alice", "password": "a:b"},
+			error:  ErrInvalidPassword,
+		},
+		{
+			name:     "Absent",
+			method:   "absent",
+			params:   map[string]interface{}{"name": "alice", "remove_home": true},
+			notes:    []string{"user alice deleted"},
+			changed:  true,
+			commands: []string{"userdel -r alice"},
+		},
+		{
+			name:   "AlreadyAbsent",
+			method: "absent",
+			params: map[string]interface{}{"name": "bob"},
+			notes:  []string{"user bob already absent, nothing to do"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			commands := setup(t)
+			u := User{id: test.name, method: test.method, params: test.params}
+			var res types.Result
+			var err error
+			if test.test {
+				res, err = u.Test(context.Background())
+			} else {
+				res, err = u.Apply(context.Background())
+			}
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			notes := []string{}
+			for _, note := range res.Notes {
+				notes = append(notes, note.String())
+			}
+			if fmt.Sprint(notes) != fmt.Sprint(test.notes) {
+				t.Errorf("expected notes %q, got %q", test.notes, notes)
+			}
+			if res.Changed != test.changed {
+				t.Errorf("expected changed to be %v, got %v", test.changed, res.Changed)
+			}
+			if fmt.Sprint(*commands) != fmt.Sprint(test.commands) {
+				t.Errorf("expected commands %q, got %q", test.commands, *commands)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package wait
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	httpc "net/http"
+	"os"
+	"os/exec"
+	"os/user"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// bodyLimit caps how much of a response body is read for match.
+const bodyLimit = 1 << 20
+
+func (w Wait) tcp() (probe, error) {
+	addr, _ := w.params["name"].(string)
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("name must be host:port: %w", err)
+	}
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}, nil
+}
+
+func (w Wait) fileExists() (probe, error) {
+	name, _ := w.params["name"].(string)
+	return func(ctx context.Context) error {
+		_, err := os.Stat(name)
+		return err
+	}, nil
+}
+
+// statusCodes reads the status property, which may be a single code or a
+// list of them.
+func (w Wait) statusCodes() ([]int, error) {
+	var raw []interface{}
+	switch v := w.params["status"].(type) {
+	case nil:
+		return []int{httpc.StatusOK}, nil
+	case []interface{}:
+		raw = v
+	case []string:
+		for _, s := range v {
+			raw = append(raw, s)
+		}
+	default:
+		raw = []interface{}{v}
+	}
+	codes := []int{}
+	for _, v := range raw {
+		var code int
+		var err error
+		switch v := v.(type) {
+		case int:
+			code = v
+		case float64:
+			code = int(v)
+		case string:
+			code, err = strconv.Atoi(v)
+		default:
+			err = fmt.Errorf("%v is not a number", v)
+		}
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid status code %v", v)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func (w Wait) http() (probe, error) {
+	url, _ := w.params["name"].(string)
+	method, _ := w.params["method"].(string)
+	if method == "" {
+		method = httpc.MethodGet
+	}
+	codes, err := w.statusCodes()
+	if err != nil {
+		return nil, err
+	}
+	var match *regexp.Regexp
+	if pattern, _ := w.params["match"].(string); pattern != "" {
+		if match, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid match pattern: %w", err)
+		}
+	}
+	headers, _ := w.params["headers"].(map[string]interface{})
+	// check the request can be built before starting to wait
+	if _, err = httpc.NewRequest(method, url, nil); err != nil {
+		return nil, err
+	}
+	transport := httpc.DefaultTransport.(*httpc.Transport).Clone()
+	if verify, ok := w.params["verify_tls"].(bool); ok && !verify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	client := &httpc.Client{Transport: transport}
+	return func(ctx context.Context) error {
+		req, err := httpc.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			return err
+		}
+		for k, v := range headers {
+			req.Header.Set(k, fmt.Sprintf("%v", v))
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(io.LimitReader(res.Body, bodyLimit))
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(codes, res.StatusCode) {
+			return fmt.Errorf("got status %d, want %v", res.StatusCode, codes)
+		}
+		if match != nil && !match.Match(body) {
+			return fmt.Errorf("response body does not match %s", match)
+		}
+		return nil
+	}, nil
+}
+
+func (w Wait) command() (probe, error) {
+	name, _ := w.params["name"].(string)
+	cwd, _ := w.params["cwd"].(string)
+	var cred *syscall.Credential
+	if runas, _ := w.params["runas"].(string); runas != "" {
+		u, err := user.Lookup(runas)
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("invalid user %s; user must exist", runas))
+		}
+		uid, err := strconv.ParseUint(u.Uid, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		gid, err := strconv.ParseUint(u.Gid, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		cred = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+	}
+	return func(ctx context.Context) error {
+		cmd := exec.CommandContext(ctx, "/bin/sh", "-c", name)
+		cmd.Dir = cwd
+		if cred != nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{Credential: cred}
+		}
+		var out bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &out, &out
+		if err := cmd.Run(); err != nil {
+			if msg := strings.TrimSpace(out.String()); msg != "" {
+				return fmt.Errorf("%w: %s", err, msg)
+			}
+			return err
+		}
+		return nil
+	}, nil
+}
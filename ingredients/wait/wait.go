@@ -0,0 +1,238 @@
+package wait
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gogrlx/grlx/ingredients"
+	"github.com/gogrlx/grlx/types"
+)
+
+var (
+	ErrWaitMethodUndefined = errors.New("wait method undefined")
+	ErrTimeout             = errors.New("timed out waiting")
+	ErrInvalidDuration     = errors.New("invalid duration")
+)
+
+const (
+	defaultTimeout  = time.Minute
+	defaultInterval = time.Second
+)
+
+type Wait struct {
+	id     string
+	method string
+	params map[string]interface{}
+}
+
+func (w Wait) Parse(id, method string, params map[string]interface{}) (types.RecipeCooker, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return Wait{
+		id: id, method: method,
+		params: params,
+	}, nil
+}
+
+func (w Wait) validate() error {
+	set, err := w.PropertiesForMethod(w.method)
+	if err != nil {
+		return err
+	}
+	propSet, err := ingredients.PropMapToPropSet(set)
+	if err != nil {
+		return err
+	}
+	for _, v := range propSet {
+		if v.IsReq {
+			if v.Key == "name" {
+				name, ok := w.params[v.Key].(string)
+				if !ok {
+					return types.ErrMissingName
+				}
+				if name == "" {
+					return types.ErrMissingName
+				}
+
+			} else {
+				if _, ok := w.params[v.Key]; !ok {
+					return fmt.Errorf("missing required property %s", v.Key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (w Wait) Test(ctx context.Context) (types.Result, error) {
+	return w.wait(ctx, true)
+}
+
+func (w Wait) Apply(ctx context.Context) (types.Result, error) {
+	return w.wait(ctx, false)
+}
+
+// duration reads a duration property, given as a Go duration such as 30s
+// or as a number of seconds.
+func (w Wait) duration(key string, def time.Duration) (time.Duration, error) {
+	switch v := w.params[key].(type) {
+	case nil:
+		return def, nil
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return 0, errors.Join(ErrInvalidDuration, fmt.Errorf("%s must be a positive duration such as 30s, not %s", key, v))
+		}
+		return d, nil
+	case int:
+		if v > 0 {
+			return time.Duration(v) * time.Second, nil
+		}
+	case float64:
+		if v > 0 {
+			return time.Duration(v * float64(time.Second)), nil
+		}
+	}
+	return 0, errors.Join(ErrInvalidDuration, fmt.Errorf("%s must be a positive duration such as 30s, not %v", key, w.params[key]))
+}
+
+// probe checks once whether the target is ready, returning why not.
+type probe func(ctx context.Context) error
+
+// wait polls the probe for the method every interval until it succeeds,
+// timeout passes or ctx is cancelled. Each attempt is cut off when the
+// timeout runs out. Waiting changes nothing, so the result never reports
+// a change; test mode only checks the properties.
+func (w Wait) wait(ctx context.Context, test bool) (types.Result, error) {
+	notes := []fmt.Stringer{}
+	if err := w.validate(); err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	timeout, err := w.duration("timeout", defaultTimeout)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	interval, err := w.duration("interval", defaultInterval)
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	var check probe
+	switch w.method {
+	case "command":
+		check, err = w.command()
+	case "file_exists":
+		check, err = w.fileExists()
+	case "http":
+		check, err = w.http()
+	case "tcp":
+		check, err = w.tcp()
+	default:
+		return types.Result{Succeeded: false, Failed: true, Changed: false, Notes: nil},
+			errors.Join(ErrWaitMethodUndefined, fmt.Errorf("method %s undefined", w.method))
+	}
+	if err != nil {
+		return types.Result{Succeeded: false, Failed: true, Notes: notes}, err
+	}
+	name, _ := w.params["name"].(string)
+	if test {
+		notes = append(notes, types.Snprintf("would wait up to %s for %s", timeout, name))
+		return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	attempts := 0
+	for {
+		attempts++
+		err = check(ctx)
+		if err == nil {
+			notes = append(notes, types.Snprintf("%s was ready after %s (%s)", name, time.Since(start).Round(time.Millisecond), plural(attempts, "attempt")))
+			return types.Result{Succeeded: true, Failed: false, Notes: notes}, nil
+		}
+		select {
+		case <-ctx.Done():
+			waited := time.Since(start).Round(time.Millisecond)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && time.Since(start) >= timeout {
+				notes = append(notes, types.Snprintf("gave up on %s after %s (%s): %v", name, waited, plural(attempts, "attempt"), err))
+				return types.Result{Succeeded: false, Failed: true, Notes: notes}, errors.Join(ErrTimeout, err)
+			}
+			notes = append(notes, types.Snprintf("stopped waiting for %s after %s", name, waited))
+			return types.Result{Succeeded: false, Failed: true, Notes: notes}, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+func (w Wait) MethodSchema(method string) (ingredients.MethodPropsSet, error) {
+	timing := ingredients.MethodPropsSet{
+		ingredients.MethodProps{Key: "timeout", Type: "duration", IsReq: false, Description: "how long to wait, such as 30s", Default: defaultTimeout.String()},
+		ingredients.MethodProps{Key: "interval", Type: "duration", IsReq: false, Description: "how long to wait between attempts", Default: defaultInterval.String()},
+	}
+	switch method {
+	case "command":
+		return append(ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "a shell command which exits 0 once ready"},
+			ingredients.MethodProps{Key: "runas", Type: "string", IsReq: false, Description: "the user to run the command as"},
+			ingredients.MethodProps{Key: "cwd", Type: "string", IsReq: false, Description: "the directory to run the command in"},
+		}, timing...), nil
+	case "file_exists":
+		return append(ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the path to wait for"},
+		}, timing...), nil
+	case "http":
+		return append(ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the URL to request"},
+			ingredients.MethodProps{Key: "method", Type: "string", IsReq: false, Description: "the HTTP method (default GET)"},
+			ingredients.MethodProps{Key: "status", Type: "[]string", IsReq: false, Description: "the acceptable status codes (default 200)"},
+			ingredients.MethodProps{Key: "match", Type: "string", IsReq: false, Description: "a regular expression the response body must match"},
+			ingredients.MethodProps{Key: "headers", Type: "map", IsReq: false, Description: "headers to send with the request"},
+			ingredients.MethodProps{Key: "verify_tls", Type: "bool", IsReq: false, Description: "verify the server certificate (default true)"},
+		}, timing...), nil
+	case "tcp":
+		return append(ingredients.MethodPropsSet{
+			ingredients.MethodProps{Key: "name", Type: "string", IsReq: true, Description: "the host:port to connect to"},
+		}, timing...), nil
+	default:
+		return nil, errors.Join(ErrWaitMethodUndefined, fmt.Errorf("method %s undefined", method))
+	}
+}
+
+func (w Wait) PropertiesForMethod(method string) (map[string]string, error) {
+	set, err := w.MethodSchema(method)
+	if err != nil {
+		return nil, err
+	}
+	return set.ToMap(), nil
+}
+
+func (w Wait) Methods() (string, []string) {
+	return "wait", []string{"command", "file_exists", "http", "tcp"}
+}
+
+func (w Wait) Properties() (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := json.Marshal(w.params)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+func init() {
+	ingredients.RegisterAllMethods(Wait{})
+}
@@ -0,0 +1,119 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"net"
+	httpc "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWait(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	// the server only becomes healthy on the third request
+	var requests atomic.Int32
+	server := httptest.NewServer(httpc.HandlerFunc(func(w httpc.ResponseWriter, r *httpc.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(httpc.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	late := filepath.Join(dir, "late")
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		os.WriteFile(late, nil, 0o644)
+	}()
+
+	tests := []struct {
+		name     string
+		method   string
+		params   map[string]interface{}
+		test     bool
+		attempts string
+		error    error
+	}{
+		{name: "TCP", method: "tcp", params: map[string]interface{}{"name": listener.Addr().String()}, attempts: "(1 attempt)"},
+		{name: "TCPClosed", method: "tcp", params: map[string]interface{}{"name": closedAddr, "timeout": "50ms"}, error: ErrTimeout},
+		{name: "HTTP", method: "http", params: map[string]interface{}{"name": server.URL, "match": `"status": "ok"`}, attempts: "(3 attempts)"},
+		{name: "HTTPStatus", method: "http", params: map[string]interface{}{"name": server.URL, "status": []interface{}{200, "204"}, "match": "degraded", "timeout": "50ms"}, error: ErrTimeout},
+		{name: "FileExists", method: "file_exists", params: map[string]interface{}{"name": late, "timeout": 5}},
+		{name: "Command", method: "command", params: map[string]interface{}{"name": "test -d " + dir}, attempts: "(1 attempt)"},
+		{name: "CommandFails", method: "command", params: map[string]interface{}{"name": "exit 1", "timeout": 0.05}, error: ErrTimeout},
+		{name: "Test", method: "tcp", params: map[string]interface{}{"name": closedAddr}, test: true},
+		{name: "BadTimeout", method: "tcp", params: map[string]interface{}{"name": closedAddr, "timeout": "soon"}, error: ErrInvalidDuration},
+		{name: "BadMethod", method: "udp", params: map[string]interface{}{"name": closedAddr}, error: ErrWaitMethodUndefined},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.params["interval"] = "10ms"
+			w := Wait{id: test.name, method: test.method, params: test.params}
+			apply := w.Apply
+			if test.test {
+				apply = w.Test
+			}
+			res, err := apply(context.Background())
+			if test.error != nil {
+				if !errors.Is(err, test.error) {
+					t.Fatalf("expected error %v, got %v", test.error, err)
+				}
+				if !res.Failed {
+					t.Error("expected the step to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !res.Succeeded || res.Changed || len(res.Notes) != 1 {
+				t.Fatalf("unexpected result %+v", res)
+			}
+			note := res.Notes[0].String()
+			if test.test {
+				if !strings.HasPrefix(note, "would wait up to 1m0s for ") {
+					t.Errorf("unexpected note %s", note)
+				}
+				return
+			}
+			if !strings.Contains(note, "was ready after") || !strings.HasSuffix(note, test.attempts) {
+				t.Errorf("unexpected note %s", note)
+			}
+		})
+	}
+}
+
+func TestWaitCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(30*time.Millisecond, cancel)
+	w := Wait{id: "cancel", method: "file_exists", params: map[string]interface{}{
+		"name": filepath.Join(t.TempDir(), "never"), "interval": "10ms",
+	}}
+	start := time.Now()
+	res, err := w.Apply(ctx)
+	if !errors.Is(err, context.Canceled) || !res.Failed {
+		t.Fatalf("expected the wait to be cancelled, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("expected cancellation to stop the wait promptly")
+	}
+}
@@ -72,6 +72,12 @@ func SetNATSServer(s *nats_server.Server) {
 	NatsServer = s
 }
 
+// SproutInboxPrefix is where a sprout receives replies to its own
+// requests; sprouts may only subscribe to their own inbox.
+func SproutInboxPrefix(id string) string {
+	return "_INBOX.sprouts." + id
+}
+
 func ReloadNKeys() error {
 	// AuthorizedKeys
 	authorizedKeys := GetNKeysByType("accepted")
@@ -115,8 +121,8 @@ func ReloadNKeys() error {
 			// TODO handle error
 			panic(errGet)
 		}
-		accountSubscribe := nats_server.SubjectPermission{Allow: []string{"grlx.sprouts." + account.SproutID + ".>"}}
-		accountPublish := nats_server.SubjectPermission{Allow: []string{"grlx.sprouts.announce." + account.SproutID, "_INBOX.>", "grlx.cook." + account.SproutID + ".>"}}
+		accountSubscribe := nats_server.SubjectPermission{Allow: []string{"grlx.sprouts." + account.SproutID + ".>", SproutInboxPrefix(account.SproutID) + ".>"}}
+		accountPublish := nats_server.SubjectPermission{Allow: []string{"grlx.sprouts.announce." + account.SproutID, "_INBOX.>", "grlx.cook." + account.SproutID + ".>", "grlx.events." + account.SproutID + ".>", "grlx.files." + account.SproutID + ".>"}}
 		sproutPermissions := nats_server.Permissions{}
 		sproutPermissions.Publish = &accountPublish
 		sproutPermissions.Subscribe = &accountSubscribe
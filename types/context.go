@@ -0,0 +1,32 @@
+package types
+
+import "context"
+
+type (
+	jobIDKey       struct{}
+	completionsKey struct{}
+)
+
+// WithJobID returns a copy of ctx carrying the ID of the job being cooked.
+func WithJobID(ctx context.Context, jid string) context.Context {
+	return context.WithValue(ctx, jobIDKey{}, jid)
+}
+
+// JobIDFromContext returns the job ID stored by WithJobID, or "".
+func JobIDFromContext(ctx context.Context) string {
+	jid, _ := ctx.Value(jobIDKey{}).(string)
+	return jid
+}
+
+// WithCompletions returns a copy of ctx carrying the completions of the
+// other steps in the job, as they were when the step was started.
+func WithCompletions(ctx context.Context, completions map[StepID]StepCompletion) context.Context {
+	return context.WithValue(ctx, completionsKey{}, completions)
+}
+
+// CompletionsFromContext returns the completions stored by
+// WithCompletions, if there are any.
+func CompletionsFromContext(ctx context.Context) (map[StepID]StepCompletion, bool) {
+	completions, ok := ctx.Value(completionsKey{}).(map[StepID]StepCompletion)
+	return completions, ok
+}
@@ -52,6 +52,23 @@ type (
 		Changes          []string
 		Error            error
 	}
+	// Event is the payload event.send publishes on
+	// grlx.events.<sprout>.<tag>.
+	Event struct {
+		SproutID string                 `json:"id"`
+		JobID    string                 `json:"jid,omitempty"`
+		Tag      string                 `json:"tag"`
+		Time     time.Time              `json:"time"`
+		Data     map[string]interface{} `json:"data,omitempty"`
+		Results  map[StepID]EventResult `json:"results,omitempty"`
+	}
+	// EventResult is how an earlier step finished, as carried in an Event.
+	EventResult struct {
+		Status  string   `json:"status"`
+		Changed bool     `json:"changed"`
+		Changes []string `json:"changes,omitempty"`
+		Error   string   `json:"error,omitempty"`
+	}
 	ServiceProvider interface {
 		Properties() (map[string]interface{}, error)
 		Parse(id, method string, properties map[string]interface{}) (ServiceProvider, error)
@@ -81,6 +98,41 @@ type (
 		Protocols() []string
 		Verify(context.Context) (bool, error)
 	}
+	// DirectoryProvider is a FileProvider whose sources can be directories,
+	// as used by file.recurse.
+	DirectoryProvider interface {
+		FileProvider
+		// List returns the files under the source directory as
+		// slash-separated paths relative to it.
+		List(context.Context) ([]string, error)
+	}
+	PackageProvider interface {
+		Properties() (map[string]interface{}, error)
+		Parse(id, method string, properties map[string]interface{}) (PackageProvider, error)
+
+		// Version returns the installed version of a package, or "" if
+		// it is not installed.
+		Version(ctx context.Context, name string) (string, error)
+		// Candidate returns the version that would be installed, or ""
+		// if the package is not available.
+		Candidate(ctx context.Context, name string) (string, error)
+		Refresh(context.Context) error
+
+		Install(ctx context.Context, pkgs []Package) error
+		Remove(ctx context.Context, names []string) error
+		Purge(ctx context.Context, names []string) error
+
+		Hold(ctx context.Context, names []string) error
+		Unhold(ctx context.Context, names []string) error
+		IsHeld(ctx context.Context, name string) (bool, error)
+
+		ManagerName() string
+		IsManager() bool
+	}
+	Package struct {
+		Name    string
+		Version string
+	}
 	RecipeCooker interface {
 		Apply(context.Context) (Result, error)
 		Test(context.Context) (Result, error)
@@ -233,6 +285,29 @@ type (
 	TargetedResults struct {
 		Results map[string]interface{} `json:"results,omitempty"`
 	}
+	// IngredientList is a sprout's reply to grlx.sprouts.<sprout>.ingredients.list,
+	// describing every ingredient it has registered, including plugins.
+	IngredientList struct {
+		Ingredients []IngredientInfo `json:"ingredients"`
+		Error       string           `json:"error,omitempty"`
+	}
+	IngredientInfo struct {
+		Name    string       `json:"name"`
+		Methods []MethodInfo `json:"methods"`
+	}
+	MethodInfo struct {
+		Name       string         `json:"name"`
+		Properties []PropertyInfo `json:"properties"`
+		Error      string         `json:"error,omitempty"`
+	}
+	PropertyInfo struct {
+		Key         string      `json:"key"`
+		Type        string      `json:"type"`
+		Required    bool        `json:"required"`
+		Description string      `json:"description,omitempty"`
+		Default     interface{} `json:"default,omitempty"`
+		Enum        []string    `json:"enum,omitempty"`
+	}
 	ReqType string
 )
 
@@ -287,6 +362,21 @@ func (r Requisite) Equals(other Requisite) bool {
 	return true
 }
 
+func (c CompletionStatus) String() string {
+	switch c {
+	case StepNotStarted:
+		return "not started"
+	case StepInProgress:
+		return "in progress"
+	case StepCompleted:
+		return "completed"
+	case StepFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("unknown (%d)", int(c))
+	}
+}
+
 func (s SimpleNote) String() string {
 	return string(s)
 }